@@ -68,6 +68,10 @@ func CreateFirstStock(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to sync FirstStock report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalFirstStock, first_stock.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync FirstStock journal", err)
+	}
+
 	return responses.JSONResponse(c, http.StatusOK, "FirstStock created successfully", first_stock)
 }
 
@@ -140,6 +144,10 @@ func UpdateFirstStock(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to sync FirstStock report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalFirstStock, first_stock.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync FirstStock journal", err)
+	}
+
 	return responses.JSONResponse(c, http.StatusOK, "FirstStock updated successfully", first_stock)
 }
 
@@ -162,9 +170,18 @@ func DeleteFirstStock(c *framework.Ctx) error {
 
 	for _, item := range items {
 		// Kurangi stok ke produk
-		if err := tools.ReduceProductStock(db, item.ProductId, item.Qty); err != nil {
+		if err := tools.ReduceProductStock(db, item.ProductId, item.Qty, tools.NewStockRef(c, models.FirstStockTrans, first_stock.ID)); err != nil {
 			return responses.InternalServerError(c, "Failed to reduce product stock", err)
 		}
+
+		// Kurangi batch yang berasal dari item ini
+		if err := tools.ReduceSourceBatch(db, first_stock.BranchID, item.ProductId, item.ID, item.Qty); err != nil {
+			return responses.InternalServerError(c, "Failed to reduce product batch", err)
+		}
+
+		if err := tools.ReduceReceiptCost(db, tools.NewStockRef(c, models.FirstStockTrans, first_stock.ID), item.ProductId, item.ID, item.Qty, item.Price); err != nil {
+			return responses.InternalServerError(c, "Failed to reduce product cost", err)
+		}
 	}
 
 	// Hapus semua item dari pembelian
@@ -177,6 +194,11 @@ func DeleteFirstStock(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to delete TransactionReports", err)
 	}
 
+	// Hapus jurnal stok awal
+	if err := tools.RemoveJournal(db, models.JournalFirstStock, first_stock.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to delete FirstStock journal", err)
+	}
+
 	// Hapus first_stock
 	if err := db.Delete(&first_stock).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to delete FirstStock", err)
@@ -188,6 +210,7 @@ func DeleteFirstStock(c *framework.Ctx) error {
 // CreateFirstStockItem Function
 func CreateFirstStockItem(c *framework.Ctx) error {
 	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
 	var item models.FirstStockItems
 
 	if err := c.BodyParser(&item); err != nil {
@@ -207,13 +230,18 @@ func CreateFirstStockItem(c *framework.Ctx) error {
 		}
 
 		// Tambah stok
-		if err := tools.AddProductStock(db, item.ProductId, item.Qty); err != nil {
+		if err := tools.AddProductStock(db, item.ProductId, item.Qty, tools.NewStockRef(c, models.FirstStockTrans, item.FirstStockId)); err != nil {
 			return responses.InternalServerError(c, "Failed to add product stock", err)
 		}
 
-		// Update harga produk jika harga baru lebih tinggi dari yang tersimpan di tabel products
-		if err := tools.UpdateProductPriceIfHigher(db, item.ProductId, item.Price); err != nil {
-			return responses.InternalServerError(c, "Failed to update product price", err)
+		// Tambah batch baru untuk qty tambahan
+		if err := tools.AddProductBatch(db, branchID, item.ProductId, existing.ID, "", item.ExpiredDate, item.Qty); err != nil {
+			return responses.InternalServerError(c, "Failed to add product batch", err)
+		}
+
+		// Hitung ulang harga pokok produk sesuai metode costing cabang
+		if err := tools.ApplyReceiptCost(db, tools.NewStockRef(c, models.FirstStockTrans, item.FirstStockId), item.ProductId, existing.ID, item.Qty, item.Price); err != nil {
+			return responses.InternalServerError(c, "Failed to update product cost", err)
 		}
 
 		// Recalculate total pembelian
@@ -238,12 +266,16 @@ func CreateFirstStockItem(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to create FirstStock item", err)
 	}
 
-	if err := tools.AddProductStock(db, item.ProductId, item.Qty); err != nil {
+	if err := tools.AddProductStock(db, item.ProductId, item.Qty, tools.NewStockRef(c, models.FirstStockTrans, item.FirstStockId)); err != nil {
 		return responses.InternalServerError(c, "Failed to add product stock", err)
 	}
 
-	if err := tools.UpdateProductPriceIfHigher(db, item.ProductId, item.Price); err != nil {
-		return responses.InternalServerError(c, "Failed to update product price", err)
+	if err := tools.AddProductBatch(db, branchID, item.ProductId, item.ID, "", item.ExpiredDate, item.Qty); err != nil {
+		return responses.InternalServerError(c, "Failed to add product batch", err)
+	}
+
+	if err := tools.ApplyReceiptCost(db, tools.NewStockRef(c, models.FirstStockTrans, item.FirstStockId), item.ProductId, item.ID, item.Qty, item.Price); err != nil {
+		return responses.InternalServerError(c, "Failed to update product cost", err)
 	}
 
 	if err := RecalculateTotalFirstStock(db, item.FirstStockId); err != nil {
@@ -257,6 +289,7 @@ func CreateFirstStockItem(c *framework.Ctx) error {
 func UpdateFirstStockItem(c *framework.Ctx) error {
 	db := config.DB
 	id := c.Param("id")
+	branchID, _ := middlewares.GetBranchID(c.Request)
 
 	var existingItem models.FirstStockItems
 	if err := db.First(&existingItem, "id = ?", id).Error; err != nil {
@@ -269,15 +302,32 @@ func UpdateFirstStockItem(c *framework.Ctx) error {
 	}
 
 	// Rollback stok lama
-	if err := tools.ReduceProductStock(db, existingItem.ProductId, existingItem.Qty); err != nil {
+	if err := tools.ReduceProductStock(db, existingItem.ProductId, existingItem.Qty, tools.NewStockRef(c, models.FirstStockTrans, existingItem.FirstStockId)); err != nil {
 		return responses.InternalServerError(c, "Failed to rollback product stock", err)
 	}
 
+	// Batalkan harga pokok dari qty lama
+	if err := tools.ReduceReceiptCost(db, tools.NewStockRef(c, models.FirstStockTrans, existingItem.FirstStockId), existingItem.ProductId, existingItem.ID, existingItem.Qty, existingItem.Price); err != nil {
+		return responses.InternalServerError(c, "Failed to rollback product cost", err)
+	}
+
 	// Tambah stok baru
-	if err := tools.AddProductStock(db, updatedItem.ProductId, updatedItem.Qty); err != nil {
+	if err := tools.AddProductStock(db, updatedItem.ProductId, updatedItem.Qty, tools.NewStockRef(c, models.FirstStockTrans, existingItem.FirstStockId)); err != nil {
 		return responses.InternalServerError(c, "Failed to add product stock", err)
 	}
 
+	// Ganti batch lama dengan batch sesuai data baru
+	if err := tools.ReduceSourceBatch(db, branchID, existingItem.ProductId, existingItem.ID, existingItem.Qty); err != nil {
+		return responses.InternalServerError(c, "Failed to rollback product batch", err)
+	}
+	expiredDate := existingItem.ExpiredDate
+	if !updatedItem.ExpiredDate.IsZero() {
+		expiredDate = updatedItem.ExpiredDate
+	}
+	if err := tools.AddProductBatch(db, branchID, updatedItem.ProductId, existingItem.ID, "", expiredDate, updatedItem.Qty); err != nil {
+		return responses.InternalServerError(c, "Failed to add product batch", err)
+	}
+
 	// Update item
 	existingItem.ProductId = updatedItem.ProductId
 	existingItem.Qty = updatedItem.Qty
@@ -288,9 +338,9 @@ func UpdateFirstStockItem(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to update FirstStock item", err)
 	}
 
-	// Update harga produk jika harga item lebih tinggi
-	if err := tools.UpdateProductPriceIfHigher(db, updatedItem.ProductId, updatedItem.Price); err != nil {
-		return responses.InternalServerError(c, "Failed to update product price", err)
+	// Hitung ulang harga pokok produk sesuai metode costing cabang
+	if err := tools.ApplyReceiptCost(db, tools.NewStockRef(c, models.FirstStockTrans, existingItem.FirstStockId), updatedItem.ProductId, existingItem.ID, updatedItem.Qty, updatedItem.Price); err != nil {
+		return responses.InternalServerError(c, "Failed to update product cost", err)
 	}
 
 	// Recalculate total & sync
@@ -305,6 +355,7 @@ func UpdateFirstStockItem(c *framework.Ctx) error {
 func DeleteFirstStockItem(c *framework.Ctx) error {
 	db := config.DB
 	id := c.Param("id")
+	branchID, _ := middlewares.GetBranchID(c.Request)
 
 	var item models.FirstStockItems
 	if err := db.First(&item, "id = ?", id).Error; err != nil {
@@ -312,10 +363,18 @@ func DeleteFirstStockItem(c *framework.Ctx) error {
 	}
 
 	// Subtract stok
-	if err := tools.ReduceProductStock(db, item.ProductId, item.Qty); err != nil {
+	if err := tools.ReduceProductStock(db, item.ProductId, item.Qty, tools.NewStockRef(c, models.FirstStockTrans, item.FirstStockId)); err != nil {
 		return responses.InternalServerError(c, "Failed to rollback product stock", err)
 	}
 
+	if err := tools.ReduceSourceBatch(db, branchID, item.ProductId, item.ID, item.Qty); err != nil {
+		return responses.InternalServerError(c, "Failed to rollback product batch", err)
+	}
+
+	if err := tools.ReduceReceiptCost(db, tools.NewStockRef(c, models.FirstStockTrans, item.FirstStockId), item.ProductId, item.ID, item.Qty, item.Price); err != nil {
+		return responses.InternalServerError(c, "Failed to rollback product cost", err)
+	}
+
 	// Hapus item
 	if err := db.Delete(&item).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to delete FirstStock item", err)
@@ -470,6 +529,7 @@ type FirstStockItemInput struct {
 	UnitId      string `json:"unit_id" validate:"required"`
 	Qty         int    `json:"qty" validate:"required,min=1"`
 	ExpiredDate string `json:"expired_date" validate:"required"` // String untuk parsing dari request
+	BatchNumber string `json:"batch_number"`                     // Opsional, default ID item first stock
 }
 
 // --- Structs Respons untuk First Stock ---
@@ -575,7 +635,12 @@ func CreateFirstStockTransaction(c *framework.Ctx) error {
 	var firstStockItemsToCreate []models.FirstStockItems
 	var firstStockItemsForResponse []FirstStockItemResponse // Slice untuk data respons
 
-	// var stockTracksToCreate []models.StockTracks
+	stockRef := tools.StockRef{
+		MovementType: models.FirstStockTrans,
+		ReferenceID:  firstStockHeader.ID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
 
 	for _, reqItem := range req.FirstStockItems {
 		parsedExpiredDate, err := time.Parse("2006-01-02", reqItem.ExpiredDate)
@@ -606,28 +671,14 @@ func CreateFirstStockTransaction(c *framework.Ctx) error {
 		}
 
 		// --- Logika Konversi Satuan ---
-		var conversionValue int = 1
-		if reqItem.UnitId != product.UnitId { // Jika unit input berbeda dengan unit dasar produk
-			var unitConversion models.UnitConversion
-			err = tx.Where("product_id = ? AND init_id = ? AND final_id = ? AND branch_id = ?",
-				reqItem.ProductId,
-				reqItem.UnitId, // Unit yang diinput
-				product.UnitId, // Unit dasar produk
-				firstStockHeader.BranchID,
-			).First(&unitConversion).Error
-
-			if err != nil {
-				if err == gorm.ErrRecordNotFound {
-					// Jika tidak ada konversi yang didefinisikan, asumsikan 1:1 atau unit dasar.
-					// Anda bisa menambahkan error di sini jika konversi mutlak diperlukan.
-					// Saat ini dibiarkan conversionValue = 1
-				} else {
-					tx.Rollback()
-					return responses.InternalServerError(c, "Failed to retrieve unit conversion details", err)
-				}
-			} else {
-				conversionValue = unitConversion.ValueConv
+		// Jika tidak ada konversi yang didefinisikan, diasumsikan 1:1 dengan unit dasar
+		conversionValue, err := tools.GetConversionValue(tx, firstStockHeader.BranchID, product, reqItem.UnitId)
+		if err != nil {
+			tx.Rollback()
+			if errors.Is(err, tools.ErrUnitFraction) {
+				return responses.BadRequest(c, err.Error(), err)
 			}
+			return responses.InternalServerError(c, "Failed to retrieve unit conversion details", err)
 		}
 		actualQtyToAdd := reqItem.Qty * conversionValue // Kuantitas aktual dalam satuan dasar
 		// --- Akhir Logika Konversi Satuan ---
@@ -663,20 +714,31 @@ func CreateFirstStockTransaction(c *framework.Ctx) error {
 		firstStockItemsForResponse = append(firstStockItemsForResponse, firstStockItemResp)
 		// --- Akhir persiapan data respons ---
 
-		// --- Tambah stok dan cek/update expired_date ---
-		updates := map[string]interface{}{
-			"stock": product.Stock + actualQtyToAdd, // Tambahkan stok aktual (dalam satuan dasar)
+		// --- Tambah stok secara atomik dan catat mutasinya ke stock_tracks ---
+		if _, _, err = tools.ChangeProductStock(tx, product.ID, actualQtyToAdd, stockRef); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to update stock for product %s", product.Name), err)
 		}
 
 		// Jika ExpiredDate stok baru lebih awal dari yang sudah ada di master produk, update.
 		if parsedExpiredDate.Before(product.ExpiredDate) {
-			updates["expired_date"] = parsedExpiredDate
+			err = tx.Model(&models.Product{}).Where("id = ?", product.ID).Update("expired_date", parsedExpiredDate).Error
+			if err != nil {
+				tx.Rollback()
+				return responses.InternalServerError(c, fmt.Sprintf("Failed to update expired_date for product %s", product.Name), err)
+			}
 		}
 
-		err = tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(updates).Error
-		if err != nil {
+		// Simpan batch sesuai expired_date item
+		if err = tools.AddProductBatch(tx, branchID, product.ID, firstStockItemDB.ID, reqItem.BatchNumber, parsedExpiredDate, actualQtyToAdd); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to create batch for product %s", product.Name), err)
+		}
+
+		// Catat layer harga pokok untuk stok awal
+		if err = tools.ApplyReceiptCost(tx, stockRef, product.ID, firstStockItemDB.ID, actualQtyToAdd, itemPrice); err != nil {
 			tx.Rollback()
-			return responses.InternalServerError(c, fmt.Sprintf("Failed to update product details (stock/expired_date) for product %s", product.Name), err)
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to update cost for product %s", product.Name), err)
 		}
 
 		calculatedTotalFirstStock += itemSubTotal // Ini adalah nilai total stok yang dimasukkan
@@ -698,6 +760,12 @@ func CreateFirstStockTransaction(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to create first stock items", err)
 	}
 
+	// Jurnal stok awal: persediaan terhadap modal awal persediaan
+	if err = tools.SyncJournal(tx, models.JournalFirstStock, firstStockHeader.ID); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create first stock journal", err)
+	}
+
 	// PENTING: TransactionReports dan DailyProfitReport TIDAK relevan untuk First Stock
 	// Karena ini bukan transaksi finansial atau penjualan/pembelian berbiaya,
 	// bagian untuk membuat TransactionReports atau mengupdate DailyProfitReport dihapus.
@@ -811,7 +879,8 @@ func RecalculateTotalFirstStock(db *gorm.DB, first_stockID string) error {
 		return err
 	}
 
-	return nil
+	// Jurnal stok awal
+	return tools.SyncJournal(db, models.JournalFirstStock, first_stock.ID)
 }
 
 // JSONFirstStockWithItemsResponse sends a standard JSON response format / structure
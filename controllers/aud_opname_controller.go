@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"net/http"
 	"strconv"
@@ -129,6 +131,14 @@ func GetMobileOpnameItemsGlimpse(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Item berhasil ditampilkan", OpnameItems)
 }
 
+// opnameExportColumns kolom ekspor daftar opname
+var opnameExportColumns = []tools.ExportColumn{
+	{Header: "No. Opname"},
+	{Header: "Tanggal"},
+	{Header: "Keterangan"},
+	{Header: "Total", Kind: tools.ExportMoney},
+}
+
 // Get All Opnames tampilkan semua opname
 func GetAllOpnames(c *framework.Ctx) error {
 	// Get branch id
@@ -162,6 +172,13 @@ func GetAllOpnames(c *framework.Ctx) error {
 		query = query.Where("LOWER(pur.description) LIKE ?", "%"+search+"%")
 	}
 
+	// ?format=csv|xlsx mengekspor seluruh hasil filter tanpa paginasi
+	if format := c.Query("format"); format != "" {
+		return exportList(c, query, format, "opname", opnameExportColumns, func(opname models.AllOpnames) []interface{} {
+			return []interface{}{opname.ID, opname.OpnameDate, opname.Description, opname.TotalOpname}
+		})
+	}
+
 	// Hitung total opname yang sesuai dengan filter
 	if err := query.Count(&total).Error; err != nil {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Pengambilan opname gagal", "Gagal menghitung stok awal")
@@ -240,6 +257,10 @@ func CreateOpname(c *framework.Ctx) error {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal membuat laporan opname", err.Error())
 	}
 
+	if err := tools.SyncJournal(db, models.JournalOpname, opname.ID); err != nil {
+		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal membuat jurnal opname", err.Error())
+	}
+
 	_ = tools.AutoCleanupOpnames(db)
 
 	return responses.JSONResponse(c, http.StatusOK, "Opname berhasil dibuat", opname)
@@ -315,6 +336,10 @@ func UpdateOpnameByID(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Gagal menyinkronkan laporan opname", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalOpname, opname.ID); err != nil {
+		return responses.InternalServerError(c, "Gagal menyinkronkan jurnal opname", err)
+	}
+
 	_ = tools.AutoCleanupOpnames(db)
 
 	return responses.JSONResponse(c, http.StatusOK, "Opname berhasil diperbarui", opname)
@@ -339,9 +364,18 @@ func DeleteOpnameByID(c *framework.Ctx) error {
 
 	for _, item := range items {
 		// Kosongkan stok ke produk
-		if err := tools.ZeroProductStock(db, item.ProductId, item.Qty); err != nil {
+		if err := tools.ZeroProductStock(db, item.ProductId, item.Qty, tools.NewStockRef(c, models.OpnameTrans, opname.ID)); err != nil {
 			return responses.InternalServerError(c, "Gagal mengosongkan stok produk", err)
 		}
+
+		// Kosongkan juga batch produk
+		if err := tools.ResetProductBatches(db, opname.BranchID, item.ProductId, item.ID, item.ExpiredDate, 0); err != nil {
+			return responses.InternalServerError(c, "Gagal mengosongkan batch produk", err)
+		}
+
+		if err := tools.SyncCostLayers(db, tools.NewStockRef(c, models.OpnameTrans, opname.ID), item.ProductId, item.ID); err != nil {
+			return responses.InternalServerError(c, "Gagal menyesuaikan harga pokok produk", err)
+		}
 	}
 
 	// Hapus semua item dari pembelian
@@ -354,6 +388,11 @@ func DeleteOpnameByID(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Gagal menghapus laporan transaksi", err)
 	}
 
+	// Hapus jurnal opname
+	if err := tools.RemoveJournal(db, models.JournalOpname, opname.ID); err != nil {
+		return responses.InternalServerError(c, "Gagal menghapus jurnal opname", err)
+	}
+
 	// Hapus opname
 	if err := db.Delete(&opname).Error; err != nil {
 		return responses.InternalServerError(c, "Gagal menghapus opname", err)
@@ -369,15 +408,8 @@ func GetOpnameWithItems(c *framework.Ctx) error {
 	// Ambil ID pembelian dari parameter URL
 	opnameID := c.Param("id")
 
-	// Struct untuk data utama opname
-	var opname models.AllOpnames
-
 	// Ambil data opname
-	err := db.Table("opnames pur").
-		Select("pur.id, pur.description, TO_CHAR(pur.opname_date, 'DD-MM-YYYY') AS opname_date, pur.total_opname, pur.payment").
-		Where("pur.id = ?", opnameID).
-		Scan(&opname).Error
-
+	opname, err := getOpname(db, opnameID)
 	if err != nil {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mendapatkan opname", err.Error())
 	}
@@ -404,15 +436,53 @@ func GetOpnameWithItems(c *framework.Ctx) error {
 	})
 }
 
+// getOpname mengambil data utama opname, tanggal sudah dalam format DD-MM-YYYY
+func getOpname(db *gorm.DB, opnameID string) (models.AllOpnames, error) {
+	var opname models.AllOpnames
+	err := db.Table("opnames pur").
+		Select("pur.id, pur.description, TO_CHAR(pur.opname_date, 'DD-MM-YYYY') AS opname_date, pur.total_opname, pur.payment").
+		Where("pur.id = ?", opnameID).
+		Scan(&opname).Error
+	return opname, err
+}
+
+// getOpnameSheetItems mengambil item opname beserta stok tercatat (qty_exist) untuk lembar opname
+func getOpnameSheetItems(db *gorm.DB, opnameID string) ([]models.AllOpnameItems, error) {
+	var items []models.AllOpnameItems
+	err := db.Table("opname_items pit").
+		Select("pit.id, pit.opname_id, pit.product_id, pro.name AS product_name, pit.price, pit.qty, pit.qty_exist, pit.sub_total, pit.sub_total_exist").
+		Joins("LEFT JOIN products pro ON pro.id = pit.product_id").
+		Where("pit.opname_id = ?", opnameID).
+		Order("pro.name ASC").
+		Scan(&items).Error
+	return items, err
+}
+
+// opnameItemError mengubah error perubahan item opname menjadi respons
+func opnameItemError(c *framework.Ctx, message string, err error) error {
+	var stockErr *tools.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		return responses.BadRequest(c, stockErr.Error(), err)
+	}
+	return responses.InternalServerError(c, message+": "+err.Error(), err)
+}
+
 // CreateOpnameItem Function
 func CreateOpnameItem(c *framework.Ctx) error {
 	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
 	var input tools.CreateOpnameItemInput
 
 	if err := c.BodyParser(&input); err != nil {
 		return responses.BadRequest(c, "Masukan tidak valid: "+err.Error(), err)
 	}
 
+	layout := "2006-01-02"
+	parsedDate, err := time.Parse(layout, input.ExpiredDate)
+	if err != nil {
+		return responses.BadRequest(c, "Format tanggal tidak valid. Gunakan YYYY-MM-DD", err)
+	}
+
 	// Ambil data produk untuk mendapatkan price, stock, dan purchase_price
 	var product models.Product
 	if err := db.Where("id = ?", input.ProductId).First(&product).Error; err != nil {
@@ -422,12 +492,6 @@ func CreateOpnameItem(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Gagal mengambil data produk: "+err.Error(), err)
 	}
 
-	layout := "2006-01-02"
-	parsedDate, err := time.Parse(layout, input.ExpiredDate)
-	if err != nil {
-		return responses.BadRequest(c, "Format tanggal tidak valid. Gunakan YYYY-MM-DD", err)
-	}
-
 	if product.Stock > 0 {
 		if product.ExpiredDate.After(parsedDate) {
 			product.ExpiredDate = parsedDate
@@ -435,9 +499,6 @@ func CreateOpnameItem(c *framework.Ctx) error {
 	} else {
 		product.ExpiredDate = parsedDate
 	}
-	if err := db.Model(&product).Update("expired_date", product.ExpiredDate).Error; err != nil {
-		return responses.InternalServerError(c, "Gagal memperbarui tanggal kedaluwarsa produk: "+err.Error(), err)
-	}
 
 	var opnameItem models.OpnameItems
 	opnameItem.OpnameId = input.OpnameId
@@ -449,50 +510,63 @@ func CreateOpnameItem(c *framework.Ctx) error {
 	opnameItem.SubTotalExist = product.Stock * product.PurchasePrice
 	opnameItem.SubTotal = opnameItem.Qty * product.PurchasePrice
 
-	var existingItem models.OpnameItems
-	err = db.Where("opname_id = ? AND product_id = ?", opnameItem.OpnameId, opnameItem.ProductId).First(&existingItem).Error
-
-	if err == nil {
-		existingItem.Qty = opnameItem.Qty
-		existingItem.SubTotal = opnameItem.SubTotal
-		existingItem.ExpiredDate = opnameItem.ExpiredDate
+	stockRef := tools.NewStockRef(c, models.OpnameTrans, opnameItem.OpnameId)
 
-		if err := db.Save(&existingItem).Error; err != nil {
-			// return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal memperbarui item opname: " + err.Error()})
-			return responses.InternalServerError(c, "Gagal memperbarui item opname: "+err.Error(), err)
+	// Item, stok, batch dan harga pokok disimpan dalam satu transaksi
+	saved := opnameItem
+	message := "Item opname berhasil disimpan"
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Update("expired_date", product.ExpiredDate).Error; err != nil {
+			return fmt.Errorf("memperbarui tanggal kedaluwarsa produk: %w", err)
 		}
 
-		if err := tools.OpnameProductStock(db, opnameItem.ProductId, opnameItem.Qty); err != nil {
-			return responses.InternalServerError(c, "Gagal menyesuaikan stok produk saat pembaruan: "+err.Error(), err)
+		var existingItem models.OpnameItems
+		err := tx.Where("opname_id = ? AND product_id = ?", opnameItem.OpnameId, opnameItem.ProductId).First(&existingItem).Error
+		if err == nil {
+			existingItem.Qty = opnameItem.Qty
+			existingItem.SubTotal = opnameItem.SubTotal
+			existingItem.ExpiredDate = opnameItem.ExpiredDate
+
+			if err := tx.Save(&existingItem).Error; err != nil {
+				return fmt.Errorf("memperbarui item opname: %w", err)
+			}
+			saved = existingItem
+			message = "Item opname berhasil diperbarui"
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			if saved.ID == "" {
+				saved.ID = helpers.GenerateID("OPI")
+			}
+			if err := tx.Create(&saved).Error; err != nil {
+				return fmt.Errorf("menambahkan item opname: %w", err)
+			}
+		} else {
+			return fmt.Errorf("pengecekan item opname: %w", err)
 		}
 
-		if err := tools.RecalculateTotalOpname(db, opnameItem.OpnameId); err != nil {
-			return responses.InternalServerError(c, "Gagal menghitung ulang total opname: "+err.Error(), err)
+		if err := tools.OpnameProductStock(tx, saved.ProductId, saved.Qty, stockRef); err != nil {
+			return fmt.Errorf("menyesuaikan stok produk: %w", err)
 		}
 
-		return responses.JSONResponse(c, http.StatusOK, "Item opname berhasil diperbarui", existingItem)
-
-	} else if err != gorm.ErrRecordNotFound {
-		return responses.InternalServerError(c, "Terjadi kesalahan di database saat pengecekan: "+err.Error(), err)
-	}
-
-	if opnameItem.ID == "" {
-		opnameItem.ID = helpers.GenerateID("OPI")
-	}
-
-	if err := db.Create(&opnameItem).Error; err != nil {
-		return responses.InternalServerError(c, "Gagal menambahkan item opname: "+err.Error(), err)
-	}
+		// Batch produk diganti sesuai hasil hitung fisik
+		if err := tools.ResetProductBatches(tx, branchID, saved.ProductId, saved.ID, saved.ExpiredDate, saved.Qty); err != nil {
+			return fmt.Errorf("menyesuaikan batch produk: %w", err)
+		}
 
-	if err := tools.OpnameProductStock(db, opnameItem.ProductId, opnameItem.Qty); err != nil {
-		return responses.InternalServerError(c, "Gagal menyesuaikan stok produk saat pembuatan: "+err.Error(), err)
-	}
+		// Samakan layer harga pokok dengan stok hasil opname
+		if err := tools.SyncCostLayers(tx, stockRef, saved.ProductId, saved.ID); err != nil {
+			return fmt.Errorf("menyesuaikan harga pokok produk: %w", err)
+		}
 
-	if err := tools.RecalculateTotalOpname(db, opnameItem.OpnameId); err != nil {
-		return responses.InternalServerError(c, "Gagal menghitung ulang total opname: "+err.Error(), err)
+		if err := tools.RecalculateTotalOpname(tx, saved.OpnameId); err != nil {
+			return fmt.Errorf("menghitung ulang total opname: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return opnameItemError(c, "Gagal menyimpan item opname", err)
 	}
 
-	return responses.JSONResponse(c, http.StatusOK, "Item opname berhasil disimpan", opnameItem)
+	return responses.JSONResponse(c, http.StatusOK, message, saved)
 }
 
 // GetAllOpnameItems tampilkan semua item berdasarkan product_name tanpa pagination
@@ -523,6 +597,7 @@ func GetAllOpnameItems(c *framework.Ctx) error {
 func UpdateOpnameItemByID(c *framework.Ctx) error {
 	db := config.DB
 	id := c.Param("id")
+	branchID, _ := middlewares.GetBranchID(c.Request)
 
 	var existingItem models.OpnameItems
 	if err := db.First(&existingItem, "id = ?", id).Error; err != nil {
@@ -534,41 +609,55 @@ func UpdateOpnameItemByID(c *framework.Ctx) error {
 		return responses.JSONResponse(c, http.StatusBadRequest, "Masukan tidak valid", nil)
 	}
 
-	// Kosongkan stok lama
-	if err := tools.ZeroProductStock(db, existingItem.ProductId, existingItem.Qty); err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengosongkan stok lama: "+err.Error(), err)
-	}
-
-	// Tambah stok baru
-	if err := tools.AddProductStock(db, updatedItem.ProductId, updatedItem.Qty); err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal menambah stok baru: "+err.Error(), err)
-	}
-
-	// Update item
-	existingItem.ProductId = updatedItem.ProductId
-	existingItem.Qty = updatedItem.Qty
-	existingItem.Price = updatedItem.Price
-	existingItem.SubTotal = updatedItem.Price * updatedItem.Qty
-
+	// Validasi input sebelum stok diubah
 	layout := "2006-01-02"
 	parsedDate, err := time.Parse(layout, updatedItem.ExpiredDate)
 	if err != nil {
 		return responses.JSONResponse(c, http.StatusBadRequest, "Format tanggal tidak valid. Gunakan YYYY-MM-DD", err)
 	}
-	existingItem.ExpiredDate = parsedDate
 
-	if err := db.Save(&existingItem).Error; err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal menyimpan item: "+err.Error(), err)
-	}
+	stockRef := tools.NewStockRef(c, models.OpnameTrans, existingItem.OpnameId)
 
-	// Update harga produk jika harga item lebih tinggi
-	if err := tools.UpdateProductPriceIfHigher(db, updatedItem.ProductId, updatedItem.Price); err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal memperbarui harga produk: "+err.Error(), err)
-	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Kosongkan stok lama
+		if err := tools.ZeroProductStock(tx, existingItem.ProductId, existingItem.Qty, stockRef); err != nil {
+			return fmt.Errorf("mengosongkan stok lama: %w", err)
+		}
 
-	// Recalculate total & sync
-	if err := tools.RecalculateTotalOpname(db, existingItem.OpnameId); err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal memperbarui total opname: "+err.Error(), err)
+		// Tambah stok baru
+		if err := tools.AddProductStock(tx, updatedItem.ProductId, updatedItem.Qty, stockRef); err != nil {
+			return fmt.Errorf("menambah stok baru: %w", err)
+		}
+
+		// Update item
+		existingItem.ProductId = updatedItem.ProductId
+		existingItem.Qty = updatedItem.Qty
+		existingItem.Price = updatedItem.Price
+		existingItem.SubTotal = updatedItem.Price * updatedItem.Qty
+		existingItem.ExpiredDate = parsedDate
+
+		if err := tx.Save(&existingItem).Error; err != nil {
+			return fmt.Errorf("menyimpan item: %w", err)
+		}
+
+		// Batch produk diganti sesuai data opname terbaru
+		if err := tools.ResetProductBatches(tx, branchID, existingItem.ProductId, existingItem.ID, parsedDate, existingItem.Qty); err != nil {
+			return fmt.Errorf("menyesuaikan batch produk: %w", err)
+		}
+
+		// Samakan layer harga pokok dengan stok hasil opname
+		if err := tools.SyncCostLayers(tx, stockRef, existingItem.ProductId, existingItem.ID); err != nil {
+			return fmt.Errorf("menyesuaikan harga pokok produk: %w", err)
+		}
+
+		// Recalculate total & sync
+		if err := tools.RecalculateTotalOpname(tx, existingItem.OpnameId); err != nil {
+			return fmt.Errorf("memperbarui total opname: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return opnameItemError(c, "Gagal memperbarui item opname", err)
 	}
 
 	return responses.JSONResponse(c, http.StatusOK, "Item berhasil diperbarui", existingItem)
@@ -578,25 +667,42 @@ func UpdateOpnameItemByID(c *framework.Ctx) error {
 func DeleteOpnameItemByID(c *framework.Ctx) error {
 	db := config.DB
 	id := c.Param("id")
+	branchID, _ := middlewares.GetBranchID(c.Request)
 
 	var item models.OpnameItems
 	if err := db.First(&item, "id = ?", id).Error; err != nil {
 		return responses.JSONResponse(c, http.StatusNotFound, "Item tidak ditemukan", err)
 	}
 
-	// Subtract stok
-	if err := tools.ReduceProductStock(db, item.ProductId, item.Qty); err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengurangi stok produk: "+err.Error(), err)
-	}
+	stockRef := tools.NewStockRef(c, models.OpnameTrans, item.OpnameId)
 
-	// Hapus item
-	if err := db.Delete(&item).Error; err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal menghapus item: "+err.Error(), err)
-	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Subtract stok
+		if err := tools.ReduceProductStock(tx, item.ProductId, item.Qty, stockRef); err != nil {
+			return fmt.Errorf("mengurangi stok produk: %w", err)
+		}
+
+		if err := tools.ReduceSourceBatch(tx, branchID, item.ProductId, item.ID, item.Qty); err != nil {
+			return fmt.Errorf("mengurangi batch produk: %w", err)
+		}
+
+		if err := tools.SyncCostLayers(tx, stockRef, item.ProductId, item.ID); err != nil {
+			return fmt.Errorf("menyesuaikan harga pokok produk: %w", err)
+		}
+
+		// Hapus item
+		if err := tx.Delete(&item).Error; err != nil {
+			return fmt.Errorf("menghapus item: %w", err)
+		}
 
-	// Recalculate total
-	if err := tools.RecalculateTotalOpname(db, item.OpnameId); err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal memperbarui total opname: "+err.Error(), err)
+		// Recalculate total
+		if err := tools.RecalculateTotalOpname(tx, item.OpnameId); err != nil {
+			return fmt.Errorf("memperbarui total opname: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return opnameItemError(c, "Gagal menghapus item opname", err)
 	}
 
 	return responses.JSONResponse(c, http.StatusOK, "Item berhasil dihapus", item)
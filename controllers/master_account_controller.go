@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+// GetAllAccounts menampilkan bagan akun cabang, bisa difilter account_type dan dicari berdasarkan kode / nama
+func GetAllAccounts(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	// Akun bawaan dibuat otomatis untuk cabang yang belum punya
+	if _, err := tools.EnsureBranchAccounts(db, branchID); err != nil {
+		return responses.InternalServerError(c, "Failed to prepare default accounts", err)
+	}
+
+	search := strings.TrimSpace(c.Query("search"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	query := db.Model(&models.Accounts{}).Where("branch_id = ?", branchID)
+
+	if accountType := strings.TrimSpace(c.Query("account_type")); accountType != "" {
+		query = query.Where("account_type = ?", accountType)
+	}
+	if search != "" {
+		search = strings.ToLower(search)
+		query = query.Where("(LOWER(code) LIKE ? OR LOWER(name) LIKE ?)", "%"+search+"%", "%"+search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to count accounts", err)
+	}
+
+	var accounts []models.Accounts
+	if err := query.Order("code ASC").Offset(offset).Limit(limit).Find(&accounts).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get accounts", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Accounts retrieved successfully", search, int(total), page, totalPages, limit, accounts)
+}
+
+// CreateAccount menambah akun ke bagan akun cabang, dipakai untuk jurnal manual
+func CreateAccount(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	nowWIB := time.Now().In(utils.Location)
+
+	var input models.AccountInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for account input", err)
+	}
+
+	code := strings.TrimSpace(input.Code)
+	var codeUsed int64
+	if err := db.Model(&models.Accounts{}).Where("branch_id = ? AND code = ?", branchID, code).Count(&codeUsed).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to check account code", err)
+	}
+	if codeUsed > 0 {
+		return responses.Conflict(c, fmt.Errorf("account code '%s' already used in this branch: duplicate entry", code))
+	}
+
+	account := models.Accounts{
+		ID:          helpers.GenerateID("ACC"),
+		Code:        code,
+		Name:        strings.TrimSpace(input.Name),
+		AccountType: models.AccountType(input.AccountType),
+		Description: input.Description,
+		Active:      input.Active == nil || *input.Active,
+		BranchID:    branchID,
+		CreatedAt:   nowWIB,
+		UpdatedAt:   nowWIB,
+	}
+	if err := db.Create(&account).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to create account", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Account created successfully", account)
+}
+
+// UpdateAccount mengubah akun cabang. Tipe akun sistem tidak bisa diubah dan akun sistem tidak bisa dinonaktifkan.
+func UpdateAccount(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var account models.Accounts
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&account).Error; err != nil {
+		return responses.NotFound(c, "Account not found")
+	}
+
+	var input models.AccountInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for account input", err)
+	}
+
+	if account.SystemKey != "" {
+		if models.AccountType(input.AccountType) != account.AccountType {
+			return responses.BadRequest(c, "Tipe akun sistem tidak bisa diubah", nil)
+		}
+		if input.Active != nil && !*input.Active {
+			return responses.BadRequest(c, "Akun sistem dipakai jurnal otomatis dan tidak bisa dinonaktifkan", nil)
+		}
+	}
+
+	code := strings.TrimSpace(input.Code)
+	var codeUsed int64
+	if err := db.Model(&models.Accounts{}).Where("branch_id = ? AND code = ? AND id <> ?", branchID, code, account.ID).Count(&codeUsed).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to check account code", err)
+	}
+	if codeUsed > 0 {
+		return responses.Conflict(c, fmt.Errorf("account code '%s' already used in this branch: duplicate entry", code))
+	}
+
+	account.Code = code
+	account.Name = strings.TrimSpace(input.Name)
+	account.AccountType = models.AccountType(input.AccountType)
+	account.Description = input.Description
+	if input.Active != nil {
+		account.Active = *input.Active
+	}
+	account.UpdatedAt = time.Now().In(utils.Location)
+
+	if err := db.Save(&account).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to update account", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Account updated successfully", account)
+}
+
+// DeleteAccount menghapus akun non-sistem yang belum pernah dipakai di jurnal
+func DeleteAccount(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var account models.Accounts
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&account).Error; err != nil {
+		return responses.NotFound(c, "Account not found")
+	}
+	if account.SystemKey != "" {
+		return responses.BadRequest(c, "Akun sistem dipakai jurnal otomatis dan tidak bisa dihapus", nil)
+	}
+
+	var usedLines int64
+	if err := db.Model(&models.JournalLines{}).Where("account_id = ?", account.ID).Count(&usedLines).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to check account usage", err)
+	}
+	if usedLines > 0 {
+		return responses.BadRequest(c, "Akun sudah dipakai di jurnal, nonaktifkan akun jika tidak dipakai lagi", nil)
+	}
+
+	if err := db.Delete(&account).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to delete account", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Account deleted successfully", account)
+}
@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// CreatePriceList membuat daftar harga baru
+func CreatePriceList(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	nowWIB := time.Now().In(utils.Location)
+
+	var input models.PriceListInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for price list input", err)
+	}
+
+	priceList := models.PriceLists{
+		ID:          helpers.GenerateID("PRL"),
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		Active:      true,
+		BranchID:    branchID,
+		CreatedAt:   nowWIB,
+		UpdatedAt:   nowWIB,
+	}
+	if input.Active != nil {
+		priceList.Active = *input.Active
+	}
+
+	if err := db.Create(&priceList).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to create price list", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Price list created successfully", priceList)
+}
+
+// UpdatePriceList mengubah nama, keterangan dan status daftar harga
+func UpdatePriceList(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var priceList models.PriceLists
+	if err := db.Where("id = ? AND branch_id = ?", c.Param("id"), branchID).First(&priceList).Error; err != nil {
+		return responses.NotFound(c, "Price list not found")
+	}
+
+	var input models.PriceListInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for price list input", err)
+	}
+
+	priceList.Name = strings.TrimSpace(input.Name)
+	priceList.Description = input.Description
+	if input.Active != nil {
+		priceList.Active = *input.Active
+	}
+	priceList.UpdatedAt = time.Now().In(utils.Location)
+
+	if err := db.Save(&priceList).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to update price list", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Price list updated successfully", priceList)
+}
+
+// DeletePriceList menghapus daftar harga yang belum pernah dipakai, selain itu daftar harga hanya dinonaktifkan.
+// Kategori member yang memakai daftar harga ini kembali ke harga jual produk.
+func DeletePriceList(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var priceList models.PriceLists
+	if err := db.Where("id = ? AND branch_id = ?", c.Param("id"), branchID).First(&priceList).Error; err != nil {
+		return responses.NotFound(c, "Price list not found")
+	}
+
+	var used int64
+	if err := db.Model(&models.SaleItems{}).Where("price_list_id = ?", priceList.ID).Count(&used).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to check price list usage", err)
+	}
+
+	if used > 0 {
+		if err := db.Model(&priceList).Update("active", false).Error; err != nil {
+			return responses.InternalServerError(c, "Failed to deactivate price list", err)
+		}
+		return responses.JSONResponse(c, http.StatusOK, "Daftar harga sudah dipakai pada penjualan, daftar harga dinonaktifkan", priceList)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Model(&models.MemberCategory{}).Where("price_list_id = ?", priceList.ID).Update("price_list_id", "").Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to detach price list from member categories", err)
+	}
+	if err := tx.Where("price_list_id = ?", priceList.ID).Delete(&models.PriceListItems{}).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to delete price list items", err)
+	}
+	if err := tx.Delete(&priceList).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to delete price list", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Price list deleted successfully", priceList)
+}
+
+// GetPriceList menampilkan daftar harga beserta kategori member pemakai dan harga produknya
+func GetPriceList(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var priceList models.PriceLists
+	if err := db.Where("id = ? AND branch_id = ?", c.Param("id"), branchID).First(&priceList).Error; err != nil {
+		return responses.NotFound(c, "Price list not found")
+	}
+
+	detail := models.PriceListDetail{
+		ID:               priceList.ID,
+		Name:             priceList.Name,
+		Description:      priceList.Description,
+		Active:           priceList.Active,
+		MemberCategories: []models.ComboMemberCategory{},
+		Items:            []models.PriceListItemDetail{},
+	}
+
+	if err := db.Table("member_categories").
+		Select("id AS member_category_id, name AS member_category_name").
+		Where("price_list_id = ? AND branch_id = ?", priceList.ID, branchID).
+		Order("name ASC").
+		Scan(&detail.MemberCategories).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get member categories", err)
+	}
+
+	query := priceListItemQuery(db, priceList.ID)
+	if productID := strings.TrimSpace(c.Query("product_id")); productID != "" {
+		query = query.Where("pli.product_id = ?", productID)
+	}
+	if err := query.Order("pro.name ASC, un.name ASC, pli.min_qty ASC").Scan(&detail.Items).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get price list items", err)
+	}
+	if err := fillPriceListSalesPrices(db, branchID, detail.Items); err != nil {
+		return responses.InternalServerError(c, "Failed to get product sales prices", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Price list retrieved successfully", detail)
+}
+
+// GetAllPriceLists menampilkan semua daftar harga dengan pagination, search dan filter status aktif
+func GetAllPriceLists(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	search := strings.TrimSpace(c.Query("search"))
+	active := strings.TrimSpace(c.Query("active"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	query := config.DB.Table("price_lists prl").
+		Select("prl.id, prl.name, prl.description, prl.active, (SELECT COUNT(*) FROM price_list_items pli WHERE pli.price_list_id = prl.id) AS item_count").
+		Where("prl.branch_id = ?", branchID)
+
+	if search != "" {
+		query = query.Where("LOWER(prl.name) LIKE ?", "%"+strings.ToLower(search)+"%")
+	}
+
+	if active != "" {
+		query = query.Where("prl.active = ?", active == "true")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to count price lists", err)
+	}
+
+	var priceLists []models.PriceListSummary
+	if err := query.Order("prl.name ASC").Offset(offset).Limit(limit).Scan(&priceLists).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get price lists", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Price lists retrieved successfully", search, int(total), page, totalPages, limit, priceLists)
+}
+
+// SavePriceListItem menambah harga produk ke daftar harga,
+// baris dengan produk, satuan dan min_qty yang sama diperbarui harganya
+func SavePriceListItem(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var priceList models.PriceLists
+	if err := db.Where("id = ? AND branch_id = ?", c.Param("id"), branchID).First(&priceList).Error; err != nil {
+		return responses.NotFound(c, "Price list not found")
+	}
+
+	var input models.PriceListItemInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for price list item input", err)
+	}
+
+	// Satuan harus bisa dijual untuk produk ini (satuan dasar atau hasil konversi)
+	saleUnit, err := tools.GetSaleUnit(db, branchID, input.ProductId, input.UnitId)
+	if err != nil {
+		return saleUnitError(c, err)
+	}
+	minQty := max(input.MinQty, 1)
+
+	var item models.PriceListItems
+	err = db.Where("price_list_id = ? AND product_id = ? AND unit_id = ? AND min_qty = ?", priceList.ID, saleUnit.ProductId, saleUnit.UnitId, minQty).
+		First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		item = models.PriceListItems{
+			ID:          helpers.GenerateID("PLI"),
+			PriceListId: priceList.ID,
+			ProductId:   saleUnit.ProductId,
+			UnitId:      saleUnit.UnitId,
+			MinQty:      minQty,
+		}
+	} else if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve price list item", err)
+	}
+
+	item.Price = input.Price
+	if err := db.Save(&item).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to save price list item", err)
+	}
+
+	var detail models.PriceListItemDetail
+	if err := priceListItemQuery(db, priceList.ID).Where("pli.id = ?", item.ID).Scan(&detail).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get price list item", err)
+	}
+	detail.SalesPrice = saleUnit.Price
+
+	return responses.JSONResponse(c, http.StatusOK, "Price list item saved successfully", detail)
+}
+
+// DeletePriceListItem menghapus satu harga dari daftar harga
+func DeletePriceListItem(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var priceList models.PriceLists
+	if err := db.Where("id = ? AND branch_id = ?", c.Param("id"), branchID).First(&priceList).Error; err != nil {
+		return responses.NotFound(c, "Price list not found")
+	}
+
+	result := db.Where("id = ? AND price_list_id = ?", c.Param("item_id"), priceList.ID).Delete(&models.PriceListItems{})
+	if result.Error != nil {
+		return responses.InternalServerError(c, "Failed to delete price list item", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return responses.NotFound(c, "Price list item not found")
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Price list item deleted successfully", framework.Map{"id": c.Param("item_id")})
+}
+
+// GetPriceOverrides menampilkan catatan harga yang diubah kasir, bisa difilter tanggal (YYYY-MM-DD) dan kasir
+func GetPriceOverrides(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	query := config.DB.Table("price_overrides pov").
+		Select(`pov.id, pov.sale_id, pov.product_id, pro.name AS product_name, COALESCE(un.name, '') AS unit_name,
+			COALESCE(pov.price_list_id, '') AS price_list_id, COALESCE(prl.name, '') AS price_list_name,
+			pov.list_price, pov.price, COALESCE(pov.reason, '') AS reason, pov.user_id, COALESCE(usr.name, '') AS user_name, pov.created_at`).
+		Joins("LEFT JOIN products pro ON pro.id = pov.product_id").
+		Joins("LEFT JOIN units un ON un.id = pov.unit_id").
+		Joins("LEFT JOIN price_lists prl ON prl.id = pov.price_list_id").
+		Joins("LEFT JOIN users usr ON usr.user_id = pov.user_id").
+		Where("pov.branch_id = ?", branchID)
+
+	if startDate := strings.TrimSpace(c.Query("start_date")); startDate != "" {
+		parsedStart, err := time.ParseInLocation("2006-01-02", startDate, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Format start_date tidak valid, gunakan YYYY-MM-DD", err)
+		}
+		query = query.Where("pov.created_at >= ?", parsedStart)
+	}
+	if endDate := strings.TrimSpace(c.Query("end_date")); endDate != "" {
+		parsedEnd, err := time.ParseInLocation("2006-01-02", endDate, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Format end_date tidak valid, gunakan YYYY-MM-DD", err)
+		}
+		query = query.Where("pov.created_at < ?", parsedEnd.AddDate(0, 0, 1))
+	}
+	if userID := strings.TrimSpace(c.Query("user_id")); userID != "" {
+		query = query.Where("pov.user_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to count price overrides", err)
+	}
+
+	var overrides []models.PriceOverrideDetail
+	if err := query.Order("pov.created_at DESC").Offset(offset).Limit(limit).Scan(&overrides).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get price overrides", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Price overrides retrieved successfully", "", int(total), page, totalPages, limit, overrides)
+}
+
+// priceListItemQuery query dasar harga daftar harga beserta nama produk dan satuan
+func priceListItemQuery(db *gorm.DB, priceListID string) *gorm.DB {
+	return db.Table("price_list_items pli").
+		Select("pli.id, pli.product_id, pro.name AS product_name, pli.unit_id, COALESCE(un.name, '') AS unit_name, pli.min_qty, pli.price").
+		Joins("JOIN products pro ON pro.id = pli.product_id").
+		Joins("LEFT JOIN units un ON un.id = pli.unit_id").
+		Where("pli.price_list_id = ?", priceListID)
+}
+
+// fillPriceListSalesPrices mengisi harga jual normal per satuan sebagai pembanding harga daftar harga
+func fillPriceListSalesPrices(db *gorm.DB, branchID string, items []models.PriceListItemDetail) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductId)
+	}
+	units, err := tools.GetSaleUnits(db, branchID, productIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		for _, unit := range units[items[i].ProductId] {
+			if unit.UnitId == items[i].UnitId {
+				items[i].SalesPrice = unit.Price
+				break
+			}
+		}
+	}
+	return nil
+}
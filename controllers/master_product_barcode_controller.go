@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// barcodeDetailQuery query dasar barcode beserta nama produk dan satuan
+func barcodeDetailQuery(db *gorm.DB, branchID string) *gorm.DB {
+	return db.Table("product_barcodes pb").
+		Select("pb.id, pb.barcode, pb.product_id, pro.name AS product_name, pb.unit_id, un.name AS unit_name, pb.qty_multiplier").
+		Joins("JOIN products pro ON pro.id = pb.product_id").
+		Joins("LEFT JOIN units un ON un.id = pb.unit_id").
+		Where("pb.branch_id = ?", branchID)
+}
+
+// GetProductBarcodes menampilkan semua barcode milik satu produk
+// Endpoint: GET /api/barcodes/product/:product_id
+func GetProductBarcodes(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	productID := c.Param("product_id")
+
+	var barcodes []models.ProductBarcodeDetail
+	if err := barcodeDetailQuery(config.DB, branchID).
+		Where("pb.product_id = ?", productID).
+		Order("pb.qty_multiplier ASC, pb.created_at ASC").
+		Scan(&barcodes).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get product barcodes", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Product barcodes retrieved successfully", barcodes)
+}
+
+// CreateProductBarcode menambahkan barcode ke produk, barcode unik per cabang.
+// Jika barcode kosong dibuatkan EAN-13 internal, jika unit_id kosong dipakai satuan dasar produk.
+// Endpoint: POST /api/barcodes
+func CreateProductBarcode(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var input models.ProductBarcodeInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for barcode input", err)
+	}
+
+	var product models.Product
+	if err := db.Where("id = ? AND branch_id = ?", input.ProductId, branchID).First(&product).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return responses.NotFound(c, "Product not found")
+		}
+		return responses.InternalServerError(c, "Failed to get product", err)
+	}
+
+	// Satuan selain satuan dasar wajib punya konversi ke satuan dasar produk
+	saleUnit, err := tools.GetSaleUnit(db, branchID, product.ID, strings.TrimSpace(input.UnitId))
+	if err != nil {
+		return saleUnitError(c, err)
+	}
+
+	code := tools.NormalizeBarcode(input.Barcode)
+	if code == "" {
+		if code, err = tools.GenerateInternalBarcode(db, branchID); err != nil {
+			return responses.InternalServerError(c, "Failed to generate barcode", err)
+		}
+	} else {
+		if err := tools.ValidateBarcode(code); err != nil {
+			return responses.BadRequest(c, err.Error(), err)
+		}
+		used, err := tools.BarcodeInUse(db, branchID, code, product.ID)
+		if err != nil {
+			return responses.InternalServerError(c, "Failed to check barcode", err)
+		}
+		if used {
+			return responses.Conflict(c, fmt.Errorf("barcode '%s' already used in this branch: duplicate entry", code))
+		}
+	}
+
+	barcode := models.ProductBarcodes{
+		ID:            helpers.GenerateID("BRC"),
+		Barcode:       code,
+		ProductId:     product.ID,
+		UnitId:        saleUnit.UnitId,
+		QtyMultiplier: saleUnit.ConvValue,
+		BranchID:      branchID,
+		CreatedAt:     time.Now().In(utils.Location),
+	}
+	if err := db.Create(&barcode).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to create barcode", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Barcode created successfully", models.ProductBarcodeDetail{
+		ID:            barcode.ID,
+		Barcode:       barcode.Barcode,
+		ProductId:     product.ID,
+		ProductName:   product.Name,
+		UnitId:        saleUnit.UnitId,
+		UnitName:      saleUnit.UnitName,
+		QtyMultiplier: saleUnit.ConvValue,
+	})
+}
+
+// DeleteProductBarcode menghapus barcode produk
+// Endpoint: DELETE /api/barcodes/:id
+func DeleteProductBarcode(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	result := config.DB.Where("id = ? AND branch_id = ?", id, branchID).Delete(&models.ProductBarcodes{})
+	if result.Error != nil {
+		return responses.InternalServerError(c, "Failed to delete barcode", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return responses.NotFound(c, "Barcode not found")
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Barcode deleted successfully", id)
+}
+
+// ScanBarcode mencari produk dari hasil scan kasir (exact match).
+// Barcode dicari lebih dulu, jika tidak ada dicocokkan dengan SKU produk (satuan dasar).
+// Endpoint: GET /api/barcodes/scan/:code
+func ScanBarcode(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	code := tools.NormalizeBarcode(c.Param("code"))
+	if code == "" {
+		return responses.BadRequest(c, "Barcode is required", nil)
+	}
+
+	var scan struct {
+		ProductId    string
+		ProductName  string
+		UnitId       string
+		Stock        int
+		BaseUnitName string
+	}
+
+	result := db.Table("product_barcodes pb").
+		Select("pb.product_id, pro.name AS product_name, pb.unit_id, pro.stock, bu.name AS base_unit_name").
+		Joins("JOIN products pro ON pro.id = pb.product_id").
+		Joins("LEFT JOIN units bu ON bu.id = pro.unit_id").
+		Where("pb.branch_id = ? AND pb.barcode = ?", branchID, code).
+		Limit(1).
+		Scan(&scan)
+	if result.Error != nil {
+		return responses.InternalServerError(c, "Failed to scan barcode", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		result = db.Table("products pro").
+			Select("pro.id AS product_id, pro.name AS product_name, pro.unit_id, pro.stock, un.name AS base_unit_name").
+			Joins("LEFT JOIN units un ON un.id = pro.unit_id").
+			Where("pro.branch_id = ? AND pro.sku = ?", branchID, code).
+			Limit(1).
+			Scan(&scan)
+		if result.Error != nil {
+			return responses.InternalServerError(c, "Failed to scan barcode", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return responses.NotFound(c, fmt.Sprintf("Barcode %s tidak terdaftar", code))
+		}
+	}
+
+	// Harga dan stok mengikuti satuan jual, termasuk harga khusus satuan konversi
+	saleUnit, err := tools.GetSaleUnit(db, branchID, scan.ProductId, scan.UnitId)
+	if err != nil {
+		return saleUnitError(c, err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Barcode found", models.BarcodeScanResponse{
+		Barcode:       code,
+		ProductId:     scan.ProductId,
+		ProductName:   scan.ProductName,
+		UnitId:        saleUnit.UnitId,
+		UnitName:      saleUnit.UnitName,
+		QtyMultiplier: saleUnit.ConvValue,
+		Price:         saleUnit.Price,
+		Stock:         saleUnit.Stock,
+		BaseUnitName:  scan.BaseUnitName,
+		BaseStock:     scan.Stock,
+	})
+}
+
+// GenerateMissingBarcodes membuatkan barcode EAN-13 internal (satuan dasar) untuk produk yang belum punya barcode.
+// Body opsional {"product_ids": [...]} membatasi produk yang diproses.
+// Endpoint: POST /api/barcodes/generate
+func GenerateMissingBarcodes(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var input struct {
+		ProductIds []string `json:"product_ids"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return responses.BadRequest(c, "Invalid request body", err)
+		}
+	}
+
+	query := db.Model(&models.Product{}).
+		Where("branch_id = ?", branchID).
+		Where("NOT EXISTS (SELECT 1 FROM product_barcodes pb WHERE pb.product_id = products.id AND pb.branch_id = products.branch_id)")
+	if len(input.ProductIds) > 0 {
+		query = query.Where("id IN ?", input.ProductIds)
+	}
+
+	var products []models.Product
+	if err := query.Order("name ASC").Find(&products).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get products", err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	nowWIB := time.Now().In(utils.Location)
+	created := make([]models.ProductBarcodeDetail, 0, len(products))
+	for _, product := range products {
+		code, err := tools.GenerateInternalBarcode(tx, branchID)
+		if err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to generate barcode", err)
+		}
+		barcode := models.ProductBarcodes{
+			ID:            helpers.GenerateID("BRC"),
+			Barcode:       code,
+			ProductId:     product.ID,
+			UnitId:        product.UnitId,
+			QtyMultiplier: 1,
+			BranchID:      branchID,
+			CreatedAt:     nowWIB,
+		}
+		if err := tx.Create(&barcode).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to create barcode", err)
+		}
+		created = append(created, models.ProductBarcodeDetail{
+			ID:            barcode.ID,
+			Barcode:       barcode.Barcode,
+			ProductId:     product.ID,
+			ProductName:   product.Name,
+			UnitId:        product.UnitId,
+			QtyMultiplier: 1,
+		})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, fmt.Sprintf("%d barcode generated", len(created)), created)
+}
+
+// GetBarcodeLabel membuat gambar label barcode (PNG base64), EAN-13 untuk kode 13 digit yang valid, selain itu Code128.
+// scale = lebar satu batang dalam piksel (1-10, default 2).
+// Endpoint: GET /api/barcodes/:id/label
+func GetBarcodeLabel(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	scale := 2
+	if s := strings.TrimSpace(c.Query("scale")); s != "" {
+		var err error
+		if scale, err = strconv.Atoi(s); err != nil || scale < 1 || scale > 10 {
+			return responses.BadRequest(c, "scale harus 1 sampai 10", err)
+		}
+	}
+
+	var label struct {
+		Barcode     string
+		ProductId   string
+		ProductName string
+		UnitId      string
+	}
+	result := config.DB.Table("product_barcodes pb").
+		Select("pb.barcode, pb.product_id, pro.name AS product_name, pb.unit_id").
+		Joins("JOIN products pro ON pro.id = pb.product_id").
+		Where("pb.id = ? AND pb.branch_id = ?", id, branchID).
+		Limit(1).
+		Scan(&label)
+	if result.Error != nil {
+		return responses.InternalServerError(c, "Failed to get barcode", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return responses.NotFound(c, "Barcode not found")
+	}
+
+	saleUnit, err := tools.GetSaleUnit(config.DB, branchID, label.ProductId, label.UnitId)
+	if err != nil {
+		return saleUnitError(c, err)
+	}
+
+	image, symbology, err := tools.RenderBarcodePNG(label.Barcode, scale)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to render barcode", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Barcode label generated successfully", models.BarcodeLabelResponse{
+		Barcode:     label.Barcode,
+		Symbology:   symbology,
+		ProductName: label.ProductName,
+		UnitName:    saleUnit.UnitName,
+		Price:       saleUnit.Price,
+		ContentType: "image/png",
+		Image:       base64.StdEncoding.EncodeToString(image),
+	})
+}
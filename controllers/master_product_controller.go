@@ -12,11 +12,13 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
 	"github.com/heru-oktafian/scafold/config"
 	"github.com/heru-oktafian/scafold/framework"
 	"github.com/heru-oktafian/scafold/helpers"
 	"github.com/heru-oktafian/scafold/middlewares"
 	"github.com/heru-oktafian/scafold/responses"
+	"gorm.io/gorm"
 )
 
 // Redis client instance (should be initialized in your app, here for example)
@@ -33,11 +35,62 @@ func CreateProduct(c *framework.Ctx) error {
 	return helpers.CreateResource(c, config.DB, &models.Product{}, branch_id, "PRD")
 }
 
-// UpdateProduct update Product
+// UpdateProduct update Product, perubahan harga beli / jual / alternatif dicatat ke riwayat harga
 func UpdateProduct(c *framework.Ctx) error {
 	id := c.Param("id")
-	// Updating Product using helpers
-	return helpers.UpdateResource(c, config.DB, &models.Product{}, id)
+
+	var product models.Product
+	if err := config.DB.First(&product, "id = ?", id).Error; err != nil {
+		return responses.NotFound(c, "Product not found")
+	}
+
+	// Kolom yang tidak dikirim (nilai kosong) tidak ikut diubah
+	var input models.Product
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+
+	userID, _ := middlewares.GetUserID(c.Request)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	ref := tools.PriceRef{
+		Source:      models.PriceSourceManual,
+		ReferenceID: id,
+		UserID:      userID,
+		BranchID:    branchID,
+	}
+
+	// Update produk dan riwayat harganya dalam satu transaksi
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Product{}).Where("id = ?", id).
+			Omit("id", "branch_id", models.PriceFieldPurchase, models.PriceFieldSales, models.PriceFieldAlternate).
+			Updates(&input).Error; err != nil {
+			return fmt.Errorf("update product: %w", err)
+		}
+
+		// Harga diubah lewat SetProductPrice agar setiap perubahan tercatat di riwayat harga
+		for _, price := range []struct {
+			field string
+			value int
+		}{
+			{models.PriceFieldPurchase, input.PurchasePrice},
+			{models.PriceFieldSales, input.SalesPrice},
+			{models.PriceFieldAlternate, input.AlternatePrice},
+		} {
+			if price.value == 0 {
+				continue
+			}
+			if err := tools.SetProductPrice(tx, ref, id, price.field, price.value); err != nil {
+				return fmt.Errorf("update %s: %w", price.field, err)
+			}
+		}
+
+		return tx.First(&product, "id = ?", id).Error
+	})
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to update product", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Product updated successfully", product)
 }
 
 // DeleteProduct hapus Product
@@ -53,7 +106,7 @@ func GetProduct(c *framework.Ctx) error {
 	var AllProduct []models.ProductDetail
 	if err := config.DB.
 		Table("products pro").
-		Select("pro.id,pro.sku,pro.name,pro.description,pro.unit_id AS unit_id,pro.stock,pro.purchase_price,pro.expired_date,pro.sales_price,pro.alternate_price,pro.product_category_id,pc.name AS product_category_name,un.name AS unit_name,pro.branch_id").
+		Select("pro.id,pro.sku,pro.name,pro.description,pro.unit_id AS unit_id,pro.stock,pro.purchase_price,pro.expired_date,pro.sales_price,pro.alternate_price,pro.product_category_id,pc.name AS product_category_name,un.name AS unit_name,pro.tax_exempt,pro.branch_id").
 		Joins("LEFT JOIN product_categories pc ON pc.id = pro.product_category_id").
 		Joins("LEFT JOIN units un ON un.id = pro.unit_id").
 		Where("pro.id = ?", id).
@@ -65,6 +118,20 @@ func GetProduct(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Data ditemukan", AllProduct)
 }
 
+// productExportColumns kolom ekspor daftar produk
+var productExportColumns = []tools.ExportColumn{
+	{Header: "SKU"},
+	{Header: "Nama Produk"},
+	{Header: "Kategori"},
+	{Header: "Satuan"},
+	{Header: "Stok", Kind: tools.ExportNumber},
+	{Header: "Harga Beli", Kind: tools.ExportMoney},
+	{Header: "Harga Jual", Kind: tools.ExportMoney},
+	{Header: "Harga Alternatif", Kind: tools.ExportMoney},
+	{Header: "Kedaluwarsa", Kind: tools.ExportDate},
+	{Header: "Bebas Pajak"},
+}
+
 // GetAllProduct tampilkan semua Product
 func GetAllProduct(c *framework.Ctx) error {
 	// Ambil ID cabang
@@ -88,7 +155,7 @@ func GetAllProduct(c *framework.Ctx) error {
 
 	// Query dasar
 	query := config.DB.Table("products pro").
-		Select("pro.id,pro.sku,pro.name,pro.description, pro.unit_id, un.name AS unit_name,pro.stock,pro.purchase_price,pro.sales_price,pro.alternate_price,pro.expired_date, pro.product_category_id, pc.name AS product_category_name").
+		Select("pro.id,pro.sku,pro.name,pro.description, pro.unit_id, un.name AS unit_name,pro.stock,pro.purchase_price,pro.sales_price,pro.alternate_price,pro.expired_date, pro.product_category_id, pc.name AS product_category_name, pro.tax_exempt").
 		Joins("LEFT JOIN product_categories pc ON pc.id = pro.product_category_id").
 		Joins("LEFT JOIN units un ON un.id = pro.unit_id").
 		Where("pro.branch_id = ?", branch_id)
@@ -102,6 +169,14 @@ func GetAllProduct(c *framework.Ctx) error {
 	// Tambahkan sorting ascending berdasarkan pro.name
 	query = query.Order("pro.name ASC")
 
+	// ?format=csv|xlsx mengekspor seluruh hasil filter tanpa paginasi
+	if format := c.Query("format"); format != "" {
+		return exportList(c, query, format, "produk", productExportColumns, func(product models.ProductDetail) []interface{} {
+			return []interface{}{product.SKU, product.Name, product.ProductCategoryName, product.UnitName, product.Stock,
+				product.PurchasePrice, product.SalesPrice, product.AlternatePrice, product.ExpiredDate, product.TaxExempt}
+		})
+	}
+
 	// Hitung total produk yang sesuai dengan filter
 	if err := query.Count(&total).Error; err != nil {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Get Products failed", "Failed to count Products")
@@ -161,18 +236,26 @@ func CmbProdSale(c *framework.Ctx) error {
 	branch_id, _ := middlewares.GetBranchID(c.Request)
 	search := strings.TrimSpace(c.Query("search"))
 
-	// Cek cache Redis terlebih dahulu
+	// Harga mengikuti daftar harga pembeli, dari price_list_id atau kategori member_id
+	priceListID := strings.TrimSpace(c.Query("price_list_id"))
+	if priceListID == "" {
+		var err error
+		priceListID, err = tools.GetMemberPriceList(config.DB, branch_id, strings.TrimSpace(c.Query("member_id")))
+		if err != nil {
+			return responses.JSONResponse(c, http.StatusInternalServerError, "Get Combo Products failed", err)
+		}
+	}
+
+	// Cek cache Redis terlebih dahulu, cache hanya berisi harga jual normal
+	useCache := search == "" && priceListID == ""
 	cached, err := GetTemporaryProductCache(fmt.Sprintf("%v", branch_id))
-	if err == nil && cached != nil && search == "" {
+	if err == nil && cached != nil && useCache {
 		return responses.JSONResponse(c, http.StatusOK, "Combo Products retrieved successfully (from cache)", cached)
 	}
 
 	var cmbProducts []models.ProdSaleCombo
 
-	query := config.DB.Table("products").
-		Select("products.id as product_id, products.name as product_name, sales_price AS price, products.stock, products.unit_id, units.name AS unit_name").
-		Joins("LEFT JOIN units ON units.id = products.unit_id").
-		Where("products.branch_id = ?", branch_id)
+	query := tools.SaleProductQuery(config.DB, branch_id)
 
 	if search != "" {
 		search = strings.ToLower(search)
@@ -185,8 +268,31 @@ func CmbProdSale(c *framework.Ctx) error {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Get Combo Products failed", err)
 	}
 
-	// Simpan ke cache jika tanpa search
-	if search == "" {
+	// Lengkapi setiap produk dengan semua satuan yang bisa dijual
+	var productIDs []string
+	if search != "" {
+		for _, product := range cmbProducts {
+			productIDs = append(productIDs, product.ProductId)
+		}
+	}
+	if search == "" || len(productIDs) > 0 {
+		saleUnits, err := tools.GetSaleUnits(config.DB, branch_id, productIDs)
+		if err != nil {
+			return responses.JSONResponse(c, http.StatusInternalServerError, "Get Combo Products failed", err)
+		}
+		if err := tools.ApplyPriceList(config.DB, priceListID, saleUnits); err != nil {
+			return responses.JSONResponse(c, http.StatusInternalServerError, "Get Combo Products failed", err)
+		}
+		for i := range cmbProducts {
+			cmbProducts[i].Units = saleUnits[cmbProducts[i].ProductId]
+			if len(cmbProducts[i].Units) > 0 {
+				cmbProducts[i].Price = cmbProducts[i].Units[0].Price
+			}
+		}
+	}
+
+	// Simpan ke cache jika tanpa search dan tanpa daftar harga
+	if useCache {
 		_ = SetTemporaryProductCache(fmt.Sprintf("%v", branch_id), cmbProducts)
 		// Log data yang disimpan ke Redis
 		data, err := json.Marshal(cmbProducts)
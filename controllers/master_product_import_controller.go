@@ -0,0 +1,452 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// Batas file import produk
+const (
+	productImportMaxSize = 10 << 20 // 10 MB
+	productImportMaxRows = 5000
+)
+
+// productImportHeaders judul kolom yang dikenali (huruf kecil tanpa spasi / underscore).
+// Judul kolom hasil ekspor daftar produk juga dikenali sehingga file ekspor bisa di-import ulang.
+var productImportHeaders = map[string]string{
+	"sku":                "sku",
+	"kodeproduk":         "sku",
+	"namaproduk":         "name",
+	"nama":               "name",
+	"name":               "name",
+	"productname":        "name",
+	"deskripsi":          "description",
+	"keterangan":         "description",
+	"description":        "description",
+	"satuan":             "unit",
+	"unit":               "unit",
+	"unitname":           "unit",
+	"kategori":           "category",
+	"category":           "category",
+	"categoryname":       "category",
+	"productcategory":    "category",
+	"hargabeli":          "purchase_price",
+	"purchaseprice":      "purchase_price",
+	"hargajual":          "sales_price",
+	"salesprice":         "sales_price",
+	"hargaalternatif":    "alternate_price",
+	"alternateprice":     "alternate_price",
+	"stok":               "stock",
+	"stokawal":           "stock",
+	"stock":              "stock",
+	"kedaluwarsa":        "expired_date",
+	"tanggalkedaluwarsa": "expired_date",
+	"expireddate":        "expired_date",
+	"bebaspajak":         "tax_exempt",
+	"taxexempt":          "tax_exempt",
+}
+
+// productImportRequired kolom yang wajib ada di baris judul
+var productImportRequired = []struct{ field, title string }{
+	{"sku", "SKU"},
+	{"name", "Nama Produk"},
+	{"unit", "Satuan"},
+	{"category", "Kategori"},
+	{"sales_price", "Harga Jual"},
+}
+
+// ImportProducts import produk dari file csv / xlsx (multipart, field "file").
+// Tanpa ?commit=true hanya validasi (dry run) dan melaporkan kesalahan per baris.
+// Dengan ?commit=true satuan dan kategori yang belum ada dibuat, produk disimpan dan stok awal dicatat
+// sebagai satu transaksi FirstStocks, semuanya dalam satu transaksi database.
+// Import dibatalkan seluruhnya jika ada baris yang tidak valid.
+func ImportProducts(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+	commit := c.Query("commit") == "true"
+
+	if err := c.Request.ParseMultipartForm(productImportMaxSize); err != nil {
+		return responses.BadRequest(c, "File import wajib dikirim sebagai multipart form dengan field file", err)
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		return responses.BadRequest(c, "File import wajib dikirim sebagai multipart form dengan field file", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, productImportMaxSize+1))
+	if err != nil {
+		return responses.BadRequest(c, "Gagal membaca file import", err)
+	}
+	if len(data) > productImportMaxSize {
+		return responses.BadRequest(c, "Ukuran file import maksimal 10 MB", nil)
+	}
+
+	sheet, err := tools.ReadSpreadsheet(header.Filename, data)
+	if err != nil {
+		return responses.BadRequest(c, "Gagal membaca file import: "+err.Error(), err)
+	}
+
+	db := config.DB
+	result, err := parseProductImport(sheet, nowWIB)
+	if err != nil {
+		return responses.BadRequest(c, err.Error(), err)
+	}
+	if err := validateProductImport(db, branchID, &result); err != nil {
+		return responses.InternalServerError(c, "Gagal memvalidasi import produk", err)
+	}
+
+	if !commit {
+		result.DryRun = true
+		return responses.JSONResponse(c, http.StatusOK, "Validasi import selesai, belum ada data yang disimpan", result)
+	}
+	if result.InvalidRows > 0 {
+		return responses.JSONResponse(c, http.StatusUnprocessableEntity, "Import dibatalkan, perbaiki baris yang tidak valid", result)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := saveProductImport(tx, branchID, userID, header.Filename, nowWIB, &result); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Gagal menyimpan import produk", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Import produk berhasil", result)
+}
+
+// parseProductImport memetakan baris file ke ProductImportRow, kesalahan format nilai dicatat per baris
+func parseProductImport(sheet [][]string, now time.Time) (models.ProductImportResponse, error) {
+	var result models.ProductImportResponse
+	if len(sheet) == 0 {
+		return result, errors.New("file import kosong")
+	}
+
+	// Petakan kolom dari baris judul
+	columns := map[string]int{}
+	for i, title := range sheet[0] {
+		key := strings.NewReplacer(" ", "", "_", "", "-", "", ".", "").Replace(strings.ToLower(strings.TrimSpace(title)))
+		if field, ok := productImportHeaders[key]; ok {
+			if _, exists := columns[field]; !exists {
+				columns[field] = i
+			}
+		}
+	}
+	var missing []string
+	for _, required := range productImportRequired {
+		if _, ok := columns[required.field]; !ok {
+			missing = append(missing, required.title)
+		}
+	}
+	if len(missing) > 0 {
+		return result, fmt.Errorf("kolom wajib tidak ditemukan: %s", strings.Join(missing, ", "))
+	}
+	if len(sheet)-1 > productImportMaxRows {
+		return result, fmt.Errorf("maksimal %d baris produk per file", productImportMaxRows)
+	}
+
+	for i, cells := range sheet[1:] {
+		cell := func(field string) string {
+			if idx, ok := columns[field]; ok && idx < len(cells) {
+				return strings.TrimSpace(cells[idx])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(cells, "")) == "" {
+			continue // Baris kosong dilewati
+		}
+
+		row := models.ProductImportRow{
+			Row:          i + 2,
+			SKU:          cell("sku"),
+			Name:         cell("name"),
+			Description:  cell("description"),
+			UnitName:     cell("unit"),
+			CategoryName: cell("category"),
+		}
+		for _, amount := range []struct {
+			field, title string
+			target       *int
+		}{
+			{"purchase_price", "harga beli", &row.PurchasePrice},
+			{"sales_price", "harga jual", &row.SalesPrice},
+			{"alternate_price", "harga alternatif", &row.AlternatePrice},
+			{"stock", "stok", &row.Stock},
+		} {
+			value, err := tools.ParseImportAmount(cell(amount.field))
+			if err != nil {
+				row.Errors = append(row.Errors, amount.title+": "+err.Error())
+				continue
+			}
+			if value < 0 {
+				row.Errors = append(row.Errors, amount.title+" tidak boleh negatif")
+			}
+			*amount.target = value
+		}
+
+		// Tanpa tanggal kedaluwarsa dipakai default 2 tahun seperti item stok lainnya
+		expiredDate := now.AddDate(2, 0, 0)
+		if value := cell("expired_date"); value != "" {
+			parsed, err := tools.ParseImportDate(value)
+			if err != nil {
+				row.Errors = append(row.Errors, "kedaluwarsa: "+err.Error())
+			} else {
+				expiredDate = parsed
+			}
+		}
+		row.ExpiredDate = expiredDate.Format("2006-01-02")
+
+		taxExempt, err := tools.ParseImportBool(cell("tax_exempt"))
+		if err != nil {
+			row.Errors = append(row.Errors, "bebas pajak: "+err.Error())
+		}
+		row.TaxExempt = taxExempt
+
+		if row.SKU == "" {
+			row.Errors = append(row.Errors, "SKU wajib diisi")
+		}
+		if row.Name == "" {
+			row.Errors = append(row.Errors, "nama produk wajib diisi")
+		}
+		if row.UnitName == "" {
+			row.Errors = append(row.Errors, "satuan wajib diisi")
+		}
+		if row.CategoryName == "" {
+			row.Errors = append(row.Errors, "kategori wajib diisi")
+		}
+		if row.SalesPrice <= 0 {
+			row.Errors = append(row.Errors, "harga jual wajib lebih dari 0")
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	if len(result.Rows) == 0 {
+		return result, errors.New("file import tidak berisi data produk")
+	}
+	return result, nil
+}
+
+// validateProductImport memeriksa SKU ganda (di file dan di cabang) serta mencatat satuan / kategori baru
+func validateProductImport(db *gorm.DB, branchID string, result *models.ProductImportResponse) error {
+	units, categories, err := productImportLookups(db, branchID)
+	if err != nil {
+		return err
+	}
+
+	var skus []string
+	for _, row := range result.Rows {
+		if row.SKU != "" {
+			skus = append(skus, strings.ToLower(row.SKU))
+		}
+	}
+	existing := map[string]bool{}
+	if len(skus) > 0 {
+		var found []string
+		if err := db.Model(&models.Product{}).
+			Where("branch_id = ? AND LOWER(sku) IN ?", branchID, skus).
+			Pluck("LOWER(sku)", &found).Error; err != nil {
+			return err
+		}
+		for _, sku := range found {
+			existing[sku] = true
+		}
+	}
+
+	seenSKU := map[string]int{}
+	newUnits := map[string]bool{}
+	newCategories := map[string]bool{}
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		sku := strings.ToLower(row.SKU)
+		if sku != "" {
+			if existing[sku] {
+				row.Errors = append(row.Errors, "SKU sudah terdaftar di cabang ini")
+			}
+			if first, ok := seenSKU[sku]; ok {
+				row.Errors = append(row.Errors, "SKU sama dengan baris "+strconv.Itoa(first))
+			} else {
+				seenSKU[sku] = row.Row
+			}
+		}
+
+		if key := strings.ToLower(row.UnitName); key != "" && units[key] == "" && !newUnits[key] {
+			newUnits[key] = true
+			result.NewUnits = append(result.NewUnits, row.UnitName)
+		}
+		if key := strings.ToLower(row.CategoryName); key != "" && categories[key] == 0 && !newCategories[key] {
+			newCategories[key] = true
+			result.NewCategories = append(result.NewCategories, row.CategoryName)
+		}
+
+		result.TotalRows++
+		if len(row.Errors) > 0 {
+			result.InvalidRows++
+			continue
+		}
+		result.ValidRows++
+		result.TotalStock += row.PurchasePrice * row.Stock
+	}
+	return nil
+}
+
+// productImportLookups satuan dan kategori cabang, dipetakan dari nama (huruf kecil) ke ID
+func productImportLookups(db *gorm.DB, branchID string) (map[string]string, map[string]uint, error) {
+	var units []models.Unit
+	if err := db.Where("branch_id = ?", branchID).Find(&units).Error; err != nil {
+		return nil, nil, err
+	}
+	var categories []models.ProductCategory
+	if err := db.Where("branch_id = ?", branchID).Find(&categories).Error; err != nil {
+		return nil, nil, err
+	}
+
+	unitIDs := make(map[string]string, len(units))
+	for _, unit := range units {
+		unitIDs[strings.ToLower(strings.TrimSpace(unit.Name))] = unit.ID
+	}
+	categoryIDs := make(map[string]uint, len(categories))
+	for _, category := range categories {
+		categoryIDs[strings.ToLower(strings.TrimSpace(category.Name))] = category.ID
+	}
+	return unitIDs, categoryIDs, nil
+}
+
+// saveProductImport membuat satuan / kategori baru, produk dan transaksi stok awal untuk baris dengan stok
+func saveProductImport(tx *gorm.DB, branchID string, userID string, fileName string, now time.Time, result *models.ProductImportResponse) error {
+	// Lookup ulang di dalam transaksi agar satuan / kategori yang baru dibuat user lain ikut terpakai
+	units, categories, err := productImportLookups(tx, branchID)
+	if err != nil {
+		return err
+	}
+
+	firstStock := models.FirstStocks{
+		ID:             helpers.GenerateID("FST"),
+		Description:    "Stok awal import produk " + fileName,
+		FirstStockDate: now,
+		BranchID:       branchID,
+		Payment:        "nocost",
+		UserID:         userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	stockRef := tools.StockRef{
+		MovementType: models.FirstStockTrans,
+		ReferenceID:  firstStock.ID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
+
+	var firstStockItems []models.FirstStockItems
+	for i := range result.Rows {
+		row := &result.Rows[i]
+
+		unitKey := strings.ToLower(row.UnitName)
+		if units[unitKey] == "" {
+			unit := models.Unit{ID: helpers.GenerateID("UNT"), Name: row.UnitName, BranchID: branchID}
+			if err := tx.Create(&unit).Error; err != nil {
+				return fmt.Errorf("gagal membuat satuan %s: %w", row.UnitName, err)
+			}
+			units[unitKey] = unit.ID
+		}
+
+		categoryKey := strings.ToLower(row.CategoryName)
+		if categories[categoryKey] == 0 {
+			category := models.ProductCategory{Name: row.CategoryName, BranchID: branchID}
+			if err := tx.Create(&category).Error; err != nil {
+				return fmt.Errorf("gagal membuat kategori %s: %w", row.CategoryName, err)
+			}
+			categories[categoryKey] = category.ID
+		}
+
+		expiredDate, err := time.ParseInLocation("2006-01-02", row.ExpiredDate, utils.Location)
+		if err != nil {
+			return err
+		}
+
+		// Stok produk dimulai dari 0 lalu ditambah lewat transaksi stok awal agar tercatat di stock_tracks
+		product := models.Product{
+			ID:                helpers.GenerateID("PRD"),
+			SKU:               row.SKU,
+			Name:              row.Name,
+			Description:       row.Description,
+			UnitId:            units[unitKey],
+			PurchasePrice:     row.PurchasePrice,
+			ExpiredDate:       expiredDate,
+			SalesPrice:        row.SalesPrice,
+			AlternatePrice:    row.AlternatePrice,
+			ProductCategoryId: categories[categoryKey],
+			TaxExempt:         row.TaxExempt,
+			BranchID:          branchID,
+		}
+		if err := tx.Create(&product).Error; err != nil {
+			return fmt.Errorf("gagal membuat produk baris %d: %w", row.Row, err)
+		}
+		row.ProductId = product.ID
+
+		if row.Stock <= 0 {
+			continue
+		}
+
+		item := models.FirstStockItems{
+			ID:           helpers.GenerateID("FSI"),
+			FirstStockId: firstStock.ID,
+			ProductId:    product.ID,
+			Price:        row.PurchasePrice,
+			Qty:          row.Stock,
+			SubTotal:     row.PurchasePrice * row.Stock,
+			ExpiredDate:  expiredDate,
+		}
+		if _, _, err := tools.ChangeProductStock(tx, product.ID, row.Stock, stockRef); err != nil {
+			return fmt.Errorf("gagal menambah stok produk %s: %w", product.Name, err)
+		}
+		if err := tools.AddProductBatch(tx, branchID, product.ID, item.ID, "", expiredDate, row.Stock); err != nil {
+			return fmt.Errorf("gagal membuat batch produk %s: %w", product.Name, err)
+		}
+		if err := tools.ApplyReceiptCost(tx, stockRef, product.ID, item.ID, row.Stock, row.PurchasePrice); err != nil {
+			return fmt.Errorf("gagal mencatat harga pokok produk %s: %w", product.Name, err)
+		}
+
+		firstStockItems = append(firstStockItems, item)
+		firstStock.TotalFirstStock += item.SubTotal
+	}
+
+	if len(firstStockItems) == 0 {
+		return nil
+	}
+	if err := tx.Create(&firstStock).Error; err != nil {
+		return fmt.Errorf("gagal membuat stok awal: %w", err)
+	}
+	if err := tx.CreateInBatches(&firstStockItems, 500).Error; err != nil {
+		return fmt.Errorf("gagal membuat item stok awal: %w", err)
+	}
+	result.FirstStockId = firstStock.ID
+	return nil
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+func TestParseProductImportRowErrors(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, utils.Location)
+	sheet := [][]string{
+		{"Kode Produk", "Nama Produk", "Satuan", "Kategori", "Harga Beli", "Harga Jual", "Stok", "Kedaluwarsa", "Bebas Pajak"},
+		{"PCT500", "Paracetamol 500 mg", "Strip", "Obat Bebas", "Rp 4.000", "5.000", "10", "17-10-2027", "Tidak"},
+		{"", "", "", "", "", "", "", "", ""},
+		{"AMX250", "", "Strip", "", "-1.000", "lima ribu", "2", "31-02-2027", "mungkin"},
+		{"VTC", "Vitamin C", "Botol"},
+	}
+
+	result, err := parseProductImport(sheet, now)
+	if err != nil {
+		t.Fatalf("parseProductImport: %v", err)
+	}
+	if len(result.Rows) != 3 {
+		t.Fatalf("jumlah baris %d, seharusnya 3 (baris kosong dilewati)", len(result.Rows))
+	}
+
+	valid := result.Rows[0]
+	if valid.Row != 2 || len(valid.Errors) != 0 {
+		t.Fatalf("baris 2 seharusnya valid, didapat baris %d dengan error %q", valid.Row, valid.Errors)
+	}
+	if valid.PurchasePrice != 4000 || valid.SalesPrice != 5000 || valid.Stock != 10 || valid.ExpiredDate != "2027-10-17" || valid.TaxExempt {
+		t.Fatalf("nilai baris 2 tidak sesuai: %+v", valid)
+	}
+
+	// Nomor baris mengikuti file, baris kosong tetap dihitung
+	bad := result.Rows[1]
+	if bad.Row != 4 {
+		t.Fatalf("nomor baris = %d, seharusnya 4", bad.Row)
+	}
+	wantErrors := []string{
+		"harga beli tidak boleh negatif",
+		"harga jual: ",
+		"kedaluwarsa: ",
+		"bebas pajak: ",
+		"nama produk wajib diisi",
+		"kategori wajib diisi",
+		"harga jual wajib lebih dari 0",
+	}
+	if len(bad.Errors) != len(wantErrors) {
+		t.Fatalf("error baris 4 = %q, seharusnya %d error", bad.Errors, len(wantErrors))
+	}
+	for i, want := range wantErrors {
+		if !strings.HasPrefix(bad.Errors[i], want) {
+			t.Errorf("error ke-%d baris 4 = %q, seharusnya diawali %q", i+1, bad.Errors[i], want)
+		}
+	}
+
+	// Baris pendek: kolom yang tidak ada dianggap kosong, kedaluwarsa default 2 tahun
+	short := result.Rows[2]
+	if short.Row != 5 || short.ExpiredDate != "2028-10-17" {
+		t.Fatalf("baris 5 = %+v", short)
+	}
+	if strings.Join(short.Errors, "|") != "kategori wajib diisi|harga jual wajib lebih dari 0" {
+		t.Fatalf("error baris 5 = %q", short.Errors)
+	}
+}
+
+func TestParseProductImportHeader(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, utils.Location)
+
+	_, err := parseProductImport([][]string{{"SKU", "Nama", "Harga Beli"}, {"A1", "Kapas", "1000"}}, now)
+	if err == nil || !strings.Contains(err.Error(), "Satuan, Kategori, Harga Jual") {
+		t.Fatalf("kolom wajib yang hilang seharusnya dilaporkan, didapat %v", err)
+	}
+
+	_, err = parseProductImport([][]string{{"sku", "name", "unit", "category", "sales_price"}, {"", "", ""}}, now)
+	if err == nil {
+		t.Fatalf("file tanpa baris data seharusnya error")
+	}
+
+	if _, err := parseProductImport(nil, now); err == nil {
+		t.Fatalf("file kosong seharusnya error")
+	}
+}
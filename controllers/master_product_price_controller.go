@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+// GetProductPriceHistory menampilkan riwayat perubahan harga satu produk,
+// bisa difilter kolom harga (field) dan tanggal (YYYY-MM-DD)
+func GetProductPriceHistory(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	productID := c.Param("id")
+
+	var product models.Product
+	if err := db.Select("id").Where("id = ? AND branch_id = ?", productID, branchID).First(&product).Error; err != nil {
+		return responses.NotFound(c, "Product not found")
+	}
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	query := db.Table("product_price_histories pph").
+		Select(`pph.id, pph.product_id, pph.field, pph.old_price, pph.new_price, pph.source,
+			COALESCE(pph.reference_id, '') AS reference_id, COALESCE(pph.user_id, '') AS user_id, COALESCE(usr.name, '') AS user_name, pph.created_at`).
+		Joins("LEFT JOIN users usr ON usr.user_id = pph.user_id").
+		Where("pph.product_id = ? AND pph.branch_id = ?", productID, branchID)
+
+	if field := strings.TrimSpace(c.Query("field")); field != "" {
+		query = query.Where("pph.field = ?", field)
+	}
+	if startDate := strings.TrimSpace(c.Query("start_date")); startDate != "" {
+		parsedStart, err := time.ParseInLocation("2006-01-02", startDate, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Format start_date tidak valid, gunakan YYYY-MM-DD", err)
+		}
+		query = query.Where("pph.created_at >= ?", parsedStart)
+	}
+	if endDate := strings.TrimSpace(c.Query("end_date")); endDate != "" {
+		parsedEnd, err := time.ParseInLocation("2006-01-02", endDate, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Format end_date tidak valid, gunakan YYYY-MM-DD", err)
+		}
+		query = query.Where("pph.created_at < ?", parsedEnd.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to count price history", err)
+	}
+
+	var histories []models.ProductPriceHistoryDetail
+	if err := query.Order("pph.created_at DESC, pph.id DESC").Offset(offset).Limit(limit).Scan(&histories).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get price history", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Price history retrieved successfully", "", int(total), page, totalPages, limit, histories)
+}
+
+// CreateScheduledPriceChange menjadwalkan perubahan harga produk, diterapkan scheduler mulai tanggal berlaku (WIB)
+func CreateScheduledPriceChange(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+	nowWIB := time.Now().In(utils.Location)
+
+	var input models.ScheduledPriceChangeInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for scheduled price input", err)
+	}
+
+	effectiveDate, err := time.ParseInLocation("2006-01-02", input.EffectiveDate, utils.Location)
+	if err != nil {
+		return responses.BadRequest(c, "Format effective_date tidak valid, gunakan YYYY-MM-DD", err)
+	}
+	today := time.Date(nowWIB.Year(), nowWIB.Month(), nowWIB.Day(), 0, 0, 0, 0, utils.Location)
+	if effectiveDate.Before(today) {
+		return responses.BadRequest(c, "Tanggal berlaku tidak boleh sebelum hari ini", nil)
+	}
+
+	var product models.Product
+	if err := db.Select("id").Where("id = ? AND branch_id = ?", input.ProductId, branchID).First(&product).Error; err != nil {
+		return responses.NotFound(c, "Product not found")
+	}
+
+	schedule := models.ScheduledPriceChanges{
+		ID:            helpers.GenerateID("SPC"),
+		ProductId:     product.ID,
+		Field:         input.Field,
+		NewPrice:      input.NewPrice,
+		EffectiveDate: effectiveDate,
+		Status:        models.ScheduledPricePending,
+		Note:          input.Note,
+		UserID:        userID,
+		BranchID:      branchID,
+		CreatedAt:     nowWIB,
+		UpdatedAt:     nowWIB,
+	}
+	if err := db.Create(&schedule).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to create scheduled price change", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Scheduled price change created successfully", schedule)
+}
+
+// GetScheduledPriceChanges menampilkan jadwal perubahan harga cabang, bisa difilter status dan produk
+func GetScheduledPriceChanges(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	query := config.DB.Table("scheduled_price_changes spc").
+		Select(`spc.id, spc.product_id, pro.name AS product_name, spc.field,
+			CASE spc.field WHEN 'purchase_price' THEN pro.purchase_price WHEN 'sales_price' THEN pro.sales_price ELSE pro.alternate_price END AS current_price,
+			spc.new_price, spc.effective_date, spc.status, COALESCE(spc.note, '') AS note, spc.applied_at,
+			spc.user_id, COALESCE(usr.name, '') AS user_name`).
+		Joins("JOIN products pro ON pro.id = spc.product_id").
+		Joins("LEFT JOIN users usr ON usr.user_id = spc.user_id").
+		Where("spc.branch_id = ?", branchID)
+
+	if status := strings.TrimSpace(c.Query("status")); status != "" {
+		query = query.Where("spc.status = ?", status)
+	}
+	if productID := strings.TrimSpace(c.Query("product_id")); productID != "" {
+		query = query.Where("spc.product_id = ?", productID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to count scheduled price changes", err)
+	}
+
+	var schedules []models.ScheduledPriceChangeDetail
+	if err := query.Order("spc.effective_date DESC, spc.created_at DESC").Offset(offset).Limit(limit).Scan(&schedules).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get scheduled price changes", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Scheduled price changes retrieved successfully", "", int(total), page, totalPages, limit, schedules)
+}
+
+// CancelScheduledPriceChange membatalkan jadwal perubahan harga yang belum diterapkan
+func CancelScheduledPriceChange(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var schedule models.ScheduledPriceChanges
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&schedule).Error; err != nil {
+		return responses.NotFound(c, "Scheduled price change not found")
+	}
+
+	result := db.Model(&models.ScheduledPriceChanges{}).
+		Where("id = ? AND status = ?", schedule.ID, models.ScheduledPricePending).
+		Update("status", models.ScheduledPriceCancelled)
+	if result.Error != nil {
+		return responses.InternalServerError(c, "Failed to cancel scheduled price change", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return responses.BadRequest(c, "Jadwal perubahan harga sudah diterapkan atau dibatalkan", nil)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Scheduled price change cancelled successfully", framework.Map{"id": schedule.ID})
+}
@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// CreatePromo membuat aturan promo baru
+func CreatePromo(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	nowWIB := time.Now().In(utils.Location)
+
+	var input models.PromoInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for promo input", err)
+	}
+
+	promo := models.Promos{
+		ID:        helpers.GenerateID("PRM"),
+		Active:    true,
+		BranchID:  branchID,
+		CreatedAt: nowWIB,
+		UpdatedAt: nowWIB,
+	}
+	if err := fillPromo(db, branchID, input, &promo); err != nil {
+		return responses.BadRequest(c, err.Error(), err)
+	}
+
+	if err := db.Create(&promo).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to create promo", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Promo created successfully", promo)
+}
+
+// UpdatePromo mengubah aturan promo, promo yang sudah dipakai tetap tercatat di item penjualan
+func UpdatePromo(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var promo models.Promos
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&promo).Error; err != nil {
+		return responses.NotFound(c, "Promo not found")
+	}
+
+	var input models.PromoInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for promo input", err)
+	}
+
+	if err := fillPromo(db, branchID, input, &promo); err != nil {
+		return responses.BadRequest(c, err.Error(), err)
+	}
+	promo.UpdatedAt = time.Now().In(utils.Location)
+
+	if err := db.Save(&promo).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to update promo", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Promo updated successfully", promo)
+}
+
+// DeletePromo menghapus promo yang belum pernah dipakai, selain itu promo hanya dinonaktifkan
+func DeletePromo(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var promo models.Promos
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&promo).Error; err != nil {
+		return responses.NotFound(c, "Promo not found")
+	}
+
+	var used int64
+	if err := db.Model(&models.SaleItems{}).Where("promo_id = ?", promo.ID).Count(&used).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to check promo usage", err)
+	}
+
+	if used > 0 {
+		if err := db.Model(&promo).Update("active", false).Error; err != nil {
+			return responses.InternalServerError(c, "Failed to deactivate promo", err)
+		}
+		return responses.JSONResponse(c, http.StatusOK, "Promo sudah dipakai pada penjualan, promo dinonaktifkan", promo)
+	}
+
+	if err := db.Delete(&promo).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to delete promo", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Promo deleted successfully", promo)
+}
+
+// GetPromo menampilkan satu promo
+func GetPromo(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var promo models.PromoDB
+	if err := promoQuery(config.DB, branchID).Where("prm.id = ?", id).Scan(&promo).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get promo", err)
+	}
+	if promo.ID == "" {
+		return responses.NotFound(c, "Promo not found")
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Promo retrieved successfully", formatPromo(promo))
+}
+
+// GetAllPromos menampilkan semua promo dengan pagination, search dan filter status aktif
+func GetAllPromos(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	search := strings.TrimSpace(c.Query("search"))
+	active := strings.TrimSpace(c.Query("active"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	query := promoQuery(config.DB, branchID)
+
+	if search != "" {
+		query = query.Where("LOWER(prm.name) LIKE ?", "%"+strings.ToLower(search)+"%")
+	}
+
+	if active != "" {
+		query = query.Where("prm.active = ?", active == "true")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to count promos", err)
+	}
+
+	var promos []models.PromoDB
+	if err := query.Order("prm.end_date DESC").Offset(offset).Limit(limit).Scan(&promos).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get promos", err)
+	}
+
+	details := make([]models.PromoResponse, 0, len(promos))
+	for _, promo := range promos {
+		details = append(details, formatPromo(promo))
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Promos retrieved successfully", search, int(total), page, totalPages, limit, details)
+}
+
+// promoQuery query dasar promo beserta nama produk, kategori produk dan kategori member
+func promoQuery(db *gorm.DB, branchID string) *gorm.DB {
+	return db.Table("promos prm").
+		Select(`prm.id, prm.name, prm.promo_type, COALESCE(prm.product_id, '') AS product_id, COALESCE(pro.name, '') AS product_name,
+			COALESCE(prm.product_category_id, 0) AS product_category_id, COALESCE(pc.name, '') AS product_category_name,
+			COALESCE(prm.member_category_id, 0) AS member_category_id, COALESCE(mc.name, '') AS member_category_name,
+			prm.value, prm.buy_qty, prm.get_qty, prm.min_basket, prm.start_date, prm.end_date, prm.active`).
+		Joins("LEFT JOIN products pro ON pro.id = prm.product_id").
+		Joins("LEFT JOIN product_categories pc ON pc.id = prm.product_category_id").
+		Joins("LEFT JOIN member_categories mc ON mc.id = prm.member_category_id").
+		Where("prm.branch_id = ?", branchID)
+}
+
+// formatPromo mengubah data promo hasil query menjadi respons dengan tanggal terformat
+func formatPromo(promo models.PromoDB) models.PromoResponse {
+	return models.PromoResponse{
+		ID:                  promo.ID,
+		Name:                promo.Name,
+		PromoType:           promo.PromoType,
+		ProductId:           promo.ProductId,
+		ProductName:         promo.ProductName,
+		ProductCategoryId:   promo.ProductCategoryId,
+		ProductCategoryName: promo.ProductCategoryName,
+		MemberCategoryId:    promo.MemberCategoryId,
+		MemberCategoryName:  promo.MemberCategoryName,
+		Value:               promo.Value,
+		BuyQty:              promo.BuyQty,
+		GetQty:              promo.GetQty,
+		MinBasket:           promo.MinBasket,
+		StartDate:           utils.FormatIndonesianDate(promo.StartDate),
+		EndDate:             utils.FormatIndonesianDate(promo.EndDate),
+		Active:              promo.Active,
+	}
+}
+
+// fillPromo memvalidasi input promo dan menyalinnya ke model
+func fillPromo(db *gorm.DB, branchID string, input models.PromoInput, promo *models.Promos) error {
+	startDate, err := time.ParseInLocation("2006-01-02", input.StartDate, utils.Location)
+	if err != nil {
+		return fmt.Errorf("format start_date tidak valid, gunakan YYYY-MM-DD")
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", input.EndDate, utils.Location)
+	if err != nil {
+		return fmt.Errorf("format end_date tidak valid, gunakan YYYY-MM-DD")
+	}
+	if endDate.Before(startDate) {
+		return fmt.Errorf("end_date tidak boleh sebelum start_date")
+	}
+
+	switch models.PromoType(input.PromoType) {
+	case models.PromoPercentage:
+		if input.Value <= 0 || input.Value > 100 {
+			return fmt.Errorf("value promo persen harus antara 1 sampai 100")
+		}
+	case models.PromoAmount:
+		if input.Value <= 0 {
+			return fmt.Errorf("value promo nominal harus lebih dari 0")
+		}
+	case models.PromoBuyXGetY:
+		if input.BuyQty <= 0 || input.GetQty <= 0 {
+			return fmt.Errorf("buy_qty dan get_qty wajib diisi untuk promo buy_x_get_y")
+		}
+		if input.ProductId == nil && input.ProductCategoryId == nil {
+			return fmt.Errorf("promo buy_x_get_y harus dibatasi produk atau kategori produk")
+		}
+	}
+
+	// Kosongkan referensi yang dikirim sebagai string / id kosong
+	if input.ProductId != nil && *input.ProductId == "" {
+		input.ProductId = nil
+	}
+	if input.ProductCategoryId != nil && *input.ProductCategoryId == 0 {
+		input.ProductCategoryId = nil
+	}
+	if input.MemberCategoryId != nil && *input.MemberCategoryId == 0 {
+		input.MemberCategoryId = nil
+	}
+
+	if input.ProductId != nil {
+		var count int64
+		db.Model(&models.Product{}).Where("id = ? AND branch_id = ?", *input.ProductId, branchID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("produk %s tidak ditemukan", *input.ProductId)
+		}
+	}
+	if input.ProductCategoryId != nil {
+		var count int64
+		db.Model(&models.ProductCategory{}).Where("id = ? AND branch_id = ?", *input.ProductCategoryId, branchID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("kategori produk %d tidak ditemukan", *input.ProductCategoryId)
+		}
+	}
+	if input.MemberCategoryId != nil {
+		var count int64
+		db.Model(&models.MemberCategory{}).Where("id = ? AND branch_id = ?", *input.MemberCategoryId, branchID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("kategori member %d tidak ditemukan", *input.MemberCategoryId)
+		}
+	}
+
+	promo.Name = input.Name
+	promo.PromoType = models.PromoType(input.PromoType)
+	promo.ProductId = input.ProductId
+	promo.ProductCategoryId = input.ProductCategoryId
+	promo.MemberCategoryId = input.MemberCategoryId
+	promo.Value = input.Value
+	promo.BuyQty = input.BuyQty
+	promo.GetQty = input.GetQty
+	promo.MinBasket = input.MinBasket
+	promo.StartDate = startDate
+	promo.EndDate = endDate
+	if input.Active != nil {
+		promo.Active = *input.Active
+	}
+
+	return nil
+}
@@ -67,7 +67,7 @@ func GetAllSupplier(c *framework.Ctx) error {
 
 	// Query dasar
 	query := config.DB.Table("suppliers s").
-		Select("s.id, s.name, s.phone, s.address, s.pic, s.supplier_category_id, sc.name AS supplier_category").
+		Select("s.id, s.name, s.phone, s.address, s.pic, s.supplier_category_id, sc.name AS supplier_category, s.payment_terms").
 		Joins("LEFT JOIN supplier_categories sc ON sc.id = s.supplier_category_id").
 		Where("s.branch_id = ?", branch_id)
 
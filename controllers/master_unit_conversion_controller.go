@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	fmt "fmt"
 	"math"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
 	"github.com/heru-oktafian/scafold/config"
 	"github.com/heru-oktafian/scafold/framework"
 	"github.com/heru-oktafian/scafold/helpers"
@@ -104,14 +106,30 @@ func CreateUnitConversion(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to retrieve final unit for validation", err)
 	}
 
+	// --- 4. Pastikan konversi baru tidak membentuk siklus dan konsisten dengan jalur konversi yang sudah ada ---
+	// Contoh: box -> strip = 10 dan strip -> tablet = 10, maka box -> tablet hanya boleh 100
+	graph, err := tools.LoadUnitGraph(tx, branchID, product)
+	if err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to retrieve unit conversions for validation", err)
+	}
+	if err := graph.CheckConversion(req.InitId, req.FinalId, req.ValueConv); err != nil {
+		tx.Rollback()
+		if errors.Is(err, tools.ErrUnitConvCycle) || errors.Is(err, tools.ErrUnitConvInconsistent) {
+			return responses.Conflict(c, err)
+		}
+		return responses.BadRequest(c, err.Error(), err)
+	}
+
 	// --- Buat objek UnitConversion baru ---
 	unitConversion := models.UnitConversion{
-		ID:        helpers.GenerateID("UNC"), // Generate ID untuk Unit Conversion
-		ProductId: req.ProductId,
-		InitId:    req.InitId,
-		FinalId:   req.FinalId,
-		ValueConv: req.ValueConv,
-		BranchID:  branchID, // Set BranchID dari token
+		ID:         helpers.GenerateID("UNC"), // Generate ID untuk Unit Conversion
+		ProductId:  req.ProductId,
+		InitId:     req.InitId,
+		FinalId:    req.FinalId,
+		ValueConv:  req.ValueConv,
+		SalesPrice: req.SalesPrice,
+		BranchID:   branchID, // Set BranchID dari token
 	}
 
 	// Simpan UnitConversion ke database
@@ -134,15 +152,120 @@ func CreateUnitConversion(c *framework.Ctx) error {
 		"final_id":        unitConversion.FinalId,
 		"final_unit_name": finalUnit.Name, // Menambahkan nama unit
 		"value_conv":      unitConversion.ValueConv,
+		"sales_price":     unitConversion.SalesPrice,
 		"branch_id":       unitConversion.BranchID,
 	})
 }
 
-// UpdateUnit update unit
+// UpdateUnitConversion update unit conversion
+// Nilai baru divalidasi terhadap graf konversi produk tanpa baris yang sedang diubah
 func UpdateUnitConversion(c *framework.Ctx) error {
+	db := config.DB
 	id := c.Param("id")
-	// Updating unit using helpers
-	return helpers.UpdateResource(c, config.DB, &models.UnitConversion{}, id)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var unitConversion models.UnitConversion
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&unitConversion).Error; err != nil {
+		return responses.NotFound(c, "Unit conversion not found")
+	}
+
+	var req models.UnitConversionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.BadRequest(c, "Format data yang dikirim tidak valid", err)
+	}
+
+	// Produk konversi tidak bisa dipindah, hapus dan buat konversi baru untuk produk lain
+	if req.ProductId != "" && req.ProductId != unitConversion.ProductId {
+		return responses.BadRequest(c, "Produk konversi satuan tidak bisa diubah", nil)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// --- Pengecekan Duplikasi (selain baris ini) ---
+	var duplicate int64
+	if err := tx.Model(&models.UnitConversion{}).
+		Where("product_id = ? AND init_id = ? AND final_id = ? AND branch_id = ? AND id <> ?", unitConversion.ProductId, req.InitId, req.FinalId, branchID, id).
+		Count(&duplicate).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to check for existing unit conversion", err)
+	}
+	if duplicate > 0 {
+		tx.Rollback()
+		return responses.Conflict(c, fmt.Errorf("unit conversion from '%s' to '%s' for product '%s' already exists in this branch: duplicate entry",
+			req.InitId, req.FinalId, unitConversion.ProductId))
+	}
+
+	var product models.Product
+	if err := tx.Where("id = ? AND branch_id = ?", unitConversion.ProductId, branchID).First(&product).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to retrieve product for validation", err)
+	}
+
+	var initUnit models.Unit
+	if err := tx.Where("id = ? AND branch_id = ?", req.InitId, branchID).First(&initUnit).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return responses.NotFound(c, fmt.Sprintf("Initial unit (InitId) with ID %s not found in branch %s.", req.InitId, branchID))
+		}
+		return responses.InternalServerError(c, "Failed to retrieve initial unit for validation", err)
+	}
+
+	var finalUnit models.Unit
+	if err := tx.Where("id = ? AND branch_id = ?", req.FinalId, branchID).First(&finalUnit).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return responses.NotFound(c, fmt.Sprintf("Final unit (FinalId) with ID %s not found in branch %s.", req.FinalId, branchID))
+		}
+		return responses.InternalServerError(c, "Failed to retrieve final unit for validation", err)
+	}
+
+	// Nilai lama baris ini tidak ikut dihitung, sehingga perubahan nilai dicek terhadap jalur konversi lain saja
+	graph, err := tools.LoadUnitGraphWithout(tx, branchID, product, unitConversion.ID)
+	if err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to retrieve unit conversions for validation", err)
+	}
+	if err := graph.CheckConversion(req.InitId, req.FinalId, req.ValueConv); err != nil {
+		tx.Rollback()
+		if errors.Is(err, tools.ErrUnitConvCycle) || errors.Is(err, tools.ErrUnitConvInconsistent) {
+			return responses.Conflict(c, err)
+		}
+		return responses.BadRequest(c, err.Error(), err)
+	}
+
+	unitConversion.InitId = req.InitId
+	unitConversion.FinalId = req.FinalId
+	unitConversion.ValueConv = req.ValueConv
+	unitConversion.SalesPrice = req.SalesPrice
+
+	if err := tx.Save(&unitConversion).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to update unit conversion", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Unit conversion updated successfully", framework.Map{
+		"id":              unitConversion.ID,
+		"product_id":      unitConversion.ProductId,
+		"init_id":         unitConversion.InitId,
+		"init_unit_name":  initUnit.Name,
+		"final_id":        unitConversion.FinalId,
+		"final_unit_name": finalUnit.Name,
+		"value_conv":      unitConversion.ValueConv,
+		"sales_price":     unitConversion.SalesPrice,
+		"branch_id":       unitConversion.BranchID,
+	})
 }
 
 // DeleteUnit hapus unit
@@ -182,7 +305,7 @@ func GetAllUnitConversion(c *framework.Ctx) error {
 
 	// Query dasar
 	query := config.DB.Table("unit_conversions unc").
-		Select("unc.id, pro.name AS product_name, uin.name AS init_name, ufi.name AS final_name, unc.value_conv, unc.product_id, unc.init_id, unc.final_id, unc.branch_id").
+		Select("unc.id, pro.name AS product_name, uin.name AS init_name, ufi.name AS final_name, unc.value_conv, unc.sales_price, unc.product_id, unc.init_id, unc.final_id, unc.branch_id").
 		Joins("LEFT JOIN products pro on pro.id = unc.product_id").
 		Joins("LEFT JOIN units uin on uin.id = unc.init_id").
 		Joins("LEFT JOIN units ufi on ufi.id = unc.final_id").
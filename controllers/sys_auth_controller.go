@@ -217,7 +217,7 @@ func GetProfile(c *framework.Ctx) error {
 	// Melakukan LEFT OUTER JOIN menggunakan GORM
 	if err := config.DB.
 		Table("user_branches usrbrc").
-		Select("usrbrc.user_id AS user_id, usr.name AS profile_name, usrbrc.branch_id AS branch_id, brc.branch_name AS branch_name, brc.address, brc.phone, brc.email, brc.bank_name, brc.account_name, brc.account_number, brc.tax_percentage, brc.journal_method, brc.default_member AS default_member, mbr.name AS member_name, brc.branch_status, brc.owner_id, brc.owner_name").
+		Select("usrbrc.user_id AS user_id, usr.name AS profile_name, usrbrc.branch_id AS branch_id, brc.branch_name AS branch_name, brc.address, brc.phone, brc.email, brc.bank_name, brc.account_name, brc.account_number, brc.tax_percentage, brc.tax_mode, brc.journal_method, brc.default_member AS default_member, mbr.name AS member_name, brc.branch_status, brc.owner_id, brc.owner_name").
 		Joins("LEFT JOIN users usr ON usr.user_id = usrbrc.user_id").
 		Joins("LEFT JOIN branches brc ON brc.id = usrbrc.branch_id").
 		Joins("LEFT JOIN members mbr ON mbr.id = brc.default_member").
@@ -163,7 +163,7 @@ func GetTopSellingProducts(c *framework.Ctx) error {
 	var results []Result
 	err := db.
 		Table("sale_items").
-		Select("products.id as product_id, products.name, SUM(sale_items.qty) as total_qty").
+		Select("products.id as product_id, products.name, SUM(sale_items.qty * sale_items.conv_value) as total_qty").
 		Joins("JOIN sales ON sales.id = sale_items.sale_id").
 		Joins("JOIN products ON products.id = sale_items.product_id").
 		Where("sales.sale_date >= ? AND sales.branch_id = ?", oneMonthAgo, branchID).
@@ -190,7 +190,7 @@ func GetLeastSellingProducts(c *framework.Ctx) error {
 	// Subquery: Ambil total qty penjualan per product dalam 1 bulan terakhir
 	subQuery := db.
 		Table("sale_items").
-		Select("product_id, SUM(qty) as total_sold").
+		Select("product_id, SUM(qty * conv_value) as total_sold").
 		Joins("JOIN sales ON sales.id = sale_items.sale_id").
 		Where("sales.sale_date BETWEEN ? AND ?", oneMonthAgo, now).
 		Group("product_id")
@@ -234,41 +234,48 @@ func GetExpiringProducts(c *framework.Ctx) error {
 	// Namun, untuk `expired_date <= ?`, `time.Now().AddDate(0, 3, 0)` sudah cukup.
 	threeMonthsLater := nowWIB.AddDate(0, 3, 0)
 
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
 	// Gunakan struct ini untuk menampung hasil kueri dari database
 	// Karena kita ingin mengakses expired_date sebagai time.Time sebelum memformatnya
-	type ProductQueryResult struct {
+	type BatchQueryResult struct {
 		ID          string    `gorm:"column:id"`
+		BatchNumber string    `gorm:"column:batch_number"`
+		ProductId   string    `gorm:"column:product_id"`
 		SKU         string    `gorm:"column:sku"`
 		Name        string    `gorm:"column:name"`
-		Stock       int       `gorm:"column:stock"`
+		Qty         int       `gorm:"column:qty"`
 		Unit        string    `gorm:"column:unit"` // Alias untuk units.name
 		ExpiredDate time.Time `gorm:"column:expired_date"`
 	}
 
-	var rawProducts []ProductQueryResult // Slice untuk menampung hasil kueri mentah
+	var rawBatches []BatchQueryResult // Slice untuk menampung hasil kueri mentah
 
-	// Lakukan satu kali kueri ke database
-	err := db.Table("products").
-		Select("products.id, products.sku, products.name, products.stock, units.name as unit, products.expired_date").
-		Joins("LEFT JOIN units ON products.unit_id = units.id").                          // Gabungkan dengan tabel units
-		Where("products.expired_date <= ? AND products.stock >= ?", threeMonthsLater, 1). // Filter berdasarkan tanggal kadaluarsa dan stok >= 1
-		Order("products.expired_date ASC").
-		Scan(&rawProducts).Error // Pindai langsung ke slice `rawProducts`
+	// Ambil stok per batch, bukan lagi satu tanggal kadaluarsa per produk
+	err := db.Table("product_batches pb").
+		Select("pb.id, pb.batch_number, pb.product_id, products.sku, products.name, pb.qty, units.name as unit, pb.expired_date").
+		Joins("JOIN products ON products.id = pb.product_id").
+		Joins("LEFT JOIN units ON products.unit_id = units.id").
+		Where("pb.branch_id = ? AND pb.expired_date <= ? AND pb.qty >= ?", branchID, threeMonthsLater, 1).
+		Order("pb.expired_date ASC, products.name ASC").
+		Scan(&rawBatches).Error
 
 	if err != nil {
 		return responses.InternalServerError(c, "Failed to fetch expiring products", err)
 	}
 
 	// Setelah mendapatkan data mentah, kita format sesuai respons yang diinginkan
-	var productsResponse []models.ProductExpiredResponse
-	for _, p := range rawProducts {
-		productsResponse = append(productsResponse, models.ProductExpiredResponse{
-			ID:          p.ID,
-			SKU:         p.SKU,
-			Name:        p.Name,
-			Stock:       p.Stock,
-			Unit:        p.Unit,
-			ExpiredDate: p.ExpiredDate.Format("2006-01-02"), // Format ke "YYYY-MM-DD"
+	var productsResponse []models.ProductBatchExpiredResponse
+	for _, b := range rawBatches {
+		productsResponse = append(productsResponse, models.ProductBatchExpiredResponse{
+			ID:          b.ID,
+			BatchNumber: b.BatchNumber,
+			ProductId:   b.ProductId,
+			SKU:         b.SKU,
+			Name:        b.Name,
+			Qty:         b.Qty,
+			Unit:        b.Unit,
+			ExpiredDate: b.ExpiredDate.Format("2006-01-02"), // Format ke "YYYY-MM-DD"
 		})
 	}
 
@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// Kolom tabel item yang sama untuk faktur, tanda terima dan nota retur
+var (
+	docColNo      = models.DocumentColumn{Title: "No", Width: 4, Right: true}
+	docColProduct = models.DocumentColumn{Title: "Produk", Width: 30}
+	docColQty     = models.DocumentColumn{Title: "Qty", Width: 7, Right: true}
+	docColUnit    = models.DocumentColumn{Title: "Satuan", Width: 10}
+	docColPrice   = models.DocumentColumn{Title: "Harga", Width: 13, Right: true}
+	docColExpired = models.DocumentColumn{Title: "Kedaluwarsa", Width: 12}
+	docColTotal   = models.DocumentColumn{Title: "Subtotal", Width: 15, Right: true}
+)
+
+// GetSalePDF faktur penjualan dalam format PDF, dilengkapi instruksi transfer dari rekening cabang
+func GetSalePDF(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	saleID := c.Param("id")
+
+	if found, err := documentInBranch(db, "sales", saleID, branchID); err != nil {
+		return responses.InternalServerError(c, "Failed to get sale", err)
+	} else if !found {
+		return responses.NotFound(c, "Sale not found")
+	}
+
+	sale, err := getSale(db, saleID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to get sale", err)
+	}
+	items, err := getSaleItems(db, saleID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to get sale items", err)
+	}
+
+	doc, branch, err := branchDocument(db, branchID, "FAKTUR PENJUALAN")
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to get branch", err)
+	}
+	doc.Info = []models.DocumentField{
+		{Label: "No. Faktur", Value: sale.ID},
+		{Label: "Tanggal", Value: utils.FormatIndonesianDate(sale.SaleDate)},
+		{Label: "Pelanggan", Value: sale.MemberName},
+		{Label: "Pembayaran", Value: tools.PaymentLabel(sale.Payment)},
+	}
+	doc.Columns = []models.DocumentColumn{docColNo, docColProduct, docColQty, docColUnit, docColPrice,
+		{Title: "Diskon", Width: 11, Right: true}, docColTotal}
+
+	subTotal := 0
+	for i, item := range items {
+		doc.Rows = append(doc.Rows, []string{
+			strconv.Itoa(i + 1), item.ProductName, strconv.Itoa(item.Qty), item.UnitName,
+			tools.FormatMoney(item.Price), tools.FormatMoney(item.PromoDiscount), tools.FormatMoney(item.SubTotal),
+		})
+		subTotal += item.SubTotal
+	}
+
+	doc.Totals = append(doc.Totals, models.DocumentField{Label: "Subtotal", Value: tools.FormatMoney(subTotal)})
+	if sale.Discount > 0 {
+		doc.Totals = append(doc.Totals, models.DocumentField{Label: "Diskon", Value: tools.FormatMoney(-sale.Discount)})
+	}
+	if sale.TaxAmount > 0 {
+		label := "PPN"
+		if branch.TaxMode == models.TaxInclusive {
+			label = "PPN (termasuk)"
+		}
+		doc.Totals = append(doc.Totals, models.DocumentField{Label: label, Value: tools.FormatMoney(sale.TaxAmount)})
+	}
+	doc.Totals = append(doc.Totals, models.DocumentField{Label: "TOTAL", Value: tools.FormatMoney(sale.TotalSale)})
+
+	if branch.BankName != "" && branch.AccountNumber != "" {
+		doc.Notes = append(doc.Notes,
+			"Pembayaran melalui transfer:",
+			fmt.Sprintf("%s No. Rek. %s a.n. %s", branch.BankName, branch.AccountNumber, branch.AccountName),
+			"Cantumkan nomor faktur "+sale.ID+" pada keterangan transfer.",
+		)
+	}
+	doc.Signatures = []string{"Penerima", "Hormat Kami"}
+
+	return sendDocumentPDF(c, "faktur-"+sale.ID+".pdf", doc, "Sale invoice PDF generated successfully")
+}
+
+// GetPurchasePDF tanda terima pembelian dalam format PDF
+func GetPurchasePDF(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	purchaseID := c.Param("id")
+
+	if found, err := documentInBranch(db, "purchases", purchaseID, branchID); err != nil {
+		return responses.InternalServerError(c, "Failed to get purchase", err)
+	} else if !found {
+		return responses.NotFound(c, "Purchase not found")
+	}
+
+	purchase, err := getPurchase(db, purchaseID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to get purchase", err)
+	}
+	items, err := getPurchaseItems(db, purchaseID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to get purchase items", err)
+	}
+
+	doc, _, err := branchDocument(db, branchID, "TANDA TERIMA PEMBELIAN")
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to get branch", err)
+	}
+	doc.Info = []models.DocumentField{
+		{Label: "No. Pembelian", Value: purchase.ID},
+		{Label: "Tanggal", Value: utils.FormatIndonesianDate(purchase.PurchaseDate)},
+		{Label: "Supplier", Value: purchase.SupplierName},
+		{Label: "Pembayaran", Value: tools.PaymentLabel(purchase.Payment)},
+	}
+	doc.Columns = []models.DocumentColumn{docColNo, docColProduct, docColQty, docColUnit, docColPrice, docColExpired, docColTotal}
+	for i, item := range items {
+		doc.Rows = append(doc.Rows, []string{
+			strconv.Itoa(i + 1), item.ProductName, strconv.Itoa(item.Qty), item.UnitName,
+			tools.FormatMoney(item.Price), item.ExpiredDate.Format("02-01-2006"), tools.FormatMoney(item.SubTotal),
+		})
+	}
+	doc.Totals = []models.DocumentField{{Label: "TOTAL", Value: tools.FormatMoney(purchase.TotalPurchase)}}
+	doc.Signatures = []string{"Diserahkan oleh", "Diterima oleh"}
+
+	return sendDocumentPDF(c, "pembelian-"+purchase.ID+".pdf", doc, "Purchase receipt PDF generated successfully")
+}
+
+// GetSaleReturnPDF nota retur penjualan dalam format PDF
+func GetSaleReturnPDF(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	saleReturnID := c.Param("id")
+
+	if found, err := documentInBranch(db, "sale_returns", saleReturnID, branchID); err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data retur penjualan", err)
+	} else if !found {
+		return responses.NotFound(c, "Retur penjualan tidak ditemukan")
+	}
+
+	saleReturn, err := getSaleReturn(db, saleReturnID)
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data retur penjualan", err)
+	}
+	items, err := getSaleReturnItems(db, saleReturnID)
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil item retur penjualan", err)
+	}
+
+	doc, _, err := branchDocument(db, branchID, "NOTA RETUR PENJUALAN")
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data cabang", err)
+	}
+	doc.Info = []models.DocumentField{
+		{Label: "No. Retur", Value: saleReturn.ID},
+		{Label: "No. Penjualan", Value: saleReturn.SaleId},
+		{Label: "Tanggal", Value: utils.FormatIndonesianDate(saleReturn.ReturnDate)},
+		{Label: "Pengembalian", Value: tools.PaymentLabel(saleReturn.Payment)},
+	}
+	doc.Columns = []models.DocumentColumn{docColNo, docColProduct, docColQty, docColUnit, docColPrice, docColTotal}
+	for i, item := range items {
+		doc.Rows = append(doc.Rows, []string{
+			strconv.Itoa(i + 1), item.ProName, strconv.Itoa(item.Qty), item.UnitName,
+			tools.FormatMoney(item.Price), tools.FormatMoney(item.SubTotal),
+		})
+	}
+	doc.Totals = []models.DocumentField{{Label: "TOTAL RETUR", Value: tools.FormatMoney(saleReturn.TotalReturn)}}
+	doc.Signatures = []string{"Pelanggan", "Petugas"}
+
+	return sendDocumentPDF(c, "retur-penjualan-"+saleReturn.ID+".pdf", doc, "PDF retur penjualan berhasil dibuat")
+}
+
+// GetBuyReturnPDF nota retur pembelian dalam format PDF
+func GetBuyReturnPDF(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	buyReturnID := c.Param("id")
+
+	if found, err := documentInBranch(db, "buy_returns", buyReturnID, branchID); err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data retur pembelian", err)
+	} else if !found {
+		return responses.NotFound(c, "Retur pembelian tidak ditemukan")
+	}
+
+	buyReturn, err := getBuyReturn(db, buyReturnID)
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data retur pembelian", err)
+	}
+	items, err := getBuyReturnItems(db, buyReturnID)
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil item retur pembelian", err)
+	}
+
+	doc, _, err := branchDocument(db, branchID, "NOTA RETUR PEMBELIAN")
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data cabang", err)
+	}
+
+	var supplierName string
+	if err := db.Table("purchases pur").
+		Select("sup.name").
+		Joins("LEFT JOIN suppliers sup ON sup.id = pur.supplier_id").
+		Where("pur.id = ?", buyReturn.PurchaseId).
+		Scan(&supplierName).Error; err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data supplier", err)
+	}
+
+	doc.Info = []models.DocumentField{
+		{Label: "No. Retur", Value: buyReturn.ID},
+		{Label: "No. Pembelian", Value: buyReturn.PurchaseId},
+		{Label: "Supplier", Value: supplierName},
+		{Label: "Tanggal", Value: utils.FormatIndonesianDate(buyReturn.ReturnDate)},
+		{Label: "Pengembalian", Value: tools.PaymentLabel(buyReturn.Payment)},
+	}
+	doc.Columns = []models.DocumentColumn{docColNo, docColProduct, docColQty, docColUnit, docColPrice, docColExpired, docColTotal}
+	for i, item := range items {
+		doc.Rows = append(doc.Rows, []string{
+			strconv.Itoa(i + 1), item.ProName, strconv.Itoa(item.Qty), item.UnitName,
+			tools.FormatMoney(item.Price), item.ExpiredDate.Format("02-01-2006"), tools.FormatMoney(item.SubTotal),
+		})
+	}
+	doc.Totals = []models.DocumentField{{Label: "TOTAL RETUR", Value: tools.FormatMoney(buyReturn.TotalReturn)}}
+	doc.Signatures = []string{"Supplier", "Petugas"}
+
+	return sendDocumentPDF(c, "retur-pembelian-"+buyReturn.ID+".pdf", doc, "PDF retur pembelian berhasil dibuat")
+}
+
+// GetOpnamePDF lembar stok opname dalam format PDF: stok tercatat, stok fisik dan selisihnya
+func GetOpnamePDF(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	opnameID := c.Param("id")
+
+	if found, err := documentInBranch(db, "opnames", opnameID, branchID); err != nil {
+		return responses.InternalServerError(c, "Gagal mendapatkan opname", err)
+	} else if !found {
+		return responses.NotFound(c, "Opname tidak ditemukan")
+	}
+
+	opname, err := getOpname(db, opnameID)
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mendapatkan opname", err)
+	}
+	items, err := getOpnameSheetItems(db, opnameID)
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mendapatkan item Opname", err)
+	}
+
+	doc, _, err := branchDocument(db, branchID, "LEMBAR STOK OPNAME")
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data cabang", err)
+	}
+	doc.Info = []models.DocumentField{
+		{Label: "No. Opname", Value: opname.ID},
+		{Label: "Tanggal", Value: opname.OpnameDate},
+		{Label: "Keterangan", Value: opname.Description},
+	}
+	doc.Columns = []models.DocumentColumn{docColNo, docColProduct,
+		{Title: "Tercatat", Width: 9, Right: true},
+		{Title: "Fisik", Width: 9, Right: true},
+		{Title: "Selisih", Width: 9, Right: true},
+		docColPrice,
+		{Title: "Nilai Selisih", Width: 15, Right: true},
+	}
+
+	totalExist, totalCounted := 0, 0
+	for i, item := range items {
+		doc.Rows = append(doc.Rows, []string{
+			strconv.Itoa(i + 1), item.ProductName, strconv.Itoa(item.QtyExist), strconv.Itoa(item.Qty),
+			strconv.Itoa(item.Qty - item.QtyExist), tools.FormatMoney(item.Price), tools.FormatMoney(item.SubTotal - item.SubTotalExist),
+		})
+		totalExist += item.SubTotalExist
+		totalCounted += item.SubTotal
+	}
+	doc.Totals = []models.DocumentField{
+		{Label: "Nilai Tercatat", Value: tools.FormatMoney(totalExist)},
+		{Label: "Nilai Fisik", Value: tools.FormatMoney(totalCounted)},
+		{Label: "SELISIH", Value: tools.FormatMoney(totalCounted - totalExist)},
+	}
+	doc.Signatures = []string{"Pemeriksa", "Mengetahui"}
+
+	return sendDocumentPDF(c, "opname-"+opname.ID+".pdf", doc, "PDF opname berhasil dibuat")
+}
+
+// documentInBranch memastikan dokumen dengan id tersebut milik cabang yang sedang login
+func documentInBranch(db *gorm.DB, table string, id string, branchID string) (bool, error) {
+	var count int64
+	err := db.Table(table).Where("id = ? AND branch_id = ?", id, branchID).Count(&count).Error
+	return count > 0, err
+}
+
+// branchDocument dokumen kosong berisi kop cabang, branch dikembalikan untuk data rekening dan pajak
+func branchDocument(db *gorm.DB, branchID string, title string) (models.Document, models.Branch, error) {
+	var branch models.Branch
+	err := db.Select("id, branch_name, address, phone, email, bank_name, account_name, account_number, tax_mode").
+		First(&branch, "id = ?", branchID).Error
+	doc := models.Document{
+		Title:         title,
+		BranchName:    branch.BranchName,
+		BranchAddress: branch.Address,
+		BranchPhone:   branch.Phone,
+		BranchEmail:   branch.Email,
+	}
+	return doc, branch, err
+}
+
+// sendDocumentPDF merender dokumen ke PDF dan mengirimkannya sebagai file base64
+func sendDocumentPDF(c *framework.Ctx, fileName string, doc models.Document, message string) error {
+	content := tools.RenderDocumentPDF(doc)
+	return responses.JSONResponse(c, http.StatusOK, message, models.DocumentFileResponse{
+		FileName:    fileName,
+		ContentType: "application/pdf",
+		Size:        len(content),
+		Content:     base64.StdEncoding.EncodeToString(content),
+	})
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// exportList mengekspor seluruh hasil query list (tanpa paginasi) ke file csv / xlsx.
+// Baris dibaca satu per satu dari cursor database dan langsung ditulis ke response,
+// sehingga hasil query maupun isi file tidak pernah ditampung sekaligus di memori.
+func exportList[T any](c *framework.Ctx, query *gorm.DB, format string, name string, columns []tools.ExportColumn, row func(T) []interface{}) error {
+	format = strings.ToLower(strings.TrimSpace(format))
+	contentType, ok := tools.ExportContentTypes[format]
+	if !ok {
+		return responses.BadRequest(c, "format harus csv atau xlsx", nil)
+	}
+
+	// Query dijalankan sebelum header dikirim agar kegagalannya masih bisa dibalas dengan JSON
+	rows, err := query.Rows()
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil data ekspor", err)
+	}
+	defer rows.Close()
+
+	fileName := fmt.Sprintf("%s-%s.%s", name, time.Now().In(utils.Location).Format("20060102-150405"), format)
+	c.Writer.Header().Set("Content-Type", contentType)
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Writer.WriteHeader(http.StatusOK)
+
+	// Setelah header terkirim, error hanya bisa memutus file yang sedang diunduh
+	writer, err := tools.NewExportWriter(format, c.Writer, name, columns)
+	if err != nil {
+		return fmt.Errorf("create export writer: %w", err)
+	}
+
+	for rows.Next() {
+		var item T
+		if err := query.ScanRows(rows, &item); err != nil {
+			return fmt.Errorf("scan export row: %w", err)
+		}
+		if err := writer.WriteRow(row(item)); err != nil {
+			return fmt.Errorf("write export row: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read export rows: %w", err)
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// idempotencyHeader header opsional untuk endpoint pembuat transaksi agar request ulang tidak membuat transaksi ganda
+const idempotencyHeader = "Idempotency-Key"
+
+// startIdempotency membaca header Idempotency-Key dan menghitung hash request.
+// done bernilai true jika respons sudah dikirim: respons tersimpan diputar ulang atau request ditolak.
+// record bernilai nil jika header tidak dikirim.
+func startIdempotency(c *framework.Ctx, endpoint string, req interface{}) (*models.IdempotencyKeys, bool, error) {
+	key := strings.TrimSpace(c.Get(idempotencyHeader))
+	if key == "" {
+		return nil, false, nil
+	}
+	if len(key) > 100 {
+		return nil, true, responses.BadRequest(c, "Idempotency-Key maksimal 100 karakter", nil)
+	}
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	hash, err := tools.HashIdempotencyRequest(req)
+	if err != nil {
+		return nil, true, responses.InternalServerError(c, "Failed to hash request", err)
+	}
+
+	record := models.IdempotencyKeys{
+		ID:          helpers.GenerateID("IDK"),
+		Key:         key,
+		UserID:      userID,
+		Endpoint:    endpoint,
+		BranchID:    branchID,
+		RequestHash: hash,
+		CreatedAt:   time.Now().In(utils.Location),
+	}
+
+	if done, err := replayIdempotency(c, record); done {
+		return nil, true, err
+	}
+
+	return &record, false, nil
+}
+
+// finishIdempotency menyimpan respons sukses di dalam transaksi sebelum commit.
+// Jika gagal karena request yang sama sudah selesai lebih dulu, transaksi dibatalkan dan respons tersimpan dikirim ulang.
+func finishIdempotency(c *framework.Ctx, tx *gorm.DB, record *models.IdempotencyKeys, status int, message string, data interface{}) (bool, error) {
+	if record == nil {
+		return false, nil
+	}
+
+	if err := tools.SaveIdempotencyKey(tx, *record, status, message, data); err != nil {
+		tx.Rollback()
+		if done, replayErr := replayIdempotency(c, *record); done {
+			return true, replayErr
+		}
+		return true, responses.InternalServerError(c, "Failed to save idempotency key", err)
+	}
+
+	return false, nil
+}
+
+// replayIdempotency mengirim ulang respons tersimpan untuk key yang sama, body berbeda ditolak
+func replayIdempotency(c *framework.Ctx, record models.IdempotencyKeys) (bool, error) {
+	stored, err := tools.GetIdempotencyKey(config.DB, record.Key, record.UserID, record.Endpoint)
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	} else if err != nil {
+		return true, responses.InternalServerError(c, "Failed to check idempotency key", err)
+	}
+
+	if stored.RequestHash != record.RequestHash {
+		return true, responses.JSONResponse(c, http.StatusUnprocessableEntity, "Idempotency-Key sudah dipakai untuk request yang berbeda", nil)
+	}
+
+	return true, responses.JSONResponse(c, stored.ResponseStatus, stored.ResponseMessage, json.RawMessage(stored.ResponseData))
+}
@@ -66,7 +66,7 @@ func GetAllMemberCategory(c *framework.Ctx) error {
 	var total int64
 
 	// Query dasar
-	query := config.DB.Table("member_categories mc").Select("mc.id, mc.name, mc.points_conversion_rate, mc.branch_id").Where("mc.branch_id = ?", branch_id)
+	query := config.DB.Table("member_categories mc").Select("mc.id, mc.name, mc.points_conversion_rate, COALESCE(mc.price_list_id, '') AS price_list_id, mc.branch_id").Where("mc.branch_id = ?", branch_id)
 
 	// Jika ada search key, tambahkan filter WHERE
 	if search != "" {
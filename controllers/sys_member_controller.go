@@ -67,7 +67,7 @@ func GetAllMember(c *framework.Ctx) error {
 
 	// Query dasar
 	query := config.DB.Table("members m").
-		Select("m.id, m.name, m.phone, m.address, m.member_category_id, mc.name AS member_category, m.points").
+		Select("m.id, m.name, m.phone, m.address, m.member_category_id, mc.name AS member_category, m.points, m.credit_limit").
 		Joins("LEFT JOIN member_categories mc ON mc.id = m.member_category_id").
 		Where("m.branch_id = ?", branch_id)
 
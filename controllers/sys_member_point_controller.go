@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+// AdjustMemberPoint menyesuaikan poin member secara manual, alasan wajib diisi
+func AdjustMemberPoint(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	var input models.AdjustMemberPointInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for point adjustment input", err)
+	}
+
+	var member models.Member
+	if err := db.Where("id = ? AND branch_id = ?", input.MemberId, branchID).First(&member).Error; err != nil {
+		return responses.NotFound(c, "Member not found")
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+
+	err := tools.AdjustMemberPoints(tx, member.ID, input.Points, tools.PointRef{
+		Reason:   input.Reason,
+		UserID:   userID,
+		BranchID: branchID,
+	})
+	if err != nil {
+		tx.Rollback()
+		return responses.BadRequest(c, "Failed to adjust member points", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit transaction", err)
+	}
+
+	if err := db.First(&member, "id = ?", member.ID).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve member", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Member points adjusted successfully", member)
+}
+
+// GetMemberPointHistory menampilkan riwayat poin member beserta saldo saat ini
+func GetMemberPointHistory(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	memberID := c.Param("member_id")
+
+	var member models.Member
+	if err := db.Where("id = ? AND branch_id = ?", memberID, branchID).First(&member).Error; err != nil {
+		return responses.NotFound(c, "Member not found")
+	}
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	var total int64
+	query := db.Model(&models.MemberPoints{}).Where("member_id = ?", member.ID)
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Get member points failed", err)
+	}
+
+	var entries []models.MemberPoints
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		return responses.InternalServerError(c, "Get member points failed", err)
+	}
+
+	var history []models.MemberPointResponse
+	for _, entry := range entries {
+		formatted := models.MemberPointResponse{
+			ID:          entry.ID,
+			PointType:   string(entry.PointType),
+			Points:      entry.Points,
+			ReferenceID: entry.ReferenceID,
+			Reason:      entry.Reason,
+			CreatedAt:   entry.CreatedAt.In(utils.Location).Format("02-01-2006 15:04"),
+		}
+		if entry.ExpiredAt != nil {
+			formatted.ExpiredAt = utils.FormatIndonesianDate(*entry.ExpiredAt)
+		}
+		history = append(history, formatted)
+	}
+
+	// Poin yang akan hangus dalam 30 hari ke depan
+	var expiringSoon int
+	if err := db.Model(&models.MemberPoints{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("member_id = ? AND remaining > 0 AND expired_at IS NOT NULL AND expired_at < ?", member.ID, time.Now().AddDate(0, 0, 30)).
+		Scan(&expiringSoon).Error; err != nil {
+		return responses.InternalServerError(c, "Get member points failed", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponse(c, http.StatusOK, "Member points retrieved successfully", framework.Map{
+		"member_id":     member.ID,
+		"member_name":   member.Name,
+		"points":        member.Points,
+		"expiring_soon": expiringSoon,
+		"page":          page,
+		"total_pages":   totalPages,
+		"total":         total,
+		"history":       history,
+	})
+}
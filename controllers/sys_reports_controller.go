@@ -5,10 +5,12 @@ import (
 	"time"
 
 	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
 	"github.com/heru-oktafian/scafold/config"
 	"github.com/heru-oktafian/scafold/framework"
 	"github.com/heru-oktafian/scafold/middlewares"
 	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
 )
 
 // Report neraca saldo
@@ -24,7 +26,7 @@ import (
 //  2. Mengambil parameter bulan (format "YYYY-MM") dari query string, lalu mengonversinya ke rentang tanggal.
 //  3. Mengambil data transaksi dari tabel "transaction_reports" berdasarkan branch_id dan rentang tanggal,
 //     serta mengelompokkan berdasarkan tipe transaksi dan tanggal.
-//  4. Mengelompokkan hasil transaksi menjadi debit (penjualan, pemasukan) dan kredit (pembelian, pengeluaran, retur penjualan).
+//  4. Mengelompokkan hasil transaksi menjadi debit (penjualan, pemasukan, cicilan piutang) dan kredit (pembelian, pengeluaran, retur penjualan, pembayaran hutang).
 //  5. Menghitung total debit, total kredit, dan saldo akhir (total debit - total kredit).
 //  6. Mengembalikan hasil dalam format JSON.
 //
@@ -35,7 +37,8 @@ import (
 //	  "credit":       [ ... ], // Daftar transaksi kredit
 //	  "total_debit":  int,     // Total nilai debit
 //	  "total_credit": int,     // Total nilai kredit
-//	  "total_saldo":  int      // Saldo akhir (debit - kredit)
+//	  "total_saldo":  int,     // Saldo akhir (debit - kredit)
+//	  "sales_by_tender": [ ... ] // Penjualan per metode pembayaran (tunai / bank), penjualan split dipecah per tender
 //	}
 //
 // Catatan:
@@ -96,10 +99,10 @@ func GetNeracaSaldo(c *framework.Ctx) error {
 		}
 
 		switch s.TransactionType {
-		case string(models.Sale), string(models.Income), string(models.BuyReturn):
+		case string(models.Sale), string(models.Income), string(models.BuyReturn), string(models.ReceivablePayment):
 			debit = append(debit, entry)
 			totalDebit += s.Total
-		case string(models.Purchase), string(models.Expense), string(models.SaleReturn):
+		case string(models.Purchase), string(models.Expense), string(models.SaleReturn), string(models.PayablePayment):
 			credit = append(credit, entry)
 			totalCredit += s.Total
 		}
@@ -107,12 +110,27 @@ func GetNeracaSaldo(c *framework.Ctx) error {
 
 	totalSaldo := totalDebit - totalCredit
 
+	// Rincian penjualan per metode pembayaran sebenarnya (penjualan split dipecah per tender),
+	// kredit dan poin tidak termasuk karena tidak dicatat sebagai kas
+	tenders, err := tools.SaleTenderTotals(db, branchID, startDate, endDate)
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal mengambil rincian pembayaran penjualan", err)
+	}
+	salesByTender := []models.SaleTenderSummary{}
+	for _, tender := range tenders {
+		if tender.Method == string(models.PaidByCredit) || tender.Method == string(models.PaidBySaldo) {
+			continue
+		}
+		salesByTender = append(salesByTender, tender)
+	}
+
 	return c.JSON(http.StatusOK, framework.Map{
-		"debit":        debit,
-		"credit":       credit,
-		"total_debit":  totalDebit,
-		"total_credit": totalCredit,
-		"total_saldo":  totalSaldo,
+		"debit":           debit,
+		"credit":          credit,
+		"total_debit":     totalDebit,
+		"total_credit":    totalCredit,
+		"total_saldo":     totalSaldo,
+		"sales_by_tender": salesByTender,
 	})
 }
 
@@ -146,3 +164,87 @@ func GetProfitGraphByMonth(c *framework.Ctx) error {
 
 	return responses.JSONResponse(c, http.StatusOK, "Sales & Profit Report on "+month, summaries)
 }
+
+// GetTaxSummary ringkasan PPN keluaran (penjualan) dan PPN masukan (pembelian) dalam satu bulan
+func GetTaxSummary(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	month := c.Query("month") // format: YYYY-MM
+
+	parsedMonth, err := time.ParseInLocation("2006-01", month, utils.Location)
+	if err != nil {
+		return responses.BadRequest(c, "Invalid month format. Use YYYY-MM.", nil)
+	}
+	startOfMonth := parsedMonth
+	endOfMonth := startOfMonth.AddDate(0, 1, 0) // awal bulan berikutnya
+
+	var branch models.Branch
+	if err := db.Select("id, tax_percentage, tax_mode").First(&branch, "id = ?", branchID).Error; err != nil {
+		return responses.NotFound(c, "Branch not found")
+	}
+
+	var outputTax models.TaxSideSummary
+	err = db.Model(&models.Sales{}).
+		Select("COUNT(*) AS transaction_count, COALESCE(SUM(total_sale), 0) AS total_amount, COALESCE(SUM(total_sale - tax_amount), 0) AS tax_base, COALESCE(SUM(tax_amount), 0) AS tax_amount").
+		Where("branch_id = ? AND sale_date >= ? AND sale_date < ?", branchID, startOfMonth, endOfMonth).
+		Scan(&outputTax).Error
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal menghitung PPN keluaran", err)
+	}
+
+	// Retur penjualan mengurangi PPN keluaran sebesar PPN baris yang diretur
+	var saleReturns models.TaxSideSummary
+	err = db.Table("sale_returns sr").
+		Select("COALESCE(SUM(sr.total_return), 0) AS return_amount, COALESCE(SUM(sr.tax_amount), 0) AS return_tax").
+		Where("sr.branch_id = ? AND sr.return_date >= ? AND sr.return_date < ?", branchID, startOfMonth, endOfMonth).
+		Scan(&saleReturns).Error
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal menghitung PPN retur penjualan", err)
+	}
+	outputTax.DeductReturns(saleReturns.ReturnAmount, saleReturns.ReturnTax)
+
+	var inputTax models.TaxSideSummary
+	err = db.Model(&models.Purchases{}).
+		Select("COUNT(*) AS transaction_count, COALESCE(SUM(total_purchase), 0) AS total_amount, COALESCE(SUM(total_purchase - tax_amount), 0) AS tax_base, COALESCE(SUM(tax_amount), 0) AS tax_amount").
+		Where("branch_id = ? AND purchase_date >= ? AND purchase_date < ?", branchID, startOfMonth, endOfMonth).
+		Scan(&inputTax).Error
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal menghitung PPN masukan", err)
+	}
+
+	// Retur pembelian mengurangi PPN masukan sebesar porsi PPN pada pembelian asalnya
+	var buyReturns models.TaxSideSummary
+	err = db.Table("buy_returns br").
+		Joins("JOIN purchases pur ON pur.id = br.purchase_id").
+		Select(`COALESCE(SUM(br.total_return), 0) AS return_amount,
+			COALESCE(SUM(CASE WHEN pur.total_purchase > 0 THEN br.total_return::bigint * pur.tax_amount / pur.total_purchase ELSE 0 END), 0) AS return_tax`).
+		Where("br.branch_id = ? AND br.return_date >= ? AND br.return_date < ?", branchID, startOfMonth, endOfMonth).
+		Scan(&buyReturns).Error
+	if err != nil {
+		return responses.InternalServerError(c, "Gagal menghitung PPN retur pembelian", err)
+	}
+	inputTax.DeductReturns(buyReturns.ReturnAmount, buyReturns.ReturnTax)
+
+	netTax := outputTax.TaxAmount - inputTax.TaxAmount
+	status := "nihil"
+	if netTax > 0 {
+		status = "kurang_bayar"
+	} else if netTax < 0 {
+		status = "lebih_bayar"
+	}
+
+	taxMode := string(branch.TaxMode)
+	if taxMode == "" {
+		taxMode = string(models.TaxExclusive)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Tax summary on "+month, models.TaxSummaryResponse{
+		Month:         month,
+		TaxPercentage: branch.TaxPercentage,
+		TaxMode:       taxMode,
+		OutputTax:     outputTax,
+		InputTax:      inputTax,
+		NetTax:        netTax,
+		Status:        status,
+	})
+}
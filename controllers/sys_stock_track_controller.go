@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+// GetStockTracksByProduct menampilkan riwayat mutasi stok satu produk
+// dengan filter start_date dan end_date (format YYYY-MM-DD)
+func GetStockTracksByProduct(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	productID := c.Param("product_id")
+
+	// Ambil parameter page dari query URL
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	var product models.Product
+	if err := config.DB.Where("id = ? AND branch_id = ?", productID, branchID).First(&product).Error; err != nil {
+		return responses.NotFound(c, "Product not found")
+	}
+
+	query := config.DB.Table("stock_tracks stk").
+		Select("stk.id, stk.movement_type, stk.reference_id, stk.product_id, pro.name AS product_name, stk.qty_before, stk.stock, stk.qty_after, stk.user_id, usr.name AS user_name, TO_CHAR(stk.created_at, 'DD-MM-YYYY HH24:MI:SS') AS created_at").
+		Joins("LEFT JOIN products pro ON pro.id = stk.product_id").
+		Joins("LEFT JOIN users usr ON usr.user_id = stk.user_id").
+		Where("stk.product_id = ? AND stk.branch_id = ?", productID, branchID)
+
+	if startDate := strings.TrimSpace(c.Query("start_date")); startDate != "" {
+		parsedStart, err := time.ParseInLocation("2006-01-02", startDate, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid start_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		query = query.Where("stk.created_at >= ?", parsedStart)
+	}
+
+	if endDate := strings.TrimSpace(c.Query("end_date")); endDate != "" {
+		parsedEnd, err := time.ParseInLocation("2006-01-02", endDate, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid end_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		query = query.Where("stk.created_at < ?", parsedEnd.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Get stock tracks failed", err)
+	}
+
+	var tracks []models.AllStockTracks
+	if err := query.Order("stk.created_at DESC, stk.id DESC").Offset(offset).Limit(limit).Scan(&tracks).Error; err != nil {
+		return responses.InternalServerError(c, "Get stock tracks failed", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(
+		c,
+		http.StatusOK,
+		"Stock tracks retrieved successfully",
+		product.Name,
+		int(total),
+		page,
+		totalPages,
+		limit,
+		tracks,
+	)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
 	"github.com/heru-oktafian/scafold/config"
 	"github.com/heru-oktafian/scafold/framework"
 	"github.com/heru-oktafian/scafold/helpers"
@@ -70,6 +71,10 @@ func CreateAnotherIncome(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to sync Another Income report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalIncome, another_income.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync Another Income journal", err)
+	}
+
 	return responses.JSONResponse(c, http.StatusOK, "Another Income created successfully", another_income)
 }
 
@@ -117,6 +122,10 @@ func UpdateAnotherIncome(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to sync Another Income report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalIncome, another_income.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync Another Income journal", err)
+	}
+
 	return responses.JSONResponse(c, http.StatusOK, "Another Income updated successfully", another_income)
 }
 
@@ -136,6 +145,11 @@ func DeleteAnotherIncome(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to delete transaction report", err)
 	}
 
+	// Hapus jurnal
+	if err := tools.RemoveJournal(db, models.JournalIncome, another_income.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to delete Another Income journal", err)
+	}
+
 	// Hapus another_income
 	if err := db.Delete(&another_income).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to delete Another Income", err)
@@ -144,6 +158,15 @@ func DeleteAnotherIncome(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Another Income deleted successfully", another_income)
 }
 
+// anotherIncomeExportColumns kolom ekspor daftar pendapatan lain
+var anotherIncomeExportColumns = []tools.ExportColumn{
+	{Header: "No. Transaksi"},
+	{Header: "Tanggal", Kind: tools.ExportDateTime},
+	{Header: "Keterangan"},
+	{Header: "Total", Kind: tools.ExportMoney},
+	{Header: "Pembayaran"},
+}
+
 // GetAllAnotherIncome tampilkan semua AnotherIncome
 func GetAllAnotherIncomes(c *framework.Ctx) error {
 	// Hitung waktu sekarang dalam WIB
@@ -201,6 +224,13 @@ func GetAllAnotherIncomes(c *framework.Ctx) error {
 		dataQuery = dataQuery.Where("ex.income_date BETWEEN ? AND ?", startDate, endDate)
 	}
 
+	// ?format=csv|xlsx mengekspor seluruh hasil filter tanpa paginasi
+	if format := c.Query("format"); format != "" {
+		return exportList(c, dataQuery.Order("ex.created_at DESC"), format, "pendapatan-lain", anotherIncomeExportColumns, func(row models.AnotherIncomes) []interface{} {
+			return []interface{}{row.ID, row.IncomeDate, row.Description, row.TotalIncome, tools.PaymentLabel(row.Payment)}
+		})
+	}
+
 	// Pertama, hitung total catatan yang sesuai dengan filter
 	if err := countQuery.Count(&total).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to count another income", err)
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
 	"github.com/heru-oktafian/scafold/config"
 	"github.com/heru-oktafian/scafold/framework"
 	"github.com/heru-oktafian/scafold/helpers"
@@ -33,6 +35,12 @@ func CreateBuyReturnTransaction(c *framework.Ctx) error {
 		return responses.JSONResponse(c, http.StatusBadRequest, "Body permintaan tidak valid", err.Error())
 	}
 
+	// Request ulang dengan Idempotency-Key yang sama mengembalikan respons pertama
+	idem, replayed, err := startIdempotency(c, "buy-returns", req)
+	if replayed {
+		return err
+	}
+
 	if req.BuyReturn.Payment == "" {
 		req.BuyReturn.Payment = "paid_by_cash"
 	}
@@ -98,6 +106,13 @@ func CreateBuyReturnTransaction(c *framework.Ctx) error {
 	var totalReturn int
 	var buyReturnItems []models.BuyReturnItems
 
+	stockRef := tools.StockRef{
+		MovementType: models.PurchaseReturnTrans,
+		ReferenceID:  buyReturnID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
+
 	for _, item := range req.BuyReturnItems {
 		parsedExpiredDate, err := time.Parse("2006-01-02", item.ExpiredDate)
 		if err != nil {
@@ -141,34 +156,39 @@ func CreateBuyReturnTransaction(c *framework.Ctx) error {
 			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal mengambil info produk untuk %s", item.ProductId), err.Error())
 		}
 
-		actualQtyToReduce := item.Qty
-
 		// Lakukan konversi unit jika diperlukan
-		if buyItem.UnitId != product.UnitId {
-			var unitConv models.UnitConversion
-			err = tx.Where("product_id = ? AND init_id = ? AND final_id = ? AND branch_id = ?",
-				buyItem.ProductId, buyItem.UnitId, product.UnitId, branchID).First(&unitConv).Error
-
-			if err != nil {
-				if err == gorm.ErrRecordNotFound {
-					actualQtyToReduce = item.Qty
-				} else {
-					tx.Rollback()
-					return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengambil konversi satuan", err.Error())
-				}
-			} else {
-				actualQtyToReduce = item.Qty * unitConv.ValueConv
-			}
+		conversionValue, err := tools.GetConversionValue(tx, branchID, product, buyItem.UnitId)
+		if err != nil {
+			tx.Rollback()
+			return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengambil konversi satuan", err.Error())
 		}
+		actualQtyToReduce := item.Qty * conversionValue
 
-		// Update stok
-		err = tx.Model(&models.Product{}).Where("id = ?", item.ProductId).
-			Update("stock", gorm.Expr("stock - ?", actualQtyToReduce)).Error
+		// Update stok secara atomik dan catat mutasinya ke stock_tracks,
+		// stok yang tidak cukup ditolak kecuali cabang mengizinkan backorder
+		_, _, err = tools.ChangeProductStock(tx, item.ProductId, -actualQtyToReduce, stockRef)
 		if err != nil {
 			tx.Rollback()
+			var stockErr *tools.InsufficientStockError
+			if errors.As(err, &stockErr) {
+				return responses.JSONResponse(c, http.StatusBadRequest, stockErr.Error(), nil)
+			}
 			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal memperbarui stok untuk produk %s", item.ProductId), err.Error())
 		}
 
+		// Kurangi batch yang diterima dari item pembelian asal
+		if err = tools.ReduceSourceBatch(tx, branchID, item.ProductId, buyItem.ID, actualQtyToReduce); err != nil {
+			tx.Rollback()
+			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal mengurangi batch untuk produk %s", item.ProductId), err.Error())
+		}
+
+		// Keluarkan nilai barang dari harga pokok sesuai harga beli per satuan dasar
+		returnCost := buyItem.Price * item.Qty / max(actualQtyToReduce, 1)
+		if err = tools.ReduceReceiptCost(tx, stockRef, item.ProductId, buyItem.ID, actualQtyToReduce, returnCost); err != nil {
+			tx.Rollback()
+			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal memperbarui harga pokok untuk produk %s", item.ProductId), err.Error())
+		}
+
 		subTotal := buyItem.Price * item.Qty
 		totalReturn += subTotal
 
@@ -216,6 +236,12 @@ func CreateBuyReturnTransaction(c *framework.Ctx) error {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal membuat laporan transaksi retur pembelian", err.Error())
 	}
 
+	// Jurnal retur pembelian
+	if err = tools.SyncJournal(tx, models.JournalBuyReturn, buyReturn.ID); err != nil {
+		tx.Rollback()
+		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal membuat jurnal retur pembelian", err.Error())
+	}
+
 	// Kurangi kuota jika berlangganan quota
 	if subscriptionType == "quota" {
 		var branch models.Branch
@@ -238,19 +264,25 @@ func CreateBuyReturnTransaction(c *framework.Ctx) error {
 		}
 	}
 
-	err = tx.Commit().Error
-	if err != nil {
-		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal melakukan commit transaksi", err.Error())
-	}
-
-	return responses.JSONResponse(c, http.StatusOK, "Transaksi retur pembelian berhasil dibuat", framework.Map{
+	response := framework.Map{
 		"id":           buyReturn.ID,
 		"purchase_id":  buyReturn.PurchaseId,
 		"return_date":  utils.FormatIndonesianDate(buyReturn.ReturnDate),
 		"total_return": buyReturn.TotalReturn,
 		"payment":      buyReturn.Payment,
 		"items":        buyReturnItems,
-	})
+	}
+
+	if replayed, err := finishIdempotency(c, tx, idem, http.StatusOK, "Transaksi retur pembelian berhasil dibuat", response); replayed {
+		return err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal melakukan commit transaksi", err.Error())
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Transaksi retur pembelian berhasil dibuat", response)
 }
 
 // GetBuyItemsForReturn digunakan untuk mengambil item pembelian yang bisa diretur
@@ -311,27 +343,13 @@ func GetBuyReturnWithItems(c *framework.Ctx) error {
 	buyReturnID := c.Param("id")
 
 	// Gunakan models.AllBuyReturns untuk mengambil data dari DB
-	var buyReturn models.AllBuyReturns
-
-	err := db.Table("buy_returns A").
-		Select("A.id, A.purchase_id, A.return_date, A.payment, A.total_return").
-		Where("A.id = ?", buyReturnID).
-		Scan(&buyReturn).Error
-
+	buyReturn, err := getBuyReturn(db, buyReturnID)
 	if err != nil {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengambil data retur pembelian", err.Error())
 	}
 
 	// Ambil item retur pembelian terkait
-	var items []models.AllBuyReturnItems
-	err = db.Table("buy_return_items A").
-		Select("A.id, A.buy_return_id, A.product_id AS pro_id, B.name AS pro_name, B.unit_id, C.name AS unit_name, A.qty, A.price, A.sub_total, A.expired_date").
-		Joins("LEFT JOIN products B on B.id=A.product_id").
-		Joins("LEFT JOIN units C on C.id=B.unit_id").
-		Where("A.buy_return_id = ?", buyReturnID).
-		Order("B.name ASC").
-		Scan(&items).Error
-
+	items, err := getBuyReturnItems(db, buyReturnID)
 	if err != nil {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengambil item retur pembelian", err.Error())
 	}
@@ -353,6 +371,29 @@ func GetBuyReturnWithItems(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Retur pembelian berhasil diambil", responseDetail)
 }
 
+// getBuyReturn mengambil data utama retur pembelian
+func getBuyReturn(db *gorm.DB, buyReturnID string) (models.AllBuyReturns, error) {
+	var buyReturn models.AllBuyReturns
+	err := db.Table("buy_returns A").
+		Select("A.id, A.purchase_id, A.return_date, A.payment, A.total_return").
+		Where("A.id = ?", buyReturnID).
+		Scan(&buyReturn).Error
+	return buyReturn, err
+}
+
+// getBuyReturnItems mengambil item retur pembelian beserta nama produk dan satuan
+func getBuyReturnItems(db *gorm.DB, buyReturnID string) ([]models.AllBuyReturnItems, error) {
+	var items []models.AllBuyReturnItems
+	err := db.Table("buy_return_items A").
+		Select("A.id, A.buy_return_id, A.product_id AS pro_id, B.name AS pro_name, B.unit_id, C.name AS unit_name, A.qty, A.price, A.sub_total, A.expired_date").
+		Joins("LEFT JOIN products B on B.id=A.product_id").
+		Joins("LEFT JOIN units C on C.id=B.unit_id").
+		Where("A.buy_return_id = ?", buyReturnID).
+		Order("B.name ASC").
+		Scan(&items).Error
+	return items, err
+}
+
 // GetAllBuyReturns menampilkan semua retur pembelian
 func GetAllBuyReturns(c *framework.Ctx) error {
 	// Hitung waktu sekarang dalam WIB
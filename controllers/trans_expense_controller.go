@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
 	"github.com/heru-oktafian/scafold/config"
 	"github.com/heru-oktafian/scafold/framework"
 	"github.com/heru-oktafian/scafold/helpers"
@@ -70,6 +71,10 @@ func CreateExpense(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to create Expense Report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalExpense, expense.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync Expense journal", err)
+	}
+
 	return responses.JSONResponse(c, http.StatusOK, "Expense created successfully", expense)
 }
 
@@ -118,6 +123,10 @@ func UpdateExpense(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to sync Expense Report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalExpense, expense.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync Expense journal", err)
+	}
+
 	return responses.JSONResponse(c, http.StatusOK, "Expense updated successfully", expense)
 }
 
@@ -137,6 +146,11 @@ func DeleteExpense(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to delete Transaction Report", err)
 	}
 
+	// Hapus jurnal
+	if err := tools.RemoveJournal(db, models.JournalExpense, expense.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to delete Expense journal", err)
+	}
+
 	// Hapus expense
 	if err := db.Delete(&expense).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to delete Expense", err)
@@ -145,6 +159,15 @@ func DeleteExpense(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Expense deleted successfully", expense)
 }
 
+// expenseExportColumns kolom ekspor daftar pengeluaran
+var expenseExportColumns = []tools.ExportColumn{
+	{Header: "No. Transaksi"},
+	{Header: "Tanggal", Kind: tools.ExportDateTime},
+	{Header: "Keterangan"},
+	{Header: "Total", Kind: tools.ExportMoney},
+	{Header: "Pembayaran"},
+}
+
 // GetAllExpenses tampilkan semua Expense
 func GetAllExpenses(c *framework.Ctx) error {
 	// Hitung waktu sekarang dalam WIB
@@ -202,6 +225,13 @@ func GetAllExpenses(c *framework.Ctx) error {
 		dataQuery = dataQuery.Where("ex.expense_date BETWEEN ? AND ?", startDate, endDate)
 	}
 
+	// ?format=csv|xlsx mengekspor seluruh hasil filter tanpa paginasi
+	if format := c.Query("format"); format != "" {
+		return exportList(c, dataQuery.Order("ex.created_at DESC"), format, "pengeluaran", expenseExportColumns, func(row models.Expenses) []interface{} {
+			return []interface{}{row.ID, row.ExpenseDate, row.Description, row.TotalExpense, tools.PaymentLabel(row.Payment)}
+		})
+	}
+
 	// Pertama, hitung total catatan yang sesuai dengan filter
 	if err := countQuery.Count(&total).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to count expenses", err)
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// GetAllJournals menampilkan jurnal cabang, bisa difilter status, source_type dan tanggal (YYYY-MM-DD)
+func GetAllJournals(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	search := strings.TrimSpace(c.Query("search"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	query := config.DB.Table("journal_entries je").
+		Select(`je.id, je.entry_date, COALESCE(je.description, '') AS description, je.source_type, COALESCE(je.source_id, '') AS source_id,
+			je.status, je.total_debit, je.total_credit, COALESCE(usr.name, '') AS user_name`).
+		Joins("LEFT JOIN users usr ON usr.user_id = je.user_id").
+		Where("je.branch_id = ?", branchID)
+
+	if status := strings.TrimSpace(c.Query("status")); status != "" {
+		query = query.Where("je.status = ?", status)
+	}
+	if sourceType := strings.TrimSpace(c.Query("source_type")); sourceType != "" {
+		query = query.Where("je.source_type = ?", sourceType)
+	}
+	if startDate := strings.TrimSpace(c.Query("start_date")); startDate != "" {
+		parsedStart, err := time.ParseInLocation("2006-01-02", startDate, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Format start_date tidak valid, gunakan YYYY-MM-DD", err)
+		}
+		query = query.Where("je.entry_date >= ?", parsedStart)
+	}
+	if endDate := strings.TrimSpace(c.Query("end_date")); endDate != "" {
+		parsedEnd, err := time.ParseInLocation("2006-01-02", endDate, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Format end_date tidak valid, gunakan YYYY-MM-DD", err)
+		}
+		query = query.Where("je.entry_date < ?", parsedEnd.AddDate(0, 0, 1))
+	}
+	if search != "" {
+		search = strings.ToLower(search)
+		query = query.Where("(LOWER(je.description) LIKE ? OR LOWER(je.source_id) LIKE ?)", "%"+search+"%", "%"+search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to count journals", err)
+	}
+
+	var journals []models.JournalEntrySummary
+	if err := query.Order("je.entry_date DESC, je.created_at DESC").Offset(offset).Limit(limit).Scan(&journals).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get journals", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Journals retrieved successfully", search, int(total), page, totalPages, limit, journals)
+}
+
+// GetJournal menampilkan satu jurnal beserta baris debit / kredit
+func GetJournal(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var entry models.JournalEntries
+	if err := db.Where("id = ? AND branch_id = ?", c.Param("id"), branchID).First(&entry).Error; err != nil {
+		return responses.NotFound(c, "Journal not found")
+	}
+
+	var lines []models.JournalLineDetail
+	if err := db.Table("journal_lines jl").
+		Select("jl.id, jl.account_id, acc.code AS account_code, acc.name AS account_name, jl.debit, jl.credit, COALESCE(jl.memo, '') AS memo").
+		Joins("JOIN accounts acc ON acc.id = jl.account_id").
+		Where("jl.journal_entry_id = ?", entry.ID).
+		Order("jl.debit DESC, acc.code ASC").
+		Scan(&lines).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get journal lines", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Journal retrieved successfully", models.JournalEntryDetail{
+		JournalEntries: entry,
+		Lines:          lines,
+	})
+}
+
+// CreateJournalEntry membuat jurnal umum (manual). Debit dan kredit harus seimbang,
+// status awalnya mengikuti metode jurnal cabang seperti jurnal dokumen.
+func CreateJournalEntry(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+	nowWIB := time.Now().In(utils.Location)
+
+	var input models.JournalEntryInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for journal input", err)
+	}
+
+	entryDate, err := time.ParseInLocation("2006-01-02", input.EntryDate, utils.Location)
+	if err != nil {
+		return responses.BadRequest(c, "Format entry_date tidak valid, gunakan YYYY-MM-DD", err)
+	}
+
+	var branch models.Branch
+	if err := db.Select("id, journal_method").First(&branch, "id = ?", branchID).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve branch", err)
+	}
+
+	entry := models.JournalEntries{
+		ID:          helpers.GenerateID("JRN"),
+		EntryDate:   entryDate,
+		Description: input.Description,
+		SourceType:  models.JournalManual,
+		Status:      models.JournalPosted,
+		UserID:      userID,
+		BranchID:    branchID,
+		CreatedAt:   nowWIB,
+		UpdatedAt:   nowWIB,
+	}
+	if branch.JournalMethod == models.Manual {
+		entry.Status = models.JournalDraft
+	} else {
+		entry.PostedBy = userID
+		entry.PostedAt = &nowWIB
+	}
+
+	lines := make([]models.JournalLines, 0, len(input.Lines))
+	for i, line := range input.Lines {
+		if (line.Debit > 0) == (line.Credit > 0) {
+			return responses.BadRequest(c, fmt.Sprintf("Baris %d harus diisi debit atau kredit saja", i+1), nil)
+		}
+
+		var account models.Accounts
+		if err := db.Select("id, active").Where("id = ? AND branch_id = ?", line.AccountId, branchID).First(&account).Error; err != nil {
+			return responses.BadRequest(c, fmt.Sprintf("Akun baris %d tidak ditemukan di cabang ini", i+1), nil)
+		}
+		if !account.Active {
+			return responses.BadRequest(c, fmt.Sprintf("Akun baris %d sudah tidak aktif", i+1), nil)
+		}
+
+		lines = append(lines, models.JournalLines{
+			ID:             helpers.GenerateID("JRL"),
+			JournalEntryId: entry.ID,
+			AccountId:      account.ID,
+			Debit:          line.Debit,
+			Credit:         line.Credit,
+			Memo:           line.Memo,
+		})
+		entry.TotalDebit += line.Debit
+		entry.TotalCredit += line.Credit
+	}
+
+	if entry.TotalDebit != entry.TotalCredit {
+		return responses.BadRequest(c, fmt.Sprintf("Jurnal tidak seimbang. Debit: %d, Kredit: %d", entry.TotalDebit, entry.TotalCredit), nil)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		return tx.Create(&lines).Error
+	})
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to create journal", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Journal created successfully", entry)
+}
+
+// PostJournalEntry memposting jurnal draft, hanya jurnal terposting yang masuk buku besar dan neraca saldo
+func PostJournalEntry(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+	nowWIB := time.Now().In(utils.Location)
+
+	var entry models.JournalEntries
+	if err := db.Where("id = ? AND branch_id = ?", c.Param("id"), branchID).First(&entry).Error; err != nil {
+		return responses.NotFound(c, "Journal not found")
+	}
+
+	result := db.Model(&models.JournalEntries{}).
+		Where("id = ? AND status = ?", entry.ID, models.JournalDraft).
+		Updates(map[string]interface{}{
+			"status":     models.JournalPosted,
+			"posted_by":  userID,
+			"posted_at":  nowWIB,
+			"updated_at": nowWIB,
+		})
+	if result.Error != nil {
+		return responses.InternalServerError(c, "Failed to post journal", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return responses.BadRequest(c, "Jurnal sudah diposting", nil)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Journal posted successfully", framework.Map{"id": entry.ID})
+}
+
+// DeleteJournalEntry menghapus jurnal manual, jurnal dokumen ikut terhapus saat dokumennya dihapus
+func DeleteJournalEntry(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var entry models.JournalEntries
+	if err := db.Where("id = ? AND branch_id = ?", c.Param("id"), branchID).First(&entry).Error; err != nil {
+		return responses.NotFound(c, "Journal not found")
+	}
+	if entry.SourceType != models.JournalManual {
+		return responses.BadRequest(c, "Jurnal dokumen hanya bisa diubah lewat dokumen sumbernya", nil)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("journal_entry_id = ?", entry.ID).Delete(&models.JournalLines{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entry).Error
+	})
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to delete journal", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Journal deleted successfully", entry)
+}
+
+// RebuildJournals membangun ulang jurnal semua dokumen cabang dari data dokumen saat ini,
+// dipakai untuk dokumen lama sebelum ada buku besar. Jurnal manual tidak disentuh.
+func RebuildJournals(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	var processed int
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		processed, err = tools.RebuildBranchJournals(tx, branchID)
+		return err
+	})
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to rebuild journals", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Journals rebuilt successfully", framework.Map{"documents": processed})
+}
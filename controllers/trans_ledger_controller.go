@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// accountMovement mutasi bersih akun, opening_net = debit - kredit sebelum periode
+type accountMovement struct {
+	AccountId   string
+	Code        string
+	Name        string
+	AccountType models.AccountType
+	OpeningNet  int
+	Debit       int
+	Credit      int
+}
+
+// ledgerPeriod membaca start_date dan end_date (YYYY-MM-DD), default awal bulan ini sampai hari ini (WIB).
+// end yang dikembalikan eksklusif (hari setelah end_date).
+func ledgerPeriod(c *framework.Ctx) (time.Time, time.Time, error) {
+	nowWIB := time.Now().In(utils.Location)
+	start := time.Date(nowWIB.Year(), nowWIB.Month(), 1, 0, 0, 0, 0, utils.Location)
+	end := time.Date(nowWIB.Year(), nowWIB.Month(), nowWIB.Day(), 0, 0, 0, 0, utils.Location)
+
+	if startDate := strings.TrimSpace(c.Query("start_date")); startDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", startDate, utils.Location)
+		if err != nil {
+			return start, end, err
+		}
+		start = parsed
+	}
+	if endDate := strings.TrimSpace(c.Query("end_date")); endDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", endDate, utils.Location)
+		if err != nil {
+			return start, end, err
+		}
+		end = parsed
+	}
+
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+// normalBalance saldo akun pada sisi saldo normalnya, positif = sesuai saldo normal
+func normalBalance(accountType models.AccountType, debit int, credit int) int {
+	if accountType.DebitNormal() {
+		return debit - credit
+	}
+	return credit - debit
+}
+
+// accountMovements menghitung mutasi jurnal terposting per akun cabang dalam periode [start, end),
+// accountID kosong berarti semua akun
+func accountMovements(db *gorm.DB, branchID string, accountID string, start time.Time, end time.Time) ([]accountMovement, error) {
+	postedLines := db.Table("journal_lines jl").
+		Select("jl.account_id, jl.debit, jl.credit, je.entry_date").
+		Joins("JOIN journal_entries je ON je.id = jl.journal_entry_id").
+		Where("je.branch_id = ? AND je.status = ? AND je.entry_date < ?", branchID, models.JournalPosted, end)
+
+	query := db.Table("accounts acc").
+		Select(`acc.id AS account_id, acc.code, acc.name, acc.account_type,
+			COALESCE(SUM(CASE WHEN mv.entry_date < ? THEN mv.debit - mv.credit ELSE 0 END), 0) AS opening_net,
+			COALESCE(SUM(CASE WHEN mv.entry_date >= ? THEN mv.debit ELSE 0 END), 0) AS debit,
+			COALESCE(SUM(CASE WHEN mv.entry_date >= ? THEN mv.credit ELSE 0 END), 0) AS credit`, start, start, start).
+		Joins("LEFT JOIN (?) mv ON mv.account_id = acc.id", postedLines).
+		Where("acc.branch_id = ?", branchID).
+		Group("acc.id, acc.code, acc.name, acc.account_type").
+		Order("acc.code ASC")
+	if accountID != "" {
+		query = query.Where("acc.id = ?", accountID)
+	}
+
+	var movements []accountMovement
+	if err := query.Scan(&movements).Error; err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// toAccountBalance saldo awal, mutasi dan saldo akhir akun pada sisi saldo normalnya
+func toAccountBalance(mv accountMovement) models.AccountBalance {
+	opening := normalBalance(mv.AccountType, mv.OpeningNet, 0)
+	return models.AccountBalance{
+		AccountId:      mv.AccountId,
+		Code:           mv.Code,
+		Name:           mv.Name,
+		AccountType:    mv.AccountType,
+		OpeningBalance: opening,
+		Debit:          mv.Debit,
+		Credit:         mv.Credit,
+		ClosingBalance: opening + normalBalance(mv.AccountType, mv.Debit, mv.Credit),
+	}
+}
+
+// GetTrialBalance neraca saldo dari jurnal terposting sampai end_date (YYYY-MM-DD, default hari ini)
+func GetTrialBalance(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	_, end, err := ledgerPeriod(c)
+	if err != nil {
+		return responses.BadRequest(c, "Format tanggal tidak valid, gunakan YYYY-MM-DD", err)
+	}
+
+	if _, err := tools.EnsureBranchAccounts(db, branchID); err != nil {
+		return responses.InternalServerError(c, "Failed to prepare default accounts", err)
+	}
+
+	// Seluruh mutasi sampai end_date dihitung sebagai saldo awal
+	movements, err := accountMovements(db, branchID, "", end, end)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to calculate trial balance", err)
+	}
+
+	response := models.TrialBalanceResponse{
+		EndDate:  end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Accounts: []models.TrialBalanceRow{},
+	}
+	for _, mv := range movements {
+		if mv.OpeningNet == 0 {
+			continue
+		}
+		row := models.TrialBalanceRow{
+			AccountId:   mv.AccountId,
+			Code:        mv.Code,
+			Name:        mv.Name,
+			AccountType: mv.AccountType,
+		}
+		if mv.OpeningNet > 0 {
+			row.BalanceDebit = mv.OpeningNet
+		} else {
+			row.BalanceCredit = -mv.OpeningNet
+		}
+		response.TotalDebit += row.BalanceDebit
+		response.TotalCredit += row.BalanceCredit
+		response.Accounts = append(response.Accounts, row)
+	}
+	response.Balanced = response.TotalDebit == response.TotalCredit
+
+	return responses.JSONResponse(c, http.StatusOK, "Trial balance retrieved successfully", response)
+}
+
+// GetGeneralLedger buku besar: saldo awal, mutasi debit / kredit dan saldo akhir setiap akun dalam periode
+func GetGeneralLedger(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	start, end, err := ledgerPeriod(c)
+	if err != nil {
+		return responses.BadRequest(c, "Format tanggal tidak valid, gunakan YYYY-MM-DD", err)
+	}
+	if !start.Before(end) {
+		return responses.BadRequest(c, "start_date tidak boleh setelah end_date", nil)
+	}
+
+	if _, err := tools.EnsureBranchAccounts(db, branchID); err != nil {
+		return responses.InternalServerError(c, "Failed to prepare default accounts", err)
+	}
+
+	movements, err := accountMovements(db, branchID, "", start, end)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to calculate general ledger", err)
+	}
+
+	balances := make([]models.AccountBalance, 0, len(movements))
+	for _, mv := range movements {
+		balances = append(balances, toAccountBalance(mv))
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "General ledger retrieved successfully", balances)
+}
+
+// GetAccountLedger rincian buku besar satu akun dalam periode beserta saldo berjalan
+func GetAccountLedger(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	accountID := c.Param("id")
+
+	start, end, err := ledgerPeriod(c)
+	if err != nil {
+		return responses.BadRequest(c, "Format tanggal tidak valid, gunakan YYYY-MM-DD", err)
+	}
+	if !start.Before(end) {
+		return responses.BadRequest(c, "start_date tidak boleh setelah end_date", nil)
+	}
+
+	movements, err := accountMovements(db, branchID, accountID, start, end)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to calculate account balance", err)
+	}
+	if len(movements) == 0 {
+		return responses.NotFound(c, "Account not found")
+	}
+
+	ledger := models.AccountLedger{
+		AccountBalance: toAccountBalance(movements[0]),
+		Lines:          []models.LedgerLine{},
+	}
+
+	if err := db.Table("journal_lines jl").
+		Select(`jl.journal_entry_id, je.entry_date, je.source_type, COALESCE(je.source_id, '') AS source_id,
+			COALESCE(je.description, '') AS description, COALESCE(jl.memo, '') AS memo, jl.debit, jl.credit`).
+		Joins("JOIN journal_entries je ON je.id = jl.journal_entry_id").
+		Where("jl.account_id = ? AND je.branch_id = ? AND je.status = ?", accountID, branchID, models.JournalPosted).
+		Where("je.entry_date >= ? AND je.entry_date < ?", start, end).
+		Order("je.entry_date ASC, je.created_at ASC, jl.id ASC").
+		Scan(&ledger.Lines).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get account ledger", err)
+	}
+
+	balance := ledger.OpeningBalance
+	for i := range ledger.Lines {
+		balance += normalBalance(ledger.AccountType, ledger.Lines[i].Debit, ledger.Lines[i].Credit)
+		ledger.Lines[i].Balance = balance
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Account ledger retrieved successfully", ledger)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/heru-oktafian/scafold/responses"
 	"github.com/heru-oktafian/scafold/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // IsPurchaseEditable Function is using to check if the purchase is editable
@@ -39,6 +40,32 @@ func IsPurchaseEditable(db *gorm.DB, purchaseID string) (bool, error) {
 	return true, nil
 }
 
+// errPurchasePaid pembelian yang sudah dicicil tidak boleh diubah agar total tidak bergeser dari pembayarannya
+var errPurchasePaid = errors.New("Pembelian sudah memiliki pembayaran hutang, item tidak bisa diubah")
+
+// checkPurchaseUnpaid dipanggil di dalam transaksi: baris pembelian dikunci sampai commit
+// agar tidak dicicil selama itemnya diubah, errPurchasePaid jika sudah ada pembayaran hutang.
+func checkPurchaseUnpaid(tx *gorm.DB, purchaseID string) error {
+	var purchase models.Purchases
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id, paid_amount").
+		First(&purchase, "id = ?", purchaseID).Error; err != nil {
+		return fmt.Errorf("fetch purchase: %w", err)
+	}
+	if purchase.PaidAmount > 0 {
+		return errPurchasePaid
+	}
+	return nil
+}
+
+// purchaseItemError mengubah error perubahan item pembelian menjadi respons
+func purchaseItemError(c *framework.Ctx, message string, err error) error {
+	if errors.Is(err, errPurchasePaid) {
+		return responses.BadRequest(c, err.Error(), nil)
+	}
+	return saleItemError(c, message, err)
+}
+
 // CreatePurchase Function is using to create new purchase
 func CreatePurchase(c *framework.Ctx) error {
 
@@ -87,6 +114,10 @@ func CreatePurchase(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to sync purchase report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalPurchase, purchase.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync purchase journal", err)
+	}
+
 	_ = reports.AutoCleanupPurchases(db)
 
 	return responses.JSONResponse(c, http.StatusOK, "Purchase created successfully", purchase)
@@ -137,6 +168,11 @@ func UpdatePurchase(c *framework.Ctx) error {
 		purchase.PurchaseDate = parsedDate
 	}
 
+	// Pembelian yang sudah dicicil tidak boleh diubah metode pembayarannya
+	if input.Payment != "" && input.Payment != purchase.Payment && purchase.PaidAmount > 0 {
+		return responses.BadRequest(c, "Pembelian sudah memiliki pembayaran hutang, metode pembayaran tidak bisa diubah", nil)
+	}
+
 	// Cek dan update Payment
 	if input.Payment != "" {
 		purchase.Payment = models.PaymentStatus(input.Payment)
@@ -144,22 +180,30 @@ func UpdatePurchase(c *framework.Ctx) error {
 
 	purchase.UpdatedAt = nowWIB
 
+	// Jatuh tempo dihitung ulang jika supplier, tanggal atau pembayaran berubah
+	if err := tools.SetPurchaseDueDate(db, &purchase); err != nil {
+		return responses.InternalServerError(c, "Failed to set purchase due date", err)
+	}
+
 	// Hitung ulang total dari purchase items
 	var items []models.PurchaseItems
 	if err := db.Where("purchase_id = ?", id).Find(&items).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to retrieve purchase items", err)
 	}
 
-	if len(items) == 0 {
-		purchase.TotalPurchase = 0
-	} else {
-		total := 0
-		for _, item := range items {
-			total += item.SubTotal
-		}
-		purchase.TotalPurchase = total
+	total := 0
+	tax := 0
+	for _, item := range items {
+		total += item.SubTotal
+		tax += item.TaxAmount
 	}
 
+	taxBranch, err := tools.GetBranchTax(db, purchase.BranchID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve branch tax settings", err)
+	}
+	purchase.TaxAmount, purchase.TotalPurchase = taxBranch.TaxTotals(total, tax, 0)
+
 	// Simpan perubahan
 	if err := db.Save(&purchase).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to update purchase", err)
@@ -170,6 +214,10 @@ func UpdatePurchase(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to sync purchase report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalPurchase, purchase.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync purchase journal", err)
+	}
+
 	_ = reports.AutoCleanupPurchases(db)
 
 	return responses.JSONResponse(c, http.StatusOK, "Purchase updated successfully", purchase)
@@ -195,32 +243,62 @@ func DeletePurchase(c *framework.Ctx) error {
 		return responses.Forbidden(c, "Data tidak bisa diedit karena sudah tersimpan lebih dari 1 jam")
 	}
 
-	// Ambil item-item dan rollback stok
-	var items []models.PurchaseItems
-	if err := db.Where("purchase_id = ?", id).Find(&items).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to retrieve purchase items", err)
+	if purchase.PaidAmount > 0 {
+		return responses.BadRequest(c, "Pembelian sudah memiliki pembayaran hutang dan tidak bisa dihapus", nil)
 	}
 
-	for _, item := range items {
-		// Rollback stok ke produk
-		if err := tools.ReduceProductStock(db, item.ProductId, item.Qty); err != nil {
-			return responses.InternalServerError(c, fmt.Sprintf("Failed to rollback stock for product ID %s", item.ProductId), err)
+	// Rollback stok, batch dan harga pokok serta penghapusan pembelian dilakukan dalam satu transaksi
+	stockRef := tools.NewStockRef(c, models.PurchaseTrans, purchase.ID)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPurchaseUnpaid(tx, purchase.ID); err != nil {
+			return err
 		}
-	}
 
-	// Hapus semua item dari pembelian
-	if err := db.Where("purchase_id = ?", id).Delete(&models.PurchaseItems{}).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to delete purchase items", err)
-	}
+		// Ambil item-item dan rollback stok
+		var items []models.PurchaseItems
+		if err := tx.Where("purchase_id = ?", id).Find(&items).Error; err != nil {
+			return fmt.Errorf("fetch purchase items: %w", err)
+		}
 
-	// Hapus laporan transaksi terkait
-	if err := db.Where("id = ? AND transaction_type = ?", purchase.ID, models.Purchase).Delete(&models.TransactionReports{}).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to delete transaction report", err)
-	}
+		for _, item := range items {
+			// Rollback stok ke produk
+			if err := tools.ReduceProductStock(tx, item.ProductId, item.Qty, stockRef); err != nil {
+				return fmt.Errorf("rollback stock for product %s: %w", item.ProductId, err)
+			}
+
+			// Kurangi batch yang berasal dari item ini
+			if err := tools.ReduceSourceBatch(tx, purchase.BranchID, item.ProductId, item.ID, item.Qty); err != nil {
+				return fmt.Errorf("rollback batch for product %s: %w", item.ProductId, err)
+			}
+
+			if err := tools.ReduceReceiptCost(tx, stockRef, item.ProductId, item.ID, item.Qty, item.Price); err != nil {
+				return fmt.Errorf("rollback cost for product %s: %w", item.ProductId, err)
+			}
+		}
+
+		// Hapus semua item dari pembelian
+		if err := tx.Where("purchase_id = ?", id).Delete(&models.PurchaseItems{}).Error; err != nil {
+			return fmt.Errorf("delete purchase items: %w", err)
+		}
 
-	// Hapus purchase
-	if err := db.Delete(&purchase).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to delete purchase", err)
+		// Hapus laporan transaksi terkait
+		if err := tx.Where("id = ? AND transaction_type = ?", purchase.ID, models.Purchase).Delete(&models.TransactionReports{}).Error; err != nil {
+			return fmt.Errorf("delete transaction report: %w", err)
+		}
+
+		// Hapus jurnal pembelian
+		if err := tools.RemoveJournal(tx, models.JournalPurchase, purchase.ID); err != nil {
+			return fmt.Errorf("delete purchase journal: %w", err)
+		}
+
+		// Hapus purchase
+		if err := tx.Delete(&purchase).Error; err != nil {
+			return fmt.Errorf("delete purchase: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return purchaseItemError(c, "Failed to delete purchase", err)
 	}
 
 	return responses.JSONResponse(c, http.StatusOK, "Purchase deleted successfully", purchase)
@@ -229,6 +307,7 @@ func DeletePurchase(c *framework.Ctx) error {
 // CreatePurchaseItem Function is using to create new purchase item
 func CreatePurchaseItem(c *framework.Ctx) error {
 	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
 	var item models.PurchaseItems
 
 	if err := c.BodyParser(&item); err != nil {
@@ -244,69 +323,86 @@ func CreatePurchaseItem(c *framework.Ctx) error {
 		return responses.Forbidden(c, "Data tidak bisa diedit karena sudah tersimpan lebih dari 1 jam")
 	}
 
-	// Cek apakah item dengan purchase_id dan product_id sudah ada
-	var existing models.PurchaseItems
-	err := db.Where("purchase_id = ? AND product_id = ?", item.PurchaseId, item.ProductId).First(&existing).Error
-	if err == nil {
-		// Sudah ada: update qty dan sub_total
-		existing.Qty += item.Qty
-		existing.SubTotal = existing.Qty * existing.Price // asumsi pakai harga awal
+	stockRef := tools.NewStockRef(c, models.PurchaseTrans, item.PurchaseId)
 
-		if err := db.Save(&existing).Error; err != nil {
-			return responses.InternalServerError(c, "Failed to update existing item", err)
+	var saved models.PurchaseItems
+	message := "Item added successfully"
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPurchaseUnpaid(tx, item.PurchaseId); err != nil {
+			return err
 		}
 
-		// Tambah stok
-		if err := tools.AddProductStock(db, item.ProductId, item.Qty); err != nil {
-			return responses.InternalServerError(c, "Failed to add product stock", err)
-		}
+		// Cek apakah item dengan purchase_id dan product_id sudah ada
+		var existing models.PurchaseItems
+		err := tx.Where("purchase_id = ? AND product_id = ?", item.PurchaseId, item.ProductId).First(&existing).Error
+		if err == nil {
+			// Sudah ada: update qty dan sub_total
+			existing.Qty += item.Qty
+			existing.SubTotal = existing.Qty * existing.Price // asumsi pakai harga awal
 
-		// Update harga produk jika harga baru lebih tinggi dari yang tersimpan di tabel products
-		if err := tools.UpdateProductPriceIfHigher(db, item.ProductId, item.Price); err != nil {
-			return responses.InternalServerError(c, "Failed to update product price", err)
-		}
+			existing.TaxAmount, err = tools.ProductLineTax(tx, branchID, existing.ProductId, existing.SubTotal)
+			if err != nil {
+				return fmt.Errorf("calculate item tax: %w", err)
+			}
 
-		// Recalculate total pembelian
-		if err := tools.RecalculateTotalPurchase(db, item.PurchaseId); err != nil {
-			return responses.InternalServerError(c, "Failed to recalculate total purchase", err)
-		}
+			if err := tx.Save(&existing).Error; err != nil {
+				return fmt.Errorf("update existing item: %w", err)
+			}
+			saved = existing
+			message = "Item updated successfully"
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Data belum ada, buat item baru
+			if item.ID == "" {
+				item.ID = helpers.GenerateID("PIT")
+			}
+			item.SubTotal = item.Qty * item.Price
 
-		return responses.JSONResponse(c, http.StatusOK, "Item updated successfully", existing)
+			item.TaxAmount, err = tools.ProductLineTax(tx, branchID, item.ProductId, item.SubTotal)
+			if err != nil {
+				return fmt.Errorf("calculate item tax: %w", err)
+			}
 
-	} else if err != gorm.ErrRecordNotFound {
-		// Error selain record not found
-		return responses.InternalServerError(c, "Failed to check existing item", err)
-	}
+			if err := tx.Create(&item).Error; err != nil {
+				return fmt.Errorf("create item: %w", err)
+			}
+			saved = item
+		} else {
+			return fmt.Errorf("check existing item: %w", err)
+		}
 
-	// Data belum ada, buat item baru
-	if item.ID == "" {
-		item.ID = helpers.GenerateID("PIT")
-	}
-	item.SubTotal = item.Qty * item.Price
+		// Tambah stok
+		if err := tools.AddProductStock(tx, item.ProductId, item.Qty, stockRef); err != nil {
+			return fmt.Errorf("add product stock: %w", err)
+		}
 
-	if err := db.Create(&item).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to create item", err)
-	}
-	// Tambah stok
-	if err := tools.AddProductStock(db, item.ProductId, item.Qty); err != nil {
-		return responses.InternalServerError(c, "Failed to add product stock", err)
-	}
+		// Tambah batch baru untuk qty tambahan
+		if err := tools.AddProductBatch(tx, branchID, item.ProductId, saved.ID, "", item.ExpiredDate, item.Qty); err != nil {
+			return fmt.Errorf("add product batch: %w", err)
+		}
 
-	if err := tools.UpdateProductPriceIfHigher(db, item.ProductId, item.Price); err != nil {
-		return responses.InternalServerError(c, "Failed to update product price", err)
-	}
+		// Hitung ulang harga pokok produk sesuai metode costing cabang
+		if err := tools.ApplyReceiptCost(tx, stockRef, item.ProductId, saved.ID, item.Qty, item.Price); err != nil {
+			return fmt.Errorf("update product cost: %w", err)
+		}
 
-	if err := tools.RecalculateTotalPurchase(db, item.PurchaseId); err != nil {
-		return responses.InternalServerError(c, "Failed to recalculate total purchase", err)
+		// Recalculate total pembelian
+		if err := tools.RecalculateTotalPurchase(tx, item.PurchaseId); err != nil {
+			return fmt.Errorf("recalculate total purchase: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return purchaseItemError(c, "Failed to save purchase item", err)
 	}
 
-	return responses.JSONResponse(c, http.StatusOK, "Item added successfully", item)
+	return responses.JSONResponse(c, http.StatusOK, message, saved)
 }
 
 // Update PurchaseItem is using to update purchase
 func UpdatePurchaseItem(c *framework.Ctx) error {
 	db := config.DB
 	id := c.Param("id")
+	branchID, _ := middlewares.GetBranchID(c.Request)
 
 	var existingItem models.PurchaseItems
 	if err := db.First(&existingItem, "id = ?", id).Error; err != nil {
@@ -328,35 +424,69 @@ func UpdatePurchaseItem(c *framework.Ctx) error {
 		return responses.BadRequest(c, "Invalid input", err)
 	}
 
-	// Rollback stok lama
-	if err := tools.ReduceProductStock(db, existingItem.ProductId, existingItem.Qty); err != nil {
-		return responses.InternalServerError(c, "Failed to rollback old stock", err)
-	}
+	stockRef := tools.NewStockRef(c, models.PurchaseTrans, existingItem.PurchaseId)
 
-	// Tambah stok baru
-	if err := tools.AddProductStock(db, updatedItem.ProductId, updatedItem.Qty); err != nil {
-		return responses.InternalServerError(c, "Failed to add new stock", err)
-	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPurchaseUnpaid(tx, existingItem.PurchaseId); err != nil {
+			return err
+		}
 
-	// Update item
-	existingItem.ProductId = updatedItem.ProductId
-	existingItem.Qty = updatedItem.Qty
-	existingItem.Price = updatedItem.Price
-	existingItem.SubTotal = updatedItem.Price * updatedItem.Qty
+		// Rollback stok lama
+		if err := tools.ReduceProductStock(tx, existingItem.ProductId, existingItem.Qty, stockRef); err != nil {
+			return fmt.Errorf("rollback old stock: %w", err)
+		}
 
-	if err := db.Save(&existingItem).Error; err != nil {
-		// return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-		return responses.InternalServerError(c, "Failed to update item", err)
-	}
+		// Batalkan harga pokok dari qty lama
+		if err := tools.ReduceReceiptCost(tx, stockRef, existingItem.ProductId, existingItem.ID, existingItem.Qty, existingItem.Price); err != nil {
+			return fmt.Errorf("rollback old cost: %w", err)
+		}
 
-	// Update harga produk jika harga item lebih tinggi
-	if err := tools.UpdateProductPriceIfHigher(db, updatedItem.ProductId, updatedItem.Price); err != nil {
-		return responses.InternalServerError(c, "Failed to update product price", err)
-	}
+		// Tambah stok baru
+		if err := tools.AddProductStock(tx, updatedItem.ProductId, updatedItem.Qty, stockRef); err != nil {
+			return fmt.Errorf("add new stock: %w", err)
+		}
+
+		// Ganti batch lama dengan batch sesuai data baru
+		if err := tools.ReduceSourceBatch(tx, branchID, existingItem.ProductId, existingItem.ID, existingItem.Qty); err != nil {
+			return fmt.Errorf("rollback old batch: %w", err)
+		}
+		expiredDate := existingItem.ExpiredDate
+		if !updatedItem.ExpiredDate.IsZero() {
+			expiredDate = updatedItem.ExpiredDate
+		}
+		if err := tools.AddProductBatch(tx, branchID, updatedItem.ProductId, existingItem.ID, "", expiredDate, updatedItem.Qty); err != nil {
+			return fmt.Errorf("add new batch: %w", err)
+		}
+
+		// Update item
+		existingItem.ProductId = updatedItem.ProductId
+		existingItem.Qty = updatedItem.Qty
+		existingItem.Price = updatedItem.Price
+		existingItem.SubTotal = updatedItem.Price * updatedItem.Qty
+
+		taxAmount, err := tools.ProductLineTax(tx, branchID, existingItem.ProductId, existingItem.SubTotal)
+		if err != nil {
+			return fmt.Errorf("calculate item tax: %w", err)
+		}
+		existingItem.TaxAmount = taxAmount
+
+		if err := tx.Save(&existingItem).Error; err != nil {
+			return fmt.Errorf("update item: %w", err)
+		}
+
+		// Hitung ulang harga pokok produk sesuai metode costing cabang
+		if err := tools.ApplyReceiptCost(tx, stockRef, updatedItem.ProductId, existingItem.ID, updatedItem.Qty, updatedItem.Price); err != nil {
+			return fmt.Errorf("update product cost: %w", err)
+		}
 
-	// Recalculate total & sync
-	if err := tools.RecalculateTotalPurchase(db, existingItem.PurchaseId); err != nil {
-		return responses.InternalServerError(c, "Failed to recalculate total purchase", err)
+		// Recalculate total & sync
+		if err := tools.RecalculateTotalPurchase(tx, existingItem.PurchaseId); err != nil {
+			return fmt.Errorf("recalculate total purchase: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return purchaseItemError(c, "Failed to update purchase item", err)
 	}
 
 	return responses.JSONResponse(c, http.StatusOK, "Item updated successfully", existingItem)
@@ -366,6 +496,7 @@ func UpdatePurchaseItem(c *framework.Ctx) error {
 func DeletePurchaseItem(c *framework.Ctx) error {
 	db := config.DB
 	id := c.Param("id")
+	branchID, _ := middlewares.GetBranchID(c.Request)
 
 	var item models.PurchaseItems
 	if err := db.First(&item, "id = ?", id).Error; err != nil {
@@ -381,24 +512,53 @@ func DeletePurchaseItem(c *framework.Ctx) error {
 		return responses.Forbidden(c, "Data tidak bisa diedit karena sudah tersimpan lebih dari 1 jam")
 	}
 
-	// Subtract stok
-	if err := tools.ReduceProductStock(db, item.ProductId, item.Qty); err != nil {
-		return responses.InternalServerError(c, "Failed to reduce product stock", err)
-	}
+	stockRef := tools.NewStockRef(c, models.PurchaseTrans, item.PurchaseId)
 
-	// Hapus item
-	if err := db.Delete(&item).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to delete item", err)
-	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPurchaseUnpaid(tx, item.PurchaseId); err != nil {
+			return err
+		}
+
+		// Subtract stok
+		if err := tools.ReduceProductStock(tx, item.ProductId, item.Qty, stockRef); err != nil {
+			return fmt.Errorf("reduce product stock: %w", err)
+		}
+
+		if err := tools.ReduceSourceBatch(tx, branchID, item.ProductId, item.ID, item.Qty); err != nil {
+			return fmt.Errorf("reduce product batch: %w", err)
+		}
+
+		if err := tools.ReduceReceiptCost(tx, stockRef, item.ProductId, item.ID, item.Qty, item.Price); err != nil {
+			return fmt.Errorf("reduce product cost: %w", err)
+		}
+
+		// Hapus item
+		if err := tx.Delete(&item).Error; err != nil {
+			return fmt.Errorf("delete item: %w", err)
+		}
 
-	// Recalculate total
-	if err := tools.RecalculateTotalPurchase(db, item.PurchaseId); err != nil {
-		return responses.InternalServerError(c, "Failed to recalculate total purchase", err)
+		// Recalculate total
+		if err := tools.RecalculateTotalPurchase(tx, item.PurchaseId); err != nil {
+			return fmt.Errorf("recalculate total purchase: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return purchaseItemError(c, "Failed to delete purchase item", err)
 	}
 
 	return responses.JSONResponse(c, http.StatusOK, "Item deleted successfully", item)
 }
 
+// purchaseExportColumns kolom ekspor daftar pembelian
+var purchaseExportColumns = []tools.ExportColumn{
+	{Header: "No. Pembelian"},
+	{Header: "Tanggal", Kind: tools.ExportDateTime},
+	{Header: "Supplier"},
+	{Header: "Total", Kind: tools.ExportMoney},
+	{Header: "Pembayaran"},
+}
+
 // Get All Purchases tampilkan semua purchase
 func GetAllPurchases(c *framework.Ctx) error {
 	// Hitung waktu sekarang dalam WIB
@@ -449,6 +609,13 @@ func GetAllPurchases(c *framework.Ctx) error {
 		query = query.Where("LOWER(sup.name) LIKE ?", "%"+search+"%")
 	}
 
+	// ?format=csv|xlsx mengekspor seluruh hasil filter tanpa paginasi
+	if format := c.Query("format"); format != "" {
+		return exportList(c, query.Order("pur.created_at DESC"), format, "pembelian", purchaseExportColumns, func(purchase models.AllPurchases) []interface{} {
+			return []interface{}{purchase.ID, purchase.PurchaseDate, purchase.SupplierName, purchase.TotalPurchase, tools.PaymentLabel(purchase.Payment)}
+		})
+	}
+
 	// Hitung total
 	if err := query.Count(&total).Error; err != nil {
 		return responses.InternalServerError(c, "Get purchase failed", err)
@@ -508,30 +675,14 @@ func GetPurchaseWithItems(c *framework.Ctx) error {
 	// Ambil ID pembelian dari parameter URL
 	purchaseID := c.Param("id")
 
-	// Struct untuk data utama purchase
-	var purchase models.AllPurchases
-
 	// Ambil data purchase dengan LEFT JOIN ke suppliers
-	err := db.Table("purchases pur").
-		Select("pur.id, pur.supplier_id, sup.name AS supplier_name, pur.purchase_date, pur.total_purchase, pur.payment").
-		Joins("LEFT JOIN suppliers sup ON sup.id = pur.supplier_id").
-		Where("pur.id = ?", purchaseID).
-		Scan(&purchase).Error
-
+	purchase, err := getPurchase(db, purchaseID)
 	if err != nil {
 		return responses.InternalServerError(c, "Failed to get purchase", err)
 	}
 
 	// Ambil item pembelian terkait
-	var items []models.AllPurchaseItems
-	err = db.Table("purchase_items pit").
-		Select("pit.id, pit.purchase_id, pit.product_id, pro.name AS product_name, pit.unit_id AS unit_id, un.name AS unit_name, pit.price, pit.qty, pit.sub_total, pit.expired_date").
-		Joins("LEFT JOIN products pro ON pro.id = pit.product_id").
-		Joins("LEFT JOIN units un ON un.id = pit.unit_id").
-		Where("pit.purchase_id = ?", purchaseID).
-		Order("pro.name ASC").
-		Scan(&items).Error
-
+	items, err := getPurchaseItems(db, purchaseID)
 	if err != nil {
 		return responses.InternalServerError(c, "Failed to get purchase items", err)
 	}
@@ -572,6 +723,30 @@ func GetPurchaseWithItems(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Purchase retrieved successfully", responseDetail)
 }
 
+// getPurchase mengambil data utama pembelian beserta nama supplier
+func getPurchase(db *gorm.DB, purchaseID string) (models.AllPurchases, error) {
+	var purchase models.AllPurchases
+	err := db.Table("purchases pur").
+		Select("pur.id, pur.supplier_id, sup.name AS supplier_name, pur.purchase_date, pur.total_purchase, pur.payment").
+		Joins("LEFT JOIN suppliers sup ON sup.id = pur.supplier_id").
+		Where("pur.id = ?", purchaseID).
+		Scan(&purchase).Error
+	return purchase, err
+}
+
+// getPurchaseItems mengambil item pembelian beserta nama produk dan satuan
+func getPurchaseItems(db *gorm.DB, purchaseID string) ([]models.AllPurchaseItems, error) {
+	var items []models.AllPurchaseItems
+	err := db.Table("purchase_items pit").
+		Select("pit.id, pit.purchase_id, pit.product_id, pro.name AS product_name, pit.unit_id AS unit_id, un.name AS unit_name, pit.price, pit.qty, pit.sub_total, pit.expired_date").
+		Joins("LEFT JOIN products pro ON pro.id = pit.product_id").
+		Joins("LEFT JOIN units un ON un.id = pit.unit_id").
+		Where("pit.purchase_id = ?", purchaseID).
+		Order("pro.name ASC").
+		Scan(&items).Error
+	return items, err
+}
+
 // CreatePurchaseTransaction controller
 func CreatePurchaseTransaction(c *framework.Ctx) error {
 	nowWIB := time.Now().In(utils.Location)
@@ -587,6 +762,12 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 		return responses.BadRequest(c, "Invalid request body", err)
 	}
 
+	// Request ulang dengan Idempotency-Key yang sama mengembalikan respons pertama
+	idem, replayed, err := startIdempotency(c, "purchases", req)
+	if replayed {
+		return err
+	}
+
 	if req.Purchase.Payment == "" {
 		req.Purchase.Payment = "paid_by_cash"
 	}
@@ -643,6 +824,7 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 	purchase.UpdatedAt = nowWIB
 
 	var calculatedTotalPurchase int
+	var calculatedTax int
 	var purchaseItemsToCreate []models.PurchaseItems
 	var purchaseItemsForResponse []models.PurchaseItemResponse // <--- Slice baru untuk data respons
 
@@ -657,7 +839,19 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to retrieve supplier details", err)
 	}
 
-	// var stockTracksToCreate []models.StockTracks
+	stockRef := tools.StockRef{
+		MovementType: models.PurchaseTrans,
+		ReferenceID:  purchaseID,
+		UserID:       purchase.UserID,
+		BranchID:     purchase.BranchID,
+	}
+
+	// Pengaturan PPN cabang untuk menghitung pajak masukan setiap item
+	taxBranch, err := tools.GetBranchTax(tx, purchase.BranchID)
+	if err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to retrieve branch tax settings", err)
+	}
 
 	for i := range req.PurchaseItems {
 		parsedExpiredDate, err := time.Parse("2006-01-02", req.PurchaseItems[i].ExpiredDate)
@@ -688,26 +882,14 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 		}
 
 		// --- Logika Konversi Satuan ---
-		var conversionValue int = 1
-		if req.PurchaseItems[i].UnitId != product.UnitId {
-			var unitConversion models.UnitConversion
-			err = tx.Where("product_id = ? AND init_id = ? AND final_id = ? AND branch_id = ?",
-				req.PurchaseItems[i].ProductId,
-				req.PurchaseItems[i].UnitId,
-				product.UnitId,
-				purchase.BranchID,
-			).First(&unitConversion).Error
-
-			if err != nil {
-				if err == gorm.ErrRecordNotFound {
-					conversionValue = 1
-				} else {
-					tx.Rollback()
-					return responses.InternalServerError(c, "Failed to retrieve unit conversion details", err)
-				}
-			} else {
-				conversionValue = unitConversion.ValueConv
+		// Nilai konversi dihitung lewat graf konversi, mendukung konversi bertingkat
+		conversionValue, err := tools.GetConversionValue(tx, purchase.BranchID, product, req.PurchaseItems[i].UnitId)
+		if err != nil {
+			tx.Rollback()
+			if errors.Is(err, tools.ErrUnitFraction) {
+				return responses.BadRequest(c, err.Error(), err)
 			}
+			return responses.InternalServerError(c, "Failed to retrieve unit conversion details", err)
 		}
 		actualQtyToAdd := req.PurchaseItems[i].Qty * conversionValue
 		// --- Akhir Logika Konversi Satuan ---
@@ -725,6 +907,7 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 			Qty:         req.PurchaseItems[i].Qty,
 			SubTotal:    itemSubTotal,
 			ExpiredDate: parsedExpiredDate,
+			TaxAmount:   taxBranch.LineTax(itemSubTotal, product.TaxExempt),
 		}
 		purchaseItemsToCreate = append(purchaseItemsToCreate, purchaseItemDB)
 
@@ -743,25 +926,45 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 		purchaseItemsForResponse = append(purchaseItemsForResponse, purchaseItemResp)
 		// --- Akhir persiapan data respons ---
 
-		// --- Tambah stok dan cek/update expired_date ---
-		updates := map[string]interface{}{
-			"stock": product.Stock + actualQtyToAdd,
+		// --- Tambah stok secara atomik dan catat mutasinya ke stock_tracks ---
+		if _, _, err = tools.ChangeProductStock(tx, product.ID, actualQtyToAdd, stockRef); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to update stock for product %s", product.Name), err)
 		}
 
+		// Jika ExpiredDate stok baru lebih awal dari yang sudah ada di master produk, update.
 		if parsedExpiredDate.Before(product.ExpiredDate) {
-			updates["expired_date"] = parsedExpiredDate
+			err = tx.Model(&models.Product{}).Where("id = ?", product.ID).Update("expired_date", parsedExpiredDate).Error
+			if err != nil {
+				tx.Rollback()
+				return responses.InternalServerError(c, fmt.Sprintf("Failed to update expired_date for product %s", product.Name), err)
+			}
 		}
 
-		err = tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(updates).Error
-		if err != nil {
+		// Simpan batch sesuai expired_date item yang diterima
+		if err = tools.AddProductBatch(tx, purchase.BranchID, product.ID, purchaseItemDB.ID, req.PurchaseItems[i].BatchNumber, parsedExpiredDate, actualQtyToAdd); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to create batch for product %s", product.Name), err)
+		}
+
+		// Hitung ulang harga pokok produk (harga input adalah harga per satuan dasar)
+		if err = tools.ApplyReceiptCost(tx, stockRef, product.ID, purchaseItemDB.ID, actualQtyToAdd, req.PurchaseItems[i].Price); err != nil {
 			tx.Rollback()
-			return responses.InternalServerError(c, fmt.Sprintf("Failed to update product details (stock/expired_date) for product %s", product.Name), err)
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to update cost for product %s", product.Name), err)
 		}
 		// --- Akhir tambah stok dan cek/update expired_date ---
 		calculatedTotalPurchase += itemSubTotal
+		calculatedTax += purchaseItemDB.TaxAmount
 	}
 
-	purchase.TotalPurchase = calculatedTotalPurchase
+	// Total pembelian ditambah PPN jika harga belum termasuk pajak
+	purchase.TaxAmount, purchase.TotalPurchase = taxBranch.TaxTotals(calculatedTotalPurchase, calculatedTax, 0)
+
+	// Jatuh tempo hutang untuk pembelian kredit
+	if err = tools.SetPurchaseDueDate(tx, &purchase); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to set purchase due date", err)
+	}
 
 	err = tx.Create(&purchase).Error
 	if err != nil {
@@ -792,6 +995,11 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to create transaction report for purchase", err)
 	}
 
+	if err = tools.SyncJournal(tx, models.JournalPurchase, purchase.ID); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create purchase journal", err)
+	}
+
 	if subscriptionType == "quota" {
 		var branch models.Branch
 		err = tx.Where("id = ?", req.Purchase.BranchID).First(&branch).Error
@@ -816,11 +1024,6 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 		}
 	}
 
-	err = tx.Commit().Error
-	if err != nil {
-		return responses.InternalServerError(c, "Failed to commit database transaction", err)
-	}
-
 	// --- Akhir: Mengkonstruksi Objek Respon ---
 	response := models.PurchaseResponse{
 		ID:            purchase.ID,
@@ -833,6 +1036,15 @@ func CreatePurchaseTransaction(c *framework.Ctx) error {
 	}
 	// --- Akhir Mengkonstruksi Objek Respon ---
 
+	if replayed, err := finishIdempotency(c, tx, idem, http.StatusOK, "Purchase transaction created successfully", response); replayed {
+		return err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
 	return responses.JSONResponse(c, http.StatusOK, "Purchase transaction created successfully", response)
 }
 
@@ -871,41 +1083,23 @@ func GetFixedPrice(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to retrieve product details", err)
 	}
 
-	// --- 2. Dapatkan UnitConversion Value ---
-	var conversionValue int = 1 // Default to 1 if no conversion or no explicit FinalID
-	var unitConversion models.UnitConversion
-
-	// Asumsi: jika final_id dari request adalah sama dengan init_id, atau
-	// jika init_id sudah merupakan unit dasar, maka value_conv adalah 1.
-	// Jika req.InitID sama dengan req.FinalID, maka konversinya 1.
-	// Jika UnitId di Product adalah satuan dasar, maka FinalId di UnitConversion harus merujuk ke UnitId di Product.
-	// Kita akan mencari konversi dari init_id ke final_id yang diberikan di parameter.
-
-	// Hanya cari konversi jika init_id tidak sama dengan final_id,
-	// dan juga init_id tidak sama dengan unit default produk (jika diasumsikan sebagai satuan dasar).
-	if req.InitID != req.FinalID { // Konversi hanya diperlukan jika satuan awal dan akhir berbeda
-		err = db.Where("product_id = ? AND init_id = ? AND final_id = ? AND branch_id = ?",
-			req.ProductID,
-			req.InitID,
-			req.FinalID,
-			branchID,
-		).First(&unitConversion).Error
-
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// Jika konversi spesifik tidak ditemukan, kita bisa memilih untuk:
-				// a) Mengembalikan error (lebih ketat)
-				// b) Mengasumsikan value_conv adalah 1 (lebih longgar, mungkin berarti unit_id == final_id secara implisit)
-				// Untuk endpoint ini, saya akan mengembalikan error karena permintaan Anda eksplisit.
-				return responses.NotFound(c, fmt.Sprintf("Unit conversion from %s to %s for product %s not found in branch %s", req.InitID, req.FinalID, req.ProductID, branchID))
-			}
-			return responses.InternalServerError(c, "Failed to retrieve unit conversion details", err)
-		}
-		conversionValue = unitConversion.ValueConv
+	// --- 2. Hitung faktor konversi lewat graf konversi produk ---
+	// Jalur konversi boleh bertingkat (misal box -> strip -> tablet), init_id dan final_id harus terhubung.
+	graph, err := tools.LoadUnitGraph(db, branchID, product)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve unit conversion details", err)
+	}
+	if _, err := graph.Ratio(req.InitID, req.FinalID); err != nil {
+		return responses.NotFound(c, fmt.Sprintf("Unit conversion from %s to %s for product %s not found in branch %s", req.InitID, req.FinalID, req.ProductID, branchID))
+	}
+	factor, ok := graph.Factor(req.InitID)
+	if !ok {
+		return responses.NotFound(c, fmt.Sprintf("Unit conversion from %s to base unit %s for product %s not found in branch %s", req.InitID, product.UnitId, req.ProductID, branchID))
 	}
 
 	// --- 3. Hitung FixPrice ---
-	fixPrice := product.PurchasePrice * conversionValue
+	// PurchasePrice produk adalah harga per satuan dasar, FixPrice = harga per satuan init_id
+	fixPrice := tools.ConvertPrice(product.PurchasePrice, factor)
 
 	// --- 4. Buat Respon ---
 	response := models.FixedPriceResponse{
@@ -955,118 +1149,41 @@ func GetProductUnitsWithConvertedPrices(c *framework.Ctx) error {
 	}
 
 	basePurchasePrice := product.PurchasePrice // Harga dasar (per unit dasar produk)
-	baseUnitID := product.UnitId               // ID unit dasar produk
 
-	// Map untuk menyimpan harga yang sudah dihitung untuk setiap unit ID
-	// Ini akan menghindari duplikasi unit di respons akhir dan memudahkan lookup
-	calculatedPrices := make(map[string]models.ProductUnitResponseItem)
-
-	// Tambahkan unit dasar produk itu sendiri sebagai opsi pertama
-	// Ini adalah harga acuan tanpa konversi
-	calculatedPrices[baseUnitID] = models.ProductUnitResponseItem{
-		UnitId:        baseUnitID,
-		PurchasePrice: basePurchasePrice,
-	}
-
-	// 2. Dapatkan semua UnitConversion yang terkait dengan ProductID ini
-	var unitConversions []models.UnitConversion
-	err = db.Where("product_id = ? AND branch_id = ?", req.ProductID, branchID).Find(&unitConversions).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
+	// 2. Bangun graf konversi produk, semua satuan yang terhubung ke satuan dasar
+	// (langsung maupun bertingkat, misal box -> strip -> tablet) bisa dipakai untuk membeli
+	graph, err := tools.LoadUnitGraph(db, branchID, product)
+	if err != nil {
 		return responses.InternalServerError(c, "Failed to retrieve unit conversions", err)
 	}
-
-	// Kumpulkan semua ID unit yang perlu dicari namanya (dari produk dasar, init_id, dan final_id konversi)
-	unitIDsToFetch := []string{baseUnitID} // Mulai dengan unit dasar produk
-	for _, uc := range unitConversions {
-		unitIDsToFetch = append(unitIDsToFetch, uc.InitId)
-		unitIDsToFetch = append(unitIDsToFetch, uc.FinalId)
-	}
+	unitIDs := graph.Units()
 
 	// Dapatkan nama-nama unit yang relevan sekaligus
 	unitNames := make(map[string]string)
 	var units []models.Unit
-	if len(unitIDsToFetch) > 0 {
-		err = db.Where("id IN (?) AND branch_id = ?", unitIDsToFetch, branchID).Find(&units).Error
-		if err != nil {
-			return responses.InternalServerError(c, "Failed to retrieve unit names", err)
-		}
-		for _, u := range units {
-			unitNames[u.ID] = u.Name
-		}
+	err = db.Where("id IN (?) AND branch_id = ?", unitIDs, branchID).Find(&units).Error
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve unit names", err)
 	}
-
-	// Set nama untuk unit dasar
-	if item, ok := calculatedPrices[baseUnitID]; ok {
-		item.UnitName = unitNames[baseUnitID]
-		calculatedPrices[baseUnitID] = item
-	}
-
-	// 3. Iterasi melalui UnitConversions dan hitung harga untuk setiap unit yang relevan
-	// Kita harus mempertimbangkan konversi 'maju' (dari unit besar ke unit kecil)
-	// dan konversi 'mundur' (dari unit kecil ke unit besar)
-	// tergantung bagaimana ValueConv didefinisikan.
-
-	// Untuk setiap konversi, tentukan harga untuk InitId dan FinalId
-	for _, uc := range unitConversions {
-		// Kasus 1: Konversi dari unit yang lebih besar ke unit yang lebih kecil (misal: Box ke Pcs, Strip ke Sachet)
-		// Jika init_id adalah unit yang lebih besar, dan final_id adalah unit yang lebih kecil,
-		// maka harga final_id = harga init_id / value_conv.
-		// Asumsi: ValueConv selalu > 0.
-
-		// Untuk menyederhanakan, kita akan selalu mengonversi ke/dari unit dasar (`baseUnitID`)
-		// jika memungkinkan, untuk mendapatkan harga yang konsisten.
-
-		// Skenario A: Konversi dari unit dasar ke unit lain (FinalId)
-		// Contoh: 1 PCS (base) = 12 SACHET.
-		// Product.PurchasePrice (harga per PCS) = 3000.
-		// Harga Sachet = 3000 / 12 = 250.
-		if uc.InitId == baseUnitID {
-			if uc.ValueConv > 0 {
-				priceForFinalID := basePurchasePrice / uc.ValueConv
-				if existing, ok := calculatedPrices[uc.FinalId]; !ok || existing.PurchasePrice > priceForFinalID {
-					// Hanya tambahkan/perbarui jika unit belum ada atau harga yang baru lebih murah
-					// (Ini bisa jadi diperlukan jika ada beberapa jalur konversi, ambil yang paling optimal)
-					calculatedPrices[uc.FinalId] = models.ProductUnitResponseItem{
-						UnitId:        uc.FinalId,
-						UnitName:      unitNames[uc.FinalId],
-						PurchasePrice: priceForFinalID,
-					}
-				}
-			}
-		}
-
-		// Skenario B: Konversi dari unit lain (InitId) ke unit dasar (FinalId)
-		// Contoh: 1 BOX = 10 PCS (base).
-		// Product.PurchasePrice (harga per PCS) = 250.
-		// Harga Box = 250 * 10 = 2500.
-		if uc.FinalId == baseUnitID {
-			if uc.ValueConv > 0 {
-				priceForInitID := basePurchasePrice * uc.ValueConv
-				if existing, ok := calculatedPrices[uc.InitId]; !ok || existing.PurchasePrice > priceForInitID {
-					// Hanya tambahkan/perbarui jika unit belum ada atau harga yang baru lebih murah
-					calculatedPrices[uc.InitId] = models.ProductUnitResponseItem{
-						UnitId:        uc.InitId,
-						UnitName:      unitNames[uc.InitId],
-						PurchasePrice: priceForInitID,
-					}
-				}
-			}
-		}
+	for _, u := range units {
+		unitNames[u.ID] = u.Name
 	}
 
-	// 4. Ubah map menjadi slice untuk respons akhir
+	// 3. Harga tiap satuan = harga dasar x jumlah satuan dasar dalam satuan tersebut,
+	// urut dari satuan terkecil
 	var finalResponseItems []models.ProductUnitResponseItem
-	for _, item := range calculatedPrices {
-		if item.UnitName != "" { // Pastikan unit punya nama yang ditemukan
-			finalResponseItems = append(finalResponseItems, item)
+	for _, unitID := range unitIDs {
+		if unitNames[unitID] == "" { // Pastikan unit punya nama yang ditemukan
+			continue
 		}
+		factor, _ := graph.Factor(unitID)
+		finalResponseItems = append(finalResponseItems, models.ProductUnitResponseItem{
+			UnitId:        unitID,
+			UnitName:      unitNames[unitID],
+			PurchasePrice: tools.ConvertPrice(basePurchasePrice, factor),
+		})
 	}
 
-	// Opsional: Urutkan hasil jika diinginkan (misal berdasarkan nama unit atau harga)
-	// sort.Slice(finalResponseItems, func(i, j int) bool {
-	// 	return finalResponseItems[i].PurchasePrice < finalResponseItems[j].PurchasePrice
-	// })
-
 	// 5. Kembalikan respons sukses
 	return responses.JSONResponse(c, http.StatusOK, fmt.Sprintf("Units retrieved successfully for Product ID %s", req.ProductID), finalResponseItems)
 }
@@ -0,0 +1,570 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// CreatePurchaseOrder membuat pesanan pembelian (draft) ke supplier tanpa mengubah stok
+func CreatePurchaseOrder(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	db := config.DB
+	var req models.PurchaseOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		return responses.BadRequest(c, "Validation failed for purchase order input", err)
+	}
+
+	var supplier models.Supplier
+	if err := db.Where("id = ?", req.PurchaseOrder.SupplierId).First(&supplier).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return responses.NotFound(c, fmt.Sprintf("Supplier with ID %s not found", req.PurchaseOrder.SupplierId))
+		}
+		return responses.InternalServerError(c, "Failed to retrieve supplier details", err)
+	}
+
+	orderDate := nowWIB
+	if req.PurchaseOrder.OrderDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", req.PurchaseOrder.OrderDate)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid order_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		orderDate = parsedDate
+	}
+
+	order := models.PurchaseOrders{
+		ID:          helpers.GenerateID("POR"),
+		SupplierId:  supplier.ID,
+		OrderDate:   orderDate,
+		BranchID:    branchID,
+		Description: req.PurchaseOrder.Description,
+		Status:      models.PODraft,
+		UserID:      userID,
+		CreatedAt:   nowWIB,
+		UpdatedAt:   nowWIB,
+	}
+
+	var orderItems []models.PurchaseOrderItems
+	for _, reqItem := range req.PurchaseOrderItems {
+		var product models.Product
+		if err := db.Where("id = ? AND branch_id = ?", reqItem.ProductId, branchID).First(&product).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return responses.NotFound(c, fmt.Sprintf("Product with ID %s not found in branch %s", reqItem.ProductId, branchID))
+			}
+			return responses.InternalServerError(c, "Failed to retrieve product details", err)
+		}
+
+		// Unit pesanan harus satuan dasar produk atau punya konversi ke satuan dasar
+		if reqItem.UnitId != product.UnitId {
+			graph, err := tools.LoadUnitGraph(db, branchID, product)
+			if err != nil {
+				return responses.InternalServerError(c, "Failed to retrieve unit conversion details", err)
+			}
+			if _, err := graph.ConvValue(reqItem.UnitId); err != nil {
+				return responses.BadRequest(c, fmt.Sprintf("Unit %s tidak memiliki konversi untuk produk %s", reqItem.UnitId, product.Name), err)
+			}
+		}
+
+		orderItems = append(orderItems, models.PurchaseOrderItems{
+			ID:              helpers.GenerateID("POI"),
+			PurchaseOrderId: order.ID,
+			ProductId:       product.ID,
+			UnitId:          reqItem.UnitId,
+			Price:           reqItem.Price,
+			Qty:             reqItem.Qty,
+			SubTotal:        reqItem.Price * reqItem.Qty,
+		})
+		order.TotalOrder += reqItem.Price * reqItem.Qty
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create purchase order", err)
+	}
+
+	if err := tx.CreateInBatches(&orderItems, len(orderItems)).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create purchase order items", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Purchase order created successfully", framework.Map{
+		"purchase_order":       order,
+		"purchase_order_items": orderItems,
+	})
+}
+
+// ApprovePurchaseOrder menyetujui pesanan pembelian, hanya untuk role finance / administrator
+func ApprovePurchaseOrder(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+	id := c.Param("id")
+
+	var order models.PurchaseOrders
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&order).Error; err != nil {
+		return responses.NotFound(c, "Purchase order not found")
+	}
+	if order.Status != models.PODraft {
+		return responses.BadRequest(c, "Hanya pesanan berstatus draft yang bisa disetujui", nil)
+	}
+
+	if err := db.Model(&order).Updates(map[string]interface{}{
+		"status":      models.POApproved,
+		"approved_by": userID,
+		"approved_at": nowWIB,
+	}).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to approve purchase order", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Purchase order approved successfully", order)
+}
+
+// CancelPurchaseOrder membatalkan pesanan yang belum diterima seluruhnya, sisa qty tidak lagi ditunggu
+func CancelPurchaseOrder(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var order models.PurchaseOrders
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&order).Error; err != nil {
+		return responses.NotFound(c, "Purchase order not found")
+	}
+	if order.Status == models.POReceived || order.Status == models.POCancelled {
+		return responses.BadRequest(c, "Pesanan sudah ditutup", nil)
+	}
+
+	if err := db.Model(&order).Update("status", models.POCancelled).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to cancel purchase order", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Purchase order cancelled successfully", order)
+}
+
+// DeletePurchaseOrder menghapus pesanan yang masih draft
+func DeletePurchaseOrder(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var order models.PurchaseOrders
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&order).Error; err != nil {
+		return responses.NotFound(c, "Purchase order not found")
+	}
+	if order.Status != models.PODraft {
+		return responses.BadRequest(c, "Hanya pesanan berstatus draft yang bisa dihapus", nil)
+	}
+
+	if err := db.Where("purchase_order_id = ?", order.ID).Delete(&models.PurchaseOrderItems{}).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to delete purchase order items", err)
+	}
+
+	if err := db.Delete(&order).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to delete purchase order", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Purchase order deleted successfully", order)
+}
+
+// ReceivePurchaseOrder mencatat penerimaan barang dari pesanan yang sudah disetujui.
+// Baris yang diterima dikonversi menjadi Purchases dan PurchaseItems, stok bertambah,
+// dan pesanan ditutup otomatis jika seluruh qty sudah diterima. Kelebihan kiriman tetap diterima
+// dan dilaporkan sebagai qty_over.
+func ReceivePurchaseOrder(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	subscriptionType, _ := middlewares.GetClaimsToken(c.Request, "subscription_type")
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+	id := c.Param("id")
+
+	db := config.DB
+	var req models.GoodsReceiptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		return responses.BadRequest(c, "Validation failed for goods receipt input", err)
+	}
+
+	if req.Payment == "" {
+		req.Payment = models.PaidByCash
+	}
+
+	purchaseDate := nowWIB
+	if req.PurchaseDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", req.PurchaseDate)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid purchase_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		purchaseDate = parsedDate
+	}
+
+	var order models.PurchaseOrders
+	if err := db.Where("id = ? AND branch_id = ?", id, branchID).First(&order).Error; err != nil {
+		return responses.NotFound(c, "Purchase order not found")
+	}
+	if order.Status != models.POApproved && order.Status != models.POPartiallyReceived {
+		return responses.BadRequest(c, "Pesanan belum disetujui atau sudah ditutup", nil)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	purchase := models.Purchases{
+		ID:              helpers.GenerateID("PUR"),
+		SupplierId:      order.SupplierId,
+		PurchaseDate:    purchaseDate,
+		BranchID:        branchID,
+		Payment:         req.Payment,
+		UserID:          userID,
+		PurchaseOrderId: order.ID,
+		CreatedAt:       nowWIB,
+		UpdatedAt:       nowWIB,
+	}
+
+	stockRef := tools.StockRef{
+		MovementType: models.PurchaseTrans,
+		ReferenceID:  purchase.ID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
+
+	taxBranch, err := tools.GetBranchTax(tx, branchID)
+	if err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to retrieve branch tax settings", err)
+	}
+
+	var subTotal, tax int
+	var purchaseItemsToCreate []models.PurchaseItems
+	for _, input := range req.Items {
+		parsedExpiredDate, err := time.Parse("2006-01-02", input.ExpiredDate)
+		if err != nil {
+			tx.Rollback()
+			return responses.BadRequest(c, fmt.Sprintf("Invalid expired_date format for item %s. Please use `YYYY-MM-DD`.", input.ItemId), err)
+		}
+
+		var orderItem models.PurchaseOrderItems
+		if err := tx.Where("id = ? AND purchase_order_id = ?", input.ItemId, order.ID).First(&orderItem).Error; err != nil {
+			tx.Rollback()
+			return responses.NotFound(c, fmt.Sprintf("Item pesanan %s tidak ditemukan", input.ItemId))
+		}
+
+		var product models.Product
+		if err := tx.Where("id = ?", orderItem.ProductId).First(&product).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to retrieve product details", err)
+		}
+
+		conversionValue, err := tools.GetConversionValue(tx, branchID, product, orderItem.UnitId)
+		if err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to retrieve unit conversion details", err)
+		}
+		actualQtyToAdd := input.Qty * conversionValue
+
+		purchaseItem := models.PurchaseItems{
+			ID:          helpers.GenerateID("PIT"),
+			PurchaseId:  purchase.ID,
+			ProductId:   product.ID,
+			UnitId:      orderItem.UnitId,
+			Price:       orderItem.Price,
+			Qty:         input.Qty,
+			SubTotal:    orderItem.Price * input.Qty,
+			ExpiredDate: parsedExpiredDate,
+		}
+		purchaseItem.TaxAmount = taxBranch.LineTax(purchaseItem.SubTotal, product.TaxExempt)
+		purchaseItemsToCreate = append(purchaseItemsToCreate, purchaseItem)
+
+		if err := tools.AddProductStock(tx, product.ID, actualQtyToAdd, stockRef); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to add stock for product %s", product.Name), err)
+		}
+
+		if err := tools.AddProductBatch(tx, branchID, product.ID, purchaseItem.ID, input.BatchNumber, parsedExpiredDate, actualQtyToAdd); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to create batch for product %s", product.Name), err)
+		}
+
+		// Harga pesanan adalah harga per unit pesanan, harga pokok dihitung per satuan dasar
+		if err := tools.ApplyReceiptCost(tx, stockRef, product.ID, purchaseItem.ID, actualQtyToAdd, orderItem.Price/conversionValue); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to update cost for product %s", product.Name), err)
+		}
+
+		if err := tx.Model(&orderItem).Update("qty_received", gorm.Expr("qty_received + ?", input.Qty)).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to update purchase order item", err)
+		}
+
+		subTotal += purchaseItem.SubTotal
+		tax += purchaseItem.TaxAmount
+	}
+
+	// Total pembelian ditambah PPN jika harga belum termasuk pajak
+	purchase.TaxAmount, purchase.TotalPurchase = taxBranch.TaxTotals(subTotal, tax, 0)
+
+	if err := tools.SetPurchaseDueDate(tx, &purchase); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to set purchase due date", err)
+	}
+
+	if err := tx.Create(&purchase).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create purchase", err)
+	}
+
+	if err := tx.CreateInBatches(&purchaseItemsToCreate, len(purchaseItemsToCreate)).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create purchase items", err)
+	}
+
+	transactionReport := models.TransactionReports{
+		ID:              helpers.GenerateID("TRX"),
+		TransactionType: models.Purchase,
+		UserID:          userID,
+		BranchID:        branchID,
+		Total:           purchase.TotalPurchase,
+		Payment:         purchase.Payment,
+		CreatedAt:       nowWIB,
+		UpdatedAt:       nowWIB,
+	}
+	if err := tx.Create(&transactionReport).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transaction report for purchase", err)
+	}
+
+	if err := tools.SyncJournal(tx, models.JournalPurchase, purchase.ID); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create purchase journal", err)
+	}
+
+	if subscriptionType == "quota" {
+		var branch models.Branch
+		if err := tx.Where("id = ?", branchID).First(&branch).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to retrieve branch details for quota update", err)
+		}
+
+		if branch.Quota <= 0 {
+			tx.Rollback()
+			return responses.BadRequest(c, fmt.Sprintf("No quota available for branch %s", branch.BranchName), errors.New("quota exceeded"))
+		}
+
+		if err := tx.Model(&branch).Update("quota", branch.Quota-1).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to update quota for branch %s", branch.BranchName), err)
+		}
+	}
+
+	// Pesanan ditutup jika tidak ada lagi baris dengan sisa qty
+	var pendingItems int64
+	if err := tx.Model(&models.PurchaseOrderItems{}).
+		Where("purchase_order_id = ? AND qty_received < qty", order.ID).
+		Count(&pendingItems).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to check purchase order items", err)
+	}
+
+	status := models.POReceived
+	if pendingItems > 0 {
+		status = models.POPartiallyReceived
+	}
+
+	if err := tx.Model(&order).Update("status", status).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to update purchase order", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit transaction", err)
+	}
+
+	items, err := getPurchaseOrderItems(db, order.ID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve purchase order items", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Goods received successfully", framework.Map{
+		"purchase_order": order,
+		"purchase":       purchase,
+		"items":          items,
+	})
+}
+
+// GetAllPurchaseOrders menampilkan pesanan pembelian cabang aktif
+func GetAllPurchaseOrders(c *framework.Ctx) error {
+	// Hitung waktu sekarang dalam WIB
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	// Ambil parameter page dan search dari query URL
+	pageParam := c.Query("page")
+	search := strings.TrimSpace(c.Query("search"))
+	status := strings.TrimSpace(c.Query("status"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	month := strings.TrimSpace(c.Query("month"))
+
+	// Jika month kosong, isi dengan bulan ini (format YYYY-MM)
+	if month == "" {
+		month = nowWIB.Format("2006-01")
+	}
+
+	var ordersFromDB []models.AllPurchaseOrders
+	var total int64
+
+	query := config.DB.Table("purchase_orders po").
+		Select("po.id, po.supplier_id, sup.name AS supplier_name, po.order_date, po.description, po.total_order, po.status").
+		Joins("LEFT JOIN suppliers sup ON sup.id = po.supplier_id").
+		Where("po.branch_id = ?", branchID)
+
+	startDate, err := time.Parse("2006-01", month)
+	if err != nil {
+		return responses.BadRequest(c, "Invalid month format", err)
+	}
+	endDate := startDate.AddDate(0, 1, 0)
+	query = query.Where("po.order_date >= ? AND po.order_date < ?", startDate, endDate)
+
+	if status != "" {
+		query = query.Where("po.status = ?", status)
+	}
+
+	if search != "" {
+		search = strings.ToLower(search)
+		query = query.Where("LOWER(sup.name) LIKE ? OR LOWER(po.id) LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Get purchase orders failed", err)
+	}
+
+	if err := query.Order("po.created_at DESC").Offset(offset).Limit(limit).Scan(&ordersFromDB).Error; err != nil {
+		return responses.InternalServerError(c, "Get purchase orders failed", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	var formattedOrders []models.PurchaseOrderResponse
+	for _, order := range ordersFromDB {
+		formattedOrders = append(formattedOrders, formatPurchaseOrder(order))
+	}
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Purchase orders retrieved successfully", search, int(total), page, totalPages, limit, formattedOrders)
+}
+
+// GetPurchaseOrderWithItems menampilkan satu pesanan beserta sisa dan kelebihan kiriman per baris
+func GetPurchaseOrderWithItems(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var order models.AllPurchaseOrders
+	err := db.Table("purchase_orders po").
+		Select("po.id, po.supplier_id, sup.name AS supplier_name, po.order_date, po.description, po.total_order, po.status").
+		Joins("LEFT JOIN suppliers sup ON sup.id = po.supplier_id").
+		Where("po.id = ? AND po.branch_id = ?", id, branchID).
+		Take(&order).Error
+	if err != nil {
+		return responses.NotFound(c, "Purchase order not found")
+	}
+
+	items, err := getPurchaseOrderItems(db, order.ID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve purchase order items", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Purchase order retrieved successfully", framework.Map{
+		"purchase_order": formatPurchaseOrder(order),
+		"items":          items,
+	})
+}
+
+// getPurchaseOrderItems mengambil item pesanan dan menghitung qty yang belum datang / kelebihan kiriman
+func getPurchaseOrderItems(db *gorm.DB, orderID string) ([]models.AllPurchaseOrderItems, error) {
+	var items []models.AllPurchaseOrderItems
+	err := db.Table("purchase_order_items poi").
+		Select("poi.id, poi.purchase_order_id, poi.product_id, pro.name AS product_name, poi.unit_id, un.name AS unit_name, poi.price, poi.qty, poi.qty_received, poi.sub_total").
+		Joins("LEFT JOIN products pro ON pro.id = poi.product_id").
+		Joins("LEFT JOIN units un ON un.id = poi.unit_id").
+		Where("poi.purchase_order_id = ?", orderID).
+		Order("pro.name ASC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		items[i].QtyOutstanding = max(items[i].Qty-items[i].QtyReceived, 0)
+		items[i].QtyOver = max(items[i].QtyReceived-items[i].Qty, 0)
+	}
+
+	return items, nil
+}
+
+// formatPurchaseOrder memformat tanggal pesanan untuk respons
+func formatPurchaseOrder(order models.AllPurchaseOrders) models.PurchaseOrderResponse {
+	return models.PurchaseOrderResponse{
+		ID:           order.ID,
+		SupplierId:   order.SupplierId,
+		SupplierName: order.SupplierName,
+		OrderDate:    utils.FormatIndonesianDate(order.OrderDate),
+		Description:  order.Description,
+		TotalOrder:   order.TotalOrder,
+		Status:       string(order.Status),
+	}
+}
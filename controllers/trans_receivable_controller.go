@@ -0,0 +1,470 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateReceivablePayment mencatat cicilan piutang member atas penjualan paid_by_credit.
+// Cicilan masuk ke transaction_reports pada tanggal bayar sebagai receivable_payment.
+func CreateReceivablePayment(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	db := config.DB
+	var input models.ReceivablePaymentInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for receivable payment input", err)
+	}
+
+	paymentDate := nowWIB
+	if input.PaymentDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", input.PaymentDate)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid payment_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		paymentDate = parsedDate
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var sale models.Sales
+	// Baris penjualan dikunci sampai commit agar dua cicilan bersamaan tidak melebihi sisa piutang
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND branch_id = ?", input.SaleId, branchID).
+		First(&sale).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return responses.NotFound(c, fmt.Sprintf("Sale with ID %s not found", input.SaleId))
+		}
+		return responses.InternalServerError(c, "Failed to retrieve sale", err)
+	}
+	if sale.Payment != models.PaidByCredit {
+		tx.Rollback()
+		return responses.BadRequest(c, "Penjualan ini bukan penjualan kredit", nil)
+	}
+
+	outstanding := tools.SaleOutstanding(sale)
+	if input.Amount > outstanding {
+		tx.Rollback()
+		return responses.BadRequest(c, fmt.Sprintf("Jumlah pembayaran melebihi sisa piutang. Sisa: %d, Dibayar: %d", outstanding, input.Amount), nil)
+	}
+
+	receivablePayment := models.ReceivablePayments{
+		ID:          helpers.GenerateID("RPY"),
+		SaleId:      sale.ID,
+		MemberId:    sale.MemberId,
+		PaymentDate: paymentDate,
+		Amount:      input.Amount,
+		Payment:     input.Payment,
+		Description: input.Description,
+		BranchID:    branchID,
+		UserID:      userID,
+		CreatedAt:   nowWIB,
+	}
+	if err := tx.Create(&receivablePayment).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create receivable payment", err)
+	}
+
+	if err := tx.Model(&sale).Updates(map[string]interface{}{
+		"paid_amount": sale.PaidAmount + input.Amount,
+		"updated_at":  nowWIB,
+	}).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to update sale", err)
+	}
+
+	// Kas bertambah pada tanggal cicilan diterima
+	transactionReport := models.TransactionReports{
+		ID:              receivablePayment.ID,
+		TransactionType: models.ReceivablePayment,
+		UserID:          userID,
+		BranchID:        branchID,
+		Total:           receivablePayment.Amount,
+		Payment:         receivablePayment.Payment,
+		CreatedAt:       paymentDate,
+		UpdatedAt:       nowWIB,
+	}
+	if err := tx.Create(&transactionReport).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transaction report for receivable payment", err)
+	}
+
+	if err := tools.SyncJournal(tx, models.JournalReceivablePayment, receivablePayment.ID); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create journal for receivable payment", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Receivable payment created successfully", framework.Map{
+		"receivable_payment": receivablePayment,
+		"sale":               sale,
+		"outstanding":        outstanding - input.Amount,
+	})
+}
+
+// GetAllReceivablePayments menampilkan cicilan piutang per bulan, bisa difilter per penjualan atau member
+func GetAllReceivablePayments(c *framework.Ctx) error {
+	// Hitung waktu sekarang dalam WIB
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	// Ambil parameter page dan search dari query URL
+	pageParam := c.Query("page")
+	search := strings.TrimSpace(c.Query("search"))
+	saleID := strings.TrimSpace(c.Query("sale_id"))
+	memberID := strings.TrimSpace(c.Query("member_id"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	month := strings.TrimSpace(c.Query("month"))
+
+	// Jika month kosong, isi dengan bulan ini (format YYYY-MM)
+	if month == "" {
+		month = nowWIB.Format("2006-01")
+	}
+
+	var payments []models.AllReceivablePayments
+	var total int64
+
+	query := config.DB.Table("receivable_payments rpy").
+		Select("rpy.id, rpy.sale_id, rpy.member_id, mbr.name AS member_name, rpy.payment_date, rpy.amount, rpy.payment, rpy.description").
+		Joins("LEFT JOIN members mbr ON mbr.id = rpy.member_id").
+		Where("rpy.branch_id = ?", branchID)
+
+	// Filter per penjualan menampilkan seluruh riwayat tanpa batas bulan
+	if saleID != "" {
+		query = query.Where("rpy.sale_id = ?", saleID)
+	} else {
+		startDate, err := time.Parse("2006-01", month)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid month format", err)
+		}
+		endDate := startDate.AddDate(0, 1, 0)
+		query = query.Where("rpy.payment_date >= ? AND rpy.payment_date < ?", startDate, endDate)
+	}
+
+	if memberID != "" {
+		query = query.Where("rpy.member_id = ?", memberID)
+	}
+
+	if search != "" {
+		search = strings.ToLower(search)
+		query = query.Where("LOWER(mbr.name) LIKE ?", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Get receivable payments failed", err)
+	}
+
+	if err := query.Order("rpy.payment_date DESC, rpy.created_at DESC").Offset(offset).Limit(limit).Scan(&payments).Error; err != nil {
+		return responses.InternalServerError(c, "Get receivable payments failed", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	var formattedPayments []models.ReceivablePaymentResponse
+	for _, payment := range payments {
+		formattedPayments = append(formattedPayments, models.ReceivablePaymentResponse{
+			ID:          payment.ID,
+			SaleId:      payment.SaleId,
+			MemberId:    payment.MemberId,
+			MemberName:  payment.MemberName,
+			PaymentDate: utils.FormatIndonesianDate(payment.PaymentDate),
+			Amount:      payment.Amount,
+			Payment:     string(payment.Payment),
+			Description: payment.Description,
+		})
+	}
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Receivable payments retrieved successfully", search, int(total), page, totalPages, limit, formattedPayments)
+}
+
+// GetReceivables menampilkan seluruh penjualan kredit yang belum lunas, diurutkan dari jatuh tempo terdekat
+func GetReceivables(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	memberID := strings.TrimSpace(c.Query("member_id"))
+
+	receivables, err := getOutstandingReceivables(config.DB, branchID, memberID)
+	if err != nil {
+		return responses.InternalServerError(c, "Get receivables failed", err)
+	}
+
+	today := time.Now().In(utils.Location)
+	var formattedReceivables []models.ReceivableResponse
+	totalOutstanding := 0
+	for _, receivable := range receivables {
+		formatted := models.ReceivableResponse{
+			SaleId:      receivable.SaleId,
+			MemberId:    receivable.MemberId,
+			MemberName:  receivable.MemberName,
+			SaleDate:    utils.FormatIndonesianDate(receivable.SaleDate),
+			TotalSale:   receivable.TotalSale,
+			PaidAmount:  receivable.PaidAmount,
+			Outstanding: receivable.TotalSale - receivable.PaidAmount - receivable.ReturnedAmount,
+			DaysOverdue: receivableDaysOverdue(receivable, today),
+		}
+		if receivable.DueDate != nil {
+			formatted.DueDate = utils.FormatIndonesianDate(*receivable.DueDate)
+		}
+		formattedReceivables = append(formattedReceivables, formatted)
+		totalOutstanding += formatted.Outstanding
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Receivables retrieved successfully", framework.Map{
+		"receivables":       formattedReceivables,
+		"total_outstanding": totalOutstanding,
+	})
+}
+
+// GetReceivableAging menampilkan umur piutang per member: belum jatuh tempo, 1-30, 31-60, 61-90 dan lebih dari 90 hari
+func GetReceivableAging(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	receivables, err := getOutstandingReceivables(config.DB, branchID, "")
+	if err != nil {
+		return responses.InternalServerError(c, "Get receivable aging failed", err)
+	}
+
+	today := time.Now().In(utils.Location)
+	agingMap := make(map[string]*models.ReceivableAgingResponse)
+	var totals models.ReceivableAgingResponse
+	for _, receivable := range receivables {
+		aging, ok := agingMap[receivable.MemberId]
+		if !ok {
+			aging = &models.ReceivableAgingResponse{
+				MemberId:   receivable.MemberId,
+				MemberName: receivable.MemberName,
+			}
+			agingMap[receivable.MemberId] = aging
+		}
+
+		outstanding := receivable.TotalSale - receivable.PaidAmount - receivable.ReturnedAmount
+		for _, bucket := range []*models.ReceivableAgingResponse{aging, &totals} {
+			switch days := receivableDaysOverdue(receivable, today); {
+			case days <= 0:
+				bucket.Current += outstanding
+			case days <= 30:
+				bucket.Days1To30 += outstanding
+			case days <= 60:
+				bucket.Days31To60 += outstanding
+			case days <= 90:
+				bucket.Days61To90 += outstanding
+			default:
+				bucket.Over90 += outstanding
+			}
+			bucket.Total += outstanding
+		}
+	}
+
+	var agingList []models.ReceivableAgingResponse
+	for _, aging := range agingMap {
+		agingList = append(agingList, *aging)
+	}
+	sort.Slice(agingList, func(i, j int) bool {
+		return agingList[i].MemberName < agingList[j].MemberName
+	})
+
+	return responses.JSONResponse(c, http.StatusOK, "Receivable aging retrieved successfully", framework.Map{
+		"members": agingList,
+		"total":   totals,
+	})
+}
+
+// GetMemberStatement menampilkan rekening koran piutang member: saldo awal, penjualan kredit,
+// cicilan dan saldo berjalan dalam rentang start_date s/d end_date (default bulan ini)
+func GetMemberStatement(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	memberID := c.Param("member_id")
+
+	var member models.Member
+	if err := db.Where("id = ? AND branch_id = ?", memberID, branchID).First(&member).Error; err != nil {
+		return responses.NotFound(c, "Member not found")
+	}
+
+	startDate := time.Date(nowWIB.Year(), nowWIB.Month(), 1, 0, 0, 0, 0, utils.Location)
+	endDate := startDate.AddDate(0, 1, 0)
+	if startParam := strings.TrimSpace(c.Query("start_date")); startParam != "" {
+		parsedDate, err := time.ParseInLocation("2006-01-02", startParam, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid start_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		startDate = parsedDate
+	}
+	if endParam := strings.TrimSpace(c.Query("end_date")); endParam != "" {
+		parsedDate, err := time.ParseInLocation("2006-01-02", endParam, utils.Location)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid end_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		endDate = parsedDate.AddDate(0, 0, 1)
+	}
+
+	// Saldo awal: penjualan kredit dikurangi cicilan dan retur sebelum start_date
+	var salesBefore, paymentsBefore, returnsBefore int
+	if err := db.Model(&models.Sales{}).
+		Select("COALESCE(SUM(total_sale), 0)").
+		Where("member_id = ? AND payment = ? AND sale_date < ?", memberID, models.PaidByCredit, startDate).
+		Scan(&salesBefore).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to calculate opening balance", err)
+	}
+	if err := db.Model(&models.ReceivablePayments{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("member_id = ? AND payment_date < ?", memberID, startDate).
+		Scan(&paymentsBefore).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to calculate opening balance", err)
+	}
+	if err := memberCreditReturns(db, memberID).
+		Select("COALESCE(SUM(sr.credit_amount), 0)").
+		Where("sr.return_date < ?", startDate).
+		Scan(&returnsBefore).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to calculate opening balance", err)
+	}
+	openingBalance := salesBefore - paymentsBefore - returnsBefore
+
+	var sales []models.Sales
+	if err := db.Where("member_id = ? AND payment = ? AND sale_date >= ? AND sale_date < ?", memberID, models.PaidByCredit, startDate, endDate).
+		Find(&sales).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve credit sales", err)
+	}
+
+	var payments []models.ReceivablePayments
+	if err := db.Where("member_id = ? AND payment_date >= ? AND payment_date < ?", memberID, startDate, endDate).
+		Find(&payments).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve receivable payments", err)
+	}
+
+	var saleReturns []models.SaleReturns
+	if err := memberCreditReturns(db, memberID).
+		Select("sr.*").
+		Where("sr.return_date >= ? AND sr.return_date < ?", startDate, endDate).
+		Scan(&saleReturns).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve sale returns", err)
+	}
+
+	var lines []models.MemberStatementLine
+	for _, sale := range sales {
+		lines = append(lines, models.MemberStatementLine{
+			Date:        sale.SaleDate,
+			ReferenceID: sale.ID,
+			Description: "Penjualan kredit",
+			Debit:       sale.TotalSale,
+		})
+	}
+	for _, payment := range payments {
+		lines = append(lines, models.MemberStatementLine{
+			Date:        payment.PaymentDate,
+			ReferenceID: payment.SaleId,
+			Description: fmt.Sprintf("Pembayaran piutang (%s)", payment.Payment),
+			Credit:      payment.Amount,
+		})
+	}
+	for _, saleReturn := range saleReturns {
+		lines = append(lines, models.MemberStatementLine{
+			Date:        saleReturn.ReturnDate,
+			ReferenceID: saleReturn.SaleId,
+			Description: "Retur penjualan kredit " + saleReturn.ID,
+			Credit:      saleReturn.CreditAmount,
+		})
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Date.Before(lines[j].Date)
+	})
+
+	balance := openingBalance
+	totalDebit, totalCredit := 0, 0
+	for i := range lines {
+		balance += lines[i].Debit - lines[i].Credit
+		lines[i].Balance = balance
+		lines[i].DateString = utils.FormatIndonesianDate(lines[i].Date)
+		totalDebit += lines[i].Debit
+		totalCredit += lines[i].Credit
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Member statement retrieved successfully", framework.Map{
+		"member_id":       member.ID,
+		"member_name":     member.Name,
+		"start_date":      utils.FormatIndonesianDate(startDate),
+		"end_date":        utils.FormatIndonesianDate(endDate.AddDate(0, 0, -1)),
+		"opening_balance": openingBalance,
+		"total_debit":     totalDebit,
+		"total_credit":    totalCredit,
+		"closing_balance": balance,
+		"lines":           lines,
+	})
+}
+
+// memberCreditReturns query retur penjualan kredit member yang mengurangi piutangnya
+func memberCreditReturns(db *gorm.DB, memberID string) *gorm.DB {
+	return db.Table("sale_returns sr").
+		Joins("JOIN sales sal ON sal.id = sr.sale_id").
+		Where("sal.member_id = ? AND sr.credit_amount > 0", memberID)
+}
+
+// getOutstandingReceivables mengambil penjualan paid_by_credit yang masih memiliki sisa piutang
+func getOutstandingReceivables(db *gorm.DB, branchID string, memberID string) ([]models.ReceivableDB, error) {
+	var receivables []models.ReceivableDB
+	query := db.Table("sales sal").
+		Select("sal.id AS sale_id, sal.member_id, mbr.name AS member_name, sal.sale_date, sal.due_date, sal.total_sale, sal.paid_amount, sal.returned_amount").
+		Joins("LEFT JOIN members mbr ON mbr.id = sal.member_id").
+		Where("sal.branch_id = ? AND sal.payment = ? AND sal.total_sale > sal.paid_amount + sal.returned_amount", branchID, models.PaidByCredit)
+
+	if memberID != "" {
+		query = query.Where("sal.member_id = ?", memberID)
+	}
+
+	err := query.Order("COALESCE(sal.due_date, sal.sale_date) ASC").Scan(&receivables).Error
+	return receivables, err
+}
+
+// receivableDaysOverdue menghitung jumlah hari lewat jatuh tempo, negatif jika belum jatuh tempo
+func receivableDaysOverdue(receivable models.ReceivableDB, today time.Time) int {
+	dueDate := receivable.SaleDate
+	if receivable.DueDate != nil {
+		dueDate = *receivable.DueDate
+	}
+	return int(today.Sub(dueDate).Hours() / 24)
+}
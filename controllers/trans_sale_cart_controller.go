@@ -0,0 +1,583 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// errCartNotOpen keranjang yang ditahan / sudah checkout tidak bisa diubah
+var errCartNotOpen = errors.New("Keranjang tidak terbuka, lanjutkan (resume) keranjang terlebih dahulu")
+
+// CreateSaleCart membuat keranjang baru untuk kasir, keranjang lain yang masih terbuka otomatis ditahan
+func CreateSaleCart(c *framework.Ctx) error {
+	db := config.DB
+	nowWIB := time.Now().In(utils.Location)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	var input models.SaleCartInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for cart input", err)
+	}
+
+	cart := models.SaleCarts{
+		ID:        helpers.GenerateID("CRT"),
+		Label:     input.Label,
+		MemberId:  input.MemberId,
+		Status:    models.CartOpen,
+		UserID:    userID,
+		BranchID:  branchID,
+		CreatedAt: nowWIB,
+		UpdatedAt: nowWIB,
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tools.ParkOpenCarts(tx, userID, branchID, cart.ID, nowWIB); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to park open carts", err)
+	}
+
+	if err := tx.Create(&cart).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create cart", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
+	return saleCartResult(c, http.StatusCreated, "Cart created successfully", cart.ID, branchID)
+}
+
+// UpdateSaleCart mengubah label dan member keranjang yang sedang terbuka
+func UpdateSaleCart(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	var input models.SaleCartInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for cart input", err)
+	}
+
+	cart, err := editableSaleCart(db, c.Param("id"), userID, branchID)
+	if err != nil {
+		return saleCartError(c, err)
+	}
+
+	cart.Label = input.Label
+	cart.MemberId = input.MemberId
+	cart.UpdatedAt = time.Now().In(utils.Location)
+	if err := db.Save(&cart).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to update cart", err)
+	}
+
+	// Member bisa berganti, harga baris keranjang dihitung ulang sesuai daftar harga member baru
+	var items []models.SaleCartItems
+	if err := db.Where("cart_id = ?", cart.ID).Find(&items).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve cart items", err)
+	}
+	for _, item := range items {
+		saleUnit, err := tools.GetSaleUnit(db, branchID, item.ProductId, item.UnitId)
+		if err != nil {
+			return saleUnitError(c, err)
+		}
+		item.Price, err = cartItemPrice(db, branchID, cart, saleUnit, item.Qty)
+		if err != nil {
+			return responses.InternalServerError(c, "Failed to resolve sale price", err)
+		}
+		item.SubTotal = item.Price * item.Qty
+		if err := db.Model(&item).Updates(map[string]interface{}{"price": item.Price, "sub_total": item.SubTotal}).Error; err != nil {
+			return responses.InternalServerError(c, "Failed to update cart item price", err)
+		}
+	}
+
+	return saleCartResult(c, http.StatusOK, "Cart updated successfully", cart.ID, branchID)
+}
+
+// DeleteSaleCart membuang keranjang beserta barisnya, keranjang yang sudah checkout tetap disimpan
+func DeleteSaleCart(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	cart, err := tools.GetUserCart(db, c.Param("id"), userID, branchID)
+	if err != nil {
+		return saleCartError(c, err)
+	}
+	if cart.Status == models.CartCheckedOut {
+		return responses.BadRequest(c, "Keranjang yang sudah di-checkout tidak bisa dihapus", nil)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("cart_id = ?", cart.ID).Delete(&models.SaleCartItems{}).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to delete cart items", err)
+	}
+
+	if err := tx.Delete(&cart).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to delete cart", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Cart deleted successfully", cart)
+}
+
+// GetSaleCart menampilkan detail keranjang beserta baris dan stok produk saat ini
+func GetSaleCart(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	if _, err := tools.GetUserCart(config.DB, c.Param("id"), userID, branchID); err != nil {
+		return saleCartError(c, err)
+	}
+
+	return saleCartResult(c, http.StatusOK, "Cart retrieved successfully", c.Param("id"), branchID)
+}
+
+// GetAllSaleCarts menampilkan keranjang milik kasir, default keranjang yang terbuka dan ditahan
+func GetAllSaleCarts(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	search := strings.TrimSpace(c.Query("search"))
+	status := strings.TrimSpace(c.Query("status"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	query := saleCartQuery(config.DB, branchID).Where("crt.user_id = ?", userID)
+
+	if status != "" {
+		query = query.Where("crt.status = ?", status)
+	} else {
+		query = query.Where("crt.status <> ?", models.CartCheckedOut)
+	}
+
+	if search != "" {
+		search = strings.ToLower(search)
+		query = query.Where("LOWER(crt.label) LIKE ? OR LOWER(mbr.name) LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Get carts failed", err)
+	}
+
+	var carts []models.AllSaleCarts
+	if err := query.Order("crt.updated_at DESC").Offset(offset).Limit(limit).Scan(&carts).Error; err != nil {
+		return responses.InternalServerError(c, "Get carts failed", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Carts retrieved successfully", search, int(total), page, totalPages, limit, carts)
+}
+
+// AddSaleCartItem menambah produk ke keranjang dengan harga jual satuan saat ini, produk dan satuan yang sama digabung qty-nya.
+// Stok hanya diperiksa, tidak dikurangi sampai checkout.
+func AddSaleCartItem(c *framework.Ctx) error {
+	db := config.DB
+	nowWIB := time.Now().In(utils.Location)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	var input models.SaleCartItemInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for cart item input", err)
+	}
+
+	cart, err := editableSaleCart(db, c.Param("id"), userID, branchID)
+	if err != nil {
+		return saleCartError(c, err)
+	}
+
+	product, err := tools.GetSaleProduct(db, branchID, input.ProductId)
+	if err == gorm.ErrRecordNotFound {
+		return responses.NotFound(c, fmt.Sprintf("Product with ID %s not found", input.ProductId))
+	} else if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve product details", err)
+	}
+
+	saleUnit, err := tools.GetSaleUnit(db, branchID, product.ProductId, input.UnitId)
+	if err != nil {
+		return saleUnitError(c, err)
+	}
+
+	if err := tools.CheckCartStock(db, branchID, cart.ID, product, input.Qty*saleUnit.ConvValue, ""); err != nil {
+		return cartStockError(c, err)
+	}
+
+	var item models.SaleCartItems
+	err = db.Where("cart_id = ? AND product_id = ? AND unit_id = ?", cart.ID, product.ProductId, saleUnit.UnitId).First(&item).Error
+	if err == gorm.ErrRecordNotFound {
+		item = models.SaleCartItems{
+			ID:        helpers.GenerateID("CRI"),
+			CartId:    cart.ID,
+			ProductId: product.ProductId,
+			UnitId:    saleUnit.UnitId,
+			CreatedAt: nowWIB,
+		}
+	} else if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve cart item", err)
+	}
+
+	item.ConvValue = saleUnit.ConvValue
+	item.Qty += input.Qty
+	item.Price, err = cartItemPrice(db, branchID, cart, saleUnit, item.Qty)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to resolve sale price", err)
+	}
+	item.SubTotal = item.Price * item.Qty
+	if err := db.Save(&item).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to save cart item", err)
+	}
+
+	db.Model(&cart).Update("updated_at", nowWIB)
+
+	return saleCartResult(c, http.StatusOK, "Cart item added successfully", cart.ID, branchID)
+}
+
+// UpdateSaleCartItem mengubah qty baris keranjang, produk dan satuan baris tidak bisa diganti
+func UpdateSaleCartItem(c *framework.Ctx) error {
+	db := config.DB
+	nowWIB := time.Now().In(utils.Location)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	var input models.SaleCartItemInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+
+	cart, err := editableSaleCart(db, c.Param("id"), userID, branchID)
+	if err != nil {
+		return saleCartError(c, err)
+	}
+
+	var item models.SaleCartItems
+	if err := db.Where("id = ? AND cart_id = ?", c.Param("item_id"), cart.ID).First(&item).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return responses.NotFound(c, "Cart item not found")
+		}
+		return responses.InternalServerError(c, "Failed to retrieve cart item", err)
+	}
+
+	// Produk baris tidak bisa diganti, hanya qty
+	input.ProductId = item.ProductId
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for cart item input", err)
+	}
+
+	product, err := tools.GetSaleProduct(db, branchID, item.ProductId)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve product details", err)
+	}
+
+	saleUnit, err := tools.GetSaleUnit(db, branchID, item.ProductId, item.UnitId)
+	if err != nil {
+		return saleUnitError(c, err)
+	}
+
+	if err := tools.CheckCartStock(db, branchID, cart.ID, product, input.Qty*saleUnit.ConvValue, item.ID); err != nil {
+		return cartStockError(c, err)
+	}
+
+	item.ConvValue = saleUnit.ConvValue
+	item.Qty = input.Qty
+	item.Price, err = cartItemPrice(db, branchID, cart, saleUnit, item.Qty)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to resolve sale price", err)
+	}
+	item.SubTotal = item.Price * item.Qty
+	if err := db.Save(&item).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to update cart item", err)
+	}
+
+	db.Model(&cart).Update("updated_at", nowWIB)
+
+	return saleCartResult(c, http.StatusOK, "Cart item updated successfully", cart.ID, branchID)
+}
+
+// DeleteSaleCartItem menghapus baris dari keranjang
+func DeleteSaleCartItem(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	cart, err := editableSaleCart(db, c.Param("id"), userID, branchID)
+	if err != nil {
+		return saleCartError(c, err)
+	}
+
+	result := db.Where("id = ? AND cart_id = ?", c.Param("item_id"), cart.ID).Delete(&models.SaleCartItems{})
+	if result.Error != nil {
+		return responses.InternalServerError(c, "Failed to delete cart item", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return responses.NotFound(c, "Cart item not found")
+	}
+
+	db.Model(&cart).Update("updated_at", time.Now().In(utils.Location))
+
+	return saleCartResult(c, http.StatusOK, "Cart item deleted successfully", cart.ID, branchID)
+}
+
+// ParkSaleCart menahan keranjang yang sedang terbuka agar kasir bisa melayani pelanggan berikutnya
+func ParkSaleCart(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	cart, err := editableSaleCart(db, c.Param("id"), userID, branchID)
+	if err != nil {
+		return saleCartError(c, err)
+	}
+
+	cart.Status = models.CartParked
+	cart.UpdatedAt = time.Now().In(utils.Location)
+	if err := db.Save(&cart).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to park cart", err)
+	}
+
+	return saleCartResult(c, http.StatusOK, "Cart parked successfully", cart.ID, branchID)
+}
+
+// ResumeSaleCart melanjutkan keranjang yang ditahan, keranjang lain yang masih terbuka otomatis ditahan
+func ResumeSaleCart(c *framework.Ctx) error {
+	db := config.DB
+	nowWIB := time.Now().In(utils.Location)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	cart, err := tools.GetUserCart(db, c.Param("id"), userID, branchID)
+	if err != nil {
+		return saleCartError(c, err)
+	}
+	if cart.Status == models.CartCheckedOut {
+		return responses.BadRequest(c, "Keranjang sudah di-checkout", nil)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tools.ParkOpenCarts(tx, userID, branchID, cart.ID, nowWIB); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to park open carts", err)
+	}
+
+	cart.Status = models.CartOpen
+	cart.UpdatedAt = nowWIB
+	if err := tx.Save(&cart).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to resume cart", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
+	return saleCartResult(c, http.StatusOK, "Cart resumed successfully", cart.ID, branchID)
+}
+
+// CheckoutSaleCart mengubah keranjang yang terbuka menjadi penjualan.
+// Harga diperbarui dari harga jual saat ini, lalu penjualan disimpan dengan alur yang sama seperti CreateSaleTransaction
+// dan keranjang ditandai checkout dalam transaksi database yang sama.
+func CheckoutSaleCart(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	var input models.SaleCartCheckoutInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+
+	// Checkout ulang dengan Idempotency-Key yang sama mengembalikan penjualan yang sudah dibuat
+	idem, replayed, err := startIdempotency(c, "carts/"+c.Param("id")+"/checkout", input)
+	if replayed {
+		return err
+	}
+
+	cart, err := editableSaleCart(db, c.Param("id"), userID, branchID)
+	if err != nil {
+		return saleCartError(c, err)
+	}
+
+	var items []models.SaleCartItems
+	if err := db.Where("cart_id = ?", cart.ID).Order("created_at ASC").Find(&items).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve cart items", err)
+	}
+	if len(items) == 0 {
+		return responses.BadRequest(c, "Keranjang masih kosong", nil)
+	}
+
+	req := SaleTransactionRequest{
+		Sale: models.Sales{
+			MemberId:       cart.MemberId,
+			Discount:       input.Discount,
+			Payment:        input.Payment,
+			PointsRedeemed: input.PointsRedeemed,
+		},
+		Payments: input.Payments,
+	}
+
+	for _, item := range items {
+		saleUnit, err := tools.GetSaleUnit(db, branchID, item.ProductId, item.UnitId)
+		if err != nil {
+			return saleUnitError(c, err)
+		}
+
+		req.SaleItems = append(req.SaleItems, models.SaleItems{
+			ProductId: item.ProductId,
+			UnitId:    saleUnit.UnitId,
+			Price:     saleUnit.Price,
+			Qty:       item.Qty,
+			SubTotal:  saleUnit.Price * item.Qty,
+		})
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		return responses.BadRequest(c, "Validate failed", err)
+	}
+
+	return saveSaleTransaction(c, req, idem, func(tx *gorm.DB, sale models.Sales) error {
+		return tools.MarkCartCheckedOut(tx, cart.ID, sale.ID, time.Now().In(utils.Location))
+	})
+}
+
+// cartItemPrice harga jual per satuan baris keranjang menurut daftar harga member keranjang
+func cartItemPrice(db *gorm.DB, branchID string, cart models.SaleCarts, saleUnit models.ProdSaleUnit, qty int) (int, error) {
+	priceListID, err := tools.GetMemberPriceList(db, branchID, cart.MemberId)
+	if err != nil {
+		return 0, err
+	}
+	return tools.ResolveSalePrice(db, priceListID, saleUnit, qty)
+}
+
+// editableSaleCart mengambil keranjang milik kasir yang masih terbuka
+func editableSaleCart(db *gorm.DB, cartID string, userID string, branchID string) (models.SaleCarts, error) {
+	cart, err := tools.GetUserCart(db, cartID, userID, branchID)
+	if err != nil {
+		return cart, err
+	}
+	if cart.Status != models.CartOpen {
+		return cart, errCartNotOpen
+	}
+	return cart, nil
+}
+
+// saleCartError mengubah error pengambilan keranjang menjadi respons
+func saleCartError(c *framework.Ctx, err error) error {
+	switch err {
+	case gorm.ErrRecordNotFound:
+		return responses.NotFound(c, "Cart not found")
+	case errCartNotOpen:
+		return responses.BadRequest(c, err.Error(), err)
+	default:
+		return responses.InternalServerError(c, "Failed to retrieve cart", err)
+	}
+}
+
+// cartStockError mengubah error pemeriksaan stok keranjang menjadi respons
+func cartStockError(c *framework.Ctx, err error) error {
+	var stockErr *tools.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		return responses.BadRequest(c, stockErr.Error(), err)
+	}
+	return responses.InternalServerError(c, "Failed to check product stock", err)
+}
+
+// saleCartQuery query dasar keranjang beserta nama member, jumlah barang dan total
+func saleCartQuery(db *gorm.DB, branchID string) *gorm.DB {
+	return db.Table("sale_carts crt").
+		Select("crt.id, crt.label, crt.member_id, mbr.name AS member_name, crt.status, crt.sale_id, "+
+			"(SELECT COALESCE(SUM(sci.qty), 0) FROM sale_cart_items sci WHERE sci.cart_id = crt.id) AS total_qty, "+
+			"(SELECT COALESCE(SUM(sci.sub_total), 0) FROM sale_cart_items sci WHERE sci.cart_id = crt.id) AS total, "+
+			"crt.created_at, crt.updated_at").
+		Joins("LEFT JOIN members mbr ON mbr.id = crt.member_id").
+		Where("crt.branch_id = ?", branchID)
+}
+
+// saleCartResult mengirim detail keranjang terbaru sebagai respons
+func saleCartResult(c *framework.Ctx, status int, message string, cartID string, branchID string) error {
+	db := config.DB
+
+	var cart models.SaleCartResponse
+	if err := saleCartQuery(db, branchID).Where("crt.id = ?", cartID).Scan(&cart.AllSaleCarts).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve cart", err)
+	}
+
+	cart.Items = []models.SaleCartItemDetail{}
+	err := db.Table("sale_cart_items sci").
+		Select("sci.id, sci.product_id, prd.name AS product_name, sci.unit_id, unt.name AS unit_name, sci.conv_value, sci.price, sci.qty, sci.sub_total, prd.stock / GREATEST(sci.conv_value, 1) AS stock").
+		Joins("LEFT JOIN products prd ON prd.id = sci.product_id").
+		Joins("LEFT JOIN units unt ON unt.id = sci.unit_id").
+		Where("sci.cart_id = ?", cartID).
+		Order("sci.created_at ASC").
+		Scan(&cart.Items).Error
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve cart items", err)
+	}
+
+	return responses.JSONResponse(c, status, message, cart)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -20,12 +21,34 @@ import (
 	"gorm.io/gorm"
 )
 
+// IsSaleEditable memeriksa apakah item / total penjualan boleh diubah.
+// Penjualan split tidak bisa diubah karena rincian pembayarannya berasal dari kasir.
+func IsSaleEditable(db *gorm.DB, saleID string) (bool, error) {
+	var payment models.PaymentStatus
+	err := db.Table("sales").
+		Select("payment").
+		Where("id = ?", saleID).
+		Scan(&payment).Error
+	if err != nil {
+		return false, err
+	}
+
+	return payment != models.PaidBySplit, nil
+}
+
+// saleUnitError mengubah error pengambilan satuan jual menjadi respons
+func saleUnitError(c *framework.Ctx, err error) error {
+	if errors.Is(err, tools.ErrUnitNotSellable) {
+		return responses.BadRequest(c, err.Error(), err)
+	}
+	if err == gorm.ErrRecordNotFound {
+		return responses.NotFound(c, "Product not found")
+	}
+	return responses.InternalServerError(c, "Failed to retrieve product unit", err)
+}
+
 // CreateSaleTransaction controller
 func CreateSaleTransaction(c *framework.Ctx) error {
-	// Hitung waktu sekarang dalam WIB
-	nowWIB := time.Now().In(utils.Location)
-
-	db := config.DB
 	var req SaleTransactionRequest
 	// Deklarasi 'err' pertama kali di sini
 	err := c.BodyParser(&req)
@@ -33,6 +56,33 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 		return responses.BadRequest(c, "Invalid request body", err)
 	}
 
+	// Request ulang dengan Idempotency-Key yang sama mengembalikan respons pertama
+	idem, replayed, err := startIdempotency(c, "sales", req)
+	if replayed {
+		return err
+	}
+
+	// --- VALIDASI INPUT ---
+	// Menggunakan 'err =' karena 'err' sudah dideklarasikan di atas
+	err = utils.ValidateStruct(req)
+	if err != nil {
+		return responses.BadRequest(c, "Validate failed", err)
+	}
+	// --- AKHIR VALIDASI INPUT ---
+
+	return saveSaleTransaction(c, req, idem, nil)
+}
+
+// saveSaleTransaction menyimpan penjualan beserta item, stok, pembayaran, laporan dan poin member dalam satu transaksi.
+// idem (opsional) menyimpan respons untuk Idempotency-Key, onCreated (opsional) dijalankan di dalam transaksi
+// yang sama sebelum commit, misalnya untuk menandai keranjang sudah checkout.
+func saveSaleTransaction(c *framework.Ctx, req SaleTransactionRequest, idem *models.IdempotencyKeys, onCreated func(tx *gorm.DB, sale models.Sales) error) error {
+	// Hitung waktu sekarang dalam WIB
+	nowWIB := time.Now().In(utils.Location)
+
+	db := config.DB
+	var err error
+
 	// Get default_member id dari token
 	defaultMember, _ := middlewares.GetClaimsToken(c.Request, "default_member")
 
@@ -45,14 +95,6 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 	// Get UserID from token
 	userID, _ := middlewares.GetUserID(c.Request)
 
-	// --- VALIDASI INPUT ---
-	// Menggunakan 'err =' karena 'err' sudah dideklarasikan di atas
-	err = utils.ValidateStruct(req)
-	if err != nil {
-		return responses.BadRequest(c, "Validate failed", err)
-	}
-	// --- AKHIR VALIDASI INPUT ---
-
 	// Modifikasi agar jika `member_id` tidak dikirim dalam request,
 	// maka `member_id` diisi `defaultMember` dari deklarasi tersebut.
 	if req.Sale.MemberId == "" {
@@ -63,6 +105,20 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 		req.Sale.Payment = "paid_by_cash"
 	}
 
+	// Metode pembayaran header mengikuti rincian pembayaran, lebih dari satu metode dicatat sebagai split
+	req.Sale.Payment, err = tools.ResolveSalePayment(req.Sale.Payment, req.Payments)
+	if err != nil {
+		return responses.BadRequest(c, err.Error(), err)
+	}
+
+	// Kasir wajib membuka shift sebelum melakukan penjualan
+	userRole, _ := middlewares.GetClaimsToken(c.Request, "user_role")
+	if userRole == string(models.Cashier) {
+		if _, err = tools.GetOpenShift(db, userID, branchID); err != nil {
+			return responses.BadRequest(c, "Kasir harus membuka shift sebelum melakukan penjualan", err)
+		}
+	}
+
 	// --- Proses Penyimpanan Data ---
 	// Mulai transaksi database
 	tx := db.Begin()
@@ -83,13 +139,72 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 	req.Sale.BranchID = branchID
 	req.Sale.CreatedAt = nowWIB
 	req.Sale.UpdatedAt = nowWIB
+	req.Sale.PaidAmount = 0
+
+	// Harga item ditentukan server dari daftar harga kategori member (atau harga jual produk),
+	// kecuali kasir mengirim price_override; perubahan harga tersebut dicatat di price_overrides
+	priceListID, err := tools.GetMemberPriceList(tx, branchID, req.Sale.MemberId)
+	if err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to retrieve member price list", err)
+	}
+	for i := range req.SaleItems {
+		// Satuan jual item, qty dan harga dalam satuan jual sedangkan stok dalam satuan dasar
+		var saleUnit models.ProdSaleUnit
+		saleUnit, err = tools.GetSaleUnit(tx, branchID, req.SaleItems[i].ProductId, req.SaleItems[i].UnitId)
+		if err != nil {
+			tx.Rollback()
+			return saleUnitError(c, err)
+		}
+		req.SaleItems[i].UnitId = saleUnit.UnitId
+		req.SaleItems[i].ConvValue = saleUnit.ConvValue
+
+		var listPrice int
+		listPrice, err = tools.ResolveSalePrice(tx, priceListID, saleUnit, req.SaleItems[i].Qty)
+		if err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to resolve sale price", err)
+		}
+		req.SaleItems[i].PriceListId = priceListID
+		req.SaleItems[i].ListPrice = listPrice
 
-	// Inisialisasi total_sale dan profit_estimate untuk kalkulasi
+		if !req.SaleItems[i].PriceOverride {
+			req.SaleItems[i].Price = listPrice
+		} else if req.SaleItems[i].Price <= 0 {
+			tx.Rollback()
+			return responses.BadRequest(c, fmt.Sprintf("Harga override untuk produk %s harus lebih dari 0", req.SaleItems[i].ProductId), nil)
+		}
+	}
+
+	// Hitung promo yang berlaku untuk setiap item di sisi server
+	promoMemberID := ""
+	if req.Sale.MemberId != defaultMember {
+		promoMemberID = req.Sale.MemberId
+	}
+	if _, err = tools.ApplySalePromos(tx, branchID, promoMemberID, nowWIB, req.SaleItems); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to apply promos", err)
+	}
+
+	// Pengaturan PPN cabang untuk menghitung pajak setiap item
+	taxBranch, err := tools.GetBranchTax(tx, branchID)
+	if err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to retrieve branch tax settings", err)
+	}
+
+	// Inisialisasi total_sale, PPN dan profit_estimate untuk kalkulasi
 	var calculatedTotalSale int
+	var calculatedTax int
 	var calculatedProfitEstimate int
 
 	// 2. Simpan data SaleItems (anak-anak) dan Update Stok
-	// var stockTracksToCreate []models.StockTracks
+	stockRef := tools.StockRef{
+		MovementType: models.SaleTrans,
+		ReferenceID:  saleID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
 
 	for i := range req.SaleItems {
 		itemID := helpers.GenerateID("SIT") // Generate ID untuk setiap SaleItem
@@ -108,29 +223,118 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 			return responses.InternalServerError(c, "Failed to retrieve product details", err)
 		}
 
-		// Periksa ketersediaan stok
-		if product.Stock < req.SaleItems[i].Qty {
+		// Qty dalam satuan dasar untuk stok, batch dan harga pokok
+		baseQty := req.SaleItems[i].BaseQty()
+
+		// Kurangi stok produk secara atomik (sekaligus memeriksa ketersediaan stok) dan catat mutasinya
+		if _, _, err = tools.ChangeProductStock(tx, product.ID, -baseQty, stockRef); err != nil {
 			tx.Rollback()
-			return responses.BadRequest(c, fmt.Sprintf("Insufficient stock for product %s. Available: %d, Requested: %d", product.Name, product.Stock, req.SaleItems[i].Qty), err)
+			var stockErr *tools.InsufficientStockError
+			if errors.As(err, &stockErr) {
+				return responses.BadRequest(c, stockErr.Error(), err)
+			}
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to update stock for product %s", product.Name), err)
 		}
 
-		// Kurangi stok produk
-		newStock := product.Stock - req.SaleItems[i].Qty
-		err = tx.Model(&models.Product{}).Where("id = ?", product.ID).Update("stock", newStock).Error
+		// Ambil qty dari batch dengan expired paling awal (FEFO)
+		if _, err = tools.ConsumeProductBatches(tx, branchID, product.ID, itemID, baseQty); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to consume batch for product %s", product.Name), err)
+		}
+
+		// Ambil harga pokok sesuai metode costing cabang dan simpan sebagai COGS item
+		var unitCost int
+		unitCost, err = tools.ConsumeCost(tx, stockRef, product.ID, baseQty)
 		if err != nil {
 			tx.Rollback()
-			return responses.InternalServerError(c, fmt.Sprintf("Failed to update stock for product %s", product.Name), err)
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to calculate cost for product %s", product.Name), err)
 		}
+		req.SaleItems[i].CostPrice = unitCost
+
+		// PPN item, produk bebas pajak tidak dikenakan PPN
+		req.SaleItems[i].TaxAmount = taxBranch.LineTax(req.SaleItems[i].SubTotal, product.TaxExempt)
 
 		// Kalkulasi total_sale dan profit_estimate dari item_sales
 		calculatedTotalSale += req.SaleItems[i].SubTotal
-		// Profit per item = (Harga Jual - Harga Beli) * Qty
-		calculatedProfitEstimate += (req.SaleItems[i].Price - product.PurchasePrice) * req.SaleItems[i].Qty
+		calculatedTax += req.SaleItems[i].TaxAmount
+		// Profit per item = Harga Jual * Qty - Harga Pokok * Qty satuan dasar - Potongan Promo
+		calculatedProfitEstimate += req.SaleItems[i].Price*req.SaleItems[i].Qty - unitCost*baseQty - req.SaleItems[i].PromoDiscount
 	}
 
-	// Set nilai total_sale dan profit_estimate pada struct Sales
-	req.Sale.TotalSale = calculatedTotalSale - req.Sale.Discount // Kurangi profit dengan diskon keseluruhan
+	// Set nilai total_sale, PPN dan profit_estimate pada struct Sales
+	// total_sale dikurangi diskon keseluruhan dan ditambah PPN jika harga belum termasuk pajak
+	req.Sale.TaxAmount, req.Sale.TotalSale = taxBranch.TaxTotals(calculatedTotalSale, calculatedTax, req.Sale.Discount)
 	req.Sale.ProfitEstimate = calculatedProfitEstimate
+	if taxBranch.TaxMode == models.TaxInclusive {
+		// PPN yang termasuk dalam harga jual bukan bagian dari profit
+		req.Sale.ProfitEstimate -= req.Sale.TaxAmount
+	}
+
+	// Penjualan kredit hanya untuk member terdaftar dan tidak boleh melebihi batas piutang
+	if req.Sale.Payment == models.PaidByCredit {
+		if req.Sale.MemberId == defaultMember {
+			tx.Rollback()
+			return responses.BadRequest(c, "Penjualan kredit hanya bisa dilakukan untuk member terdaftar", nil)
+		}
+
+		if err = tools.CheckMemberCredit(tx, req.Sale.MemberId, "", req.Sale.TotalSale); err != nil {
+			tx.Rollback()
+			return responses.BadRequest(c, err.Error(), err)
+		}
+	}
+
+	if err = tools.SetSaleDueDate(tx, &req.Sale); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to set sale due date", err)
+	}
+
+	// Penukaran poin member sebagai pembayaran, nilai poin mengikuti pengaturan cabang
+	req.Sale.PointsAmount = 0
+	if req.Sale.PointsRedeemed < 0 {
+		tx.Rollback()
+		return responses.BadRequest(c, "points_redeemed tidak boleh negatif", nil)
+	}
+	if req.Sale.PointsRedeemed > 0 {
+		if req.Sale.MemberId == defaultMember {
+			tx.Rollback()
+			return responses.BadRequest(c, "Penukaran poin hanya bisa dilakukan oleh member terdaftar", nil)
+		}
+
+		var branch models.Branch
+		if err = tx.Select("point_value").First(&branch, "id = ?", branchID).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to retrieve branch point value", err)
+		}
+
+		req.Sale.PointsAmount = req.Sale.PointsRedeemed * branch.PointValue
+		if req.Sale.PointsAmount > req.Sale.TotalSale {
+			tx.Rollback()
+			return responses.BadRequest(c, fmt.Sprintf("Nilai poin melebihi total penjualan. Total: %d, Nilai poin: %d", req.Sale.TotalSale, req.Sale.PointsAmount), nil)
+		}
+
+		err = tools.RedeemMemberPoints(tx, req.Sale.MemberId, req.Sale.PointsRedeemed, tools.PointRef{
+			ReferenceID: saleID,
+			Reason:      "Penukaran poin pada penjualan",
+			UserID:      userID,
+			BranchID:    branchID,
+		})
+		if err != nil {
+			tx.Rollback()
+			return responses.BadRequest(c, fmt.Sprintf("Gagal menukar poin member: %s", err.Error()), err)
+		}
+	}
+
+	if req.Sale.Payment == models.PaidBySaldo && req.Sale.PointsAmount != req.Sale.TotalSale {
+		tx.Rollback()
+		return responses.BadRequest(c, "Pembayaran paid_by_saldo harus dibayar penuh dengan poin member", nil)
+	}
+
+	// Rincian pembayaran harus sama dengan total setelah dikurangi nilai poin, kembalian tunai dihitung di sini
+	salePayments, err := tools.PrepareSalePayments(req.Sale, req.Payments)
+	if err != nil {
+		tx.Rollback()
+		return responses.BadRequest(c, err.Error(), err)
+	}
 
 	// Simpan data Sales setelah kalkulasi total dan profit
 	err = tx.Create(&req.Sale).Error
@@ -146,6 +350,26 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 		return responses.InternalServerError(c, "Failed to create sale items", err)
 	}
 
+	// Catat item yang harganya diubah kasir dari harga daftar harga
+	for _, item := range req.SaleItems {
+		if !item.PriceOverride || item.Price == item.ListPrice {
+			continue
+		}
+		if err = tools.RecordPriceOverride(tx, item, userID, branchID, nowWIB); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to record price override", err)
+		}
+	}
+
+	// Simpan rincian pembayaran
+	if len(salePayments) > 0 {
+		if err = tx.Create(&salePayments).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to create sale payments", err)
+		}
+	}
+	req.Payments = salePayments
+
 	// 3. Simpan data di TransactionReports
 	transactionReportID := helpers.GenerateID("TRX")
 	transactionReport := models.TransactionReports{
@@ -153,7 +377,7 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 		TransactionType: models.Sale, // Tipe transaksi adalah "sale"
 		UserID:          req.Sale.UserID,
 		BranchID:        req.Sale.BranchID,
-		Total:           req.Sale.TotalSale - req.Sale.Discount,
+		Total:           req.Sale.TotalSale - req.Sale.Discount - req.Sale.PointsAmount, // Nilai poin bukan pemasukan kas
 		Payment:         req.Sale.Payment,
 		CreatedAt:       nowWIB,
 		UpdatedAt:       nowWIB,
@@ -267,11 +491,15 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 		}
 
 		if memberCategory.PointsConversionRate > 0 {
-			// Pastikan total_sale adalah float untuk perhitungan poin
-			pointsEarned := float64(req.Sale.TotalSale) / float64(memberCategory.PointsConversionRate)
-			member.Points += int(pointsEarned) // Tambahkan poin yang didapat (gunakan int jika kolom points int)
-
-			err = tx.Save(&member).Error
+			// Poin hanya dihitung dari bagian yang tidak dibayar dengan poin
+			pointsEarned := float64(req.Sale.TotalSale-req.Sale.PointsAmount) / float64(memberCategory.PointsConversionRate)
+
+			err = tools.EarnMemberPoints(tx, member.ID, int(pointsEarned), tools.PointRef{
+				ReferenceID: saleID,
+				Reason:      "Poin dari penjualan",
+				UserID:      userID,
+				BranchID:    branchID,
+			})
 			if err != nil {
 				tx.Rollback()
 				return responses.InternalServerError(c, fmt.Sprintf("Failed to update points for member %s", member.ID), err)
@@ -283,6 +511,23 @@ func CreateSaleTransaction(c *framework.Ctx) error {
 		}
 	}
 
+	// Jurnal penjualan, dibuat setelah item dan rincian pembayaran tersimpan
+	if err = tools.SyncJournal(tx, models.JournalSale, req.Sale.ID); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create sale journal", err)
+	}
+
+	if onCreated != nil {
+		if err = onCreated(tx, req.Sale); err != nil {
+			tx.Rollback()
+			return responses.BadRequest(c, err.Error(), err)
+		}
+	}
+
+	if replayed, err := finishIdempotency(c, tx, idem, http.StatusOK, "Sale transaction created successfully", req); replayed {
+		return err
+	}
+
 	// Commit transaksi jika semua berhasil
 	err = tx.Commit().Error
 	if err != nil {
@@ -326,6 +571,21 @@ func UpdateSale(c *framework.Ctx) error {
 	}
 	// Jika nil → tidak diubah, tetap pakai MemberID yang sudah ada
 
+	// Penjualan yang sudah dicicil tidak boleh diubah metode pembayarannya
+	if input.Payment != "" && models.PaymentStatus(input.Payment) != sale.Payment && sale.PaidAmount > 0 {
+		return responses.BadRequest(c, "Penjualan sudah memiliki cicilan piutang, metode pembayaran tidak bisa diubah", nil)
+	}
+
+	// Penjualan split tidak bisa diubah total / metode pembayarannya, dan split hanya dibuat saat transaksi
+	discountChanged := input.Discount != nil && *input.Discount != sale.Discount
+	paymentChanged := input.Payment != "" && models.PaymentStatus(input.Payment) != sale.Payment
+	if sale.Payment == models.PaidBySplit && (discountChanged || paymentChanged) {
+		return responses.BadRequest(c, "Penjualan dengan pembayaran split tidak bisa diubah diskon atau metode pembayarannya", nil)
+	}
+	if models.PaymentStatus(input.Payment) == models.PaidBySplit && paymentChanged {
+		return responses.BadRequest(c, "Pembayaran split hanya bisa dibuat saat transaksi penjualan", nil)
+	}
+
 	if input.Payment != "" {
 		sale.Payment = models.PaymentStatus(input.Payment)
 	}
@@ -338,24 +598,50 @@ func UpdateSale(c *framework.Ctx) error {
 	}
 
 	total := 0
+	tax := 0
 	for _, item := range items {
 		total += item.SubTotal
+		tax += item.TaxAmount
 	}
 
 	// Gunakan diskon baru jika dikirim, jika tidak tetap pakai yang lama
 	if input.Discount != nil {
 		sale.Discount = *input.Discount
 	}
-	sale.TotalSale = total - sale.Discount
+
+	taxBranch, err := tools.GetBranchTax(db, sale.BranchID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve branch tax settings", err)
+	}
+	sale.TaxAmount, sale.TotalSale = taxBranch.TaxTotals(total, tax, sale.Discount)
+
+	// Penjualan kredit dicek ulang terhadap batas piutang member
+	if sale.Payment == models.PaidByCredit && sale.PaidAmount == 0 {
+		if err := tools.CheckMemberCredit(db, sale.MemberId, sale.ID, sale.TotalSale); err != nil {
+			return responses.BadRequest(c, err.Error(), err)
+		}
+	}
+
+	if err := tools.SetSaleDueDate(db, &sale); err != nil {
+		return responses.InternalServerError(c, "Failed to set sale due date", err)
+	}
 
 	if err := db.Save(&sale).Error; err != nil {
 		return responses.InternalServerError(c, "Failed to update sale", err)
 	}
 
+	if err := tools.SyncSalePayments(db, sale); err != nil {
+		return responses.InternalServerError(c, "Failed to sync sale payments", err)
+	}
+
 	if err := reports.SyncSaleReport(db, sale); err != nil {
 		return responses.InternalServerError(c, "Failed to sync sale report", err)
 	}
 
+	if err := tools.SyncJournal(db, models.JournalSale, sale.ID); err != nil {
+		return responses.InternalServerError(c, "Failed to sync sale journal", err)
+	}
+
 	_ = reports.AutoCleanupSales(db)
 	_ = reports.SyncDailyProfitReport(db, sale)
 
@@ -373,22 +659,79 @@ func DeleteSale(c *framework.Ctx) error {
 		return responses.NotFound(c, "Sale not found")
 	}
 
-	// Ambil & hapus item, serta rollback stok
-	var items []models.SaleItems
-	if err := db.Where("sale_id = ?", id).Find(&items).Error; err == nil {
+	if sale.PaidAmount > 0 {
+		return responses.BadRequest(c, "Penjualan sudah memiliki cicilan piutang dan tidak bisa dihapus", nil)
+	}
+
+	// Pengembalian stok, batch dan harga pokok serta penghapusan penjualan dilakukan dalam satu transaksi
+	stockRef := tools.NewStockRef(c, models.SaleTrans, sale.ID)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Ambil & hapus item, serta rollback stok
+		var items []models.SaleItems
+		if err := tx.Where("sale_id = ?", id).Find(&items).Error; err != nil {
+			return fmt.Errorf("fetch sale items: %w", err)
+		}
 		for _, item := range items {
-			_ = tools.SubtractProductStock(db, item.ProductId, item.Qty)
+			if err := tools.SubtractProductStock(tx, item.ProductId, item.BaseQty(), stockRef); err != nil {
+				return fmt.Errorf("restore stock for product %s: %w", item.ProductId, err)
+			}
+			if _, err := tools.RestoreProductBatches(tx, sale.BranchID, item.ProductId, item.ID, item.BaseQty()); err != nil {
+				return fmt.Errorf("restore batch for product %s: %w", item.ProductId, err)
+			}
+			if err := tools.ApplyReceiptCost(tx, stockRef, item.ProductId, item.ID, item.BaseQty(), item.CostPrice); err != nil {
+				return fmt.Errorf("restore cost for product %s: %w", item.ProductId, err)
+			}
+		}
+		if err := tx.Where("sale_id = ?", id).Delete(&models.SaleItems{}).Error; err != nil {
+			return fmt.Errorf("delete sale items: %w", err)
 		}
-		db.Where("sale_id = ?", id).Delete(&models.SaleItems{})
-	}
 
-	// Hapus laporan transaksi
-	if err := db.Where("id = ? AND transaction_type = ?", sale.ID, models.Sale).Delete(&models.TransactionReports{}).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to delete transaction report", err)
-	}
+		// Hapus rincian pembayaran
+		if err := tx.Where("sale_id = ?", sale.ID).Delete(&models.SalePayments{}).Error; err != nil {
+			return fmt.Errorf("delete sale payments: %w", err)
+		}
+
+		// Hapus laporan transaksi
+		if err := tx.Where("id = ? AND transaction_type = ?", sale.ID, models.Sale).Delete(&models.TransactionReports{}).Error; err != nil {
+			return fmt.Errorf("delete transaction report: %w", err)
+		}
+
+		// Hapus jurnal penjualan
+		if err := tools.RemoveJournal(tx, models.JournalSale, sale.ID); err != nil {
+			return fmt.Errorf("delete sale journal: %w", err)
+		}
+
+		// Tarik poin yang didapat dari penjualan ini, dikurangi poin yang sudah ditarik lewat retur
+		var earnedPoints int
+		err := tx.Model(&models.MemberPoints{}).
+			Select("COALESCE(SUM(points), 0)").
+			Where("member_id = ? AND ((reference_id = ? AND point_type = ?) OR (point_type = ? AND reference_id IN (?)))",
+				sale.MemberId, sale.ID, models.PointEarn, models.PointReversal,
+				tx.Model(&models.SaleReturns{}).Select("id").Where("sale_id = ?", sale.ID)).
+			Scan(&earnedPoints).Error
+		if err != nil {
+			return fmt.Errorf("fetch earned points: %w", err)
+		}
+		pointRef := tools.PointRef{
+			ReferenceID: sale.ID,
+			Reason:      "Penarikan poin karena penjualan dihapus",
+			UserID:      stockRef.UserID,
+			BranchID:    sale.BranchID,
+		}
+		if _, err := tools.ReverseMemberPoints(tx, sale.MemberId, sale.ID, earnedPoints, pointRef); err != nil {
+			return fmt.Errorf("reverse member points: %w", err)
+		}
 
-	// Hapus data penjualan
-	if err := db.Delete(&sale).Error; err != nil {
+		// Kembalikan poin yang ditukar pada penjualan ini
+		pointRef.Reason = "Pengembalian poin karena penjualan dihapus"
+		if err := tools.RefundMemberPoints(tx, sale.MemberId, sale.PointsRedeemed, pointRef); err != nil {
+			return fmt.Errorf("refund redeemed points: %w", err)
+		}
+
+		// Hapus data penjualan
+		return tx.Delete(&sale).Error
+	})
+	if err != nil {
 		return responses.InternalServerError(c, "Failed to delete sale", err)
 	}
 
@@ -401,85 +744,166 @@ func DeleteSale(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Sale deleted successfully", sale)
 }
 
+// saleItemError mengubah error perubahan item penjualan menjadi respons, stok tidak cukup dikembalikan sebagai bad request
+func saleItemError(c *framework.Ctx, message string, err error) error {
+	var stockErr *tools.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		return responses.BadRequest(c, stockErr.Error(), err)
+	}
+	return responses.InternalServerError(c, message, err)
+}
+
+// syncEditedSale menghitung ulang total, jurnal dan rincian pembayaran penjualan setelah itemnya berubah
+func syncEditedSale(tx *gorm.DB, saleID string) (models.Sales, error) {
+	var sale models.Sales
+	if err := reports.RecalculateTotalSale(tx, saleID); err != nil {
+		return sale, fmt.Errorf("recalculate total sale: %w", err)
+	}
+	if err := tools.SyncJournal(tx, models.JournalSale, saleID); err != nil {
+		return sale, fmt.Errorf("sync sale journal: %w", err)
+	}
+	if err := tx.First(&sale, "id = ?", saleID).Error; err != nil {
+		return sale, fmt.Errorf("fetch sale: %w", err)
+	}
+	if err := tools.SyncSalePayments(tx, sale); err != nil {
+		return sale, fmt.Errorf("sync sale payments: %w", err)
+	}
+	return sale, nil
+}
+
 // CreateSaleItem Function
 func CreateSaleItem(c *framework.Ctx) error {
 	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
 	var item models.SaleItems
 
 	if err := c.BodyParser(&item); err != nil {
 		return responses.BadRequest(c, "Invalid input", err)
 	}
 
-	// Ambil harga jual produk dari tabel products
-	var product models.Product
-	if err := db.Select("sales_price").Where("id = ?", item.ProductId).First(&product).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to fetch product price", err)
+	editable, errr := IsSaleEditable(db, item.SaleId)
+	if errr != nil {
+		return responses.InternalServerError(c, "Failed to retrieve sale payment", errr)
+	}
+	if !editable {
+		return responses.Forbidden(c, "Penjualan dengan pembayaran split tidak bisa diubah")
 	}
 
-	// Gunakan sales_price dari produk, abaikan inputan frontend
-	item.Price = product.SalesPrice
-
-	// Cek apakah item dengan sale_id dan product_id sudah ada
-	var existing models.SaleItems
-	err := db.Where("sale_id = ? AND product_id = ?", item.SaleId, item.ProductId).First(&existing).Error
-	if err == nil {
-		// Sudah ada: update qty dan sub_total
-		existing.Qty += item.Qty
-		existing.Price = product.SalesPrice
-		existing.SubTotal = existing.Qty * existing.Price
+	// Ambil harga jual per satuan dari daftar harga member / produk / konversi satuan, abaikan inputan frontend
+	saleUnit, err := tools.GetSaleUnit(db, branchID, item.ProductId, item.UnitId)
+	if err != nil {
+		return saleUnitError(c, err)
+	}
+	priceListID, err := tools.GetSalePriceList(db, branchID, item.SaleId)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve member price list", err)
+	}
+	item.UnitId = saleUnit.UnitId
+	item.ConvValue = saleUnit.ConvValue
+	item.PriceListId = priceListID
+	item.Price, err = tools.ResolveSalePrice(db, priceListID, saleUnit, item.Qty)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to resolve sale price", err)
+	}
+	item.ListPrice = item.Price
+	addedBaseQty := item.BaseQty()
+	stockRef := tools.NewStockRef(c, models.SaleTrans, item.SaleId)
 
-		if err := db.Save(&existing).Error; err != nil {
-			return responses.InternalServerError(c, "Failed to update sale item", err)
+	// Stok, item, batch, harga pokok dan total penjualan disimpan dalam satu transaksi
+	var sale models.Sales
+	merged := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Kurangi stok lebih dulu, item ditolak jika stok tidak cukup
+		if err := tools.ReduceProductStock(tx, item.ProductId, addedBaseQty, stockRef); err != nil {
+			return err
 		}
 
-		if err := tools.ReduceProductStock(db, item.ProductId, item.Qty); err != nil {
-			return responses.InternalServerError(c, "Failed to reduce product stock", err)
-		}
+		// Cek apakah item dengan sale_id, product_id dan satuan yang sama sudah ada
+		var existing models.SaleItems
+		err := tx.Where("sale_id = ? AND product_id = ? AND unit_id = ?", item.SaleId, item.ProductId, item.UnitId).First(&existing).Error
+		if err == nil {
+			// Sudah ada: update qty dan sub_total
+			merged = true
+			existing.Qty += item.Qty
+			existing.ConvValue = saleUnit.ConvValue
+			existing.PriceListId = priceListID
+			// Harga bertingkat dihitung ulang dari qty gabungan
+			existing.Price, err = tools.ResolveSalePrice(tx, priceListID, saleUnit, existing.Qty)
+			if err != nil {
+				return fmt.Errorf("resolve sale price: %w", err)
+			}
+			existing.ListPrice = existing.Price
+			existing.SubTotal = existing.Qty * existing.Price
+			existing.PromoId = "" // Promo hanya dihitung saat transaksi dibuat
+			existing.PromoDiscount = 0
 
-		if err := reports.RecalculateTotalSale(db, item.SaleId); err != nil {
-			return responses.InternalServerError(c, "Failed to recalculate total sale", err)
-		}
+			existing.TaxAmount, err = tools.ProductLineTax(tx, branchID, existing.ProductId, existing.SubTotal)
+			if err != nil {
+				return fmt.Errorf("calculate item tax: %w", err)
+			}
 
-		// Sync laporan profit harian
-		var sale models.Sales
-		if err := db.First(&sale, "id = ?", item.SaleId).Error; err != nil {
-			return responses.InternalServerError(c, "Failed to fetch sale", err)
-		}
+			if _, err := tools.ConsumeProductBatches(tx, branchID, item.ProductId, existing.ID, addedBaseQty); err != nil {
+				return fmt.Errorf("consume product batch: %w", err)
+			}
 
-		_ = reports.SyncDailyProfitReport(db, sale)
+			// Harga pokok item (per satuan dasar) adalah rata-rata dari qty lama dan qty tambahan
+			unitCost, err := tools.ConsumeCost(tx, stockRef, item.ProductId, addedBaseQty)
+			if err != nil {
+				return fmt.Errorf("calculate product cost: %w", err)
+			}
+			existing.CostPrice = ((existing.BaseQty()-addedBaseQty)*existing.CostPrice + addedBaseQty*unitCost) / existing.BaseQty()
 
-		return responses.JSONResponse(c, http.StatusOK, "Item updated successfully", existing)
+			if err := tx.Save(&existing).Error; err != nil {
+				return fmt.Errorf("update sale item: %w", err)
+			}
+			item = existing
+		} else if err == gorm.ErrRecordNotFound {
+			// Data belum ada, buat item baru
+			if item.ID == "" {
+				item.ID = helpers.GenerateID("SIT")
+			}
+			item.SubTotal = item.Qty * item.Price
+			item.PromoId = ""
+			item.PromoDiscount = 0
 
-	} else if err != gorm.ErrRecordNotFound {
-		return responses.InternalServerError(c, "Failed to find existing sale item", err)
-	}
+			item.TaxAmount, err = tools.ProductLineTax(tx, branchID, item.ProductId, item.SubTotal)
+			if err != nil {
+				return fmt.Errorf("calculate item tax: %w", err)
+			}
 
-	// Data belum ada, buat item baru
-	if item.ID == "" {
-		item.ID = helpers.GenerateID("SIT")
-	}
-	item.SubTotal = item.Qty * item.Price
+			if err := tx.Create(&item).Error; err != nil {
+				return fmt.Errorf("create sale item: %w", err)
+			}
 
-	if err := db.Create(&item).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to create sale item", err)
-	}
+			if _, err := tools.ConsumeProductBatches(tx, branchID, item.ProductId, item.ID, addedBaseQty); err != nil {
+				return fmt.Errorf("consume product batch: %w", err)
+			}
 
-	if err := tools.ReduceProductStock(db, item.ProductId, item.Qty); err != nil {
-		return responses.InternalServerError(c, "Failed to reduce product stock", err)
-	}
+			unitCost, err := tools.ConsumeCost(tx, stockRef, item.ProductId, addedBaseQty)
+			if err != nil {
+				return fmt.Errorf("calculate product cost: %w", err)
+			}
+			item.CostPrice = unitCost
+			if err := tx.Model(&item).Update("cost_price", item.CostPrice).Error; err != nil {
+				return fmt.Errorf("update sale item cost: %w", err)
+			}
+		} else {
+			return fmt.Errorf("find existing sale item: %w", err)
+		}
 
-	if err := reports.RecalculateTotalSale(db, item.SaleId); err != nil {
-		return responses.InternalServerError(c, "Failed to recalculate total sale", err)
+		sale, err = syncEditedSale(tx, item.SaleId)
+		return err
+	})
+	if err != nil {
+		return saleItemError(c, "Failed to save sale item", err)
 	}
 
 	// Sync laporan profit harian
-	var sale models.Sales
-	if err := db.First(&sale, "id = ?", item.SaleId).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to fetch sale", err)
-	}
-
 	_ = reports.SyncDailyProfitReport(db, sale)
 
+	if merged {
+		return responses.JSONResponse(c, http.StatusOK, "Item updated successfully", item)
+	}
 	return responses.JSONResponse(c, http.StatusOK, "Item added successfully", item)
 }
 
@@ -487,57 +911,107 @@ func CreateSaleItem(c *framework.Ctx) error {
 func UpdateSaleItem(c *framework.Ctx) error {
 	db := config.DB
 	id := c.Param("id")
+	branchID, _ := middlewares.GetBranchID(c.Request)
 
 	var existingItem models.SaleItems
 	if err := db.First(&existingItem, "id = ?", id).Error; err != nil {
 		return responses.NotFound(c, "Item not found")
 	}
 
-	// Parsing data baru dari body (hanya untuk ambil ProductId dan Qty baru)
+	editable, errr := IsSaleEditable(db, existingItem.SaleId)
+	if errr != nil {
+		return responses.InternalServerError(c, "Failed to retrieve sale payment", errr)
+	}
+	if !editable {
+		return responses.Forbidden(c, "Penjualan dengan pembayaran split tidak bisa diubah")
+	}
+
+	// Parsing data baru dari body (hanya untuk ambil ProductId, satuan dan Qty baru)
 	var updatedData struct {
 		ProductId string `json:"product_id"`
+		UnitId    string `json:"unit_id"`
 		Qty       int    `json:"qty"`
 	}
 	if err := c.BodyParser(&updatedData); err != nil {
 		return responses.BadRequest(c, "Invalid input", err)
 	}
 
-	// Rollback stok lama
-	if err := tools.AddProductStock(db, existingItem.ProductId, existingItem.Qty); err != nil {
-		return responses.InternalServerError(c, "Failed to add product stock", err)
+	// Ambil harga jual per satuan dari daftar harga member / produk / konversi satuan baru
+	saleUnit, err := tools.GetSaleUnit(db, branchID, updatedData.ProductId, updatedData.UnitId)
+	if err != nil {
+		return saleUnitError(c, err)
 	}
-
-	// Ambil harga jual dari produk baru
-	var product models.Product
-	if err := db.Select("sales_price").Where("id = ?", updatedData.ProductId).First(&product).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to get product price", err)
+	priceListID, err := tools.GetSalePriceList(db, branchID, existingItem.SaleId)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve member price list", err)
 	}
-
-	// Kurangi stok baru
-	if err := tools.ReduceProductStock(db, updatedData.ProductId, updatedData.Qty); err != nil {
-		return responses.InternalServerError(c, "Failed to reduce product stock", err)
+	price, err := tools.ResolveSalePrice(db, priceListID, saleUnit, updatedData.Qty)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to resolve sale price", err)
 	}
 
-	// Update item
+	oldItem := existingItem
+	stockRef := tools.NewStockRef(c, models.SaleTrans, existingItem.SaleId)
+
 	existingItem.ProductId = updatedData.ProductId
+	existingItem.UnitId = saleUnit.UnitId
+	existingItem.ConvValue = saleUnit.ConvValue
 	existingItem.Qty = updatedData.Qty
-	existingItem.Price = product.SalesPrice
-	existingItem.SubTotal = product.SalesPrice * updatedData.Qty
+	existingItem.PriceListId = priceListID
+	existingItem.ListPrice = price
+	existingItem.Price = price
+	existingItem.SubTotal = price * updatedData.Qty
+	existingItem.PromoId = "" // Promo hanya dihitung saat transaksi dibuat
+	existingItem.PromoDiscount = 0
+
+	// Pengembalian qty lama dan pengambilan qty baru disimpan dalam satu transaksi
+	var sale models.Sales
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Rollback stok lama lalu kurangi stok baru, perubahan ditolak jika stok tidak cukup
+		if err := tools.AddProductStock(tx, oldItem.ProductId, oldItem.BaseQty(), stockRef); err != nil {
+			return fmt.Errorf("add product stock: %w", err)
+		}
+		if err := tools.ReduceProductStock(tx, existingItem.ProductId, existingItem.BaseQty(), stockRef); err != nil {
+			return err
+		}
 
-	if err := db.Save(&existingItem).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to update sale item", err)
-	}
+		// Kembalikan qty lama ke batch asal
+		if _, err := tools.RestoreProductBatches(tx, branchID, oldItem.ProductId, oldItem.ID, oldItem.BaseQty()); err != nil {
+			return fmt.Errorf("restore product batch: %w", err)
+		}
 
-	if err := reports.RecalculateTotalSale(db, existingItem.SaleId); err != nil {
-		return responses.InternalServerError(c, "Failed to recalculate total sale", err)
-	}
+		// Kembalikan harga pokok dari qty lama
+		if err := tools.ApplyReceiptCost(tx, stockRef, oldItem.ProductId, oldItem.ID, oldItem.BaseQty(), oldItem.CostPrice); err != nil {
+			return fmt.Errorf("restore product cost: %w", err)
+		}
 
-	// Sync laporan profit harian
-	var sale models.Sales
-	if err := db.First(&sale, "id = ?", existingItem.SaleId).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to fetch sale", err)
+		if _, err := tools.ConsumeProductBatches(tx, branchID, existingItem.ProductId, existingItem.ID, existingItem.BaseQty()); err != nil {
+			return fmt.Errorf("consume product batch: %w", err)
+		}
+
+		unitCost, err := tools.ConsumeCost(tx, stockRef, existingItem.ProductId, existingItem.BaseQty())
+		if err != nil {
+			return fmt.Errorf("calculate product cost: %w", err)
+		}
+		existingItem.CostPrice = unitCost
+
+		existingItem.TaxAmount, err = tools.ProductLineTax(tx, branchID, existingItem.ProductId, existingItem.SubTotal)
+		if err != nil {
+			return fmt.Errorf("calculate item tax: %w", err)
+		}
+
+		if err := tx.Save(&existingItem).Error; err != nil {
+			return fmt.Errorf("update sale item: %w", err)
+		}
+
+		sale, err = syncEditedSale(tx, existingItem.SaleId)
+		return err
+	})
+	if err != nil {
+		return saleItemError(c, "Failed to update sale item", err)
 	}
 
+	// Sync laporan profit harian
 	_ = reports.SyncDailyProfitReport(db, sale)
 
 	return responses.JSONResponse(c, http.StatusOK, "Item updated successfully", existingItem)
@@ -547,30 +1021,63 @@ func UpdateSaleItem(c *framework.Ctx) error {
 func DeleteSaleItem(c *framework.Ctx) error {
 	db := config.DB
 	id := c.Param("id")
+	branchID, _ := middlewares.GetBranchID(c.Request)
 
 	var item models.SaleItems
 	if err := db.First(&item, "id = ?", id).Error; err != nil {
 		return responses.NotFound(c, "Item not found")
 	}
 
-	// Rollback stok
-	if err := tools.AddProductStock(db, item.ProductId, item.Qty); err != nil {
-		return responses.InternalServerError(c, "Failed to add product stock", err)
+	editable, errr := IsSaleEditable(db, item.SaleId)
+	if errr != nil {
+		return responses.InternalServerError(c, "Failed to retrieve sale payment", errr)
 	}
-
-	// Hapus item
-	if err := db.Delete(&item).Error; err != nil {
-		return responses.InternalServerError(c, "Failed to delete sale item", err)
+	if !editable {
+		return responses.Forbidden(c, "Penjualan dengan pembayaran split tidak bisa diubah")
 	}
 
-	// Recalculate total
-	if err := reports.RecalculateTotalSale(db, item.SaleId); err != nil {
-		return responses.InternalServerError(c, "Failed to recalculate total sale", err)
+	stockRef := tools.NewStockRef(c, models.SaleTrans, item.SaleId)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Rollback stok
+		if err := tools.AddProductStock(tx, item.ProductId, item.BaseQty(), stockRef); err != nil {
+			return fmt.Errorf("add product stock: %w", err)
+		}
+
+		if _, err := tools.RestoreProductBatches(tx, branchID, item.ProductId, item.ID, item.BaseQty()); err != nil {
+			return fmt.Errorf("restore product batch: %w", err)
+		}
+
+		if err := tools.ApplyReceiptCost(tx, stockRef, item.ProductId, item.ID, item.BaseQty(), item.CostPrice); err != nil {
+			return fmt.Errorf("restore product cost: %w", err)
+		}
+
+		// Hapus item
+		if err := tx.Delete(&item).Error; err != nil {
+			return fmt.Errorf("delete sale item: %w", err)
+		}
+
+		_, err := syncEditedSale(tx, item.SaleId)
+		return err
+	})
+	if err != nil {
+		return saleItemError(c, "Failed to delete sale item", err)
 	}
 
 	return responses.JSONResponse(c, http.StatusOK, "Item deleted successfully", item)
 }
 
+// saleExportColumns kolom ekspor daftar penjualan
+var saleExportColumns = []tools.ExportColumn{
+	{Header: "No. Penjualan"},
+	{Header: "Tanggal", Kind: tools.ExportDateTime},
+	{Header: "Member"},
+	{Header: "Diskon", Kind: tools.ExportMoney},
+	{Header: "Total", Kind: tools.ExportMoney},
+	{Header: "Estimasi Laba", Kind: tools.ExportMoney},
+	{Header: "Pembayaran"},
+}
+
 // GetAllSales tampilkan semua sale
 func GetAllSales(c *framework.Ctx) error {
 	// Hitung waktu sekarang dalam WIB
@@ -623,6 +1130,13 @@ func GetAllSales(c *framework.Ctx) error {
 		query = query.Where("sl.sale_date BETWEEN ? AND ?", startDate, endDate)
 	}
 
+	// ?format=csv|xlsx mengekspor seluruh hasil filter tanpa paginasi
+	if format := c.Query("format"); format != "" {
+		return exportList(c, query, format, "penjualan", saleExportColumns, func(sale models.AllSales) []interface{} {
+			return []interface{}{sale.ID, sale.SaleDate, sale.MemberName, sale.Discount, sale.TotalSale, sale.ProfitEstimate, tools.PaymentLabel(sale.Payment)}
+		})
+	}
+
 	if err := query.Count(&total).Error; err != nil {
 		return responses.InternalServerError(c, "Get sale failed", err)
 	}
@@ -786,9 +1300,10 @@ func GetAllSaleItems(c *framework.Ctx) error {
 
 	// Query dasar
 	query := config.DB.Table("sale_items sit").
-		Select("sit.id, sit.sale_id, sit.product_id, pro.name AS product_name, sit.price, sit.qty, un.name AS unit_name, sit.sub_total").
+		Select("sit.id, sit.sale_id, sit.product_id, pro.name AS product_name, sit.price, sit.qty, sit.unit_id, un.name AS unit_name, sit.conv_value, sit.promo_id, prm.name AS promo_name, sit.promo_discount, sit.tax_amount, sit.sub_total").
 		Joins("LEFT JOIN products pro ON pro.id = sit.product_id").
-		Joins("LEFT JOIN units un ON un.id = pro.unit_id").
+		Joins("LEFT JOIN units un ON un.id = sit.unit_id").
+		Joins("LEFT JOIN promos prm ON prm.id = sit.promo_id").
 		Where("sit.sale_id = ?", saleID).
 		Order("pro.name ASC")
 
@@ -807,28 +1322,13 @@ func GetSaleWithItems(c *framework.Ctx) error {
 	saleID := c.Param("id")
 
 	// Gunakan models.AllSales untuk mengambil data dari DB
-	var sale models.AllSales
-
-	err := db.Table("sales sl").
-		Select("sl.id, sl.member_id, mbr.name AS member_name, sl.sale_date, sl.discount, sl.total_sale, sl.profit_estimate, sl.payment").
-		Joins("LEFT JOIN members mbr ON mbr.id = sl.member_id").
-		Where("sl.id = ?", saleID).
-		Scan(&sale).Error
-
+	sale, err := getSale(db, saleID)
 	if err != nil {
 		return responses.InternalServerError(c, "Failed to get sale", err)
 	}
 
 	// Ambil item pembelian terkait
-	var items []models.AllSaleItems
-	err = db.Table("sale_items sit").
-		Select("sit.id, sit.sale_id, sit.product_id, pro.name AS product_name, sit.price, sit.qty, un.name AS unit_name, sit.sub_total").
-		Joins("LEFT JOIN products pro ON pro.id = sit.product_id").
-		Joins("LEFT JOIN units un ON un.id = pro.unit_id").
-		Where("sit.sale_id = ?", saleID).
-		Order("pro.name ASC").
-		Scan(&items).Error
-
+	items, err := getSaleItems(db, saleID)
 	if err != nil {
 		return responses.InternalServerError(c, "Failed to get sale items", err)
 	}
@@ -846,6 +1346,7 @@ func GetSaleWithItems(c *framework.Ctx) error {
 		SaleDate:       formattedSaleDate, // Gunakan tanggal yang sudah diformat
 		TotalSale:      sale.TotalSale,
 		Discount:       sale.Discount,
+		TaxAmount:      sale.TaxAmount,
 		ProfitEstimate: sale.ProfitEstimate,
 		Payment:        string(sale.Payment),
 		Items:          items,
@@ -855,8 +1356,34 @@ func GetSaleWithItems(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Sale retrieved successfully", responseDetail)
 }
 
+// getSale mengambil data utama penjualan beserta nama member
+func getSale(db *gorm.DB, saleID string) (models.AllSales, error) {
+	var sale models.AllSales
+	err := db.Table("sales sl").
+		Select("sl.id, sl.member_id, mbr.name AS member_name, sl.sale_date, sl.discount, sl.tax_amount, sl.total_sale, sl.profit_estimate, sl.payment").
+		Joins("LEFT JOIN members mbr ON mbr.id = sl.member_id").
+		Where("sl.id = ?", saleID).
+		Scan(&sale).Error
+	return sale, err
+}
+
+// getSaleItems mengambil item penjualan beserta nama produk, satuan dan promo
+func getSaleItems(db *gorm.DB, saleID string) ([]models.AllSaleItems, error) {
+	var items []models.AllSaleItems
+	err := db.Table("sale_items sit").
+		Select("sit.id, sit.sale_id, sit.product_id, pro.name AS product_name, sit.price, sit.qty, sit.unit_id, un.name AS unit_name, sit.conv_value, sit.promo_id, prm.name AS promo_name, sit.promo_discount, sit.tax_amount, sit.sub_total").
+		Joins("LEFT JOIN products pro ON pro.id = sit.product_id").
+		Joins("LEFT JOIN units un ON un.id = sit.unit_id").
+		Joins("LEFT JOIN promos prm ON prm.id = sit.promo_id").
+		Where("sit.sale_id = ?", saleID).
+		Order("pro.name ASC").
+		Scan(&items).Error
+	return items, err
+}
+
 // Request body struct untuk transaksi penjualan
 type SaleTransactionRequest struct {
-	Sale      models.Sales       `json:"sale" validate:"required"`
-	SaleItems []models.SaleItems `json:"sale_items" validate:"required,min=1,dive"` // dive untuk validasi setiap item di slice
+	Sale      models.Sales          `json:"sale" validate:"required"`
+	SaleItems []models.SaleItems    `json:"sale_items" validate:"required,min=1,dive"` // dive untuk validasi setiap item di slice
+	Payments  []models.SalePayments `json:"payments" validate:"dive"`                  // Opsional, rincian pembayaran split tunai / bank
 }
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// GetSaleReceipt menampilkan struk penjualan siap cetak.
+// format=text (default) mengembalikan teks biasa, format=escpos menambahkan byte stream ESC/POS (base64)
+// untuk printer thermal. width=58 (default) atau 80 menentukan jumlah kolom struk.
+func GetSaleReceipt(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	defaultMember, _ := middlewares.GetClaimsToken(c.Request, "default_member")
+	saleID := c.Param("id")
+
+	format := strings.ToLower(strings.TrimSpace(c.Query("format")))
+	if format == "" {
+		format = "text"
+	}
+	if format != "text" && format != "escpos" {
+		return responses.BadRequest(c, "format harus text atau escpos", nil)
+	}
+
+	width := 58
+	if w := strings.TrimSpace(c.Query("width")); w != "" {
+		var err error
+		if width, err = strconv.Atoi(w); err != nil {
+			return responses.BadRequest(c, "width harus 58 atau 80", err)
+		}
+	}
+	columns, ok := tools.ReceiptColumns[width]
+	if !ok {
+		return responses.BadRequest(c, "width harus 58 atau 80", nil)
+	}
+
+	var sale models.Sales
+	if err := db.Where("id = ? AND branch_id = ?", saleID, branchID).First(&sale).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return responses.NotFound(c, "Sale not found")
+		}
+		return responses.InternalServerError(c, "Failed to get sale", err)
+	}
+
+	var branch models.Branch
+	if err := db.Select("id, branch_name, address, phone, tax_mode, receipt_header, receipt_footer").First(&branch, "id = ?", branchID).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get branch", err)
+	}
+
+	items, err := getSaleItems(db, saleID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to get sale items", err)
+	}
+
+	var payments []models.SalePayments
+	if err := db.Where("sale_id = ?", saleID).Order("created_at ASC, id ASC").Find(&payments).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get sale payments", err)
+	}
+
+	var cashier string
+	if err := db.Table("users").Select("name").Where("user_id = ?", sale.UserID).Scan(&cashier).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to get cashier", err)
+	}
+
+	// Pelanggan umum (default member) tidak dicetak
+	var memberName string
+	if sale.MemberId != defaultMember {
+		if err := db.Table("members").Select("name").Where("id = ?", sale.MemberId).Scan(&memberName).Error; err != nil {
+			return responses.InternalServerError(c, "Failed to get member", err)
+		}
+	}
+
+	data := models.SaleReceipt{
+		BranchName:   branch.BranchName,
+		Address:      branch.Address,
+		Phone:        branch.Phone,
+		Header:       branch.ReceiptHeader,
+		Footer:       branch.ReceiptFooter,
+		SaleID:       sale.ID,
+		SaleDate:     sale.SaleDate.In(utils.Location).Format("02-01-2006 15:04"),
+		Cashier:      cashier,
+		MemberName:   memberName,
+		Items:        items,
+		Discount:     sale.Discount,
+		TaxAmount:    sale.TaxAmount,
+		TaxMode:      branch.TaxMode,
+		PointsAmount: sale.PointsAmount,
+		Total:        sale.TotalSale,
+		Payment:      sale.Payment,
+		Payments:     payments,
+	}
+	for _, item := range items {
+		data.SubTotal += item.SubTotal
+	}
+
+	receipt := tools.BuildReceipt(data, columns)
+	response := models.SaleReceiptResponse{
+		Format:     format,
+		PaperWidth: width,
+		Columns:    columns,
+		Lines:      receipt.Lines(),
+		Text:       receipt.Text(),
+	}
+	if format == "escpos" {
+		response.EscPos = base64.StdEncoding.EncodeToString(receipt.EscPos())
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Sale receipt retrieved successfully", response)
+}
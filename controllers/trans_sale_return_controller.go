@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
 	"github.com/heru-oktafian/scafold/config"
 	"github.com/heru-oktafian/scafold/framework"
 	"github.com/heru-oktafian/scafold/helpers"
@@ -16,6 +17,7 @@ import (
 	"github.com/heru-oktafian/scafold/responses"
 	"github.com/heru-oktafian/scafold/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateSaleReturnTransaction adalah fungsi untuk membuat transaksi retur penjualan baru
@@ -33,6 +35,12 @@ func CreateSaleReturnTransaction(c *framework.Ctx) error {
 		return responses.JSONResponse(c, http.StatusBadRequest, "Body permintaan tidak valid", err.Error())
 	}
 
+	// Request ulang dengan Idempotency-Key yang sama mengembalikan respons pertama
+	idem, replayed, err := startIdempotency(c, "sale-returns", req)
+	if replayed {
+		return err
+	}
+
 	if req.SaleReturn.Payment == "" {
 		req.SaleReturn.Payment = "paid_by_cash"
 	}
@@ -72,9 +80,9 @@ func CreateSaleReturnTransaction(c *framework.Ctx) error {
 		}
 	}()
 
-	// Validasi apakah sale_id valid
+	// Validasi apakah sale_id valid, baris penjualan dikunci karena sisa piutangnya bisa berkurang
 	var sale models.Sales
-	err = tx.Where("id = ?", req.SaleReturn.SaleId).First(&sale).Error
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", req.SaleReturn.SaleId).First(&sale).Error
 	if err != nil {
 		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
@@ -95,9 +103,23 @@ func CreateSaleReturnTransaction(c *framework.Ctx) error {
 		UpdatedAt:  nowWIB,
 	}
 
-	var totalReturn int
+	// PPN retur dihitung per baris dengan pengaturan pajak cabang, seperti saat item dijual
+	taxBranch, err := tools.GetBranchTax(tx, branchID)
+	if err != nil {
+		tx.Rollback()
+		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengambil pengaturan pajak cabang", err.Error())
+	}
+
+	var totalReturn, totalTax int
 	var saleReturnItems []models.SaleReturnItems
 
+	stockRef := tools.StockRef{
+		MovementType: models.SaleReturnTrans,
+		ReferenceID:  saleReturnID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
+
 	for _, item := range req.SaleReturnItems {
 		parsedExpiredDate, err := time.Parse("2006-01-02", item.ExpiredDate)
 		if err != nil {
@@ -105,20 +127,34 @@ func CreateSaleReturnTransaction(c *framework.Ctx) error {
 			return responses.JSONResponse(c, http.StatusBadRequest, fmt.Sprintf("expired_date tidak valid untuk produk %s", item.ProductId), err.Error())
 		}
 
+		// Ambil informasi produk
+		var product models.Product
+		err = tx.Where("id = ?", item.ProductId).First(&product).Error
+		if err != nil {
+			tx.Rollback()
+			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal mengambil info produk untuk %s", item.ProductId), err.Error())
+		}
+
+		// Satuan retur mengikuti satuan jual item penjualan asal, kosong = satuan dasar produk
+		unitID := item.UnitId
+		if unitID == "" {
+			unitID = product.UnitId
+		}
+
 		// Validasi item berasal dari sale_id
-		// Ambil item penjualan untuk sale_id + product_id
+		// Ambil item penjualan untuk sale_id + product_id + satuan jual
 		var saleItem models.SaleItems
-		err = tx.Where("sale_id = ? AND product_id = ?", req.SaleReturn.SaleId, item.ProductId).First(&saleItem).Error
+		err = tx.Where("sale_id = ? AND product_id = ? AND unit_id = ?", req.SaleReturn.SaleId, item.ProductId, unitID).First(&saleItem).Error
 		if err != nil {
 			tx.Rollback()
-			return responses.JSONResponse(c, http.StatusBadRequest, fmt.Sprintf("Produk %s tidak ditemukan pada penjualan asal", item.ProductId), err.Error())
+			return responses.JSONResponse(c, http.StatusBadRequest, fmt.Sprintf("Produk %s dengan satuan %s tidak ditemukan pada penjualan asal", item.ProductId, unitID), err.Error())
 		}
 
-		// Ambil total qty yang sudah diretur sebelumnya
+		// Ambil total qty (dalam satuan jual yang sama) yang sudah diretur sebelumnya
 		var totalReturnedQty int64
 		err = tx.Model(&models.SaleReturnItems{}).
 			Select("COALESCE(SUM(qty), 0)").
-			Where("product_id = ? AND sale_return_id IN (SELECT id FROM sale_returns WHERE sale_id = ?)", item.ProductId, req.SaleReturn.SaleId).
+			Where("product_id = ? AND unit_id = ? AND sale_return_id IN (SELECT id FROM sale_returns WHERE sale_id = ?)", item.ProductId, unitID, req.SaleReturn.SaleId).
 			Scan(&totalReturnedQty).Error
 
 		if err != nil {
@@ -133,40 +169,80 @@ func CreateSaleReturnTransaction(c *framework.Ctx) error {
 				item.ProductId, saleItem.Qty, totalReturnedQty, item.Qty), nil)
 		}
 
-		// Ambil informasi produk
-		var product models.Product
-		err = tx.Where("id = ?", item.ProductId).First(&product).Error
+		// Stok, batch dan harga pokok dalam satuan dasar
+		convValue := max(saleItem.ConvValue, 1)
+		actualQtyToReduce := item.Qty * convValue
+
+		// Update stok secara atomik dan catat mutasinya ke stock_tracks
+		_, _, err = tools.ChangeProductStock(tx, item.ProductId, actualQtyToReduce, stockRef)
 		if err != nil {
 			tx.Rollback()
-			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal mengambil info produk untuk %s", item.ProductId), err.Error())
+			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal memperbarui stok untuk produk %s", item.ProductId), err.Error())
 		}
 
-		actualQtyToReduce := item.Qty
-
-		// Update stok
-		err = tx.Model(&models.Product{}).Where("id = ?", item.ProductId).
-			Update("stock", gorm.Expr("stock + ?", actualQtyToReduce)).Error
+		// Kembalikan qty ke batch yang dipakai item penjualan asal,
+		// sisanya (penjualan sebelum ada batch) dibuatkan batch baru sesuai expired_date retur
+		returnItemID := helpers.GenerateID("SRI")
+		remainingQty, err := tools.RestoreProductBatches(tx, branchID, item.ProductId, saleItem.ID, actualQtyToReduce)
+		if err == nil {
+			err = tools.AddProductBatch(tx, branchID, item.ProductId, returnItemID, "", parsedExpiredDate, remainingQty)
+		}
 		if err != nil {
 			tx.Rollback()
-			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal memperbarui stok untuk produk %s", item.ProductId), err.Error())
+			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal mengembalikan batch untuk produk %s", item.ProductId), err.Error())
+		}
+
+		// Barang kembali masuk dengan harga pokok saat terjual
+		returnCost := saleItem.CostPrice
+		if returnCost == 0 {
+			returnCost = product.PurchasePrice
+		}
+		if err = tools.ApplyReceiptCost(tx, stockRef, item.ProductId, returnItemID, actualQtyToReduce, returnCost); err != nil {
+			tx.Rollback()
+			return responses.JSONResponse(c, http.StatusInternalServerError, fmt.Sprintf("Gagal memperbarui harga pokok untuk produk %s", item.ProductId), err.Error())
+		}
+
+		// Harga retur memakai harga bersih setelah potongan promo
+		returnPrice := saleItem.Price
+		if saleItem.PromoDiscount > 0 {
+			returnPrice = saleItem.SubTotal / saleItem.Qty
 		}
 
-		subTotal := saleItem.Price * item.Qty
+		subTotal := returnPrice * item.Qty
+		lineTax := taxBranch.LineTax(subTotal, product.TaxExempt)
 		totalReturn += subTotal
+		totalTax += lineTax
 
 		saleReturnItems = append(saleReturnItems, models.SaleReturnItems{
-			ID:           helpers.GenerateID("SRI"),
+			ID:           returnItemID,
 			SaleReturnId: saleReturnID,
+			SaleItemId:   saleItem.ID,
 			ProductId:    item.ProductId,
-			Price:        saleItem.Price,
+			UnitId:       saleItem.UnitId,
+			ConvValue:    convValue,
+			Price:        returnPrice,
 			Qty:          item.Qty,
 			SubTotal:     subTotal,
+			TaxAmount:    lineTax,
 			ExpiredDate:  parsedExpiredDate,
 		})
 
 	}
 
+	// Pada mode exclusive harga item belum termasuk PPN, PPN ikut dikembalikan ke pembeli
+	if taxBranch.TaxMode != models.TaxInclusive {
+		totalReturn += totalTax
+	}
 	saleReturn.TotalReturn = totalReturn
+	saleReturn.TaxAmount = totalTax
+
+	// Retur penjualan kredit mengurangi sisa piutang lebih dulu, kelebihannya dikembalikan sesuai payment retur
+	if sale.Payment == models.PaidByCredit {
+		saleReturn.CreditAmount = min(totalReturn, max(tools.SaleOutstanding(sale), 0))
+		if saleReturn.CreditAmount == totalReturn {
+			saleReturn.Payment = models.PaidByCredit
+		}
+	}
 
 	err = tx.Create(&saleReturn).Error
 	if err != nil {
@@ -174,20 +250,33 @@ func CreateSaleReturnTransaction(c *framework.Ctx) error {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal membuat retur penjualan", err.Error())
 	}
 
+	if saleReturn.CreditAmount > 0 {
+		err = tx.Model(&models.Sales{}).Where("id = ?", sale.ID).
+			Update("returned_amount", gorm.Expr("returned_amount + ?", saleReturn.CreditAmount)).Error
+		if err != nil {
+			tx.Rollback()
+			return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengurangi piutang penjualan", err.Error())
+		}
+	}
+
 	err = tx.CreateInBatches(&saleReturnItems, len(saleReturnItems)).Error
 	if err != nil {
 		tx.Rollback()
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal membuat item retur penjualan", err.Error())
 	}
 
-	// Tambahkan ke laporan transaksi
+	// Tambahkan ke laporan transaksi, bagian yang mengurangi piutang bukan uang keluar
+	reportTotal := saleReturn.TotalReturn
+	if saleReturn.Payment != models.PaidByCredit {
+		reportTotal -= saleReturn.CreditAmount
+	}
 	transactionReportID := helpers.GenerateID("TRX")
 	transactionReport := models.TransactionReports{
 		ID:              transactionReportID,
 		TransactionType: models.SaleReturn,
 		UserID:          userID,
 		BranchID:        branchID,
-		Total:           saleReturn.TotalReturn,
+		Total:           reportTotal,
 		Payment:         saleReturn.Payment,
 		CreatedAt:       nowWIB,
 		UpdatedAt:       nowWIB,
@@ -198,6 +287,35 @@ func CreateSaleReturnTransaction(c *framework.Ctx) error {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal membuat laporan transaksi retur penjualan", err.Error())
 	}
 
+	// Jurnal retur penjualan
+	if err = tools.SyncJournal(tx, models.JournalSaleReturn, saleReturn.ID); err != nil {
+		tx.Rollback()
+		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal membuat jurnal retur penjualan", err.Error())
+	}
+
+	// Tarik kembali poin member sebanding dengan nilai barang yang diretur
+	var earnedPoints int
+	err = tx.Model(&models.MemberPoints{}).
+		Select("COALESCE(SUM(points), 0)").
+		Where("member_id = ? AND reference_id = ? AND point_type = ?", sale.MemberId, sale.ID, models.PointEarn).
+		Scan(&earnedPoints).Error
+	if err != nil {
+		tx.Rollback()
+		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengambil poin penjualan asal", err.Error())
+	}
+	if saleGross := sale.TotalSale + sale.Discount; earnedPoints > 0 && saleGross > 0 {
+		_, err = tools.ReverseMemberPoints(tx, sale.MemberId, sale.ID, earnedPoints*totalReturn/saleGross, tools.PointRef{
+			ReferenceID: saleReturnID,
+			Reason:      "Penarikan poin karena retur penjualan",
+			UserID:      userID,
+			BranchID:    branchID,
+		})
+		if err != nil {
+			tx.Rollback()
+			return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal menarik poin member", err.Error())
+		}
+	}
+
 	// Kurangi kuota jika berlangganan quota
 	if subscriptionType == "quota" {
 		var branch models.Branch
@@ -220,19 +338,26 @@ func CreateSaleReturnTransaction(c *framework.Ctx) error {
 		}
 	}
 
+	response := framework.Map{
+		"id":            saleReturn.ID,
+		"sale_id":       saleReturn.SaleId,
+		"return_date":   utils.FormatIndonesianDate(saleReturn.ReturnDate),
+		"total_return":  saleReturn.TotalReturn,
+		"credit_amount": saleReturn.CreditAmount,
+		"payment":       saleReturn.Payment,
+		"items":         saleReturnItems,
+	}
+
+	if replayed, err := finishIdempotency(c, tx, idem, http.StatusOK, "Transaksi retur penjualan berhasil dibuat", response); replayed {
+		return err
+	}
+
 	err = tx.Commit().Error
 	if err != nil {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal melakukan commit transaksi", err.Error())
 	}
 
-	return responses.JSONResponse(c, http.StatusOK, "Transaksi retur penjualan berhasil dibuat", framework.Map{
-		"id":           saleReturn.ID,
-		"sale_id":      saleReturn.SaleId,
-		"return_date":  utils.FormatIndonesianDate(saleReturn.ReturnDate),
-		"total_return": saleReturn.TotalReturn,
-		"payment":      saleReturn.Payment,
-		"items":        saleReturnItems,
-	})
+	return responses.JSONResponse(c, http.StatusOK, "Transaksi retur penjualan berhasil dibuat", response)
 }
 
 // GetSaleItemsForReturn digunakan untuk mengambil item penjualan yang bisa diretur
@@ -243,12 +368,13 @@ func GetSaleItemsForReturn(c *framework.Ctx) error {
 	}
 
 	var results []struct {
-		ProID    string `json:"pro_id"`
-		ProName  string `json:"pro_name"`
-		Stock    int    `json:"stock"`
-		UnitID   string `json:"unit_id"`
-		UnitName string `json:"unit_name"`
-		Price    int    `json:"price"`
+		ProID     string `json:"pro_id"`
+		ProName   string `json:"pro_name"`
+		Stock     int    `json:"stock"`
+		UnitID    string `json:"unit_id"`
+		UnitName  string `json:"unit_name"`
+		ConvValue int    `json:"conv_value"`
+		Price     int    `json:"price"`
 	}
 
 	err := config.DB.Raw(`
@@ -256,21 +382,23 @@ func GetSaleItemsForReturn(c *framework.Ctx) error {
             A.product_id AS pro_id,
             B.name AS pro_name,
             A.qty AS stock,
-            B.unit_id,
+            A.unit_id,
             C.name AS unit_name,
-            A.price
+            A.conv_value,
+            A.sub_total / A.qty AS price
         FROM sale_items A
         LEFT JOIN products B ON B.id = A.product_id
-        LEFT JOIN units C ON C.id = B.unit_id
+        LEFT JOIN units C ON C.id = A.unit_id
         LEFT JOIN (
             SELECT 
                 sri.product_id, 
+                sri.unit_id,
                 SUM(sri.qty) AS total_returned
             FROM sale_return_items sri
             INNER JOIN sale_returns sr ON sri.sale_return_id = sr.id
             WHERE sr.sale_id = ?
-            GROUP BY sri.product_id
-        ) R ON R.product_id = A.product_id
+            GROUP BY sri.product_id, sri.unit_id
+        ) R ON R.product_id = A.product_id AND R.unit_id = A.unit_id
         WHERE A.sale_id = ? 
         AND COALESCE(R.total_returned, 0) < A.qty
     `, saleId, saleId).Scan(&results).Error
@@ -293,27 +421,13 @@ func GetSaleReturnWithItems(c *framework.Ctx) error {
 	saleReturnID := c.Param("id")
 
 	// Gunakan models.AllSaleReturns untuk mengambil data dari DB
-	var saleReturn models.AllSaleReturns
-
-	err := db.Table("sale_returns A").
-		Select("A.id, A.sale_id, A.return_date, A.payment, A.total_return").
-		Where("A.id = ?", saleReturnID).
-		Scan(&saleReturn).Error
-
+	saleReturn, err := getSaleReturn(db, saleReturnID)
 	if err != nil {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengambil data retur penjualan", err.Error())
 	}
 
 	// Ambil item retur penjualan terkait
-	var items []models.AllSaleReturnItems
-	err = db.Table("sale_return_items A").
-		Select("A.id, A.sale_return_id, A.product_id AS pro_id, B.name AS pro_name, B.unit_id, C.name AS unit_name, A.qty, A.price, A.sub_total, A.expired_date").
-		Joins("LEFT JOIN products B on B.id=A.product_id").
-		Joins("LEFT JOIN units C on C.id=B.unit_id").
-		Where("A.sale_return_id = ?", saleReturnID).
-		Order("B.name ASC").
-		Scan(&items).Error
-
+	items, err := getSaleReturnItems(db, saleReturnID)
 	if err != nil {
 		return responses.JSONResponse(c, http.StatusInternalServerError, "Gagal mengambil item retur penjualan", err.Error())
 	}
@@ -335,6 +449,29 @@ func GetSaleReturnWithItems(c *framework.Ctx) error {
 	return responses.JSONResponse(c, http.StatusOK, "Retur penjualan berhasil diambil", responseDetail)
 }
 
+// getSaleReturn mengambil data utama retur penjualan
+func getSaleReturn(db *gorm.DB, saleReturnID string) (models.AllSaleReturns, error) {
+	var saleReturn models.AllSaleReturns
+	err := db.Table("sale_returns A").
+		Select("A.id, A.sale_id, A.return_date, A.payment, A.total_return").
+		Where("A.id = ?", saleReturnID).
+		Scan(&saleReturn).Error
+	return saleReturn, err
+}
+
+// getSaleReturnItems mengambil item retur penjualan beserta nama produk dan satuan
+func getSaleReturnItems(db *gorm.DB, saleReturnID string) ([]models.AllSaleReturnItems, error) {
+	var items []models.AllSaleReturnItems
+	err := db.Table("sale_return_items A").
+		Select("A.id, A.sale_return_id, A.product_id AS pro_id, B.name AS pro_name, A.unit_id, C.name AS unit_name, A.conv_value, A.qty, A.price, A.sub_total, A.expired_date").
+		Joins("LEFT JOIN products B on B.id=A.product_id").
+		Joins("LEFT JOIN units C on C.id=A.unit_id").
+		Where("A.sale_return_id = ?", saleReturnID).
+		Order("B.name ASC").
+		Scan(&items).Error
+	return items, err
+}
+
 // GetAllSaleReturns menampilkan semua retur penjualan
 func GetAllSaleReturns(c *framework.Ctx) error {
 	// Hitung waktu sekarang dalam WIB
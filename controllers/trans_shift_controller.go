@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// OpenShift membuka shift kasir dengan modal awal laci kas
+func OpenShift(c *framework.Ctx) error {
+	db := config.DB
+	nowWIB := time.Now().In(utils.Location)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	var input models.OpenShiftInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for shift input", err)
+	}
+
+	// Satu kasir hanya boleh punya satu shift terbuka per cabang
+	if _, err := tools.GetOpenShift(db, userID, branchID); err == nil {
+		return responses.BadRequest(c, "Masih ada shift yang belum ditutup", nil)
+	} else if err != gorm.ErrRecordNotFound {
+		return responses.InternalServerError(c, "Failed to check open shift", err)
+	}
+
+	shift := models.CashierShifts{
+		ID:           helpers.GenerateID("SHF"),
+		UserID:       userID,
+		BranchID:     branchID,
+		Status:       models.ShiftOpen,
+		OpeningCash:  input.OpeningCash,
+		ExpectedCash: input.OpeningCash,
+		OpenedAt:     nowWIB,
+		CreatedAt:    nowWIB,
+		UpdatedAt:    nowWIB,
+	}
+
+	if err := db.Create(&shift).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to open shift", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Shift opened successfully", shift)
+}
+
+// CloseShift menutup shift kasir yang sedang terbuka, menghitung kas seharusnya dan selisih kas fisik
+func CloseShift(c *framework.Ctx) error {
+	db := config.DB
+	nowWIB := time.Now().In(utils.Location)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	var input models.CloseShiftInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for shift input", err)
+	}
+
+	shift, err := tools.GetOpenShift(db, userID, branchID)
+	if err == gorm.ErrRecordNotFound {
+		return responses.NotFound(c, "Tidak ada shift yang sedang terbuka")
+	} else if err != nil {
+		return responses.InternalServerError(c, "Failed to get open shift", err)
+	}
+
+	expectedCash, err := tools.ShiftExpectedCash(db, shift, nowWIB)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to calculate expected cash", err)
+	}
+
+	shift.Status = models.ShiftClosed
+	shift.ClosedAt = &nowWIB
+	shift.ExpectedCash = expectedCash
+	shift.CountedCash = input.CountedCash
+	shift.Variance = input.CountedCash - expectedCash
+	shift.Notes = input.Notes
+	shift.UpdatedAt = nowWIB
+
+	// Shift hanya tertutup jika Z-report berhasil dibuat
+	tx := db.Begin()
+	if err := tx.Save(&shift).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to close shift", err)
+	}
+
+	report, err := buildZReport(tx, shift.ID, branchID)
+	if err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to build Z-report", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit database transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Shift closed successfully", report)
+}
+
+// GetCurrentShift menampilkan shift kasir yang sedang terbuka beserta kas seharusnya saat ini
+func GetCurrentShift(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	shift, err := tools.GetOpenShift(db, userID, branchID)
+	if err == gorm.ErrRecordNotFound {
+		return responses.NotFound(c, "Tidak ada shift yang sedang terbuka")
+	} else if err != nil {
+		return responses.InternalServerError(c, "Failed to get open shift", err)
+	}
+
+	report, err := buildZReport(db, shift.ID, branchID)
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to build shift summary", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Current shift retrieved successfully", report)
+}
+
+// GetShiftZReport menampilkan laporan akhir shift (Z-report) siap cetak
+func GetShiftZReport(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	report, err := buildZReport(config.DB, id, branchID)
+	if err == gorm.ErrRecordNotFound {
+		return responses.NotFound(c, "Shift not found")
+	} else if err != nil {
+		return responses.InternalServerError(c, "Failed to build Z-report", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Z-report retrieved successfully", report)
+}
+
+// GetAllShifts menampilkan riwayat shift kasir dengan pagination dan filter bulan
+func GetAllShifts(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	nowWIB := time.Now().In(utils.Location)
+
+	search := strings.TrimSpace(c.Query("search"))
+	status := strings.TrimSpace(c.Query("status"))
+	month := strings.TrimSpace(c.Query("month"))
+
+	// Jika month kosong, isi dengan bulan ini (format YYYY-MM)
+	if month == "" {
+		month = nowWIB.Format("2006-01")
+	}
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	startDate, err := time.ParseInLocation("2006-01", month, utils.Location)
+	if err != nil {
+		return responses.BadRequest(c, "Invalid month format", err)
+	}
+	endDate := startDate.AddDate(0, 1, 0)
+
+	query := shiftQuery(config.DB, branchID).
+		Where("shf.opened_at >= ? AND shf.opened_at < ?", startDate, endDate)
+
+	if status != "" {
+		query = query.Where("shf.status = ?", status)
+	}
+
+	if search != "" {
+		query = query.Where("LOWER(usr.name) LIKE ?", "%"+strings.ToLower(search)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Get shifts failed", err)
+	}
+
+	var shifts []models.AllCashierShifts
+	if err := query.Order("shf.opened_at DESC").Offset(offset).Limit(limit).Scan(&shifts).Error; err != nil {
+		return responses.InternalServerError(c, "Get shifts failed", err)
+	}
+
+	formattedShifts := make([]models.ShiftResponse, 0, len(shifts))
+	for _, shift := range shifts {
+		formattedShifts = append(formattedShifts, formatShift(shift))
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Shifts retrieved successfully", search, int(total), page, totalPages, limit, formattedShifts)
+}
+
+// shiftQuery query dasar shift beserta nama kasir
+func shiftQuery(db *gorm.DB, branchID string) *gorm.DB {
+	return db.Table("cashier_shifts shf").
+		Select("shf.id, shf.user_id, usr.name AS user_name, shf.branch_id, shf.status, shf.opening_cash, shf.expected_cash, shf.counted_cash, shf.variance, shf.notes, shf.opened_at, shf.closed_at").
+		Joins("LEFT JOIN users usr ON usr.user_id = shf.user_id").
+		Where("shf.branch_id = ?", branchID)
+}
+
+// formatShift mengubah data shift hasil query menjadi respons dengan tanggal terformat
+func formatShift(shift models.AllCashierShifts) models.ShiftResponse {
+	formatted := models.ShiftResponse{
+		ID:           shift.ID,
+		UserID:       shift.UserID,
+		UserName:     shift.UserName,
+		Status:       string(shift.Status),
+		OpeningCash:  shift.OpeningCash,
+		ExpectedCash: shift.ExpectedCash,
+		CountedCash:  shift.CountedCash,
+		Variance:     shift.Variance,
+		Notes:        shift.Notes,
+		OpenedAt:     shift.OpenedAt.In(utils.Location).Format("02-01-2006 15:04"),
+	}
+	if shift.ClosedAt != nil {
+		formatted.ClosedAt = shift.ClosedAt.In(utils.Location).Format("02-01-2006 15:04")
+	}
+	return formatted
+}
+
+// buildZReport menyusun laporan shift: penjualan per metode pembayaran, arus kas tunai dan rekonsiliasi laci kas.
+// Shift yang masih terbuka dihitung sampai saat ini.
+func buildZReport(db *gorm.DB, shiftID string, branchID string) (models.ShiftZReport, error) {
+	var report models.ShiftZReport
+	nowWIB := time.Now().In(utils.Location)
+
+	var row models.AllCashierShifts
+	if err := shiftQuery(db, branchID).Where("shf.id = ?", shiftID).Scan(&row).Error; err != nil {
+		return report, err
+	}
+	if row.ID == "" {
+		return report, gorm.ErrRecordNotFound
+	}
+
+	shift := models.CashierShifts{
+		ID:          row.ID,
+		UserID:      row.UserID,
+		BranchID:    row.BranchID,
+		Status:      row.Status,
+		OpeningCash: row.OpeningCash,
+		OpenedAt:    row.OpenedAt,
+		ClosedAt:    row.ClosedAt,
+	}
+
+	var err error
+	report.SalesByPayment, err = tools.ShiftSalesByPayment(db, shift, nowWIB)
+	if err != nil {
+		return report, err
+	}
+	for _, sale := range report.SalesByPayment {
+		report.TotalSales += sale.Total
+	}
+
+	report.CashFlows, report.CashIn, report.CashOut, err = tools.ShiftCashFlows(db, shift, nowWIB)
+	if err != nil {
+		return report, err
+	}
+
+	// Shift terbuka belum punya kas seharusnya tersimpan, hitung dari transaksi sampai saat ini
+	if row.Status == models.ShiftOpen {
+		row.ExpectedCash = row.OpeningCash + report.CashIn - report.CashOut
+	}
+
+	var branch models.Branch
+	if err := db.Select("id, branch_name").First(&branch, "id = ?", branchID).Error; err != nil {
+		return report, err
+	}
+
+	report.Shift = formatShift(row)
+	report.BranchName = branch.BranchName
+	report.PrintLines = zReportLines(report)
+
+	return report, nil
+}
+
+// zReportLines menyusun baris teks Z-report untuk dicetak di printer struk 32 kolom
+func zReportLines(report models.ShiftZReport) []string {
+	separator := strings.Repeat("-", 32)
+	row := func(label string, value int) string {
+		return fmt.Sprintf("%-18s%14d", label, value)
+	}
+
+	lines := []string{
+		report.BranchName,
+		"Z-REPORT " + report.Shift.ID,
+		"Kasir : " + report.Shift.UserName,
+		"Buka  : " + report.Shift.OpenedAt,
+	}
+	if report.Shift.ClosedAt != "" {
+		lines = append(lines, "Tutup : "+report.Shift.ClosedAt)
+	}
+
+	lines = append(lines, separator, "PENJUALAN PER PEMBAYARAN")
+	for _, sale := range report.SalesByPayment {
+		lines = append(lines, row(fmt.Sprintf("%s (%d)", sale.Payment, sale.Count), sale.Total))
+	}
+	lines = append(lines, row("Total Penjualan", report.TotalSales))
+
+	lines = append(lines, separator, "ARUS KAS TUNAI")
+	for _, flow := range report.CashFlows {
+		total := flow.Total
+		if flow.Direction == "out" {
+			total = -total
+		}
+		lines = append(lines, row(fmt.Sprintf("%s (%d)", flow.TransactionType, flow.Count), total))
+	}
+
+	lines = append(lines,
+		separator,
+		row("Modal Awal", report.Shift.OpeningCash),
+		row("Kas Masuk", report.CashIn),
+		row("Kas Keluar", -report.CashOut),
+		row("Kas Seharusnya", report.Shift.ExpectedCash),
+	)
+	if report.Shift.Status == string(models.ShiftClosed) {
+		lines = append(lines,
+			row("Kas Fisik", report.Shift.CountedCash),
+			row("Selisih", report.Shift.Variance),
+		)
+	}
+	lines = append(lines, separator)
+
+	return lines
+}
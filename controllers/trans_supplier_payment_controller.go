@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateSupplierPayment mencatat pembayaran (boleh sebagian) atas pembelian paid_by_credit.
+// Pembayaran masuk ke transaction_reports pada tanggal bayar. Pembelian tetap paid_by_credit
+// setelah lunas agar kas keluarnya hanya tercatat dari pembayaran hutang, lunas dilihat dari paid_amount.
+func CreateSupplierPayment(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	db := config.DB
+	var input models.SupplierPaymentInput
+	if err := c.BodyParser(&input); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return responses.BadRequest(c, "Validation failed for supplier payment input", err)
+	}
+
+	paymentDate := nowWIB
+	if input.PaymentDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", input.PaymentDate)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid payment_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		paymentDate = parsedDate
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Baris pembelian dikunci sampai commit agar dua pembayaran bersamaan tidak melebihi sisa hutang
+	var purchase models.Purchases
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND branch_id = ?", input.PurchaseId, branchID).
+		First(&purchase).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return responses.NotFound(c, fmt.Sprintf("Purchase with ID %s not found", input.PurchaseId))
+		}
+		return responses.InternalServerError(c, "Failed to retrieve purchase", err)
+	}
+	if purchase.Payment != models.PaidByCredit {
+		tx.Rollback()
+		return responses.BadRequest(c, "Pembelian ini bukan pembelian kredit", nil)
+	}
+
+	outstanding := purchase.TotalPurchase - purchase.PaidAmount
+	if outstanding <= 0 {
+		tx.Rollback()
+		return responses.BadRequest(c, "Hutang pembelian ini sudah lunas", nil)
+	}
+	if input.Amount > outstanding {
+		tx.Rollback()
+		return responses.BadRequest(c, fmt.Sprintf("Jumlah pembayaran melebihi sisa hutang. Sisa: %d, Dibayar: %d", outstanding, input.Amount), nil)
+	}
+
+	supplierPayment := models.SupplierPayments{
+		ID:          helpers.GenerateID("SPY"),
+		PurchaseId:  purchase.ID,
+		SupplierId:  purchase.SupplierId,
+		PaymentDate: paymentDate,
+		Amount:      input.Amount,
+		Payment:     input.Payment,
+		Description: input.Description,
+		BranchID:    branchID,
+		UserID:      userID,
+		CreatedAt:   nowWIB,
+	}
+	if err := tx.Create(&supplierPayment).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create supplier payment", err)
+	}
+
+	if err := tx.Model(&purchase).Updates(map[string]interface{}{
+		"paid_amount": purchase.PaidAmount + input.Amount,
+		"updated_at":  nowWIB,
+	}).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to update purchase", err)
+	}
+
+	// Kas berkurang pada tanggal pembayaran hutang
+	transactionReport := models.TransactionReports{
+		ID:              supplierPayment.ID,
+		TransactionType: models.PayablePayment,
+		UserID:          userID,
+		BranchID:        branchID,
+		Total:           supplierPayment.Amount,
+		Payment:         supplierPayment.Payment,
+		CreatedAt:       paymentDate,
+		UpdatedAt:       nowWIB,
+	}
+	if err := tx.Create(&transactionReport).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transaction report for supplier payment", err)
+	}
+
+	if err := tools.SyncJournal(tx, models.JournalPayablePayment, supplierPayment.ID); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create journal for supplier payment", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Supplier payment created successfully", framework.Map{
+		"supplier_payment": supplierPayment,
+		"purchase":         purchase,
+		"outstanding":      outstanding - input.Amount,
+	})
+}
+
+// GetAllSupplierPayments menampilkan pembayaran hutang per bulan, bisa difilter per pembelian atau supplier
+func GetAllSupplierPayments(c *framework.Ctx) error {
+	// Hitung waktu sekarang dalam WIB
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	// Ambil parameter page dan search dari query URL
+	pageParam := c.Query("page")
+	search := strings.TrimSpace(c.Query("search"))
+	purchaseID := strings.TrimSpace(c.Query("purchase_id"))
+	supplierID := strings.TrimSpace(c.Query("supplier_id"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	month := strings.TrimSpace(c.Query("month"))
+
+	// Jika month kosong, isi dengan bulan ini (format YYYY-MM)
+	if month == "" {
+		month = nowWIB.Format("2006-01")
+	}
+
+	var payments []models.AllSupplierPayments
+	var total int64
+
+	query := config.DB.Table("supplier_payments spy").
+		Select("spy.id, spy.purchase_id, spy.supplier_id, sup.name AS supplier_name, spy.payment_date, spy.amount, spy.payment, spy.description").
+		Joins("LEFT JOIN suppliers sup ON sup.id = spy.supplier_id").
+		Where("spy.branch_id = ?", branchID)
+
+	// Filter per pembelian menampilkan seluruh riwayat tanpa batas bulan
+	if purchaseID != "" {
+		query = query.Where("spy.purchase_id = ?", purchaseID)
+	} else {
+		startDate, err := time.Parse("2006-01", month)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid month format", err)
+		}
+		endDate := startDate.AddDate(0, 1, 0)
+		query = query.Where("spy.payment_date >= ? AND spy.payment_date < ?", startDate, endDate)
+	}
+
+	if supplierID != "" {
+		query = query.Where("spy.supplier_id = ?", supplierID)
+	}
+
+	if search != "" {
+		search = strings.ToLower(search)
+		query = query.Where("LOWER(sup.name) LIKE ?", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Get supplier payments failed", err)
+	}
+
+	if err := query.Order("spy.payment_date DESC, spy.created_at DESC").Offset(offset).Limit(limit).Scan(&payments).Error; err != nil {
+		return responses.InternalServerError(c, "Get supplier payments failed", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	var formattedPayments []models.SupplierPaymentResponse
+	for _, payment := range payments {
+		formattedPayments = append(formattedPayments, models.SupplierPaymentResponse{
+			ID:           payment.ID,
+			PurchaseId:   payment.PurchaseId,
+			SupplierId:   payment.SupplierId,
+			SupplierName: payment.SupplierName,
+			PaymentDate:  utils.FormatIndonesianDate(payment.PaymentDate),
+			Amount:       payment.Amount,
+			Payment:      string(payment.Payment),
+			Description:  payment.Description,
+		})
+	}
+
+	return responses.JSONResponseGetAll(c, http.StatusOK, "Supplier payments retrieved successfully", search, int(total), page, totalPages, limit, formattedPayments)
+}
+
+// GetPayables menampilkan seluruh pembelian kredit yang belum lunas, diurutkan dari jatuh tempo terdekat
+func GetPayables(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	supplierID := strings.TrimSpace(c.Query("supplier_id"))
+
+	payables, err := getOutstandingPayables(config.DB, branchID, supplierID)
+	if err != nil {
+		return responses.InternalServerError(c, "Get payables failed", err)
+	}
+
+	today := time.Now().In(utils.Location)
+	var formattedPayables []models.PayableResponse
+	totalOutstanding := 0
+	for _, payable := range payables {
+		formatted := models.PayableResponse{
+			PurchaseId:    payable.PurchaseId,
+			SupplierId:    payable.SupplierId,
+			SupplierName:  payable.SupplierName,
+			PurchaseDate:  utils.FormatIndonesianDate(payable.PurchaseDate),
+			TotalPurchase: payable.TotalPurchase,
+			PaidAmount:    payable.PaidAmount,
+			Outstanding:   payable.TotalPurchase - payable.PaidAmount,
+			DaysOverdue:   payableDaysOverdue(payable, today),
+		}
+		if payable.DueDate != nil {
+			formatted.DueDate = utils.FormatIndonesianDate(*payable.DueDate)
+		}
+		formattedPayables = append(formattedPayables, formatted)
+		totalOutstanding += formatted.Outstanding
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Payables retrieved successfully", framework.Map{
+		"payables":          formattedPayables,
+		"total_outstanding": totalOutstanding,
+	})
+}
+
+// GetPayableAging menampilkan umur hutang per supplier: belum jatuh tempo, 1-30, 31-60, 61-90 dan lebih dari 90 hari
+func GetPayableAging(c *framework.Ctx) error {
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	payables, err := getOutstandingPayables(config.DB, branchID, "")
+	if err != nil {
+		return responses.InternalServerError(c, "Get payable aging failed", err)
+	}
+
+	today := time.Now().In(utils.Location)
+	agingMap := make(map[string]*models.PayableAgingResponse)
+	var totals models.PayableAgingResponse
+	for _, payable := range payables {
+		aging, ok := agingMap[payable.SupplierId]
+		if !ok {
+			aging = &models.PayableAgingResponse{
+				SupplierId:   payable.SupplierId,
+				SupplierName: payable.SupplierName,
+			}
+			agingMap[payable.SupplierId] = aging
+		}
+
+		outstanding := payable.TotalPurchase - payable.PaidAmount
+		for _, bucket := range []*models.PayableAgingResponse{aging, &totals} {
+			switch days := payableDaysOverdue(payable, today); {
+			case days <= 0:
+				bucket.Current += outstanding
+			case days <= 30:
+				bucket.Days1To30 += outstanding
+			case days <= 60:
+				bucket.Days31To60 += outstanding
+			case days <= 90:
+				bucket.Days61To90 += outstanding
+			default:
+				bucket.Over90 += outstanding
+			}
+			bucket.Total += outstanding
+		}
+	}
+
+	var agingList []models.PayableAgingResponse
+	for _, aging := range agingMap {
+		agingList = append(agingList, *aging)
+	}
+	sort.Slice(agingList, func(i, j int) bool {
+		return agingList[i].SupplierName < agingList[j].SupplierName
+	})
+
+	return responses.JSONResponse(c, http.StatusOK, "Payable aging retrieved successfully", framework.Map{
+		"suppliers": agingList,
+		"total":     totals,
+	})
+}
+
+// getOutstandingPayables mengambil pembelian paid_by_credit yang masih memiliki sisa hutang
+func getOutstandingPayables(db *gorm.DB, branchID string, supplierID string) ([]models.PayableDB, error) {
+	var payables []models.PayableDB
+	query := db.Table("purchases pur").
+		Select("pur.id AS purchase_id, pur.supplier_id, sup.name AS supplier_name, pur.purchase_date, pur.due_date, pur.total_purchase, pur.paid_amount").
+		Joins("LEFT JOIN suppliers sup ON sup.id = pur.supplier_id").
+		Where("pur.branch_id = ? AND pur.payment = ? AND pur.total_purchase > pur.paid_amount", branchID, models.PaidByCredit)
+
+	if supplierID != "" {
+		query = query.Where("pur.supplier_id = ?", supplierID)
+	}
+
+	err := query.Order("COALESCE(pur.due_date, pur.purchase_date) ASC").Scan(&payables).Error
+	return payables, err
+}
+
+// payableDaysOverdue menghitung jumlah hari lewat jatuh tempo, negatif jika belum jatuh tempo
+func payableDaysOverdue(payable models.PayableDB, today time.Time) int {
+	dueDate := payable.PurchaseDate
+	if payable.DueDate != nil {
+		dueDate = *payable.DueDate
+	}
+	return int(today.Sub(dueDate).Hours() / 24)
+}
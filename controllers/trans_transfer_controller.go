@@ -0,0 +1,607 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
+	"github.com/heru-oktafian/scafold/config"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"github.com/heru-oktafian/scafold/responses"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateTransfer membuat draft transfer stok ke cabang lain milik owner yang sama
+func CreateTransfer(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+
+	db := config.DB
+	var req models.StockTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		return responses.BadRequest(c, "Validation failed for transfer input", err)
+	}
+
+	if req.Transfer.ToBranchID == branchID {
+		return responses.BadRequest(c, "Cabang tujuan tidak boleh sama dengan cabang asal", nil)
+	}
+
+	// Pastikan cabang tujuan satu owner dengan cabang asal
+	var fromBranch, toBranch models.Branch
+	if err := db.First(&fromBranch, "id = ?", branchID).Error; err != nil {
+		return responses.NotFound(c, "Cabang asal tidak ditemukan")
+	}
+	if err := db.First(&toBranch, "id = ?", req.Transfer.ToBranchID).Error; err != nil {
+		return responses.NotFound(c, "Cabang tujuan tidak ditemukan")
+	}
+	if fromBranch.OwnerId == "" || fromBranch.OwnerId != toBranch.OwnerId {
+		return responses.Forbidden(c, "Transfer hanya bisa dilakukan antar cabang dengan owner yang sama")
+	}
+
+	transferDate := nowWIB
+	if req.Transfer.TransferDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", req.Transfer.TransferDate)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid transfer_date format. Please use `YYYY-MM-DD`.", err)
+		}
+		transferDate = parsedDate
+	}
+
+	transfer := models.StockTransfers{
+		ID:           helpers.GenerateID("TRF"),
+		TransferDate: transferDate,
+		FromBranchID: branchID,
+		ToBranchID:   toBranch.ID,
+		Description:  req.Transfer.Description,
+		Status:       models.TransferDraft,
+		UserID:       userID,
+		CreatedAt:    nowWIB,
+		UpdatedAt:    nowWIB,
+	}
+
+	var transferItems []models.StockTransferItems
+	for _, reqItem := range req.TransferItems {
+		var product models.Product
+		if err := db.Where("id = ? AND branch_id = ?", reqItem.ProductId, branchID).First(&product).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return responses.NotFound(c, fmt.Sprintf("Product with ID %s not found in branch %s", reqItem.ProductId, branchID))
+			}
+			return responses.InternalServerError(c, "Failed to retrieve product details", err)
+		}
+
+		// Produk tujuan dipetakan lewat SKU
+		var destProduct models.Product
+		if err := db.Where("sku = ? AND branch_id = ?", product.SKU, toBranch.ID).First(&destProduct).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return responses.NotFound(c, fmt.Sprintf("Produk dengan SKU %s belum ada di cabang %s", product.SKU, toBranch.BranchName))
+			}
+			return responses.InternalServerError(c, "Failed to retrieve destination product", err)
+		}
+
+		transferItems = append(transferItems, models.StockTransferItems{
+			ID:            helpers.GenerateID("TRI"),
+			TransferId:    transfer.ID,
+			ProductId:     product.ID,
+			DestProductId: destProduct.ID,
+			Price:         product.PurchasePrice, // Estimasi, ditetapkan ulang saat dikirim
+			Qty:           reqItem.Qty,
+			SubTotal:      product.PurchasePrice * reqItem.Qty,
+			ExpiredDate:   product.ExpiredDate,
+		})
+		transfer.TotalTransfer += product.PurchasePrice * reqItem.Qty
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Create(&transfer).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transfer", err)
+	}
+
+	if err := tx.CreateInBatches(&transferItems, len(transferItems)).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transfer items", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusCreated, "Transfer created successfully", framework.Map{
+		"transfer":       transfer,
+		"transfer_items": transferItems,
+	})
+}
+
+// ShipTransfer mengirim transfer: stok cabang asal berkurang dan tercatat sebagai transfer_out
+func ShipTransfer(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+	id := c.Param("id")
+
+	db := config.DB
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Transfer dan item dikunci sampai commit agar dua permintaan kirim tidak mengurangi stok dua kali
+	var transfer models.StockTransfers
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND from_branch_id = ?", id, branchID).
+		First(&transfer).Error; err != nil {
+		tx.Rollback()
+		return responses.NotFound(c, "Transfer not found")
+	}
+	if transfer.Status != models.TransferDraft {
+		tx.Rollback()
+		return responses.BadRequest(c, "Hanya transfer berstatus draft yang bisa dikirim", nil)
+	}
+
+	var items []models.StockTransferItems
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transfer_id = ?", transfer.ID).
+		Find(&items).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to retrieve transfer items", err)
+	}
+
+	stockRef := tools.StockRef{
+		MovementType: models.TransferOutTrans,
+		ReferenceID:  transfer.ID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
+
+	totalTransfer := 0
+	var shippedItems []models.StockTransferItems
+	for _, item := range items {
+		var product models.Product
+		if err := tx.First(&product, "id = ?", item.ProductId).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to retrieve product details", err)
+		}
+
+		if err := tools.ReduceProductStock(tx, product.ID, item.Qty, stockRef); err != nil {
+			tx.Rollback()
+			return responses.BadRequest(c, fmt.Sprintf("Gagal mengurangi stok produk %s: %s", product.Name, err.Error()), err)
+		}
+
+		consumed, err := tools.ConsumeProductBatches(tx, branchID, product.ID, item.ID, item.Qty)
+		if err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to consume batch for product %s", product.Name), err)
+		}
+
+		unitCost, err := tools.ConsumeCost(tx, stockRef, product.ID, item.Qty)
+		if err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, fmt.Sprintf("Failed to calculate cost for product %s", product.Name), err)
+		}
+
+		// Expired date mengikuti batch yang benar-benar keluar (FEFO), item dipecah per tanggal kadaluarsa
+		for j, line := range splitTransferItem(item, consumed, product.ExpiredDate) {
+			line.Price = unitCost
+			line.SubTotal = unitCost * line.Qty
+			if j == 0 {
+				err = tx.Save(&line).Error
+			} else {
+				err = tx.Create(&line).Error
+			}
+			if err != nil {
+				tx.Rollback()
+				return responses.InternalServerError(c, "Failed to update transfer item", err)
+			}
+
+			shippedItems = append(shippedItems, line)
+			totalTransfer += line.SubTotal
+		}
+	}
+
+	if err := tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":         models.TransferShipped,
+		"total_transfer": totalTransfer,
+		"shipped_by":     userID,
+		"shipped_at":     nowWIB,
+	}).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to update transfer", err)
+	}
+	if err := tx.First(&transfer, "id = ?", transfer.ID).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to reload transfer", err)
+	}
+
+	// Tambahkan ke laporan transaksi cabang asal
+	transactionReport := models.TransactionReports{
+		ID:              helpers.GenerateID("TRX"),
+		TransactionType: models.TransferOut,
+		UserID:          userID,
+		BranchID:        branchID,
+		Total:           totalTransfer,
+		Payment:         models.Nocost,
+		CreatedAt:       nowWIB,
+		UpdatedAt:       nowWIB,
+	}
+	if err := tx.Create(&transactionReport).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transaction report", err)
+	}
+
+	// Jurnal cabang asal: persediaan keluar ke rekening antar cabang
+	if err := tools.SyncJournal(tx, models.JournalTransferOut, transfer.ID); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transfer journal", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Transfer shipped successfully", framework.Map{
+		"transfer":       transfer,
+		"transfer_items": shippedItems,
+	})
+}
+
+// splitTransferItem memecah item transfer per tanggal kadaluarsa batch yang dipakai saat kirim.
+// Baris pertama tetap memakai ID item asal, qty stok lama tanpa batch memakai expired_date produk.
+func splitTransferItem(item models.StockTransferItems, consumed []tools.ConsumedBatch, productExpiry time.Time) []models.StockTransferItems {
+	unbatched := item.Qty
+	for _, batch := range consumed {
+		unbatched -= batch.Qty
+	}
+	if unbatched > 0 {
+		consumed = append(consumed, tools.ConsumedBatch{ExpiredDate: productExpiry, Qty: unbatched})
+	}
+
+	var lines []models.StockTransferItems
+	for _, batch := range consumed {
+		// Batch dengan tanggal kadaluarsa yang sama digabung dalam satu baris
+		if n := len(lines); n > 0 && lines[n-1].ExpiredDate.Equal(batch.ExpiredDate) {
+			lines[n-1].Qty += batch.Qty
+			continue
+		}
+
+		line := item
+		if len(lines) > 0 {
+			line.ID = helpers.GenerateID("TRI")
+		}
+		line.Qty = batch.Qty
+		line.ExpiredDate = batch.ExpiredDate
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// ReceiveTransfer menerima transfer (boleh sebagian): stok cabang tujuan bertambah dan tercatat sebagai transfer_in
+func ReceiveTransfer(c *framework.Ctx) error {
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	userID, _ := middlewares.GetUserID(c.Request)
+	id := c.Param("id")
+
+	db := config.DB
+	var req models.ReceiveTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.BadRequest(c, "Invalid request body", err)
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		return responses.BadRequest(c, "Validation failed for receive input", err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return responses.InternalServerError(c, "Failed to begin database transaction", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Transfer dan item dikunci sampai commit agar penerimaan bersamaan tidak melebihi qty kiriman
+	var transfer models.StockTransfers
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND to_branch_id = ?", id, branchID).
+		First(&transfer).Error; err != nil {
+		tx.Rollback()
+		return responses.NotFound(c, "Transfer not found")
+	}
+	if transfer.Status != models.TransferShipped && transfer.Status != models.TransferPartiallyReceived {
+		tx.Rollback()
+		return responses.BadRequest(c, "Transfer belum dikirim atau sudah diterima seluruhnya", nil)
+	}
+
+	stockRef := tools.StockRef{
+		MovementType: models.TransferInTrans,
+		ReferenceID:  transfer.ID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
+
+	receivedValue := 0
+	for _, input := range req.Items {
+		var item models.StockTransferItems
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND transfer_id = ?", input.ItemId, transfer.ID).
+			First(&item).Error; err != nil {
+			tx.Rollback()
+			return responses.NotFound(c, fmt.Sprintf("Item transfer %s tidak ditemukan", input.ItemId))
+		}
+
+		remaining := item.Qty - item.QtyReceived
+		if input.Qty > remaining {
+			tx.Rollback()
+			return responses.BadRequest(c, fmt.Sprintf("Qty diterima untuk item %s melebihi sisa kiriman. Sisa: %d, Diterima: %d", item.ID, remaining, input.Qty), nil)
+		}
+
+		if err := tools.AddProductStock(tx, item.DestProductId, input.Qty, stockRef); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to add product stock", err)
+		}
+
+		if err := tools.AddProductBatch(tx, branchID, item.DestProductId, item.ID, "", item.ExpiredDate, input.Qty); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to add product batch", err)
+		}
+
+		if err := tools.ApplyReceiptCost(tx, stockRef, item.DestProductId, item.ID, input.Qty, item.Price); err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to update product cost", err)
+		}
+
+		if err := tx.Model(&item).Update("qty_received", gorm.Expr("qty_received + ?", input.Qty)).Error; err != nil {
+			tx.Rollback()
+			return responses.InternalServerError(c, "Failed to update transfer item", err)
+		}
+
+		receivedValue += input.Qty * item.Price
+	}
+
+	// Cek apakah seluruh item sudah diterima
+	var pendingItems int64
+	if err := tx.Model(&models.StockTransferItems{}).
+		Where("transfer_id = ? AND qty_received < qty", transfer.ID).
+		Count(&pendingItems).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to check transfer items", err)
+	}
+
+	status := models.TransferReceived
+	if pendingItems > 0 {
+		status = models.TransferPartiallyReceived
+	}
+
+	if err := tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":      status,
+		"received_by": userID,
+		"received_at": nowWIB,
+	}).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to update transfer", err)
+	}
+	if err := tx.First(&transfer, "id = ?", transfer.ID).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to reload transfer", err)
+	}
+
+	// Tambahkan ke laporan transaksi cabang tujuan
+	transactionReport := models.TransactionReports{
+		ID:              helpers.GenerateID("TRX"),
+		TransactionType: models.TransferIn,
+		UserID:          userID,
+		BranchID:        branchID,
+		Total:           receivedValue,
+		Payment:         models.Nocost,
+		CreatedAt:       nowWIB,
+		UpdatedAt:       nowWIB,
+	}
+	if err := tx.Create(&transactionReport).Error; err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transaction report", err)
+	}
+
+	// Jurnal cabang tujuan per penerimaan, sumbernya laporan transaksi penerimaan ini
+	if err := tools.SyncJournal(tx, models.JournalTransferIn, transactionReport.ID); err != nil {
+		tx.Rollback()
+		return responses.InternalServerError(c, "Failed to create transfer journal", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return responses.InternalServerError(c, "Failed to commit transaction", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Transfer received successfully", transfer)
+}
+
+// DeleteTransfer menghapus transfer yang masih draft
+func DeleteTransfer(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var transfer models.StockTransfers
+	if err := db.Where("id = ? AND from_branch_id = ?", id, branchID).First(&transfer).Error; err != nil {
+		return responses.NotFound(c, "Transfer not found")
+	}
+	if transfer.Status != models.TransferDraft {
+		return responses.BadRequest(c, "Hanya transfer berstatus draft yang bisa dihapus", nil)
+	}
+
+	if err := db.Where("transfer_id = ?", transfer.ID).Delete(&models.StockTransferItems{}).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to delete transfer items", err)
+	}
+
+	if err := db.Delete(&transfer).Error; err != nil {
+		return responses.InternalServerError(c, "Failed to delete transfer", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Transfer deleted successfully", transfer)
+}
+
+// GetAllTransfers menampilkan transfer keluar dan masuk untuk cabang aktif
+func GetAllTransfers(c *framework.Ctx) error {
+	// Hitung waktu sekarang dalam WIB
+	nowWIB := time.Now().In(utils.Location)
+
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	// Ambil parameter page dan search dari query URL
+	pageParam := c.Query("page")
+	search := strings.TrimSpace(c.Query("search"))
+
+	// Konversi page ke int, default ke 1 jika tidak valid
+	page := 1
+	if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 10                  // Tetapkan limit ke 10 data per halaman
+	offset := (page - 1) * limit // Hitung offset berdasarkan halaman dan limit
+
+	month := strings.TrimSpace(c.Query("month"))
+
+	// Jika month kosong, isi dengan bulan ini (format YYYY-MM)
+	if month == "" {
+		month = nowWIB.Format("2006-01")
+	}
+
+	var transfersFromDB []models.AllStockTransfers
+	var total int64
+
+	query := config.DB.Table("stock_transfers trf").
+		Select("trf.id, trf.transfer_date, trf.from_branch_id, fbr.branch_name AS from_branch_name, trf.to_branch_id, tbr.branch_name AS to_branch_name, trf.description, trf.total_transfer, trf.status").
+		Joins("LEFT JOIN branches fbr ON fbr.id = trf.from_branch_id").
+		Joins("LEFT JOIN branches tbr ON tbr.id = trf.to_branch_id").
+		Where("trf.from_branch_id = ? OR trf.to_branch_id = ?", branchID, branchID).
+		Order("trf.created_at DESC")
+
+	if search != "" {
+		search = strings.ToLower(search)
+		query = query.Where("LOWER(trf.description) LIKE ? OR LOWER(trf.id) LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	if month != "" {
+		parsedMonth, err := time.Parse("2006-01", month)
+		if err != nil {
+			return responses.BadRequest(c, "Invalid month format. Month should be in format YYYY-MM", err)
+		}
+		startDate := parsedMonth
+		endDate := startDate.AddDate(0, 1, 0).Add(-time.Nanosecond)
+		query = query.Where("trf.transfer_date BETWEEN ? AND ?", startDate, endDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return responses.InternalServerError(c, "Get transfers failed", err)
+	}
+
+	if err := query.Offset(offset).Limit(limit).Scan(&transfersFromDB).Error; err != nil {
+		return responses.InternalServerError(c, "Get transfers failed", err)
+	}
+
+	var formattedTransfers []models.StockTransferResponse
+	for _, transfer := range transfersFromDB {
+		formattedTransfers = append(formattedTransfers, models.StockTransferResponse{
+			ID:             transfer.ID,
+			TransferDate:   utils.FormatIndonesianDate(transfer.TransferDate),
+			FromBranchID:   transfer.FromBranchID,
+			FromBranchName: transfer.FromBranchName,
+			ToBranchID:     transfer.ToBranchID,
+			ToBranchName:   transfer.ToBranchName,
+			Description:    transfer.Description,
+			TotalTransfer:  transfer.TotalTransfer,
+			Status:         string(transfer.Status),
+		})
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return responses.JSONResponseGetAll(
+		c,
+		http.StatusOK,
+		"Transfers retrieved successfully",
+		search,
+		int(total),
+		page,
+		totalPages,
+		limit,
+		formattedTransfers,
+	)
+}
+
+// GetTransferWithItems menampilkan satu transfer beserta item-nya
+func GetTransferWithItems(c *framework.Ctx) error {
+	db := config.DB
+	branchID, _ := middlewares.GetBranchID(c.Request)
+	id := c.Param("id")
+
+	var transfer models.AllStockTransfers
+	err := db.Table("stock_transfers trf").
+		Select("trf.id, trf.transfer_date, trf.from_branch_id, fbr.branch_name AS from_branch_name, trf.to_branch_id, tbr.branch_name AS to_branch_name, trf.description, trf.total_transfer, trf.status").
+		Joins("LEFT JOIN branches fbr ON fbr.id = trf.from_branch_id").
+		Joins("LEFT JOIN branches tbr ON tbr.id = trf.to_branch_id").
+		Where("trf.id = ? AND (trf.from_branch_id = ? OR trf.to_branch_id = ?)", id, branchID, branchID).
+		Take(&transfer).Error
+	if err != nil {
+		return responses.NotFound(c, "Transfer not found")
+	}
+
+	var items []models.AllStockTransferItems
+	err = db.Table("stock_transfer_items tri").
+		Select("tri.id, tri.transfer_id, tri.product_id, pro.name AS product_name, tri.dest_product_id, pro.sku, tri.price, tri.qty, tri.qty_received, tri.sub_total, TO_CHAR(tri.expired_date, 'DD-MM-YYYY') AS expired_date").
+		Joins("LEFT JOIN products pro ON pro.id = tri.product_id").
+		Where("tri.transfer_id = ?", id).
+		Order("pro.name ASC").
+		Scan(&items).Error
+	if err != nil {
+		return responses.InternalServerError(c, "Failed to retrieve transfer items", err)
+	}
+
+	return responses.JSONResponse(c, http.StatusOK, "Transfer retrieved successfully", framework.Map{
+		"transfer": models.StockTransferResponse{
+			ID:             transfer.ID,
+			TransferDate:   utils.FormatIndonesianDate(transfer.TransferDate),
+			FromBranchID:   transfer.FromBranchID,
+			FromBranchName: transfer.FromBranchName,
+			ToBranchID:     transfer.ToBranchID,
+			ToBranchName:   transfer.ToBranchName,
+			Description:    transfer.Description,
+			TotalTransfer:  transfer.TotalTransfer,
+			Status:         string(transfer.Status),
+		},
+		"items": items,
+	})
+}
@@ -8,6 +8,7 @@ require (
 	github.com/heru-oktafian/scafold v1.0.27
 	github.com/robfig/cron/v3 v3.0.1
 	golang.org/x/crypto v0.41.0
+	gorm.io/driver/postgres v1.6.0
 	gorm.io/gorm v1.25.10
 )
 
@@ -34,7 +35,6 @@ require (
 	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
-	gorm.io/driver/postgres v1.6.0 // indirect
 )
 
 replace github.com/heru-oktafian/scafold => ../scafold
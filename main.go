@@ -8,6 +8,7 @@ import (
 	models "github.com/heru-oktafian/api-retail/models"
 	routes "github.com/heru-oktafian/api-retail/routes"
 	scheduler "github.com/heru-oktafian/api-retail/scheduler"
+	tools "github.com/heru-oktafian/api-retail/tools"
 	config "github.com/heru-oktafian/scafold/config"
 	env "github.com/heru-oktafian/scafold/env"
 	framework "github.com/heru-oktafian/scafold/framework"
@@ -31,6 +32,33 @@ func main() {
 	// Initialize database connection
 	config.KoneksiPG(os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
 
+	// Pastikan tipe ENUM yang dipakai tabel baru sudah tersedia
+	for _, stmt := range []string{
+		`DO $$ BEGIN CREATE TYPE movement_type AS ENUM ('purchase', 'purchase_return', 'sale', 'sale_return', 'opname', 'first_stock'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE costing_method AS ENUM ('average', 'fifo'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE transfer_status AS ENUM ('draft', 'shipped', 'partially_received', 'received'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE purchase_order_status AS ENUM ('draft', 'approved', 'partially_received', 'received', 'cancelled'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE point_type AS ENUM ('earn', 'redeem', 'expire', 'adjust', 'reversal'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE promo_type AS ENUM ('percentage', 'amount', 'buy_x_get_y'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE tax_mode AS ENUM ('exclusive', 'inclusive'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE shift_status AS ENUM ('open', 'closed'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE stock_policy AS ENUM ('forbid_negative', 'allow_backorder'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE cart_status AS ENUM ('open', 'parked', 'checked_out'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE account_type AS ENUM ('asset', 'liability', 'equity', 'revenue', 'expense'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`DO $$ BEGIN CREATE TYPE journal_status AS ENUM ('draft', 'posted'); EXCEPTION WHEN duplicate_object THEN null; END $$;`,
+		`ALTER TYPE movement_type ADD VALUE IF NOT EXISTS 'transfer_out'`,
+		`ALTER TYPE movement_type ADD VALUE IF NOT EXISTS 'transfer_in'`,
+		`ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'transfer_out'`,
+		`ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'transfer_in'`,
+		`ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'payable_payment'`,
+		`ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'receivable_payment'`,
+		`ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'paid_by_split'`,
+	} {
+		if err := config.DB.Exec(stmt).Error; err != nil {
+			log.Fatalf("Gagal membuat tipe enum: %v", err)
+		}
+	}
+
 	// Migrasi model dengan pengecekan tabel yang sudah ada
 	for _, model := range []interface{}{
 		&models.AnotherIncomes{},
@@ -38,6 +66,7 @@ func main() {
 		&models.Branch{},
 		&models.BuyReturnItems{},
 		&models.BuyReturns{},
+		&models.CostLayers{},
 		&models.DailyProfitReport{},
 		&models.DailyAsset{},
 		&models.Expenses{},
@@ -45,15 +74,40 @@ func main() {
 		&models.FirstStocks{},
 		&models.MemberCategory{},
 		&models.Member{},
+		&models.MemberPoints{},
+		&models.Promos{},
+		&models.PriceLists{},
+		&models.PriceListItems{},
+		&models.PriceOverrides{},
+		&models.ProductPriceHistory{},
+		&models.ScheduledPriceChanges{},
+		&models.Accounts{},
+		&models.JournalEntries{},
+		&models.JournalLines{},
+		&models.CashierShifts{},
+		&models.SalePayments{},
+		&models.SaleCarts{},
+		&models.SaleCartItems{},
+		&models.IdempotencyKeys{},
 		&models.OpnameItems{},
 		&models.Opnames{},
+		&models.ProductBatches{},
+		&models.ProductBatchUsages{},
+		&models.ProductBarcodes{},
 		&models.ProductCategory{},
 		&models.Product{},
 		&models.PurchaseItems{},
+		&models.PurchaseOrderItems{},
+		&models.PurchaseOrders{},
 		&models.Purchases{},
+		&models.ReceivablePayments{},
 		&models.SaleItems{},
 		&models.Sales{},
+		&models.StockTracks{},
+		&models.StockTransferItems{},
+		&models.StockTransfers{},
 		&models.SupplierCategory{},
+		&models.SupplierPayments{},
 		&models.Supplier{},
 		&models.TransactionReports{},
 		&models.UnitConversion{},
@@ -73,6 +127,91 @@ func main() {
 		}
 	}
 
+	// Tambah kolom baru pada tabel yang sudah ada
+	for _, column := range []struct {
+		model interface{}
+		field string
+	}{
+		{&models.Branch{}, "CostingMethod"},
+		{&models.SaleItems{}, "CostPrice"},
+		{&models.Purchases{}, "PurchaseOrderId"},
+		{&models.Purchases{}, "DueDate"},
+		{&models.Purchases{}, "PaidAmount"},
+		{&models.Supplier{}, "PaymentTerms"},
+		{&models.Sales{}, "DueDate"},
+		{&models.Sales{}, "PaidAmount"},
+		{&models.Member{}, "CreditLimit"},
+		{&models.MemberCategory{}, "CreditLimit"},
+		{&models.MemberCategory{}, "CreditTerms"},
+		{&models.Branch{}, "PointValue"},
+		{&models.Branch{}, "PointExpiryDays"},
+		{&models.Sales{}, "PointsRedeemed"},
+		{&models.Sales{}, "PointsAmount"},
+		{&models.SaleItems{}, "PromoId"},
+		{&models.SaleItems{}, "PromoDiscount"},
+		{&models.Branch{}, "TaxMode"},
+		{&models.Product{}, "TaxExempt"},
+		{&models.Sales{}, "TaxAmount"},
+		{&models.SaleItems{}, "TaxAmount"},
+		{&models.Purchases{}, "TaxAmount"},
+		{&models.PurchaseItems{}, "TaxAmount"},
+		{&models.Branch{}, "StockPolicy"},
+		{&models.Branch{}, "ReceiptHeader"},
+		{&models.Branch{}, "ReceiptFooter"},
+		{&models.UnitConversion{}, "SalesPrice"},
+		{&models.SaleItems{}, "UnitId"},
+		{&models.SaleItems{}, "ConvValue"},
+		{&models.SaleReturnItems{}, "UnitId"},
+		{&models.SaleReturnItems{}, "ConvValue"},
+		{&models.SaleReturnItems{}, "SaleItemId"},
+		{&models.Sales{}, "ReturnedAmount"},
+		{&models.SaleReturns{}, "CreditAmount"},
+		{&models.SaleReturns{}, "TaxAmount"},
+		{&models.SaleReturnItems{}, "TaxAmount"},
+		{&models.SaleCartItems{}, "UnitId"},
+		{&models.SaleCartItems{}, "ConvValue"},
+		{&models.MemberCategory{}, "PriceListId"},
+		{&models.SaleItems{}, "PriceListId"},
+		{&models.SaleItems{}, "ListPrice"},
+	} {
+		if !config.DB.Migrator().HasColumn(column.model, column.field) {
+			log.Printf("Menambah kolom %s pada model %T...", column.field, column.model)
+			if err := config.DB.Migrator().AddColumn(column.model, column.field); err != nil {
+				log.Fatalf("Gagal menambah kolom %s pada model %T: %v", column.field, column.model, err)
+			}
+		}
+	}
+
+	// Buat batch awal untuk stok lama yang belum punya batch
+	if err := tools.SeedProductBatches(config.DB); err != nil {
+		log.Printf("Gagal membuat batch awal produk: %v", err)
+	}
+
+	// Buat layer harga pokok awal untuk stok lama
+	if err := tools.SeedCostLayers(config.DB); err != nil {
+		log.Printf("Gagal membuat layer harga pokok awal produk: %v", err)
+	}
+
+	// Buat saldo awal buku besar poin untuk member lama
+	if err := tools.SeedMemberPoints(config.DB); err != nil {
+		log.Printf("Gagal membuat saldo awal poin member: %v", err)
+	}
+
+	// Buat rincian pembayaran untuk penjualan lama
+	if err := tools.SeedSalePayments(config.DB); err != nil {
+		log.Printf("Gagal membuat rincian pembayaran penjualan lama: %v", err)
+	}
+
+	// Isi satuan jual item penjualan, retur dan keranjang lama
+	if err := tools.SeedSaleLineUnits(config.DB); err != nil {
+		log.Printf("Gagal mengisi satuan jual item penjualan lama: %v", err)
+	}
+
+	// Buat bagan akun bawaan untuk cabang yang belum punya
+	if err := tools.SeedBranchAccounts(config.DB); err != nil {
+		log.Printf("Gagal membuat bagan akun bawaan cabang: %v", err)
+	}
+
 	// Initialize Redis connection
 	redisDB := 0
 	if dbStr := os.Getenv("REDIS_DB"); dbStr != "" {
@@ -104,8 +243,15 @@ func main() {
 	routes.SysUserRoutes(app)
 	routes.SysMemberCategoryRoutes(app)
 	routes.SysMemberRoutes(app)
+	routes.SysMemberPointRoutes(app)
+	routes.MasterPromoRoutes(app)
+	routes.MasterPriceListRoutes(app)
+	routes.MasterScheduledPriceRoutes(app)
+	routes.TransShiftRoutes(app)
+	routes.TransSaleCartRoutes(app)
 	routes.SysDashboardRoutes(app)
 	routes.SysReportRoutes(app)
+	routes.SysStockTrackRoutes(app)
 	routes.DailyAssetRoutes(app)
 	routes.AuditFirstStockRoutes(app)
 	routes.AuditFirstStockWithItems(app)
@@ -119,16 +265,22 @@ func main() {
 	routes.MasterSupplierRoutes(app)
 	routes.MasterUnitRoutes(app)
 	routes.MasterProductRoutes(app)
+	routes.MasterProductBarcodeRoutes(app)
 	routes.MasterUnitConversionRoutes(app)
 	routes.TransAnotherIncomeRoutes(app)
 	routes.TransExpenseRoutes(app)
 	routes.TransPurchaseRoutes(app)
 	routes.TransPurchaseItemRoutes(app)
+	routes.TransPurchaseOrderRoutes(app)
+	routes.TransPayableRoutes(app)
+	routes.TransReceivableRoutes(app)
 	routes.TransSaleRoutes(app)
 	routes.TransSaleDetailRoutes(app)
 	routes.TransSaleItemRoutes(app)
 	routes.TransBuyReturnRoutes(app)
 	routes.TransSaleReturnRoutes(app)
+	routes.TransTransferRoutes(app)
+	routes.TransJournalRoutes(app)
 	routes.CmbProdSaleReturn(app)
 	routes.CmbSaleRoute(app)
 	routes.CmbProdBuyReturn(app)
@@ -0,0 +1,126 @@
+package models
+
+import "time"
+
+// Kunci akun sistem, dipakai jurnal otomatis untuk mencari akun cabang tanpa bergantung pada kode akun
+const (
+	AccCash                = "cash"
+	AccBank                = "bank"
+	AccReceivable          = "receivable"
+	AccInventory           = "inventory"
+	AccTaxIn               = "tax_in"
+	AccInterBranch         = "inter_branch"
+	AccPayable             = "payable"
+	AccOtherPayable        = "other_payable"
+	AccTaxOut              = "tax_out"
+	AccOpeningEquity       = "opening_equity"
+	AccSales               = "sales"
+	AccSalesReturn         = "sales_return"
+	AccOtherIncome         = "other_income"
+	AccCOGS                = "cogs"
+	AccOperatingExpense    = "operating_expense"
+	AccInventoryAdjustment = "inventory_adjustment"
+	AccPointRedemption     = "point_redemption"
+)
+
+// Accounts model, bagan akun (chart of accounts) per cabang
+type Accounts struct {
+	ID          string      `gorm:"type:varchar(15);primaryKey" json:"id"`
+	Code        string      `gorm:"type:varchar(20);not null;uniqueIndex:idx_account_code" json:"code"`
+	Name        string      `gorm:"type:varchar(100);not null" json:"name"`
+	AccountType AccountType `gorm:"type:account_type;not null;default:'asset'" json:"account_type"`
+	SystemKey   string      `gorm:"type:varchar(30);index" json:"system_key"` // Terisi untuk akun yang dipakai jurnal otomatis
+	Description string      `gorm:"type:text;" json:"description"`
+	Active      bool        `gorm:"not null;default:true" json:"active"`
+	BranchID    string      `gorm:"type:varchar(15);not null;uniqueIndex:idx_account_code;index" json:"branch_id"`
+	CreatedAt   time.Time   `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AccountInput body untuk membuat / mengubah akun
+type AccountInput struct {
+	Code        string `json:"code" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	AccountType string `json:"account_type" validate:"required,oneof=asset liability equity revenue expense"`
+	Description string `json:"description"`
+	Active      *bool  `json:"active"`
+}
+
+// DefaultAccount akun bawaan yang dibuat untuk setiap cabang
+type DefaultAccount struct {
+	Code        string
+	Name        string
+	AccountType AccountType
+	SystemKey   string
+}
+
+// DefaultAccounts bagan akun bawaan cabang
+var DefaultAccounts = []DefaultAccount{
+	{Code: "1101", Name: "Kas", AccountType: AccountAsset, SystemKey: AccCash},
+	{Code: "1102", Name: "Bank", AccountType: AccountAsset, SystemKey: AccBank},
+	{Code: "1103", Name: "Piutang Usaha", AccountType: AccountAsset, SystemKey: AccReceivable},
+	{Code: "1104", Name: "Persediaan Barang", AccountType: AccountAsset, SystemKey: AccInventory},
+	{Code: "1105", Name: "PPN Masukan", AccountType: AccountAsset, SystemKey: AccTaxIn},
+	{Code: "1106", Name: "Rekening Antar Cabang", AccountType: AccountAsset, SystemKey: AccInterBranch},
+	{Code: "2101", Name: "Hutang Usaha", AccountType: AccountLiability, SystemKey: AccPayable},
+	{Code: "2102", Name: "Hutang Lain-lain", AccountType: AccountLiability, SystemKey: AccOtherPayable},
+	{Code: "2103", Name: "PPN Keluaran", AccountType: AccountLiability, SystemKey: AccTaxOut},
+	{Code: "3101", Name: "Modal Awal Persediaan", AccountType: AccountEquity, SystemKey: AccOpeningEquity},
+	{Code: "4101", Name: "Penjualan", AccountType: AccountRevenue, SystemKey: AccSales},
+	{Code: "4102", Name: "Retur Penjualan", AccountType: AccountRevenue, SystemKey: AccSalesReturn},
+	{Code: "4201", Name: "Pendapatan Lain-lain", AccountType: AccountRevenue, SystemKey: AccOtherIncome},
+	{Code: "5101", Name: "Harga Pokok Penjualan", AccountType: AccountExpense, SystemKey: AccCOGS},
+	{Code: "5201", Name: "Beban Operasional", AccountType: AccountExpense, SystemKey: AccOperatingExpense},
+	{Code: "5202", Name: "Selisih Persediaan", AccountType: AccountExpense, SystemKey: AccInventoryAdjustment},
+	{Code: "5203", Name: "Beban Penukaran Poin", AccountType: AccountExpense, SystemKey: AccPointRedemption},
+}
+
+// AccountBalance saldo akun dalam periode, dipakai neraca saldo dan buku besar
+type AccountBalance struct {
+	AccountId      string      `json:"account_id"`
+	Code           string      `json:"code"`
+	Name           string      `json:"name"`
+	AccountType    AccountType `json:"account_type"`
+	OpeningBalance int         `json:"opening_balance"` // Saldo sebelum periode, positif = sisi saldo normal
+	Debit          int         `json:"debit"`
+	Credit         int         `json:"credit"`
+	ClosingBalance int         `json:"closing_balance"`
+}
+
+// TrialBalanceRow baris neraca saldo, saldo akhir ditampilkan di kolom debit atau kredit
+type TrialBalanceRow struct {
+	AccountId     string      `json:"account_id"`
+	Code          string      `json:"code"`
+	Name          string      `json:"name"`
+	AccountType   AccountType `json:"account_type"`
+	BalanceDebit  int         `json:"balance_debit"`
+	BalanceCredit int         `json:"balance_credit"`
+}
+
+// TrialBalanceResponse neraca saldo per tanggal
+type TrialBalanceResponse struct {
+	EndDate     string            `json:"end_date"`
+	Accounts    []TrialBalanceRow `json:"accounts"`
+	TotalDebit  int               `json:"total_debit"`
+	TotalCredit int               `json:"total_credit"`
+	Balanced    bool              `json:"balanced"`
+}
+
+// LedgerLine mutasi akun di buku besar beserta saldo berjalan
+type LedgerLine struct {
+	JournalEntryId string    `json:"journal_entry_id"`
+	EntryDate      time.Time `json:"entry_date"`
+	SourceType     string    `json:"source_type"`
+	SourceId       string    `json:"source_id"`
+	Description    string    `json:"description"`
+	Memo           string    `json:"memo"`
+	Debit          int       `json:"debit"`
+	Credit         int       `json:"credit"`
+	Balance        int       `json:"balance"`
+}
+
+// AccountLedger buku besar satu akun dalam periode
+type AccountLedger struct {
+	AccountBalance
+	Lines []LedgerLine `json:"lines"`
+}
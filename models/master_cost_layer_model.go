@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CostLayers model, lapisan harga pokok per penerimaan barang untuk metode FIFO
+type CostLayers struct {
+	ID        string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	ProductId string    `gorm:"type:varchar(15);not null;index" json:"product_id"`
+	BranchID  string    `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	SourceID  string    `gorm:"type:varchar(15);not null;index" json:"source_id"` // ID item purchase / first stock / retur asal layer
+	UnitCost  int       `gorm:"type:int;not null;default:0" json:"unit_cost"`
+	Qty       int       `gorm:"type:int;not null;default:0" json:"qty"` // Sisa qty di layer ini
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -8,6 +8,46 @@ const (
 	Automatic JournalMethod = "automatic"
 )
 
+// Initialize custom type for ENUM CostingMethod
+type CostingMethod string
+
+const (
+	AverageCost CostingMethod = "average"
+	FIFOCost    CostingMethod = "fifo"
+)
+
+// Initialize custom type for ENUM TransferStatus
+type TransferStatus string
+
+const (
+	TransferDraft             TransferStatus = "draft"
+	TransferShipped           TransferStatus = "shipped"
+	TransferPartiallyReceived TransferStatus = "partially_received"
+	TransferReceived          TransferStatus = "received"
+)
+
+// Initialize custom type for ENUM PurchaseOrderStatus
+type PurchaseOrderStatus string
+
+const (
+	PODraft             PurchaseOrderStatus = "draft"
+	POApproved          PurchaseOrderStatus = "approved"
+	POPartiallyReceived PurchaseOrderStatus = "partially_received"
+	POReceived          PurchaseOrderStatus = "received"
+	POCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+// Initialize custom type for ENUM PointType
+type PointType string
+
+const (
+	PointEarn     PointType = "earn"
+	PointRedeem   PointType = "redeem"
+	PointExpire   PointType = "expire"
+	PointAdjust   PointType = "adjust"
+	PointReversal PointType = "reversal"
+)
+
 // Initialize custom type for ENUM SubcriptionType
 type SubcriptionType string
 
@@ -47,7 +87,74 @@ const (
 	PaidByBank   PaymentStatus = "paid_by_bank"
 	PaidByCredit PaymentStatus = "paid_by_credit"
 	PaidBySaldo  PaymentStatus = "paid_by_saldo"
+	PaidBySplit  PaymentStatus = "paid_by_split" // Penjualan dibayar dengan lebih dari satu metode, rinciannya di sale_payments
 	Pending      PaymentStatus = "pending"
 	Opname       PaymentStatus = "opname"
 	Nocost       PaymentStatus = "nocost"
 )
+
+// Initialize custom type for ENUM PromoType
+type PromoType string
+
+const (
+	PromoPercentage PromoType = "percentage"  // Potongan persen per unit
+	PromoAmount     PromoType = "amount"      // Potongan nominal per unit
+	PromoBuyXGetY   PromoType = "buy_x_get_y" // Beli X gratis Y untuk produk yang sama
+)
+
+// Initialize custom type for ENUM TaxMode
+type TaxMode string
+
+const (
+	TaxExclusive TaxMode = "exclusive" // Harga belum termasuk PPN, PPN ditambahkan ke total
+	TaxInclusive TaxMode = "inclusive" // Harga sudah termasuk PPN
+)
+
+// Initialize custom type for ENUM ShiftStatus
+type ShiftStatus string
+
+const (
+	ShiftOpen   ShiftStatus = "open"
+	ShiftClosed ShiftStatus = "closed"
+)
+
+// Initialize custom type for ENUM CartStatus
+type CartStatus string
+
+const (
+	CartOpen       CartStatus = "open"        // Keranjang yang sedang dilayani kasir
+	CartParked     CartStatus = "parked"      // Keranjang yang ditahan sementara
+	CartCheckedOut CartStatus = "checked_out" // Keranjang yang sudah menjadi penjualan
+)
+
+// Initialize custom type for ENUM StockPolicy
+type StockPolicy string
+
+const (
+	StockForbidNegative StockPolicy = "forbid_negative" // Stok tidak boleh minus, transaksi ditolak jika stok kurang
+	StockAllowBackorder StockPolicy = "allow_backorder" // Stok boleh minus (backorder)
+)
+
+// Initialize custom type for ENUM AccountType
+type AccountType string
+
+const (
+	AccountAsset     AccountType = "asset"     // Aset, saldo normal debit
+	AccountLiability AccountType = "liability" // Kewajiban, saldo normal kredit
+	AccountEquity    AccountType = "equity"    // Modal, saldo normal kredit
+	AccountRevenue   AccountType = "revenue"   // Pendapatan, saldo normal kredit
+	AccountExpense   AccountType = "expense"   // Beban, saldo normal debit
+)
+
+// DebitNormal true jika saldo normal akun di sisi debit (aset dan beban)
+func (t AccountType) DebitNormal() bool {
+	return t == AccountAsset || t == AccountExpense
+}
+
+// Initialize custom type for ENUM JournalStatus
+type JournalStatus string
+
+const (
+	JournalDraft  JournalStatus = "draft"  // Belum diposting, tidak masuk buku besar
+	JournalPosted JournalStatus = "posted" // Sudah diposting ke buku besar
+)
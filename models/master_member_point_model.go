@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// MemberPoints model, buku besar poin member (earn, redeem, expire, adjust, reversal)
+type MemberPoints struct {
+	ID          string     `gorm:"type:varchar(15);primaryKey" json:"id"`
+	MemberId    string     `gorm:"type:varchar(15);not null;index" json:"member_id"`
+	PointType   PointType  `gorm:"type:point_type;not null;default:'earn'" json:"point_type"`
+	Points      int        `gorm:"type:int;not null;default:0" json:"points"`    // Positif menambah saldo, negatif mengurangi
+	Remaining   int        `gorm:"type:int;not null;default:0" json:"remaining"` // Sisa poin masuk yang belum terpakai / kadaluarsa
+	ReferenceID string     `gorm:"type:varchar(15);index" json:"reference_id"`   // ID penjualan / retur asal mutasi poin
+	Reason      string     `gorm:"type:text;" json:"reason"`
+	ExpiredAt   *time.Time `json:"expired_at"`
+	BranchID    string     `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	UserID      string     `gorm:"type:varchar(15);" json:"user_id"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// AdjustMemberPointInput body untuk penyesuaian poin manual
+type AdjustMemberPointInput struct {
+	MemberId string `json:"member_id" validate:"required"`
+	Points   int    `json:"points" validate:"required"` // Positif menambah, negatif mengurangi
+	Reason   string `json:"reason" validate:"required"`
+}
+
+// MemberPointResponse model riwayat poin yang sudah diformat
+type MemberPointResponse struct {
+	ID          string `json:"id"`
+	PointType   string `json:"point_type"`
+	Points      int    `json:"points"`
+	ReferenceID string `json:"reference_id"`
+	Reason      string `json:"reason"`
+	ExpiredAt   string `json:"expired_at"`
+	CreatedAt   string `json:"created_at"`
+}
@@ -5,6 +5,9 @@ type MemberCategory struct {
 	ID                   uint   `gorm:"primaryKey;autoIncrement" json:"id"`
 	Name                 string `gorm:"type:varchar(100);not null" json:"name" validate:"required"`
 	PointsConversionRate int    `gorm:"type:int;not null;default:0" json:"points_conversion_rate" validate:"required"`
+	CreditLimit          int    `gorm:"type:int;not null;default:0" json:"credit_limit"` // Batas piutang default untuk member kategori ini, 0 = tidak boleh kredit
+	CreditTerms          int    `gorm:"type:int;not null;default:0" json:"credit_terms"` // Tempo pembayaran piutang dalam hari
+	PriceListId          string `gorm:"type:varchar(15)" json:"price_list_id"`           // Daftar harga default member kategori ini, kosong = harga jual produk
 	BranchID             string `gorm:"type:varchar(15);not null" json:"branch_id" validate:"required"`
 }
 
@@ -22,6 +25,7 @@ type Member struct {
 	Address          string `gorm:"type:text;" json:"address"`
 	MemberCategoryId uint   `gorm:"not null" json:"member_category_id" validate:"required"`
 	Points           int    `gorm:"type:int;not null;default:0" json:"points" validate:"required"` // Ubah ini
+	CreditLimit      int    `gorm:"type:int;not null;default:0" json:"credit_limit"`               // Batas piutang khusus member, 0 = ikut kategori
 	BranchID         string `gorm:"type:varchar(15);not null" json:"branch_id" validate:"required"`
 }
 
@@ -33,4 +37,5 @@ type MemberDetail struct {
 	Address        string `gorm:"type:text;" json:"address"`
 	MemberCategory string `gorm:"type:varchar(100);not null" json:"member_category" validate:"required"`
 	Points         int    `gorm:"type:int;not null;default:0" json:"points" validate:"required"` // Ubah ini
+	CreditLimit    int    `gorm:"type:int;not null;default:0" json:"credit_limit"`
 }
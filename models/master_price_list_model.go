@@ -0,0 +1,112 @@
+package models
+
+import "time"
+
+// PriceLists model, daftar harga bernama (misal eceran, grosir, klinik) yang bisa dipasang ke kategori member.
+// Produk / satuan yang tidak ada di daftar harga tetap memakai harga jual produk.
+type PriceLists struct {
+	ID          string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
+	Description string    `gorm:"type:text;" json:"description"`
+	Active      bool      `gorm:"not null;default:true" json:"active"`
+	BranchID    string    `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PriceListItems model, harga produk per satuan jual di dalam daftar harga.
+// Beberapa baris dengan MinQty berbeda membentuk harga bertingkat (quantity break).
+type PriceListItems struct {
+	ID          string `gorm:"type:varchar(15);primaryKey" json:"id"`
+	PriceListId string `gorm:"type:varchar(15);not null;uniqueIndex:idx_price_list_item" json:"price_list_id"`
+	ProductId   string `gorm:"type:varchar(15);not null;uniqueIndex:idx_price_list_item;index" json:"product_id"`
+	UnitId      string `gorm:"type:varchar(15);not null;uniqueIndex:idx_price_list_item" json:"unit_id"`
+	MinQty      int    `gorm:"type:int;not null;default:1;uniqueIndex:idx_price_list_item" json:"min_qty"` // Qty minimal dalam satuan ini agar harga berlaku
+	Price       int    `gorm:"type:int;not null;default:0" json:"price"`                                   // Harga per satuan
+}
+
+// PriceOverrides model, catatan harga item penjualan yang diubah kasir dari harga daftar harga
+type PriceOverrides struct {
+	ID          string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	SaleId      string    `gorm:"type:varchar(15);not null;index" json:"sale_id"`
+	SaleItemId  string    `gorm:"type:varchar(15);not null" json:"sale_item_id"`
+	ProductId   string    `gorm:"type:varchar(15);not null" json:"product_id"`
+	UnitId      string    `gorm:"type:varchar(15)" json:"unit_id"`
+	PriceListId string    `gorm:"type:varchar(15)" json:"price_list_id"` // Kosong = harga jual produk
+	ListPrice   int       `gorm:"type:int;not null;default:0" json:"list_price"`
+	Price       int       `gorm:"type:int;not null;default:0" json:"price"` // Harga yang dipakai kasir
+	Reason      string    `gorm:"type:varchar(255)" json:"reason"`
+	UserID      string    `gorm:"type:varchar(15);not null" json:"user_id"`
+	BranchID    string    `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// PriceListInput body untuk membuat / mengubah daftar harga
+type PriceListInput struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	Active      *bool  `json:"active"`
+}
+
+// PriceListItemInput body untuk menambah / mengubah harga produk di daftar harga,
+// baris dengan produk, satuan dan min_qty yang sama akan ditimpa
+type PriceListItemInput struct {
+	ProductId string `json:"product_id" validate:"required"`
+	UnitId    string `json:"unit_id"` // Kosong = satuan dasar produk
+	MinQty    int    `json:"min_qty" validate:"min=0"`
+	Price     int    `json:"price" validate:"required,min=1"`
+}
+
+// PriceListItemDetail harga daftar harga beserta nama produk dan satuan
+type PriceListItemDetail struct {
+	ID          string `json:"id"`
+	ProductId   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	UnitId      string `json:"unit_id"`
+	UnitName    string `json:"unit_name"`
+	MinQty      int    `json:"min_qty"`
+	Price       int    `json:"price"`
+	SalesPrice  int    `json:"sales_price"` // Harga jual normal per satuan sebagai pembanding
+}
+
+// PriceListDetail daftar harga beserta isi harga produknya
+type PriceListDetail struct {
+	ID               string                `json:"id"`
+	Name             string                `json:"name"`
+	Description      string                `json:"description"`
+	Active           bool                  `json:"active"`
+	MemberCategories []ComboMemberCategory `json:"member_categories"` // Kategori member yang memakai daftar harga ini
+	Items            []PriceListItemDetail `json:"items"`
+}
+
+// PriceListSummary daftar harga untuk tampilan list
+type PriceListSummary struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Active      bool   `json:"active"`
+	ItemCount   int    `json:"item_count"`
+}
+
+// PriceBreak harga bertingkat, berlaku jika qty >= MinQty
+type PriceBreak struct {
+	MinQty int `json:"min_qty"`
+	Price  int `json:"price"`
+}
+
+// PriceOverrideDetail catatan perubahan harga beserta nama produk, kasir dan daftar harga
+type PriceOverrideDetail struct {
+	ID            string    `json:"id"`
+	SaleId        string    `json:"sale_id"`
+	ProductId     string    `json:"product_id"`
+	ProductName   string    `json:"product_name"`
+	UnitName      string    `json:"unit_name"`
+	PriceListId   string    `json:"price_list_id"`
+	PriceListName string    `json:"price_list_name"`
+	ListPrice     int       `json:"list_price"`
+	Price         int       `json:"price"`
+	Reason        string    `json:"reason"`
+	UserID        string    `json:"user_id"`
+	UserName      string    `json:"user_name"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// ProductBarcodes model, barcode kemasan produk. Satu produk bisa punya beberapa barcode,
+// misal per pcs dan per dus, dengan QtyMultiplier = jumlah satuan dasar dalam kemasan tersebut.
+type ProductBarcodes struct {
+	ID            string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	Barcode       string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_product_barcode_branch" json:"barcode"`
+	ProductId     string    `gorm:"type:varchar(15);not null;index" json:"product_id"`
+	UnitId        string    `gorm:"type:varchar(15);not null" json:"unit_id"`
+	QtyMultiplier int       `gorm:"type:int;not null;default:1" json:"qty_multiplier"`
+	BranchID      string    `gorm:"type:varchar(15);not null;uniqueIndex:idx_product_barcode_branch" json:"branch_id"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ProductBarcodeInput body untuk menambah barcode produk.
+// Barcode kosong akan dibuatkan EAN-13 internal, UnitId kosong memakai satuan dasar produk.
+type ProductBarcodeInput struct {
+	ProductId string `json:"product_id" validate:"required"`
+	Barcode   string `json:"barcode" validate:"max=50"`
+	UnitId    string `json:"unit_id"`
+}
+
+// ProductBarcodeDetail barcode beserta nama produk dan satuan
+type ProductBarcodeDetail struct {
+	ID            string `json:"id"`
+	Barcode       string `json:"barcode"`
+	ProductId     string `json:"product_id"`
+	ProductName   string `json:"product_name"`
+	UnitId        string `json:"unit_id"`
+	UnitName      string `json:"unit_name"`
+	QtyMultiplier int    `json:"qty_multiplier"`
+}
+
+// BarcodeScanResponse hasil scan barcode di kasir, harga dan stok dalam satuan kemasan barcode
+type BarcodeScanResponse struct {
+	Barcode       string `json:"barcode"`
+	ProductId     string `json:"product_id"`
+	ProductName   string `json:"product_name"`
+	UnitId        string `json:"unit_id"`
+	UnitName      string `json:"unit_name"`
+	QtyMultiplier int    `json:"qty_multiplier"` // Jumlah satuan dasar per kemasan
+	Price         int    `json:"price"`          // Harga jual per kemasan
+	Stock         int    `json:"stock"`          // Stok dalam satuan kemasan (dibulatkan ke bawah)
+	BaseUnitName  string `json:"base_unit_name"`
+	BaseStock     int    `json:"base_stock"`
+}
+
+// BarcodeLabelResponse gambar label barcode (PNG base64)
+type BarcodeLabelResponse struct {
+	Barcode     string `json:"barcode"`
+	Symbology   string `json:"symbology"` // ean13 atau code128
+	ProductName string `json:"product_name"`
+	UnitName    string `json:"unit_name"`
+	Price       int    `json:"price"`
+	ContentType string `json:"content_type"`
+	Image       string `json:"image"`
+}
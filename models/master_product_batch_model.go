@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ProductBatches model, stok per batch / lot per cabang
+type ProductBatches struct {
+	ID          string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	ProductId   string    `gorm:"type:varchar(15);not null;index" json:"product_id" validate:"required"`
+	BatchNumber string    `gorm:"type:varchar(50);not null" json:"batch_number" validate:"required"`
+	ExpiredDate time.Time `gorm:"not null" json:"expired_date" validate:"required"`
+	Qty         int       `gorm:"type:int;not null;default:0" json:"qty"`
+	SourceID    string    `gorm:"type:varchar(15);not null;index" json:"source_id"` // ID item purchase / first_stock / opname asal batch
+	BranchID    string    `gorm:"type:varchar(15);not null;index" json:"branch_id" validate:"required"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ProductBatchUsages model, mencatat batch mana yang dipakai oleh item penjualan
+// agar retur bisa dikembalikan ke batch yang sama
+type ProductBatchUsages struct {
+	ID          string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	BatchId     string    `gorm:"type:varchar(15);not null;index" json:"batch_id"`
+	ReferenceID string    `gorm:"type:varchar(15);not null;index" json:"reference_id"` // ID sale item
+	Qty         int       `gorm:"type:int;not null;default:0" json:"qty"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ProductBatchExpiredResponse menampilkan batch yang mendekati kadaluarsa
+type ProductBatchExpiredResponse struct {
+	ID          string `json:"id"`
+	BatchNumber string `json:"batch_number"`
+	ProductId   string `json:"product_id"`
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	Qty         int    `json:"qty"`
+	Unit        string `json:"unit"`
+	ExpiredDate string `json:"expired_date"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// ProductImportRow satu baris file import produk beserta hasil validasinya
+type ProductImportRow struct {
+	Row            int      `json:"row"` // Nomor baris di file, baris judul = 1
+	SKU            string   `json:"sku"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	UnitName       string   `json:"unit_name"`
+	CategoryName   string   `json:"category_name"`
+	PurchasePrice  int      `json:"purchase_price"`
+	SalesPrice     int      `json:"sales_price"`
+	AlternatePrice int      `json:"alternate_price"`
+	Stock          int      `json:"stock"`
+	ExpiredDate    string   `json:"expired_date"` // YYYY-MM-DD
+	TaxExempt      bool     `json:"tax_exempt"`
+	ProductId      string   `json:"product_id,omitempty"` // Diisi setelah import disimpan
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// ProductImportResponse hasil import produk, pada dry run tidak ada data yang disimpan
+type ProductImportResponse struct {
+	DryRun        bool               `json:"dry_run"`
+	TotalRows     int                `json:"total_rows"`
+	ValidRows     int                `json:"valid_rows"`
+	InvalidRows   int                `json:"invalid_rows"`
+	NewUnits      []string           `json:"new_units"`      // Satuan yang belum ada dan akan / sudah dibuat
+	NewCategories []string           `json:"new_categories"` // Kategori yang belum ada dan akan / sudah dibuat
+	FirstStockId  string             `json:"first_stock_id,omitempty"`
+	TotalStock    int                `json:"total_first_stock"` // Nilai stok awal (harga beli x stok)
+	Rows          []ProductImportRow `json:"rows"`
+}
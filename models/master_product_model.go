@@ -15,6 +15,7 @@ type Product struct {
 	SalesPrice        int       `gorm:"type:int;not null;default:0" json:"sales_price" validate:"required"`
 	AlternatePrice    int       `gorm:"type:int;not null;default:0" json:"alternate_price" validate:"required"`
 	ProductCategoryId uint      `gorm:"not null" json:"product_category_id" validate:"required"`
+	TaxExempt         bool      `gorm:"not null;default:false" json:"tax_exempt"` // Produk bebas PPN
 	BranchID          string    `gorm:"type:varchar(15);not null" json:"branch_id" validate:"required"`
 }
 
@@ -48,6 +49,7 @@ type ProductDetail struct {
 	AlternatePrice      int       `gorm:"type:int;not null;default:0" json:"alternate_price" validate:"required"`
 	ProductCategoryId   uint      `gorm:"not null" json:"product_category_id" validate:"required"`
 	ProductCategoryName string    `gorm:"type:varchar(100);not null" json:"product_category_name" validate:"required"`
+	TaxExempt           bool      `json:"tax_exempt"`
 }
 
 // ProdConvCombo adalah model untuk combo box konversi produk
@@ -58,11 +60,25 @@ type ProdConvCombo struct {
 
 // ProdSaleCombo adalah model untuk combo box penjualan produk
 type ProdSaleCombo struct {
-	ProductId   string `json:"product_id"`
-	ProductName string `json:"product_name"`
-	Price       int    `json:"price"`
-	Stock       int    `json:"stock"`
-	UnitName    string `json:"unit_name"`
+	ProductId   string         `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	Price       int            `json:"price"`
+	Stock       int            `json:"stock"`
+	UnitId      string         `json:"unit_id"`
+	UnitName    string         `json:"unit_name"`
+	Units       []ProdSaleUnit `gorm:"-" json:"units"` // Semua satuan yang bisa dijual, satuan dasar di urutan pertama
+}
+
+// ProdSaleUnit satuan jual produk beserta nilai konversi, harga dan stok dalam satuan tersebut
+type ProdSaleUnit struct {
+	ProductId string `json:"-"`
+	UnitId    string `json:"unit_id"`
+	UnitName  string `json:"unit_name"`
+	ConvValue int    `json:"conv_value"` // Jumlah satuan dasar per satuan jual
+	Price     int    `json:"price"`
+	Stock     int    `json:"stock"` // Stok dibulatkan ke bawah
+
+	PriceBreaks []PriceBreak `json:"price_breaks,omitempty"` // Harga bertingkat dari daftar harga pembeli
 }
 
 // ProdPurchaseCombo adalah model untuk combo box pembelian produk
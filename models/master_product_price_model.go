@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// Sumber perubahan harga produk selain mutasi stok (MovementType dipakai untuk perubahan harga pokok otomatis)
+const (
+	PriceSourceManual    = "manual"    // Diubah lewat update produk
+	PriceSourceScheduled = "scheduled" // Diterapkan scheduler dari jadwal perubahan harga
+)
+
+// Kolom harga produk yang dicatat riwayatnya
+const (
+	PriceFieldPurchase  = "purchase_price"
+	PriceFieldSales     = "sales_price"
+	PriceFieldAlternate = "alternate_price"
+)
+
+// Status jadwal perubahan harga
+const (
+	ScheduledPricePending   = "pending"
+	ScheduledPriceApplied   = "applied"
+	ScheduledPriceCancelled = "cancelled"
+)
+
+// ProductPriceHistory model, satu baris untuk setiap perubahan harga produk
+type ProductPriceHistory struct {
+	ID          string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	ProductId   string    `gorm:"type:varchar(15);not null;index" json:"product_id"`
+	Field       string    `gorm:"type:varchar(20);not null" json:"field"` // purchase_price / sales_price / alternate_price
+	OldPrice    int       `gorm:"type:int;not null;default:0" json:"old_price"`
+	NewPrice    int       `gorm:"type:int;not null;default:0" json:"new_price"`
+	Source      string    `gorm:"type:varchar(20);not null" json:"source"` // manual / scheduled / jenis mutasi stok (purchase, sale, opname, ...)
+	ReferenceID string    `gorm:"type:varchar(15)" json:"reference_id"`    // Dokumen sumber perubahan
+	UserID      string    `gorm:"type:varchar(15)" json:"user_id"`
+	BranchID    string    `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ScheduledPriceChanges model, perubahan harga produk yang diterapkan scheduler pada tanggal berlaku
+type ScheduledPriceChanges struct {
+	ID            string     `gorm:"type:varchar(15);primaryKey" json:"id"`
+	ProductId     string     `gorm:"type:varchar(15);not null;index" json:"product_id"`
+	Field         string     `gorm:"type:varchar(20);not null" json:"field"`
+	NewPrice      int        `gorm:"type:int;not null;default:0" json:"new_price"`
+	EffectiveDate time.Time  `gorm:"not null;index" json:"effective_date"` // Berlaku mulai pukul 00:00 WIB tanggal ini
+	Status        string     `gorm:"type:varchar(15);not null;default:'pending'" json:"status"`
+	Note          string     `gorm:"type:varchar(255)" json:"note"`
+	AppliedAt     *time.Time `json:"applied_at"`
+	UserID        string     `gorm:"type:varchar(15);not null" json:"user_id"`
+	BranchID      string     `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ScheduledPriceChangeInput body untuk menjadwalkan perubahan harga
+type ScheduledPriceChangeInput struct {
+	ProductId     string `json:"product_id" validate:"required"`
+	Field         string `json:"field" validate:"required,oneof=purchase_price sales_price alternate_price"`
+	NewPrice      int    `json:"new_price" validate:"min=0"`
+	EffectiveDate string `json:"effective_date" validate:"required"` // Format YYYY-MM-DD
+	Note          string `json:"note"`
+}
+
+// ProductPriceHistoryDetail riwayat harga beserta nama user
+type ProductPriceHistoryDetail struct {
+	ID          string    `json:"id"`
+	ProductId   string    `json:"product_id"`
+	Field       string    `json:"field"`
+	OldPrice    int       `json:"old_price"`
+	NewPrice    int       `json:"new_price"`
+	Source      string    `json:"source"`
+	ReferenceID string    `json:"reference_id"`
+	UserID      string    `json:"user_id"`
+	UserName    string    `json:"user_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ScheduledPriceChangeDetail jadwal perubahan harga beserta nama produk dan harga saat ini
+type ScheduledPriceChangeDetail struct {
+	ID            string     `json:"id"`
+	ProductId     string     `json:"product_id"`
+	ProductName   string     `json:"product_name"`
+	Field         string     `json:"field"`
+	CurrentPrice  int        `json:"current_price"`
+	NewPrice      int        `json:"new_price"`
+	EffectiveDate time.Time  `json:"effective_date"`
+	Status        string     `json:"status"`
+	Note          string     `json:"note"`
+	AppliedAt     *time.Time `json:"applied_at"`
+	UserID        string     `json:"user_id"`
+	UserName      string     `json:"user_name"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Promos model, aturan promo berlaku per produk / kategori / semua produk dalam rentang tanggal
+type Promos struct {
+	ID                string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	Name              string    `gorm:"type:varchar(100);not null" json:"name"`
+	PromoType         PromoType `gorm:"type:promo_type;not null;default:'percentage'" json:"promo_type"`
+	ProductId         *string   `gorm:"type:varchar(15);index" json:"product_id"`      // Kosong = tidak dibatasi produk
+	ProductCategoryId *uint     `gorm:"index" json:"product_category_id"`              // Kosong = tidak dibatasi kategori
+	MemberCategoryId  *uint     `json:"member_category_id"`                            // Kosong = berlaku untuk semua pembeli
+	Value             int       `gorm:"type:int;not null;default:0" json:"value"`      // Persen / nominal potongan per unit
+	BuyQty            int       `gorm:"type:int;not null;default:0" json:"buy_qty"`    // Khusus buy_x_get_y
+	GetQty            int       `gorm:"type:int;not null;default:0" json:"get_qty"`    // Khusus buy_x_get_y
+	MinBasket         int       `gorm:"type:int;not null;default:0" json:"min_basket"` // Minimal total belanja sebelum promo
+	StartDate         time.Time `gorm:"not null" json:"start_date"`
+	EndDate           time.Time `gorm:"not null" json:"end_date"`
+	Active            bool      `gorm:"not null;default:true" json:"active"`
+	BranchID          string    `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	CreatedAt         time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PromoInput body untuk membuat / mengubah promo
+type PromoInput struct {
+	Name              string  `json:"name" validate:"required"`
+	PromoType         string  `json:"promo_type" validate:"required,oneof=percentage amount buy_x_get_y"`
+	ProductId         *string `json:"product_id"`
+	ProductCategoryId *uint   `json:"product_category_id"`
+	MemberCategoryId  *uint   `json:"member_category_id"`
+	Value             int     `json:"value" validate:"min=0"`
+	BuyQty            int     `json:"buy_qty" validate:"min=0"`
+	GetQty            int     `json:"get_qty" validate:"min=0"`
+	MinBasket         int     `json:"min_basket" validate:"min=0"`
+	StartDate         string  `json:"start_date" validate:"required"` // Format YYYY-MM-DD
+	EndDate           string  `json:"end_date" validate:"required"`   // Format YYYY-MM-DD
+	Active            *bool   `json:"active"`
+}
+
+// PromoDB model promo beserta nama relasi hasil query
+type PromoDB struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	PromoType           string    `json:"promo_type"`
+	ProductId           string    `json:"product_id"`
+	ProductName         string    `json:"product_name"`
+	ProductCategoryId   uint      `json:"product_category_id"`
+	ProductCategoryName string    `json:"product_category_name"`
+	MemberCategoryId    uint      `json:"member_category_id"`
+	MemberCategoryName  string    `json:"member_category_name"`
+	Value               int       `json:"value"`
+	BuyQty              int       `json:"buy_qty"`
+	GetQty              int       `json:"get_qty"`
+	MinBasket           int       `json:"min_basket"`
+	StartDate           time.Time `json:"start_date"`
+	EndDate             time.Time `json:"end_date"`
+	Active              bool      `json:"active"`
+}
+
+// PromoResponse model promo yang sudah diformat untuk ditampilkan
+type PromoResponse struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	PromoType           string `json:"promo_type"`
+	ProductId           string `json:"product_id"`
+	ProductName         string `json:"product_name"`
+	ProductCategoryId   uint   `json:"product_category_id"`
+	ProductCategoryName string `json:"product_category_name"`
+	MemberCategoryId    uint   `json:"member_category_id"`
+	MemberCategoryName  string `json:"member_category_name"`
+	Value               int    `json:"value"`
+	BuyQty              int    `json:"buy_qty"`
+	GetQty              int    `json:"get_qty"`
+	MinBasket           int    `json:"min_basket"`
+	StartDate           string `json:"start_date"`
+	EndDate             string `json:"end_date"`
+	Active              bool   `json:"active"`
+}
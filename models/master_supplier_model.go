@@ -8,6 +8,7 @@ type Supplier struct {
 	Address            string `gorm:"type:text;" json:"address"`
 	PIC                string `gorm:"type:varchar(255);" json:"pic"`
 	SupplierCategoryId uint   `gorm:"not null" json:"supplier_category_id" validate:"required"`
+	PaymentTerms       int    `gorm:"type:int;not null;default:0" json:"payment_terms"` // Tempo pembayaran dalam hari
 	BranchID           string `gorm:"type:varchar(15);not null" json:"branch_id" validate:"required"`
 }
 
@@ -20,6 +21,7 @@ type SupplierDetail struct {
 	PIC                string `gorm:"type:varchar(255);" json:"pic"`
 	SupplierCategoryId uint   `gorm:"not null" json:"supplier_category_id" validate:"required"`
 	SupplierCategory   string `gorm:"type:varchar(100);not null" json:"supplier_category" validate:"required"`
+	PaymentTerms       int    `gorm:"type:int;not null;default:0" json:"payment_terms"`
 }
 
 // Supplier All model yang akan ditampilkan di data detail
@@ -2,12 +2,13 @@ package models
 
 // UnitConversion model yang akan disimpan di database
 type UnitConversion struct {
-	ID        string `gorm:"type:varchar(15);primaryKey" json:"id"`
-	ProductId string `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
-	InitId    string `gorm:"type:varchar(15);not null" json:"init_id" validate:"required"`
-	FinalId   string `gorm:"type:varchar(15);not null" json:"final_id" validate:"required"`
-	ValueConv int    `gorm:"type:int;not null;default:0" json:"value_conv" validate:"required"`
-	BranchID  string `gorm:"type:varchar(15);not null" json:"branch_id" validate:"required"`
+	ID         string `gorm:"type:varchar(15);primaryKey" json:"id"`
+	ProductId  string `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
+	InitId     string `gorm:"type:varchar(15);not null" json:"init_id" validate:"required"`
+	FinalId    string `gorm:"type:varchar(15);not null" json:"final_id" validate:"required"`
+	ValueConv  int    `gorm:"type:int;not null;default:0" json:"value_conv" validate:"required"`
+	SalesPrice int    `gorm:"type:int;not null;default:0" json:"sales_price"` // Harga jual per satuan InitId, 0 = harga jual produk x ValueConv
+	BranchID   string `gorm:"type:varchar(15);not null" json:"branch_id" validate:"required"`
 }
 
 // UnitConversionDetail model yang akan ditampilkan di data detail
@@ -17,6 +18,7 @@ type UnitConversionDetail struct {
 	InitName    string `gorm:"type:varchar(100);not null" json:"init_name" validate:"required"`
 	FinalName   string `gorm:"type:varchar(100);not null" json:"final_name" validate:"required"`
 	ValueConv   int    `gorm:"type:int;not null;default:0" json:"value_conv" validate:"required"`
+	SalesPrice  int    `json:"sales_price"`
 	ProductId   string `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
 	InitId      string `gorm:"type:varchar(15);not null" json:"init_id" validate:"required"`
 	FinalId     string `gorm:"type:varchar(15);not null" json:"final_id" validate:"required"`
@@ -25,10 +27,11 @@ type UnitConversionDetail struct {
 
 // UnitConversionRequest merepresentasikan request body untuk membuat UnitConversion baru
 type UnitConversionRequest struct {
-	ProductId string `json:"product_id" validate:"required"`
-	InitId    string `json:"init_id" validate:"required"`
-	FinalId   string `json:"final_id" validate:"required"`
-	ValueConv int    `json:"value_conv" validate:"required,min=1"` // ValueConv harus minimal 1
+	ProductId  string `json:"product_id" validate:"required"`
+	InitId     string `json:"init_id" validate:"required"`
+	FinalId    string `json:"final_id" validate:"required"`
+	ValueConv  int    `json:"value_conv" validate:"required,min=1"` // ValueConv harus minimal 1
+	SalesPrice int    `json:"sales_price" validate:"min=0"`         // Opsional, harga jual per satuan InitId
 }
 
 // GetUnitsByProductIdRequest merepresentasikan body request untuk endpoint GET ini
@@ -15,12 +15,19 @@ type Branch struct {
 	AccountName      string          `gorm:"type:varchar(255);" json:"account_name"`
 	AccountNumber    string          `gorm:"type:varchar(100);" json:"account_number"`
 	TaxPercentage    int             `gorm:"type:int;default:0" json:"tax_percentage"`
+	TaxMode          TaxMode         `gorm:"type:tax_mode;not null;default:'exclusive'" json:"tax_mode"`
 	JournalMethod    JournalMethod   `gorm:"type:journal_method; default:'automatic'" json:"journal_method" validate:"required"`
 	BranchStatus     DataStatus      `gorm:"type:data_status;default:'inactive'" json:"branch_status"`
 	LicenseDate      time.Time       `gorm:"not null" json:"license_date" validate:"required"`
 	DefaultMember    string          `gorm:"type:varchar(15);not null" json:"default_member" validate:"required"`
 	SubscriptionType SubcriptionType `gorm:"type:subscription_type; default:'month'" json:"subscription_type" validate:"required"` // Kolom baru
 	Quota            int             `gorm:"type:integer;default:0" json:"quota"`
+	CostingMethod    CostingMethod   `gorm:"type:costing_method;not null;default:'average'" json:"costing_method"`
+	PointValue       int             `gorm:"type:int;not null;default:1" json:"point_value"`       // Nilai rupiah per poin saat ditukar
+	PointExpiryDays  int             `gorm:"type:int;not null;default:0" json:"point_expiry_days"` // Masa berlaku poin dalam hari, 0 = tidak kadaluarsa
+	StockPolicy      StockPolicy     `gorm:"type:stock_policy;not null;default:'forbid_negative'" json:"stock_policy"`
+	ReceiptHeader    string          `gorm:"type:text;" json:"receipt_header"` // Teks tambahan di bagian atas struk
+	ReceiptFooter    string          `gorm:"type:text;" json:"receipt_footer"` // Teks di bagian bawah struk, misal ucapan terima kasih
 }
 
 // SetID is function to set ID into Branch
@@ -28,6 +35,33 @@ func (b *Branch) SetID(id string) {
 	b.ID = id
 }
 
+// LineTax menghitung PPN satu baris item dari subTotal, produk bebas pajak tidak dikenakan PPN.
+// Pada mode inclusive PPN diambil dari porsi yang sudah termasuk di dalam harga.
+func (b *Branch) LineTax(subTotal int, exempt bool) int {
+	if exempt || b.TaxPercentage <= 0 || subTotal <= 0 {
+		return 0
+	}
+	if b.TaxMode == TaxInclusive {
+		return subTotal - subTotal*100/(100+b.TaxPercentage)
+	}
+	return subTotal * b.TaxPercentage / 100
+}
+
+// TaxTotals menghitung PPN transaksi dan grand total dari jumlah sub_total dan PPN seluruh item.
+// Diskon transaksi mengurangi DPP secara proporsional, PPN hanya ditambahkan ke total pada mode exclusive.
+func (b *Branch) TaxTotals(subTotal int, lineTax int, discount int) (int, int) {
+	tax := lineTax
+	if discount > 0 && subTotal > 0 {
+		tax = lineTax * max(subTotal-discount, 0) / subTotal
+	}
+
+	total := subTotal - discount
+	if b.TaxMode != TaxInclusive {
+		total += tax
+	}
+	return tax, total
+}
+
 // Profile Struct untuk profile model yang akan ditampilkan pada function GetDetail
 type Profile struct {
 	UserID        string        `gorm:"type:varchar(15);primaryKey" json:"user_id" validate:"required"`
@@ -43,6 +77,7 @@ type Profile struct {
 	AccountName   string        `gorm:"type:varchar(255);" json:"account_name"`
 	AccountNumber string        `gorm:"type:varchar(100);" json:"account_number"`
 	TaxPercentage int           `gorm:"type:int;default:0" json:"tax_percentage"`
+	TaxMode       TaxMode       `gorm:"type:tax_mode" json:"tax_mode"`
 	JournalMethod JournalMethod `gorm:"type:journal_method; default:'automatic'" json:"journal_method" validate:"required"`
 	BranchStatus  DataStatus    `gorm:"type:data_status;default:'inactive'" json:"branch_status"`
 	LicenseDate   time.Time     `gorm:"not null" json:"license_date" validate:"required"`
@@ -25,16 +25,6 @@ type WeeklyProfitReportResponse struct {
 	HPPPercentage    int `json:"hpp_percentage"`
 }
 
-// ProductExpiredResponse defines the structure for each expiring product item in the response
-type ProductExpiredResponse struct {
-	ID          string `json:"id"`
-	SKU         string `json:"sku"`
-	Name        string `json:"name"`
-	Stock       int    `json:"stock"`
-	Unit        string `json:"unit"`         // This will hold the unit name from the 'units' table
-	ExpiredDate string `json:"expired_date"` // Formatted date string
-}
-
 type ResponseProfitReportMonthly struct {
 	Status      string      `json:"status"`
 	Message     string      `json:"message"`
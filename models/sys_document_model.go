@@ -0,0 +1,37 @@
+package models
+
+// DocumentField pasangan label dan nilai, dipakai untuk info dokumen (nomor, tanggal) dan baris total
+type DocumentField struct {
+	Label string
+	Value string
+}
+
+// DocumentColumn kolom tabel item pada dokumen
+type DocumentColumn struct {
+	Title string
+	Width float64 // Porsi lebar kolom, dibandingkan dengan total Width semua kolom
+	Right bool    // Rata kanan, untuk angka
+}
+
+// Document isi dokumen cetak (faktur, nota, lembar opname) yang dirender ke PDF
+type Document struct {
+	Title         string
+	BranchName    string
+	BranchAddress string
+	BranchPhone   string
+	BranchEmail   string
+	Info          []DocumentField
+	Columns       []DocumentColumn
+	Rows          [][]string
+	Totals        []DocumentField
+	Notes         []string // Catatan di bawah total, misal instruksi pembayaran transfer
+	Signatures    []string // Judul kolom tanda tangan, misal "Hormat Kami"
+}
+
+// DocumentFileResponse file hasil generate, isi file dikirim dalam base64
+type DocumentFileResponse struct {
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	Content     string `json:"content"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKeys model, hasil request pembuat transaksi yang dikirim dengan header Idempotency-Key.
+// Request ulang dengan key yang sama mengembalikan respons tersimpan tanpa membuat transaksi baru.
+type IdempotencyKeys struct {
+	ID              string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	Key             string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_idempotency_scope" json:"key"`
+	UserID          string    `gorm:"type:varchar(15);not null;uniqueIndex:idx_idempotency_scope" json:"user_id"`
+	Endpoint        string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_idempotency_scope" json:"endpoint"`
+	BranchID        string    `gorm:"type:varchar(15);not null" json:"branch_id"`
+	RequestHash     string    `gorm:"type:varchar(64);not null" json:"request_hash"` // SHA-256 body request
+	ResponseStatus  int       `gorm:"type:int;not null;default:0" json:"response_status"`
+	ResponseMessage string    `gorm:"type:varchar(255);" json:"response_message"`
+	ResponseData    string    `gorm:"type:text;" json:"response_data"` // Data respons dalam bentuk JSON
+	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP;index" json:"created_at"`
+}
@@ -6,14 +6,18 @@ import "time"
 type TransactionType string
 
 const (
-	Purchase   TransactionType = "purchase"
-	Sale       TransactionType = "sale"
-	Expense    TransactionType = "expense"
-	Income     TransactionType = "income"
-	FirstStock TransactionType = "first_stock"
-	Ipname     TransactionType = "opname"
-	SaleReturn TransactionType = "sale_return"
-	BuyReturn  TransactionType = "buy_return"
+	Purchase          TransactionType = "purchase"
+	Sale              TransactionType = "sale"
+	Expense           TransactionType = "expense"
+	Income            TransactionType = "income"
+	FirstStock        TransactionType = "first_stock"
+	Ipname            TransactionType = "opname"
+	SaleReturn        TransactionType = "sale_return"
+	BuyReturn         TransactionType = "buy_return"
+	TransferOut       TransactionType = "transfer_out"
+	TransferIn        TransactionType = "transfer_in"
+	PayablePayment    TransactionType = "payable_payment"
+	ReceivablePayment TransactionType = "receivable_payment"
 )
 
 // TransactionReport model
@@ -67,16 +71,67 @@ const (
 	SaleReturnTrans     MovementType = "sale_return"
 	OpnameTrans         MovementType = "opname"
 	FirstStockTrans     MovementType = "first_stock"
+	TransferOutTrans    MovementType = "transfer_out"
+	TransferInTrans     MovementType = "transfer_in"
 )
 
-// stock_tracks model
+// stock_tracks model, satu baris untuk setiap mutasi stok produk
 type StockTracks struct {
 	ID           string       `gorm:"type:varchar(15);primaryKey" json:"id" validate:"required"`
 	MovementType MovementType `gorm:"type:movement_type;not null;default:'purchase'" json:"movement_type" validate:"required"`
-	ProductID    string       `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
+	ReferenceID  string       `gorm:"type:varchar(15);not null;index" json:"reference_id" validate:"required"`
+	ProductID    string       `gorm:"type:varchar(15);not null;index" json:"product_id" validate:"required"`
+	QtyBefore    int          `gorm:"type:int;not null;default:0" json:"qty_before"`
 	Stock        int          `gorm:"type:int;not null;default:0" json:"stock" validate:"required"`
-	UserID       string       `gorm:"type:varchar(15);primaryKey" json:"user_id" validate:"required"`
-	BranchID     string       `gorm:"type:varchar(15);not null" json:"branch_id" validate:"required"`
+	QtyAfter     int          `gorm:"type:int;not null;default:0" json:"qty_after"`
+	UserID       string       `gorm:"type:varchar(15);not null" json:"user_id" validate:"required"`
+	BranchID     string       `gorm:"type:varchar(15);not null;index" json:"branch_id" validate:"required"`
 	CreatedAt    time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt    time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
 }
+
+// All stock_tracks model, dipakai untuk response GET /api/stock-tracks/:product_id
+type AllStockTracks struct {
+	ID           string `json:"id"`
+	MovementType string `json:"movement_type"`
+	ReferenceID  string `json:"reference_id"`
+	ProductID    string `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	QtyBefore    int    `json:"qty_before"`
+	Stock        int    `json:"stock"`
+	QtyAfter     int    `json:"qty_after"`
+	UserID       string `json:"user_id"`
+	UserName     string `json:"user_name"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// TaxSideSummary ringkasan PPN satu sisi (keluaran dari penjualan / masukan dari pembelian).
+// Total, DPP dan PPN sudah dikurangi retur pada periode yang sama.
+type TaxSideSummary struct {
+	TransactionCount int `json:"transaction_count"`
+	TotalAmount      int `json:"total_amount"` // Total transaksi termasuk PPN
+	TaxBase          int `json:"tax_base"`     // DPP (total transaksi dikurangi PPN)
+	TaxAmount        int `json:"tax_amount"`
+	ReturnAmount     int `json:"return_amount"` // Total retur termasuk PPN
+	ReturnTax        int `json:"return_tax"`    // Porsi PPN dari retur
+}
+
+// DeductReturns mengurangi total, DPP dan PPN dengan nilai retur pada periode yang sama
+func (s *TaxSideSummary) DeductReturns(returnAmount int, returnTax int) {
+	s.ReturnAmount = returnAmount
+	s.ReturnTax = returnTax
+	s.TotalAmount -= returnAmount
+	s.TaxAmount -= returnTax
+	s.TaxBase = s.TotalAmount - s.TaxAmount
+}
+
+// TaxSummaryResponse ringkasan PPN bulanan untuk pelaporan SPT Masa PPN
+type TaxSummaryResponse struct {
+	Month         string         `json:"month"`
+	TaxPercentage int            `json:"tax_percentage"`
+	TaxMode       string         `json:"tax_mode"`
+	OutputTax     TaxSideSummary `json:"output_tax"` // PPN keluaran
+	InputTax      TaxSideSummary `json:"input_tax"`  // PPN masukan
+	NetTax        int            `json:"net_tax"`    // PPN keluaran - PPN masukan
+	Status        string         `json:"status"`     // kurang_bayar, lebih_bayar atau nihil
+}
@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// JournalSource jenis dokumen sumber jurnal, nilainya sama dengan transaction_type kecuali jurnal manual
+type JournalSource string
+
+const (
+	JournalSale              JournalSource = "sale"
+	JournalPurchase          JournalSource = "purchase"
+	JournalSaleReturn        JournalSource = "sale_return"
+	JournalBuyReturn         JournalSource = "buy_return"
+	JournalExpense           JournalSource = "expense"
+	JournalIncome            JournalSource = "income"
+	JournalOpname            JournalSource = "opname"
+	JournalFirstStock        JournalSource = "first_stock"
+	JournalTransferOut       JournalSource = "transfer_out"
+	JournalTransferIn        JournalSource = "transfer_in"
+	JournalPayablePayment    JournalSource = "payable_payment"
+	JournalReceivablePayment JournalSource = "receivable_payment"
+	JournalManual            JournalSource = "manual" // Jurnal umum yang diinput user
+)
+
+// JournalEntries model, header jurnal. Jurnal dari dokumen dibuat ulang setiap kali dokumennya berubah.
+type JournalEntries struct {
+	ID          string        `gorm:"type:varchar(15);primaryKey" json:"id"`
+	EntryDate   time.Time     `gorm:"not null;index" json:"entry_date"`
+	Description string        `gorm:"type:text;" json:"description"`
+	SourceType  JournalSource `gorm:"type:varchar(20);not null;index:idx_journal_source" json:"source_type"`
+	SourceId    string        `gorm:"type:varchar(15);index:idx_journal_source" json:"source_id"` // Kosong untuk jurnal manual
+	Status      JournalStatus `gorm:"type:journal_status;not null;default:'draft'" json:"status"`
+	TotalDebit  int           `gorm:"type:int;not null;default:0" json:"total_debit"`
+	TotalCredit int           `gorm:"type:int;not null;default:0" json:"total_credit"`
+	UserID      string        `gorm:"type:varchar(15);not null" json:"user_id"`
+	PostedBy    string        `gorm:"type:varchar(15);" json:"posted_by"`
+	PostedAt    *time.Time    `json:"posted_at"`
+	BranchID    string        `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	CreatedAt   time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// JournalLines model, baris debit / kredit jurnal
+type JournalLines struct {
+	ID             string `gorm:"type:varchar(15);primaryKey" json:"id"`
+	JournalEntryId string `gorm:"type:varchar(15);not null;index" json:"journal_entry_id"`
+	AccountId      string `gorm:"type:varchar(15);not null;index" json:"account_id"`
+	Debit          int    `gorm:"type:int;not null;default:0" json:"debit"`
+	Credit         int    `gorm:"type:int;not null;default:0" json:"credit"`
+	Memo           string `gorm:"type:varchar(255)" json:"memo"`
+}
+
+// JournalLineInput baris jurnal manual, isi salah satu dari debit atau kredit
+type JournalLineInput struct {
+	AccountId string `json:"account_id" validate:"required"`
+	Debit     int    `json:"debit" validate:"min=0"`
+	Credit    int    `json:"credit" validate:"min=0"`
+	Memo      string `json:"memo"`
+}
+
+// JournalEntryInput body untuk membuat jurnal manual
+type JournalEntryInput struct {
+	EntryDate   string             `json:"entry_date" validate:"required"` // Format YYYY-MM-DD
+	Description string             `json:"description" validate:"required"`
+	Lines       []JournalLineInput `json:"lines" validate:"required,min=2,dive"`
+}
+
+// JournalEntrySummary jurnal untuk tampilan list
+type JournalEntrySummary struct {
+	ID          string        `json:"id"`
+	EntryDate   time.Time     `json:"entry_date"`
+	Description string        `json:"description"`
+	SourceType  JournalSource `json:"source_type"`
+	SourceId    string        `json:"source_id"`
+	Status      JournalStatus `json:"status"`
+	TotalDebit  int           `json:"total_debit"`
+	TotalCredit int           `json:"total_credit"`
+	UserName    string        `json:"user_name"`
+}
+
+// JournalLineDetail baris jurnal beserta kode dan nama akun
+type JournalLineDetail struct {
+	ID          string `json:"id"`
+	AccountId   string `json:"account_id"`
+	AccountCode string `json:"account_code"`
+	AccountName string `json:"account_name"`
+	Debit       int    `json:"debit"`
+	Credit      int    `json:"credit"`
+	Memo        string `json:"memo"`
+}
+
+// JournalEntryDetail jurnal beserta baris-barisnya
+type JournalEntryDetail struct {
+	JournalEntries
+	Lines []JournalLineDetail `json:"lines"`
+}
@@ -12,6 +12,7 @@ type PurchaseItems struct {
 	Qty         int       `gorm:"type:int;not null;default:0" json:"qty" validate:"required"`
 	SubTotal    int       `gorm:"type:int;not null;default:0" json:"sub_total" validate:"required"`
 	ExpiredDate time.Time `gorm:"not null;default:(NOW() + interval '2 year')" json:"expired_date" validate:"required"`
+	TaxAmount   int       `gorm:"type:int;not null;default:0" json:"tax_amount"` // PPN untuk baris ini
 }
 
 // All Purchase Items model
@@ -78,6 +79,7 @@ type PurchaseItemInput struct {
 	Qty         int    `json:"qty" validate:"required"`
 	Price       int    `json:"price" validate:"required"`
 	ExpiredDate string `json:"expired_date" validate:"required"` // <--- Diubah menjadi string
+	BatchNumber string `json:"batch_number"`                     // Opsional, default ID item pembelian
 }
 
 type FormatedPurchaseItems struct {
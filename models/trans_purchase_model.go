@@ -4,15 +4,19 @@ import "time"
 
 // Purchases model
 type Purchases struct {
-	ID            string        `gorm:"type:varchar(15);primaryKey" json:"id"`
-	SupplierId    string        `gorm:"type:varchar(15);not null" json:"supplier_id" validate:"required"`
-	PurchaseDate  time.Time     `gorm:"not null" json:"purchase_date" validate:"required"`
-	BranchID      string        `gorm:"type:varchar(15);not null" json:"branch_id"`
-	TotalPurchase int           `gorm:"type:int;not null;default:0" json:"total_purchase"`
-	Payment       PaymentStatus `gorm:"type:payment_status;not null;default:'unpaid'" json:"payment"`
-	UserID        string        `gorm:"type:varchar(15);not null" json:"user_id"`
-	CreatedAt     time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt     time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+	ID              string        `gorm:"type:varchar(15);primaryKey" json:"id"`
+	SupplierId      string        `gorm:"type:varchar(15);not null" json:"supplier_id" validate:"required"`
+	PurchaseDate    time.Time     `gorm:"not null" json:"purchase_date" validate:"required"`
+	BranchID        string        `gorm:"type:varchar(15);not null" json:"branch_id"`
+	TotalPurchase   int           `gorm:"type:int;not null;default:0" json:"total_purchase"`
+	Payment         PaymentStatus `gorm:"type:payment_status;not null;default:'unpaid'" json:"payment"`
+	UserID          string        `gorm:"type:varchar(15);not null" json:"user_id"`
+	PurchaseOrderId string        `gorm:"type:varchar(15);index" json:"purchase_order_id"` // Terisi jika pembelian berasal dari penerimaan PO
+	DueDate         *time.Time    `json:"due_date"`                                        // Jatuh tempo hutang untuk pembelian paid_by_credit
+	PaidAmount      int           `gorm:"type:int;not null;default:0" json:"paid_amount"`  // Total pembayaran hutang yang sudah dilakukan
+	TaxAmount       int           `gorm:"type:int;not null;default:0" json:"tax_amount"`   // PPN masukan pembelian
+	CreatedAt       time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // All Purchases model
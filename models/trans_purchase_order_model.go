@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// PurchaseOrders model, pesanan pembelian ke supplier sebelum barang diterima
+type PurchaseOrders struct {
+	ID          string              `gorm:"type:varchar(15);primaryKey" json:"id"`
+	SupplierId  string              `gorm:"type:varchar(15);not null" json:"supplier_id" validate:"required"`
+	OrderDate   time.Time           `gorm:"not null" json:"order_date" validate:"required"`
+	BranchID    string              `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	Description string              `gorm:"type:text;" json:"description"`
+	TotalOrder  int                 `gorm:"type:int;not null;default:0" json:"total_order"`
+	Status      PurchaseOrderStatus `gorm:"type:purchase_order_status;not null;default:'draft'" json:"status"`
+	UserID      string              `gorm:"type:varchar(15);not null" json:"user_id"`
+	ApprovedBy  string              `gorm:"type:varchar(15);" json:"approved_by"`
+	ApprovedAt  *time.Time          `json:"approved_at"`
+	CreatedAt   time.Time           `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PurchaseOrderItems model, baris pesanan dengan harga yang disepakati per unit pesanan
+type PurchaseOrderItems struct {
+	ID              string `gorm:"type:varchar(15);primaryKey" json:"id"`
+	PurchaseOrderId string `gorm:"type:varchar(15);not null;index" json:"purchase_order_id"`
+	ProductId       string `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
+	UnitId          string `gorm:"type:varchar(15);not null" json:"unit_id" validate:"required"`
+	Price           int    `gorm:"type:int;not null;default:0" json:"price" validate:"required"` // Harga sepakat per unit pesanan
+	Qty             int    `gorm:"type:int;not null;default:0" json:"qty" validate:"required,min=1"`
+	QtyReceived     int    `gorm:"type:int;not null;default:0" json:"qty_received"`
+	SubTotal        int    `gorm:"type:int;not null;default:0" json:"sub_total"`
+}
+
+// PurchaseOrderRequest body untuk membuat pesanan pembelian
+type PurchaseOrderRequest struct {
+	PurchaseOrder      PurchaseOrderInput       `json:"purchase_order" validate:"required"`
+	PurchaseOrderItems []PurchaseOrderItemInput `json:"purchase_order_items" validate:"required,min=1,dive"`
+}
+
+// PurchaseOrderInput header pesanan pembelian dari request
+type PurchaseOrderInput struct {
+	SupplierId  string `json:"supplier_id" validate:"required"`
+	OrderDate   string `json:"order_date"` // Format YYYY-MM-DD, default hari ini
+	Description string `json:"description"`
+}
+
+// PurchaseOrderItemInput item pesanan pembelian dari request
+type PurchaseOrderItemInput struct {
+	ProductId string `json:"product_id" validate:"required"`
+	UnitId    string `json:"unit_id" validate:"required"`
+	Qty       int    `json:"qty" validate:"required,min=1"`
+	Price     int    `json:"price" validate:"required"`
+}
+
+// GoodsReceiptRequest body penerimaan barang dari pesanan pembelian
+type GoodsReceiptRequest struct {
+	PurchaseDate string                  `json:"purchase_date"` // Format YYYY-MM-DD, default hari ini
+	Payment      PaymentStatus           `json:"payment"`
+	Items        []GoodsReceiptItemInput `json:"items" validate:"required,min=1,dive"`
+}
+
+// GoodsReceiptItemInput qty yang diterima per baris pesanan
+type GoodsReceiptItemInput struct {
+	ItemId      string `json:"item_id" validate:"required"`
+	Qty         int    `json:"qty" validate:"required,min=1"`
+	ExpiredDate string `json:"expired_date" validate:"required"`
+	BatchNumber string `json:"batch_number"`
+}
+
+// AllPurchaseOrders model untuk daftar pesanan pembelian
+type AllPurchaseOrders struct {
+	ID           string              `json:"id"`
+	SupplierId   string              `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	OrderDate    time.Time           `json:"order_date"`
+	Description  string              `json:"description"`
+	TotalOrder   int                 `json:"total_order"`
+	Status       PurchaseOrderStatus `json:"status"`
+}
+
+// PurchaseOrderResponse model pesanan pembelian yang sudah diformat
+type PurchaseOrderResponse struct {
+	ID           string `json:"id"`
+	SupplierId   string `json:"supplier_id"`
+	SupplierName string `json:"supplier_name"`
+	OrderDate    string `json:"order_date"`
+	Description  string `json:"description"`
+	TotalOrder   int    `json:"total_order"`
+	Status       string `json:"status"`
+}
+
+// AllPurchaseOrderItems model item pesanan beserta sisa dan kelebihan kiriman
+type AllPurchaseOrderItems struct {
+	ID              string `json:"id"`
+	PurchaseOrderId string `json:"purchase_order_id"`
+	ProductId       string `json:"product_id"`
+	ProductName     string `json:"product_name"`
+	UnitId          string `json:"unit_id"`
+	UnitName        string `json:"unit_name"`
+	Price           int    `json:"price"`
+	Qty             int    `json:"qty"`
+	QtyReceived     int    `json:"qty_received"`
+	QtyOutstanding  int    `json:"qty_outstanding"`
+	QtyOver         int    `json:"qty_over"`
+	SubTotal        int    `json:"sub_total"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// ReceivablePayments model, cicilan pembayaran piutang member atas penjualan paid_by_credit
+type ReceivablePayments struct {
+	ID          string        `gorm:"type:varchar(15);primaryKey" json:"id"`
+	SaleId      string        `gorm:"type:varchar(15);not null;index" json:"sale_id" validate:"required"`
+	MemberId    string        `gorm:"type:varchar(15);not null;index" json:"member_id"`
+	PaymentDate time.Time     `gorm:"not null" json:"payment_date"`
+	Amount      int           `gorm:"type:int;not null;default:0" json:"amount" validate:"required,min=1"`
+	Payment     PaymentStatus `gorm:"type:payment_status;not null;default:'paid_by_cash'" json:"payment"`
+	Description string        `gorm:"type:text;" json:"description"`
+	BranchID    string        `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	UserID      string        `gorm:"type:varchar(15);not null" json:"user_id"`
+	CreatedAt   time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ReceivablePaymentInput body untuk mencatat cicilan piutang
+type ReceivablePaymentInput struct {
+	SaleId      string        `json:"sale_id" validate:"required"`
+	PaymentDate string        `json:"payment_date"` // Format YYYY-MM-DD, default hari ini
+	Amount      int           `json:"amount" validate:"required,min=1"`
+	Payment     PaymentStatus `json:"payment" validate:"required,oneof=paid_by_cash paid_by_bank"`
+	Description string        `json:"description"`
+}
+
+// AllReceivablePayments model untuk daftar cicilan piutang
+type AllReceivablePayments struct {
+	ID          string        `json:"id"`
+	SaleId      string        `json:"sale_id"`
+	MemberId    string        `json:"member_id"`
+	MemberName  string        `json:"member_name"`
+	PaymentDate time.Time     `json:"payment_date"`
+	Amount      int           `json:"amount"`
+	Payment     PaymentStatus `json:"payment"`
+	Description string        `json:"description"`
+}
+
+// ReceivablePaymentResponse model cicilan piutang yang sudah diformat
+type ReceivablePaymentResponse struct {
+	ID          string `json:"id"`
+	SaleId      string `json:"sale_id"`
+	MemberId    string `json:"member_id"`
+	MemberName  string `json:"member_name"`
+	PaymentDate string `json:"payment_date"`
+	Amount      int    `json:"amount"`
+	Payment     string `json:"payment"`
+	Description string `json:"description"`
+}
+
+// ReceivableDB model penjualan kredit yang belum lunas
+type ReceivableDB struct {
+	SaleId         string     `json:"sale_id"`
+	MemberId       string     `json:"member_id"`
+	MemberName     string     `json:"member_name"`
+	SaleDate       time.Time  `json:"sale_date"`
+	DueDate        *time.Time `json:"due_date"`
+	TotalSale      int        `json:"total_sale"`
+	PaidAmount     int        `json:"paid_amount"`
+	ReturnedAmount int        `json:"returned_amount"` // Retur yang mengurangi piutang
+}
+
+// ReceivableResponse model piutang yang sudah diformat
+type ReceivableResponse struct {
+	SaleId      string `json:"sale_id"`
+	MemberId    string `json:"member_id"`
+	MemberName  string `json:"member_name"`
+	SaleDate    string `json:"sale_date"`
+	DueDate     string `json:"due_date"`
+	TotalSale   int    `json:"total_sale"`
+	PaidAmount  int    `json:"paid_amount"`
+	Outstanding int    `json:"outstanding"`
+	DaysOverdue int    `json:"days_overdue"`
+}
+
+// ReceivableAgingResponse umur piutang per member
+type ReceivableAgingResponse struct {
+	MemberId   string `json:"member_id"`
+	MemberName string `json:"member_name"`
+	Current    int    `json:"current"`
+	Days1To30  int    `json:"days_1_30"`
+	Days31To60 int    `json:"days_31_60"`
+	Days61To90 int    `json:"days_61_90"`
+	Over90     int    `json:"over_90"`
+	Total      int    `json:"total"`
+}
+
+// MemberStatementLine satu baris mutasi pada rekening koran member
+type MemberStatementLine struct {
+	Date        time.Time `json:"-"`
+	DateString  string    `json:"date"`
+	ReferenceID string    `json:"reference_id"`
+	Description string    `json:"description"`
+	Debit       int       `json:"debit"`  // Penjualan kredit menambah piutang
+	Credit      int       `json:"credit"` // Cicilan mengurangi piutang
+	Balance     int       `json:"balance"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// SaleCarts model, keranjang penjualan (draft) milik kasir yang bisa ditahan dan dilanjutkan
+type SaleCarts struct {
+	ID        string     `gorm:"type:varchar(15);primaryKey" json:"id"`
+	Label     string     `gorm:"type:varchar(100);" json:"label"` // Penanda keranjang, misal nama pelanggan
+	MemberId  string     `gorm:"type:varchar(15);" json:"member_id"`
+	Status    CartStatus `gorm:"type:cart_status;not null;default:'open'" json:"status"`
+	SaleId    string     `gorm:"type:varchar(15);index" json:"sale_id"` // Penjualan hasil checkout
+	UserID    string     `gorm:"type:varchar(15);not null;index" json:"user_id"`
+	BranchID  string     `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SaleCartItems model, baris produk di dalam keranjang penjualan
+type SaleCartItems struct {
+	ID        string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	CartId    string    `gorm:"type:varchar(15);not null;index" json:"cart_id"`
+	ProductId string    `gorm:"type:varchar(15);not null" json:"product_id"`
+	UnitId    string    `gorm:"type:varchar(15)" json:"unit_id"`               // Satuan jual
+	ConvValue int       `gorm:"type:int;not null;default:1" json:"conv_value"` // Jumlah satuan dasar per satuan jual
+	Price     int       `gorm:"type:int;not null;default:0" json:"price"`      // Harga jual per satuan saat produk dimasukkan ke keranjang
+	Qty       int       `gorm:"type:int;not null;default:0" json:"qty"`        // Qty dalam satuan jual
+	SubTotal  int       `gorm:"type:int;not null;default:0" json:"sub_total"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// SaleCartInput body untuk membuat / mengubah keranjang
+type SaleCartInput struct {
+	Label    string `json:"label" validate:"max=100"`
+	MemberId string `json:"member_id"`
+}
+
+// SaleCartItemInput body untuk menambah / mengubah baris keranjang
+type SaleCartItemInput struct {
+	ProductId string `json:"product_id" validate:"required"`
+	UnitId    string `json:"unit_id"` // Kosong = satuan dasar produk
+	Qty       int    `json:"qty" validate:"required,min=1"`
+}
+
+// SaleCartCheckoutInput body untuk checkout keranjang menjadi penjualan
+type SaleCartCheckoutInput struct {
+	Discount       int            `json:"discount" validate:"min=0"`
+	Payment        PaymentStatus  `json:"payment"`
+	PointsRedeemed int            `json:"points_redeemed" validate:"min=0"`
+	Payments       []SalePayments `json:"payments" validate:"dive"`
+}
+
+// AllSaleCarts model ringkasan keranjang hasil query
+type AllSaleCarts struct {
+	ID         string     `json:"id"`
+	Label      string     `json:"label"`
+	MemberId   string     `json:"member_id"`
+	MemberName string     `json:"member_name"`
+	Status     CartStatus `json:"status"`
+	SaleId     string     `json:"sale_id"`
+	TotalQty   int        `json:"total_qty"`
+	Total      int        `json:"total"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// SaleCartItemDetail baris keranjang beserta nama produk dan stok saat ini
+type SaleCartItemDetail struct {
+	ID          string `json:"id"`
+	ProductId   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	UnitId      string `json:"unit_id"`
+	UnitName    string `json:"unit_name"`
+	ConvValue   int    `json:"conv_value"`
+	Price       int    `json:"price"`
+	Qty         int    `json:"qty"`
+	SubTotal    int    `json:"sub_total"`
+	Stock       int    `json:"stock"` // Stok dalam satuan jual baris ini
+}
+
+// SaleCartResponse detail keranjang beserta barisnya
+type SaleCartResponse struct {
+	AllSaleCarts
+	Items []SaleCartItemDetail `json:"items"`
+}
@@ -2,24 +2,49 @@ package models
 
 // Sale Items model
 type SaleItems struct {
-	ID        string `gorm:"type:varchar(15);primaryKey" json:"id"`    // Hapus validate:"required"
-	SaleId    string `gorm:"type:varchar(15);not null" json:"sale_id"` // Hapus validate:"required"
-	ProductId string `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
-	Price     int    `gorm:"type:int;not null;default:0" json:"price" validate:"required"`
-	Qty       int    `gorm:"type:int;not null;default:0" json:"qty" validate:"required,min=1"`
-	SubTotal  int    `gorm:"type:int;not null;default:0" json:"sub_total" validate:"required"`
+	ID            string `gorm:"type:varchar(15);primaryKey" json:"id"`    // Hapus validate:"required"
+	SaleId        string `gorm:"type:varchar(15);not null" json:"sale_id"` // Hapus validate:"required"
+	ProductId     string `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
+	UnitId        string `gorm:"type:varchar(15)" json:"unit_id"`                                  // Satuan jual, kosong = satuan dasar produk
+	ConvValue     int    `gorm:"type:int;not null;default:1" json:"conv_value"`                    // Jumlah satuan dasar per satuan jual
+	Price         int    `gorm:"type:int;not null;default:0" json:"price" validate:"min=0"`        // Harga per satuan jual, diisi dari daftar harga kecuali price_override
+	Qty           int    `gorm:"type:int;not null;default:0" json:"qty" validate:"required,min=1"` // Qty dalam satuan jual
+	SubTotal      int    `gorm:"type:int;not null;default:0" json:"sub_total" validate:"min=0"`
+	CostPrice     int    `gorm:"type:int;not null;default:0" json:"cost_price"`     // Harga pokok per satuan dasar saat terjual (COGS)
+	PromoId       string `gorm:"type:varchar(15);index" json:"promo_id"`            // Promo yang diterapkan pada baris ini
+	PromoDiscount int    `gorm:"type:int;not null;default:0" json:"promo_discount"` // Total potongan promo untuk baris ini
+	TaxAmount     int    `gorm:"type:int;not null;default:0" json:"tax_amount"`     // PPN untuk baris ini
+	PriceListId   string `gorm:"type:varchar(15)" json:"price_list_id"`             // Daftar harga pembeli saat terjual, kosong = harga jual produk
+	ListPrice     int    `gorm:"type:int;not null;default:0" json:"list_price"`     // Harga menurut daftar harga sebelum diubah kasir
+
+	PriceOverride  bool   `gorm:"-" json:"price_override"`  // true jika kasir memakai harga sendiri (dicatat di price_overrides)
+	OverrideReason string `gorm:"-" json:"override_reason"` // Alasan perubahan harga
+}
+
+// BaseQty qty item dalam satuan dasar produk, dipakai untuk mutasi stok, batch dan harga pokok
+func (item SaleItems) BaseQty() int {
+	if item.ConvValue <= 0 {
+		return item.Qty
+	}
+	return item.Qty * item.ConvValue
 }
 
 // All Sale Items model
 type AllSaleItems struct {
-	ID          string `gorm:"type:varchar(15);primaryKey" json:"id" validate:"required"`
-	SaleId      string `gorm:"type:varchar(15);not null" json:"sale_id" validate:"required"`
-	ProductId   string `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
-	ProductName string `gorm:"type:varchar(255);not null" json:"product_name" validate:"required"`
-	Price       int    `gorm:"type:int;not null;default:0" json:"price" validate:"required"`
-	Qty         int    `gorm:"type:int;not null;default:0" json:"qty" validate:"required"`
-	UnitName    string `gorm:"type:varchar(255);not null" json:"unit_name" validate:"required"`
-	SubTotal    int    `gorm:"type:int;not null;default:0" json:"sub_total" validate:"required"`
+	ID            string `gorm:"type:varchar(15);primaryKey" json:"id" validate:"required"`
+	SaleId        string `gorm:"type:varchar(15);not null" json:"sale_id" validate:"required"`
+	ProductId     string `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
+	ProductName   string `gorm:"type:varchar(255);not null" json:"product_name" validate:"required"`
+	Price         int    `gorm:"type:int;not null;default:0" json:"price" validate:"required"`
+	Qty           int    `gorm:"type:int;not null;default:0" json:"qty" validate:"required"`
+	UnitId        string `json:"unit_id"`
+	UnitName      string `gorm:"type:varchar(255);not null" json:"unit_name" validate:"required"`
+	ConvValue     int    `json:"conv_value"`
+	PromoId       string `json:"promo_id"`
+	PromoName     string `json:"promo_name"`
+	PromoDiscount int    `json:"promo_discount"`
+	TaxAmount     int    `json:"tax_amount"`
+	SubTotal      int    `gorm:"type:int;not null;default:0" json:"sub_total" validate:"required"`
 }
 
 // SaleItemResponse adalah struct khusus untuk data detail penjualan,
@@ -31,6 +56,7 @@ type SaleItemResponse struct {
 	SaleDate       string      `json:"sale_date"` // Ini akan menjadi STRING yang diformat
 	TotalSale      int         `json:"total_sale"`
 	Discount       int         `json:"discount"`
+	TaxAmount      int         `json:"tax_amount"`
 	ProfitEstimate int         `json:"profit_estimate"`
 	Payment        string      `json:"payment"`
 	Items          interface{} `json:"items"` // Items bisa berupa []models.AllSaleItems
@@ -14,6 +14,12 @@ type Sales struct {
 	Discount       int           `gorm:"type:int;not null;default:0" json:"discount"`        // Tetap ada jika diskon wajib diisi klien
 	ProfitEstimate int           `gorm:"type:int;not null;default:0" json:"profit_estimate"` // Hapus validate:"required"
 	Payment        PaymentStatus `gorm:"type:payment_status;not null;default:'unpaid'" json:"payment"`
+	DueDate        *time.Time    `json:"due_date"`                                           // Jatuh tempo piutang untuk penjualan paid_by_credit
+	PaidAmount     int           `gorm:"type:int;not null;default:0" json:"paid_amount"`     // Total cicilan piutang yang sudah diterima
+	ReturnedAmount int           `gorm:"type:int;not null;default:0" json:"returned_amount"` // Total retur yang mengurangi piutang (nota kredit)
+	PointsRedeemed int           `gorm:"type:int;not null;default:0" json:"points_redeemed"` // Poin member yang ditukar sebagai pembayaran
+	PointsAmount   int           `gorm:"type:int;not null;default:0" json:"points_amount"`   // Nilai rupiah dari poin yang ditukar
+	TaxAmount      int           `gorm:"type:int;not null;default:0" json:"tax_amount"`      // PPN keluaran penjualan
 	UserID         string        `gorm:"type:varchar(15);not null" json:"user_id"`
 	CreatedAt      time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt      time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
@@ -25,6 +31,7 @@ type AllSales struct {
 	MemberId       string        `gorm:"type:varchar(15);not null" json:"member_id" validate:"required"`
 	MemberName     string        `gorm:"type:varchar(100);not null" json:"member_name" validate:"required"`
 	Discount       int           `gorm:"type:int;not null;default:0" json:"discount"`
+	TaxAmount      int           `gorm:"type:int;not null;default:0" json:"tax_amount"`
 	ProfitEstimate int           `gorm:"type:int;not null;default:0" json:"profit_estimate" validate:"required"`
 	SaleDate       time.Time     `gorm:"not null" json:"sale_date" validate:"required"` // Tetap time.Time
 	TotalSale      int           `gorm:"type:int;not null;default:0" json:"total_sale" validate:"required"`
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// SalePayments model, rincian pembayaran (tender) sebuah penjualan
+type SalePayments struct {
+	ID              string        `gorm:"type:varchar(15);primaryKey" json:"id"`
+	SaleId          string        `gorm:"type:varchar(15);not null;index" json:"sale_id"`
+	Method          PaymentStatus `gorm:"type:payment_status;not null;default:'paid_by_cash'" json:"method" validate:"required,oneof=paid_by_cash paid_by_bank"`
+	Amount          int           `gorm:"type:int;not null;default:0" json:"amount" validate:"required,min=1"` // Nominal yang dibayarkan dengan metode ini
+	Tendered        int           `gorm:"type:int;not null;default:0" json:"tendered" validate:"min=0"`        // Uang tunai yang diterima kasir
+	ChangeDue       int           `gorm:"type:int;not null;default:0" json:"change_due"`                       // Kembalian tunai
+	ReferenceNumber string        `gorm:"type:varchar(100);" json:"reference_number"`                          // No. referensi transfer / EDC
+	BranchID        string        `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	CreatedAt       time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// SaleTenderSummary total pembayaran penjualan per metode pembayaran
+type SaleTenderSummary struct {
+	Method string `json:"method"`
+	Count  int    `json:"count"`
+	Total  int    `json:"total"`
+}
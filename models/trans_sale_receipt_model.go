@@ -0,0 +1,33 @@
+package models
+
+// SaleReceipt data yang dicetak pada struk penjualan
+type SaleReceipt struct {
+	BranchName   string         `json:"branch_name"`
+	Address      string         `json:"address"`
+	Phone        string         `json:"phone"`
+	Header       string         `json:"header"`
+	Footer       string         `json:"footer"`
+	SaleID       string         `json:"sale_id"`
+	SaleDate     string         `json:"sale_date"`
+	Cashier      string         `json:"cashier"`
+	MemberName   string         `json:"member_name"`
+	Items        []AllSaleItems `json:"items"`
+	SubTotal     int            `json:"sub_total"` // Jumlah sub_total item (setelah promo)
+	Discount     int            `json:"discount"`
+	TaxAmount    int            `json:"tax_amount"`
+	TaxMode      TaxMode        `json:"tax_mode"`
+	PointsAmount int            `json:"points_amount"`
+	Total        int            `json:"total"`
+	Payment      PaymentStatus  `json:"payment"`
+	Payments     []SalePayments `json:"payments"`
+}
+
+// SaleReceiptResponse struk penjualan siap cetak
+type SaleReceiptResponse struct {
+	Format     string   `json:"format"`           // text / escpos
+	PaperWidth int      `json:"paper_width"`      // Lebar kertas dalam mm (58 / 80)
+	Columns    int      `json:"columns"`          // Jumlah karakter per baris
+	Lines      []string `json:"lines"`            // Baris teks struk
+	Text       string   `json:"text"`             // Struk teks biasa
+	EscPos     string   `json:"escpos,omitempty"` // Byte stream ESC/POS dalam base64, hanya untuk format escpos
+}
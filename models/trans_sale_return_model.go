@@ -6,25 +6,31 @@ import (
 
 // SaleReturns model
 type SaleReturns struct {
-	ID          string        `gorm:"type:varchar(15);primaryKey" json:"id"`
-	SaleId      string        `gorm:"type:varchar(15);not null" json:"sale_id" validate:"required"`
-	ReturnDate  time.Time     `gorm:"not null" json:"return_date" validate:"required"`
-	BranchID    string        `gorm:"type:varchar(15);not null" json:"branch_id"`
-	TotalReturn int           `gorm:"type:int;not null;default:0" json:"total_purchase"`
-	Payment     PaymentStatus `gorm:"type:payment_status;not null;default:'paid_by_cash'" json:"payment"`
-	UserID      string        `gorm:"type:varchar(15);not null" json:"user_id"`
-	CreatedAt   time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt   time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+	ID           string        `gorm:"type:varchar(15);primaryKey" json:"id"`
+	SaleId       string        `gorm:"type:varchar(15);not null" json:"sale_id" validate:"required"`
+	ReturnDate   time.Time     `gorm:"not null" json:"return_date" validate:"required"`
+	BranchID     string        `gorm:"type:varchar(15);not null" json:"branch_id"`
+	TotalReturn  int           `gorm:"type:int;not null;default:0" json:"total_purchase"`
+	TaxAmount    int           `gorm:"type:int;not null;default:0" json:"tax_amount"`    // PPN keluaran yang dibatalkan oleh retur
+	CreditAmount int           `gorm:"type:int;not null;default:0" json:"credit_amount"` // Bagian retur yang mengurangi piutang penjualan kredit
+	Payment      PaymentStatus `gorm:"type:payment_status;not null;default:'paid_by_cash'" json:"payment"`
+	UserID       string        `gorm:"type:varchar(15);not null" json:"user_id"`
+	CreatedAt    time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // Sale Return Items model
 type SaleReturnItems struct {
 	ID           string    `gorm:"type:varchar(15);primaryKey" json:"id"`
 	SaleReturnId string    `gorm:"type:varchar(15);not null" json:"sale_return_id" validate:"required"`
+	SaleItemId   string    `gorm:"type:varchar(15)" json:"sale_item_id"` // Item penjualan asal yang diretur
 	ProductId    string    `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`
+	UnitId       string    `gorm:"type:varchar(15)" json:"unit_id"`               // Satuan jual item penjualan asal
+	ConvValue    int       `gorm:"type:int;not null;default:1" json:"conv_value"` // Jumlah satuan dasar per satuan jual
 	Price        int       `gorm:"type:int;not null;default:0" json:"price" validate:"required"`
 	Qty          int       `gorm:"type:int;not null;default:0" json:"qty" validate:"required"`
 	SubTotal     int       `gorm:"type:int;not null;default:0" json:"sub_total" validate:"required"`
+	TaxAmount    int       `gorm:"type:int;not null;default:0" json:"tax_amount"` // PPN baris retur
 	ExpiredDate  time.Time `gorm:"not null;default:(NOW() + interval '2 year')" json:"expired_date" validate:"required"`
 }
 
@@ -49,6 +55,7 @@ type SaleReturnInput struct {
 type SaleReturnItemInput struct {
 	ID          string `json:"id"`
 	ProductId   string `json:"product_id" validate:"required"`
+	UnitId      string `json:"unit_id"` // Satuan jual di penjualan asal, kosong = satuan dasar produk
 	Qty         int    `json:"qty" validate:"required"`
 	Price       int    `json:"price"`
 	ExpiredDate string `json:"expired_date" validate:"required"` // <--- Diubah menjadi string
@@ -71,6 +78,7 @@ type AllSaleReturnItems struct {
 	ProName      string    `gorm:"type:varchar(255);not null" json:"pro_name" validate:"required"`
 	UnitId       string    `gorm:"type:varchar(15);primaryKey" json:"unit_id"`
 	UnitName     string    `gorm:"type:varchar(255);not null" json:"unit_name" validate:"required"`
+	ConvValue    int       `json:"conv_value"`
 	Price        int       `gorm:"type:int;not null;default:0" json:"price" validate:"required"`
 	Qty          int       `gorm:"type:int;not null;default:0" json:"qty" validate:"required"`
 	SubTotal     int       `gorm:"type:int;not null;default:0" json:"sub_total" validate:"required"`
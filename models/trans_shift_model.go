@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// CashierShifts model, satu shift kasir dari buka sampai tutup kas
+type CashierShifts struct {
+	ID           string      `gorm:"type:varchar(15);primaryKey" json:"id"`
+	UserID       string      `gorm:"type:varchar(15);not null;index" json:"user_id"`
+	BranchID     string      `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	Status       ShiftStatus `gorm:"type:shift_status;not null;default:'open'" json:"status"`
+	OpeningCash  int         `gorm:"type:int;not null;default:0" json:"opening_cash"`  // Modal awal laci kas
+	ExpectedCash int         `gorm:"type:int;not null;default:0" json:"expected_cash"` // Modal awal + kas masuk - kas keluar selama shift
+	CountedCash  int         `gorm:"type:int;not null;default:0" json:"counted_cash"`  // Uang fisik yang dihitung saat tutup shift
+	Variance     int         `gorm:"type:int;not null;default:0" json:"variance"`      // Selisih counted_cash - expected_cash
+	Notes        string      `gorm:"type:text;" json:"notes"`
+	OpenedAt     time.Time   `gorm:"not null" json:"opened_at"`
+	ClosedAt     *time.Time  `json:"closed_at"`
+	CreatedAt    time.Time   `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// OpenShiftInput body untuk membuka shift
+type OpenShiftInput struct {
+	OpeningCash int `json:"opening_cash" validate:"min=0"`
+}
+
+// CloseShiftInput body untuk menutup shift
+type CloseShiftInput struct {
+	CountedCash int    `json:"counted_cash" validate:"min=0"`
+	Notes       string `json:"notes"`
+}
+
+// AllCashierShifts model shift beserta nama kasir hasil query
+type AllCashierShifts struct {
+	ID           string      `json:"id"`
+	UserID       string      `json:"user_id"`
+	UserName     string      `json:"user_name"`
+	BranchID     string      `json:"branch_id"`
+	Status       ShiftStatus `json:"status"`
+	OpeningCash  int         `json:"opening_cash"`
+	ExpectedCash int         `json:"expected_cash"`
+	CountedCash  int         `json:"counted_cash"`
+	Variance     int         `json:"variance"`
+	Notes        string      `json:"notes"`
+	OpenedAt     time.Time   `json:"opened_at"`
+	ClosedAt     *time.Time  `json:"closed_at"`
+}
+
+// ShiftCashFlow total transaksi tunai per tipe transaksi selama shift
+type ShiftCashFlow struct {
+	TransactionType string `json:"transaction_type"`
+	Direction       string `json:"direction"` // in / out
+	Count           int    `json:"count"`
+	Total           int    `json:"total"`
+}
+
+// ShiftPaymentTotal total penjualan per metode pembayaran selama shift
+type ShiftPaymentTotal struct {
+	Payment string `json:"payment"`
+	Count   int    `json:"count"`
+	Total   int    `json:"total"`
+}
+
+// ShiftResponse model shift yang sudah diformat
+type ShiftResponse struct {
+	ID           string `json:"id"`
+	UserID       string `json:"user_id"`
+	UserName     string `json:"user_name"`
+	Status       string `json:"status"`
+	OpeningCash  int    `json:"opening_cash"`
+	ExpectedCash int    `json:"expected_cash"`
+	CountedCash  int    `json:"counted_cash"`
+	Variance     int    `json:"variance"`
+	Notes        string `json:"notes"`
+	OpenedAt     string `json:"opened_at"`
+	ClosedAt     string `json:"closed_at"`
+}
+
+// ShiftZReport laporan akhir shift (Z-report)
+type ShiftZReport struct {
+	Shift          ShiftResponse       `json:"shift"`
+	BranchName     string              `json:"branch_name"`
+	SalesByPayment []ShiftPaymentTotal `json:"sales_by_payment"`
+	TotalSales     int                 `json:"total_sales"`
+	CashFlows      []ShiftCashFlow     `json:"cash_flows"`
+	CashIn         int                 `json:"cash_in"`
+	CashOut        int                 `json:"cash_out"`
+	PrintLines     []string            `json:"print_lines"` // Baris teks siap cetak
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// SupplierPayments model, pembayaran (boleh sebagian) atas pembelian paid_by_credit
+type SupplierPayments struct {
+	ID          string        `gorm:"type:varchar(15);primaryKey" json:"id"`
+	PurchaseId  string        `gorm:"type:varchar(15);not null;index" json:"purchase_id" validate:"required"`
+	SupplierId  string        `gorm:"type:varchar(15);not null;index" json:"supplier_id"`
+	PaymentDate time.Time     `gorm:"not null" json:"payment_date"`
+	Amount      int           `gorm:"type:int;not null;default:0" json:"amount" validate:"required,min=1"`
+	Payment     PaymentStatus `gorm:"type:payment_status;not null;default:'paid_by_cash'" json:"payment"`
+	Description string        `gorm:"type:text;" json:"description"`
+	BranchID    string        `gorm:"type:varchar(15);not null;index" json:"branch_id"`
+	UserID      string        `gorm:"type:varchar(15);not null" json:"user_id"`
+	CreatedAt   time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// SupplierPaymentInput body untuk mencatat pembayaran hutang
+type SupplierPaymentInput struct {
+	PurchaseId  string        `json:"purchase_id" validate:"required"`
+	PaymentDate string        `json:"payment_date"` // Format YYYY-MM-DD, default hari ini
+	Amount      int           `json:"amount" validate:"required,min=1"`
+	Payment     PaymentStatus `json:"payment" validate:"required,oneof=paid_by_cash paid_by_bank"`
+	Description string        `json:"description"`
+}
+
+// AllSupplierPayments model untuk daftar pembayaran hutang
+type AllSupplierPayments struct {
+	ID           string        `json:"id"`
+	PurchaseId   string        `json:"purchase_id"`
+	SupplierId   string        `json:"supplier_id"`
+	SupplierName string        `json:"supplier_name"`
+	PaymentDate  time.Time     `json:"payment_date"`
+	Amount       int           `json:"amount"`
+	Payment      PaymentStatus `json:"payment"`
+	Description  string        `json:"description"`
+}
+
+// SupplierPaymentResponse model pembayaran hutang yang sudah diformat
+type SupplierPaymentResponse struct {
+	ID           string `json:"id"`
+	PurchaseId   string `json:"purchase_id"`
+	SupplierId   string `json:"supplier_id"`
+	SupplierName string `json:"supplier_name"`
+	PaymentDate  string `json:"payment_date"`
+	Amount       int    `json:"amount"`
+	Payment      string `json:"payment"`
+	Description  string `json:"description"`
+}
+
+// PayableDB model hutang pembelian yang belum lunas
+type PayableDB struct {
+	PurchaseId    string     `json:"purchase_id"`
+	SupplierId    string     `json:"supplier_id"`
+	SupplierName  string     `json:"supplier_name"`
+	PurchaseDate  time.Time  `json:"purchase_date"`
+	DueDate       *time.Time `json:"due_date"`
+	TotalPurchase int        `json:"total_purchase"`
+	PaidAmount    int        `json:"paid_amount"`
+}
+
+// PayableResponse model hutang pembelian yang sudah diformat
+type PayableResponse struct {
+	PurchaseId    string `json:"purchase_id"`
+	SupplierId    string `json:"supplier_id"`
+	SupplierName  string `json:"supplier_name"`
+	PurchaseDate  string `json:"purchase_date"`
+	DueDate       string `json:"due_date"`
+	TotalPurchase int    `json:"total_purchase"`
+	PaidAmount    int    `json:"paid_amount"`
+	Outstanding   int    `json:"outstanding"`
+	DaysOverdue   int    `json:"days_overdue"`
+}
+
+// PayableAgingResponse umur hutang per supplier
+type PayableAgingResponse struct {
+	SupplierId   string `json:"supplier_id"`
+	SupplierName string `json:"supplier_name"`
+	Current      int    `json:"current"`
+	Days1To30    int    `json:"days_1_30"`
+	Days31To60   int    `json:"days_31_60"`
+	Days61To90   int    `json:"days_61_90"`
+	Over90       int    `json:"over_90"`
+	Total        int    `json:"total"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// StockTransfers model, dokumen pemindahan stok antar cabang
+type StockTransfers struct {
+	ID            string         `gorm:"type:varchar(15);primaryKey" json:"id"`
+	TransferDate  time.Time      `gorm:"not null" json:"transfer_date" validate:"required"`
+	FromBranchID  string         `gorm:"type:varchar(15);not null;index" json:"from_branch_id" validate:"required"`
+	ToBranchID    string         `gorm:"type:varchar(15);not null;index" json:"to_branch_id" validate:"required"`
+	Description   string         `gorm:"type:text;" json:"description"`
+	TotalTransfer int            `gorm:"type:int;not null;default:0" json:"total_transfer"`
+	Status        TransferStatus `gorm:"type:transfer_status;not null;default:'draft'" json:"status"`
+	UserID        string         `gorm:"type:varchar(15);not null" json:"user_id"`
+	ShippedBy     string         `gorm:"type:varchar(15);" json:"shipped_by"`
+	ShippedAt     *time.Time     `json:"shipped_at"`
+	ReceivedBy    string         `gorm:"type:varchar(15);" json:"received_by"`
+	ReceivedAt    *time.Time     `json:"received_at"`
+	CreatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// StockTransferItems model
+type StockTransferItems struct {
+	ID            string    `gorm:"type:varchar(15);primaryKey" json:"id"`
+	TransferId    string    `gorm:"type:varchar(15);not null;index" json:"transfer_id"`
+	ProductId     string    `gorm:"type:varchar(15);not null" json:"product_id" validate:"required"`      // Produk di cabang asal
+	DestProductId string    `gorm:"type:varchar(15);not null" json:"dest_product_id" validate:"required"` // Produk di cabang tujuan (dipetakan lewat SKU)
+	Price         int       `gorm:"type:int;not null;default:0" json:"price"`                             // Harga pokok per unit saat dikirim
+	Qty           int       `gorm:"type:int;not null;default:0" json:"qty" validate:"required,min=1"`
+	QtyReceived   int       `gorm:"type:int;not null;default:0" json:"qty_received"`
+	SubTotal      int       `gorm:"type:int;not null;default:0" json:"sub_total"`
+	ExpiredDate   time.Time `gorm:"not null;default:(NOW() + interval '2 year')" json:"expired_date"`
+}
+
+// StockTransferRequest body untuk membuat draft transfer
+type StockTransferRequest struct {
+	Transfer      StockTransferInput       `json:"transfer" validate:"required"`
+	TransferItems []StockTransferItemInput `json:"transfer_items" validate:"required,min=1,dive"`
+}
+
+// StockTransferInput header transfer dari request
+type StockTransferInput struct {
+	ToBranchID   string `json:"to_branch_id" validate:"required"`
+	TransferDate string `json:"transfer_date"` // Format YYYY-MM-DD, default hari ini
+	Description  string `json:"description"`
+}
+
+// StockTransferItemInput item transfer dari request
+type StockTransferItemInput struct {
+	ProductId string `json:"product_id" validate:"required"`
+	Qty       int    `json:"qty" validate:"required,min=1"`
+}
+
+// ReceiveTransferRequest body untuk penerimaan transfer, boleh sebagian
+type ReceiveTransferRequest struct {
+	Items []ReceiveTransferItemInput `json:"items" validate:"required,min=1,dive"`
+}
+
+// ReceiveTransferItemInput qty yang diterima per item transfer
+type ReceiveTransferItemInput struct {
+	ItemId string `json:"item_id" validate:"required"`
+	Qty    int    `json:"qty" validate:"required,min=1"`
+}
+
+// AllStockTransfers model untuk daftar transfer
+type AllStockTransfers struct {
+	ID             string         `json:"id"`
+	TransferDate   time.Time      `json:"transfer_date"`
+	FromBranchID   string         `json:"from_branch_id"`
+	FromBranchName string         `json:"from_branch_name"`
+	ToBranchID     string         `json:"to_branch_id"`
+	ToBranchName   string         `json:"to_branch_name"`
+	Description    string         `json:"description"`
+	TotalTransfer  int            `json:"total_transfer"`
+	Status         TransferStatus `json:"status"`
+}
+
+// StockTransferResponse model transfer yang sudah diformat
+type StockTransferResponse struct {
+	ID             string `json:"id"`
+	TransferDate   string `json:"transfer_date"`
+	FromBranchID   string `json:"from_branch_id"`
+	FromBranchName string `json:"from_branch_name"`
+	ToBranchID     string `json:"to_branch_id"`
+	ToBranchName   string `json:"to_branch_name"`
+	Description    string `json:"description"`
+	TotalTransfer  int    `json:"total_transfer"`
+	Status         string `json:"status"`
+}
+
+// AllStockTransferItems model item transfer beserta nama produk
+type AllStockTransferItems struct {
+	ID            string `json:"id"`
+	TransferId    string `json:"transfer_id"`
+	ProductId     string `json:"product_id"`
+	ProductName   string `json:"product_name"`
+	DestProductId string `json:"dest_product_id"`
+	SKU           string `json:"sku"`
+	Price         int    `json:"price"`
+	Qty           int    `json:"qty"`
+	QtyReceived   int    `json:"qty_received"`
+	SubTotal      int    `json:"sub_total"`
+	ExpiredDate   string `json:"expired_date"`
+}
@@ -48,6 +48,7 @@ func SyncSaleReport(db *gorm.DB, sale models.Sales) error {
 
 func RecalculateTotalSale(db *gorm.DB, saleID string) error {
 	var total int
+	var tax int
 	var profitEstimate int
 
 	// Ambil seluruh item dari sale
@@ -65,27 +66,43 @@ func RecalculateTotalSale(db *gorm.DB, saleID string) error {
 	// Hitung total dan estimasi profit
 	for _, item := range saleItems {
 		total += item.SubTotal
-
-		var product models.Product
-		if err := db.Select("purchase_price").First(&product, "id = ?", item.ProductId).Error; err != nil {
-			return err
+		tax += item.TaxAmount
+
+		// Gunakan harga pokok yang tersimpan saat item terjual,
+		// item lama yang belum punya cost_price memakai harga pokok produk saat ini
+		costPrice := item.CostPrice
+		if costPrice == 0 {
+			var product models.Product
+			if err := db.Select("purchase_price").First(&product, "id = ?", item.ProductId).Error; err != nil {
+				return err
+			}
+			costPrice = product.PurchasePrice
 		}
 
-		profitPerItem := item.Price - product.PurchasePrice
-		profitEstimate += profitPerItem * item.Qty
+		// Harga jual per satuan jual, harga pokok per satuan dasar
+		profitEstimate += item.Price*item.Qty - costPrice*item.BaseQty() - item.PromoDiscount
 	}
 
 	// Tetapkan diskon (pastikan tidak null)
 	discount := sale.Discount
 
-	// Total sale dikurangi diskon
-	totalAfterDiscount := total - discount
+	// Pengaturan PPN cabang
+	var branch models.Branch
+	if err := db.Select("id, tax_percentage, tax_mode").First(&branch, "id = ?", sale.BranchID).Error; err != nil {
+		return err
+	}
+
+	// Total sale dikurangi diskon, ditambah PPN jika harga belum termasuk pajak
+	saleTax, totalAfterDiscount := branch.TaxTotals(total, tax, discount)
 	if totalAfterDiscount < 0 {
 		totalAfterDiscount = 0
 	}
 
-	// Estimasi profit juga dikurangi diskon
+	// Estimasi profit juga dikurangi diskon, dan PPN jika sudah termasuk dalam harga
 	finalProfit := profitEstimate - discount
+	if branch.TaxMode == models.TaxInclusive {
+		finalProfit -= saleTax
+	}
 	if finalProfit < 0 {
 		finalProfit = 0
 	}
@@ -93,6 +110,7 @@ func RecalculateTotalSale(db *gorm.DB, saleID string) error {
 	// Update ke tabel sales
 	if err := db.Model(&models.Sales{}).Where("id = ?", saleID).Updates(map[string]interface{}{
 		"total_sale":      totalAfterDiscount,
+		"tax_amount":      saleTax,
 		"profit_estimate": finalProfit,
 	}).Error; err != nil {
 		return err
@@ -31,6 +31,7 @@ func AuditOpnameRoutes(app *framework.Fiber) {
 	auditOpname.Get("/", controllers.GetAllOpnames)
 	auditOpname.Post("/", controllers.CreateOpname)
 	auditOpname.Get("/:id", controllers.GetOpnameWithItems)
+	auditOpname.Get("/:id/pdf", controllers.GetOpnamePDF)
 	auditOpname.Put("/:id", controllers.UpdateOpnameByID)
 	auditOpname.Delete("/:id", controllers.DeleteOpnameByID)
 }
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// MasterPriceListRoutes mengatur rute-rute untuk daftar harga dan catatan perubahan harga di kasir
+func MasterPriceListRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT
+	priceListAPI := app.Group("/api/price-lists", middlewares.Protected(JWTSecret))
+
+	// Catatan perubahan harga di kasir hanya untuk admin dan finance
+	priceListAPI.Get("/overrides", controllers.GetPriceOverrides, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+
+	// Semua role bisa melihat daftar harga, hanya admin yang bisa mengatur daftar harga
+	priceListAPI.Get("/", controllers.GetAllPriceLists, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+	priceListAPI.Get("/:id", controllers.GetPriceList, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+	priceListAPI.Post("/", controllers.CreatePriceList, middlewares.AuthorizeRole("superadmin", "administrator"))
+	priceListAPI.Put("/:id", controllers.UpdatePriceList, middlewares.AuthorizeRole("superadmin", "administrator"))
+	priceListAPI.Delete("/:id", controllers.DeletePriceList, middlewares.AuthorizeRole("superadmin", "administrator"))
+	priceListAPI.Post("/:id/items", controllers.SavePriceListItem, middlewares.AuthorizeRole("superadmin", "administrator"))
+	priceListAPI.Delete("/:id/items/:item_id", controllers.DeletePriceListItem, middlewares.AuthorizeRole("superadmin", "administrator"))
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// MasterProductBarcodeRoutes mengatur rute-rute barcode produk dan scan kasir
+func MasterProductBarcodeRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT dan ROLE Authorization
+	barcodeAPI := app.Group("/api/barcodes", middlewares.Protected(JWTSecret))
+
+	// GET /api/barcodes/scan/:code - Scan barcode / SKU di kasir
+	barcodeAPI.Get("/scan/:code", controllers.ScanBarcode, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+
+	// GET /api/barcodes/product/:product_id - Daftar barcode satu produk
+	barcodeAPI.Get("/product/:product_id", controllers.GetProductBarcodes, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+
+	// POST /api/barcodes - Menambah barcode produk
+	barcodeAPI.Post("/", controllers.CreateProductBarcode, middlewares.AuthorizeRole("operator", "superadmin", "administrator"))
+
+	// POST /api/barcodes/generate - Membuat barcode internal untuk produk yang belum punya barcode
+	barcodeAPI.Post("/generate", controllers.GenerateMissingBarcodes, middlewares.AuthorizeRole("superadmin", "administrator"))
+
+	// GET /api/barcodes/:id/label - Gambar label barcode
+	barcodeAPI.Get("/:id/label", controllers.GetBarcodeLabel, middlewares.AuthorizeRole("operator", "superadmin", "administrator"))
+
+	// DELETE /api/barcodes/:id - Menghapus barcode
+	barcodeAPI.Delete("/:id", controllers.DeleteProductBarcode, middlewares.AuthorizeRole("superadmin", "administrator"))
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// MasterScheduledPriceRoutes mengatur rute-rute untuk jadwal perubahan harga produk
+func MasterScheduledPriceRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT
+	scheduledPriceAPI := app.Group("/api/scheduled-prices", middlewares.Protected(JWTSecret))
+
+	// Admin dan finance bisa melihat jadwal, hanya admin yang bisa menjadwalkan / membatalkan
+	scheduledPriceAPI.Get("/", controllers.GetScheduledPriceChanges, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+	scheduledPriceAPI.Post("/", controllers.CreateScheduledPriceChange, middlewares.AuthorizeRole("superadmin", "administrator"))
+	scheduledPriceAPI.Delete("/:id", controllers.CancelScheduledPriceChange, middlewares.AuthorizeRole("superadmin", "administrator"))
+}
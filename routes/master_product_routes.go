@@ -18,7 +18,9 @@ func MasterProductRoutes(app *framework.Fiber) {
 
 	productAPI.Post("/", controllers.CreateProduct)
 	productAPI.Get("/", controllers.GetAllProduct)
+	productAPI.Post("/import", controllers.ImportProducts, middlewares.AuthorizeRole("superadmin", "administrator"))
 	productAPI.Get("/:id", controllers.GetProduct)
+	productAPI.Get("/:id/price-history", controllers.GetProductPriceHistory)
 	productAPI.Put("/:id", controllers.UpdateProduct)
 	productAPI.Delete("/:id", controllers.DeleteProduct)
 }
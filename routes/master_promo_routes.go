@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// MasterPromoRoutes mengatur rute-rute untuk aturan promo
+func MasterPromoRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT
+	promoAPI := app.Group("/api/promos", middlewares.Protected(JWTSecret))
+
+	// Semua role bisa melihat promo, hanya admin yang bisa mengatur promo
+	promoAPI.Get("/", controllers.GetAllPromos, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+	promoAPI.Get("/:id", controllers.GetPromo, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+	promoAPI.Post("/", controllers.CreatePromo, middlewares.AuthorizeRole("superadmin", "administrator"))
+	promoAPI.Put("/:id", controllers.UpdatePromo, middlewares.AuthorizeRole("superadmin", "administrator"))
+	promoAPI.Delete("/:id", controllers.DeletePromo, middlewares.AuthorizeRole("superadmin", "administrator"))
+}
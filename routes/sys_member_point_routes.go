@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// SysMemberPointRoutes mengatur rute-rute untuk riwayat dan penyesuaian poin member
+func SysMemberPointRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT
+	memberPointAPI := app.Group("/api/member-points", middlewares.Protected(JWTSecret))
+
+	// GET /api/member-points/:member_id - Mengambil riwayat poin member
+	memberPointAPI.Get("/:member_id", controllers.GetMemberPointHistory, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+
+	// POST /api/member-points/adjust - Menyesuaikan poin member secara manual
+	memberPointAPI.Post("/adjust", controllers.AdjustMemberPoint, middlewares.AuthorizeRole("superadmin", "administrator"))
+}
@@ -16,4 +16,5 @@ func SysReportRoutes(app *framework.Fiber) {
 	reports := app.Group("/api/report", middlewares.Protected(JWTSecret), middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
 	reports.Get("/neraca-saldo", controllers.GetNeracaSaldo)
 	reports.Get("/profit-by-month", controllers.GetProfitGraphByMonth)
+	reports.Get("/tax-summary", controllers.GetTaxSummary)
 }
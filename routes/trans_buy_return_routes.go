@@ -22,6 +22,9 @@ func TransBuyReturnRoutes(app *framework.Fiber) {
 	// GET /api/buy-returns/:id - Mengambil transaksi pembelian retur berdasarkan ID
 	transBuyReturnAPI.Get("/:id", controllers.GetBuyReturnWithItems)
 
+	// GET /api/buy-returns/:id/pdf - Nota retur pembelian dalam format PDF (base64)
+	transBuyReturnAPI.Get("/:id/pdf", controllers.GetBuyReturnPDF)
+
 	// POST /api/buy-returns - Membuat transaksi pembelian retur baru
 	transBuyReturnAPI.Post("/", controllers.CreateBuyReturnTransaction)
 }
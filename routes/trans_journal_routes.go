@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// TransJournalRoutes mengatur rute-rute bagan akun, jurnal dan buku besar
+func TransJournalRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Bagan akun cabang, finance bisa melihat, hanya admin yang bisa mengubah
+	accountAPI := app.Group("/api/accounts", middlewares.Protected(JWTSecret))
+	accountAPI.Get("/", controllers.GetAllAccounts, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+	accountAPI.Post("/", controllers.CreateAccount, middlewares.AuthorizeRole("superadmin", "administrator"))
+	accountAPI.Put("/:id", controllers.UpdateAccount, middlewares.AuthorizeRole("superadmin", "administrator"))
+	accountAPI.Delete("/:id", controllers.DeleteAccount, middlewares.AuthorizeRole("superadmin", "administrator"))
+
+	// Jurnal dokumen dan jurnal umum
+	journalAPI := app.Group("/api/journals", middlewares.Protected(JWTSecret))
+	journalAPI.Get("/", controllers.GetAllJournals, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+	journalAPI.Get("/:id", controllers.GetJournal, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+	journalAPI.Post("/", controllers.CreateJournalEntry, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+	journalAPI.Post("/rebuild", controllers.RebuildJournals, middlewares.AuthorizeRole("superadmin", "administrator"))
+	journalAPI.Put("/:id/post", controllers.PostJournalEntry, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+	journalAPI.Delete("/:id", controllers.DeleteJournalEntry, middlewares.AuthorizeRole("superadmin", "administrator"))
+
+	// Neraca saldo dan buku besar, hanya dari jurnal terposting
+	ledgerAPI := app.Group("/api/ledger", middlewares.Protected(JWTSecret), middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+	ledgerAPI.Get("/trial-balance", controllers.GetTrialBalance)
+	ledgerAPI.Get("/general", controllers.GetGeneralLedger)
+	ledgerAPI.Get("/accounts/:id", controllers.GetAccountLedger)
+}
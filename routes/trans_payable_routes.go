@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// TransPayableRoutes mengatur rute-rute untuk hutang supplier dan pembayarannya
+func TransPayableRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT dan ROLE Authorization
+	supplierPaymentAPI := app.Group("/api/supplier-payments", middlewares.Protected(JWTSecret), middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+
+	// GET /api/supplier-payments - Mengambil semua pembayaran hutang
+	supplierPaymentAPI.Get("/", controllers.GetAllSupplierPayments)
+
+	// POST /api/supplier-payments - Mencatat pembayaran hutang pembelian kredit
+	supplierPaymentAPI.Post("/", controllers.CreateSupplierPayment)
+
+	payableAPI := app.Group("/api/payables", middlewares.Protected(JWTSecret), middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+
+	// GET /api/payables - Mengambil pembelian kredit yang belum lunas
+	payableAPI.Get("/", controllers.GetPayables)
+
+	// GET /api/payables/aging - Mengambil umur hutang per supplier
+	payableAPI.Get("/aging", controllers.GetPayableAging)
+}
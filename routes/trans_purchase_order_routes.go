@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// TransPurchaseOrderRoutes mengatur rute-rute untuk resource pesanan pembelian dan penerimaan barang
+func TransPurchaseOrderRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT
+	purchaseOrderAPI := app.Group("/api/purchase-orders", middlewares.Protected(JWTSecret))
+
+	// GET /api/purchase-orders - Mengambil semua pesanan pembelian
+	purchaseOrderAPI.Get("/", controllers.GetAllPurchaseOrders, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+
+	// GET /api/purchase-orders/:id - Mengambil pesanan pembelian beserta item-nya
+	purchaseOrderAPI.Get("/:id", controllers.GetPurchaseOrderWithItems, middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+
+	// POST /api/purchase-orders - Membuat pesanan pembelian baru
+	purchaseOrderAPI.Post("/", controllers.CreatePurchaseOrder, middlewares.AuthorizeRole("operator", "finance", "superadmin", "administrator"))
+
+	// PUT /api/purchase-orders/:id/approve - Menyetujui pesanan pembelian
+	purchaseOrderAPI.Put("/:id/approve", controllers.ApprovePurchaseOrder, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+
+	// PUT /api/purchase-orders/:id/cancel - Membatalkan pesanan pembelian
+	purchaseOrderAPI.Put("/:id/cancel", controllers.CancelPurchaseOrder, middlewares.AuthorizeRole("finance", "superadmin", "administrator"))
+
+	// POST /api/purchase-orders/:id/receive - Mencatat penerimaan barang dari pesanan
+	purchaseOrderAPI.Post("/:id/receive", controllers.ReceivePurchaseOrder, middlewares.AuthorizeRole("operator", "finance", "superadmin", "administrator"))
+
+	// DELETE /api/purchase-orders/:id - Menghapus pesanan yang masih draft
+	purchaseOrderAPI.Delete("/:id", controllers.DeletePurchaseOrder, middlewares.AuthorizeRole("operator", "finance", "superadmin", "administrator"))
+}
@@ -22,6 +22,9 @@ func TransPurchaseRoutes(app *framework.Fiber) {
 	// GET /api/purchases/:id - Mengambil transaksi pembelian berdasarkan ID
 	transPurchaseAPI.Get("/:id", controllers.GetPurchaseWithItems)
 
+	// GET /api/purchases/:id/pdf - Tanda terima pembelian dalam format PDF (base64)
+	transPurchaseAPI.Get("/:id/pdf", controllers.GetPurchasePDF)
+
 	// POST /api/purchases - Membuat transaksi pembelian baru
 	transPurchaseAPI.Post("/", controllers.CreatePurchaseTransaction)
 
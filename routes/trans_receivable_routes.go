@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// TransReceivableRoutes mengatur rute-rute untuk piutang member dan cicilannya
+func TransReceivableRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT dan ROLE Authorization
+	receivablePaymentAPI := app.Group("/api/receivable-payments", middlewares.Protected(JWTSecret), middlewares.AuthorizeRole("cashier", "finance", "superadmin", "administrator"))
+
+	// GET /api/receivable-payments - Mengambil semua cicilan piutang
+	receivablePaymentAPI.Get("/", controllers.GetAllReceivablePayments)
+
+	// POST /api/receivable-payments - Mencatat cicilan piutang penjualan kredit
+	receivablePaymentAPI.Post("/", controllers.CreateReceivablePayment)
+
+	receivableAPI := app.Group("/api/receivables", middlewares.Protected(JWTSecret), middlewares.AuthorizeRole("cashier", "finance", "superadmin", "administrator"))
+
+	// GET /api/receivables - Mengambil penjualan kredit yang belum lunas
+	receivableAPI.Get("/", controllers.GetReceivables)
+
+	// GET /api/receivables/aging - Mengambil umur piutang per member
+	receivableAPI.Get("/aging", controllers.GetReceivableAging)
+
+	// GET /api/receivables/statement/:member_id - Mengambil rekening koran piutang member
+	receivableAPI.Get("/statement/:member_id", controllers.GetMemberStatement)
+}
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// TransSaleCartRoutes mengatur rute-rute untuk keranjang penjualan yang bisa ditahan dan dilanjutkan
+func TransSaleCartRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT dan ROLE Authorization
+	cartAPI := app.Group("/api/carts", middlewares.Protected(JWTSecret), middlewares.AuthorizeRole("operator", "cashier", "finance", "superadmin", "administrator"))
+
+	// GET /api/carts - Mengambil keranjang milik kasir (default terbuka dan ditahan)
+	cartAPI.Get("/", controllers.GetAllSaleCarts)
+
+	// GET /api/carts/:id - Mengambil detail keranjang
+	cartAPI.Get("/:id", controllers.GetSaleCart)
+
+	// POST /api/carts - Membuat keranjang baru
+	cartAPI.Post("/", controllers.CreateSaleCart)
+
+	// PUT /api/carts/:id - Mengubah label / member keranjang
+	cartAPI.Put("/:id", controllers.UpdateSaleCart)
+
+	// DELETE /api/carts/:id - Membuang keranjang
+	cartAPI.Delete("/:id", controllers.DeleteSaleCart)
+
+	// Baris keranjang
+	cartAPI.Post("/:id/items", controllers.AddSaleCartItem)
+	cartAPI.Put("/:id/items/:item_id", controllers.UpdateSaleCartItem)
+	cartAPI.Delete("/:id/items/:item_id", controllers.DeleteSaleCartItem)
+
+	// Tahan, lanjutkan dan checkout keranjang
+	cartAPI.Post("/:id/park", controllers.ParkSaleCart)
+	cartAPI.Post("/:id/resume", controllers.ResumeSaleCart)
+	cartAPI.Post("/:id/checkout", controllers.CheckoutSaleCart)
+}
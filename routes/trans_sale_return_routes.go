@@ -22,6 +22,9 @@ func TransSaleReturnRoutes(app *framework.Fiber) {
 	// GET /api/sale-returns/:id - Mengambil transaksi penjualan retur berdasarkan ID
 	transSaleReturnAPI.Get("/:id", controllers.GetSaleReturnWithItems)
 
+	// GET /api/sale-returns/:id/pdf - Nota retur penjualan dalam format PDF (base64)
+	transSaleReturnAPI.Get("/:id/pdf", controllers.GetSaleReturnPDF)
+
 	// POST /api/sale-returns - Membuat transaksi penjualan retur baru
 	transSaleReturnAPI.Post("/", controllers.CreateSaleReturnTransaction)
 }
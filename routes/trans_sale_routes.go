@@ -22,6 +22,12 @@ func TransSaleRoutes(app *framework.Fiber) {
 	// GET /api/sales/:id - Mengambil transaksi penjualan berdasarkan ID
 	transSaleAPI.Get("/:id", controllers.GetSaleWithItems)
 
+	// GET /api/sales/:id/receipt - Struk penjualan (?format=text|escpos&width=58|80)
+	transSaleAPI.Get("/:id/receipt", controllers.GetSaleReceipt)
+
+	// GET /api/sales/:id/pdf - Faktur penjualan dalam format PDF (base64)
+	transSaleAPI.Get("/:id/pdf", controllers.GetSalePDF)
+
 	// POST /api/sales - Membuat transaksi penjualan baru
 	transSaleAPI.Post("/", controllers.CreateSaleTransaction)
 
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// TransShiftRoutes mengatur rute-rute untuk shift kasir dan Z-report
+func TransShiftRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT
+	shiftAPI := app.Group("/api/shifts", middlewares.Protected(JWTSecret))
+
+	// Buka / tutup shift hanya untuk kasir
+	shiftAPI.Post("/open", controllers.OpenShift, middlewares.AuthorizeRole("cashier"))
+	shiftAPI.Post("/close", controllers.CloseShift, middlewares.AuthorizeRole("cashier"))
+	shiftAPI.Get("/current", controllers.GetCurrentShift, middlewares.AuthorizeRole("cashier"))
+
+	// Riwayat shift dan Z-report
+	shiftAPI.Get("/", controllers.GetAllShifts, middlewares.AuthorizeRole("cashier", "finance", "superadmin", "administrator"))
+	shiftAPI.Get("/:id/z-report", controllers.GetShiftZReport, middlewares.AuthorizeRole("cashier", "finance", "superadmin", "administrator"))
+}
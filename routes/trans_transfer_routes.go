@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/heru-oktafian/api-retail/controllers"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/middlewares"
+)
+
+// TransTransferRoutes mengatur rute-rute untuk resource transfer stok antar cabang
+func TransTransferRoutes(app *framework.Fiber) {
+	// Load Secret Key from environment
+	JWTSecret := os.Getenv("JWT_SECRET_KEY")
+
+	// Grup rute yang DILINDUNGI dengan JWT dan ROLE Authorization
+	transTransferAPI := app.Group("/api/transfers", middlewares.Protected(JWTSecret), middlewares.AuthorizeRole("operator", "superadmin", "administrator"))
+
+	// GET /api/transfers - Mengambil semua transfer keluar dan masuk cabang aktif
+	transTransferAPI.Get("/", controllers.GetAllTransfers)
+
+	// GET /api/transfers/:id - Mengambil transfer beserta item-nya
+	transTransferAPI.Get("/:id", controllers.GetTransferWithItems)
+
+	// POST /api/transfers - Membuat draft transfer baru
+	transTransferAPI.Post("/", controllers.CreateTransfer)
+
+	// PUT /api/transfers/:id/ship - Mengirim transfer dari cabang asal
+	transTransferAPI.Put("/:id/ship", controllers.ShipTransfer)
+
+	// PUT /api/transfers/:id/receive - Menerima transfer di cabang tujuan, boleh sebagian
+	transTransferAPI.Put("/:id/receive", controllers.ReceiveTransfer)
+
+	// DELETE /api/transfers/:id - Menghapus transfer yang masih draft
+	transTransferAPI.Delete("/:id", controllers.DeleteTransfer)
+}
@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/utils"
 	"gorm.io/gorm"
 
 	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/api-retail/tools"
 )
 
 // Contoh: Backup DB pake pg_dump
@@ -44,22 +46,62 @@ func ClearRedisCache() {
 	log.Println("Redis cache dibersihkan")
 }
 
-func DeactivateExpiredPromos() {
-	// Query: UPDATE promos SET active = false WHERE end_date < NOW()
-	log.Println("Promo kadaluarsa dinonaktifkan")
+// DeactivateExpiredPromos menonaktifkan promo yang sudah lewat tanggal berakhirnya (WIB)
+func DeactivateExpiredPromos(db *gorm.DB) error {
+	affected, err := tools.DeactivateExpiredPromos(db, time.Now().In(utils.Location))
+	if err != nil {
+		log.Printf("[PROMO] Error deactivating expired promos: %v", err)
+		return err
+	}
+	log.Printf("[PROMO] %d promo kadaluarsa dinonaktifkan", affected)
+	return nil
+}
+
+// ApplyScheduledPriceChanges menerapkan jadwal perubahan harga produk yang tanggal berlakunya sudah tiba (WIB)
+func ApplyScheduledPriceChanges(db *gorm.DB) error {
+	applied, err := tools.ApplyScheduledPriceChanges(db, time.Now().In(utils.Location))
+	if err != nil {
+		log.Printf("[PRICE] Error applying scheduled price changes: %v", err)
+	}
+	log.Printf("[PRICE] %d jadwal perubahan harga diterapkan", applied)
+	return err
 }
 
-// AssetCounter menghitung dan menyimpan nilai aset harian berdasarkan stok, harga beli produk, dan mengurangi total pembelian kredit
+// PurgeIdempotencyKeys menghapus Idempotency-Key yang sudah melewati masa simpan
+func PurgeIdempotencyKeys(db *gorm.DB) error {
+	affected, err := tools.PurgeIdempotencyKeys(db, time.Now().In(utils.Location).Add(-tools.IdempotencyKeyTTL))
+	if err != nil {
+		log.Printf("[IDEMPOTENCY] Error purging idempotency keys: %v", err)
+		return err
+	}
+	log.Printf("[IDEMPOTENCY] %d idempotency key dihapus", affected)
+	return nil
+}
+
+// AssetCounter menghitung dan menyimpan nilai aset harian berdasarkan stok, harga beli produk, dan mengurangi sisa hutang pembelian kredit
 func AssetCounter(db *gorm.DB) error {
 	// SQL query untuk menghitung nilai aset per cabang
+	// Cabang dengan metode FIFO dinilai dari sisa layer harga pokok,
+	// selain itu dari stok * harga pokok rata-rata produk
 	query := `
 		SELECT 
-			branch_id,
-			SUM(stock * purchase_price) as total_asset
+			p.branch_id,
+			SUM(
+				CASE WHEN b.costing_method = 'fifo' AND cl.layer_qty = p.stock
+					THEN cl.layer_value
+					ELSE p.stock * p.purchase_price
+				END
+			) as total_asset
 		FROM 
-			products
+			products p
+			LEFT JOIN branches b ON b.id = p.branch_id
+			LEFT JOIN (
+				SELECT product_id, SUM(qty) AS layer_qty, SUM(qty * unit_cost) AS layer_value
+				FROM cost_layers
+				GROUP BY product_id
+			) cl ON cl.product_id = p.id
 		GROUP BY 
-			branch_id
+			p.branch_id
 	`
 
 	type BranchAsset struct {
@@ -73,11 +115,11 @@ func AssetCounter(db *gorm.DB) error {
 		return err
 	}
 
-	// Query untuk total pembelian kredit per cabang
+	// Query untuk sisa hutang pembelian kredit per cabang (total dikurangi yang sudah dibayar)
 	creditQuery := `
 		SELECT 
 			branch_id,
-			COALESCE(SUM(total_purchase), 0) as total_credit
+			COALESCE(SUM(total_purchase - paid_amount), 0) as total_credit
 		FROM 
 			purchases
 		WHERE 
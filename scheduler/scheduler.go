@@ -4,6 +4,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/heru-oktafian/api-retail/tools"
 	"github.com/robfig/cron/v3"
 	"gorm.io/gorm"
 )
@@ -30,6 +31,15 @@ func InitScheduler(db *gorm.DB) *cron.Cron {
 		}
 	})
 
+	// Hanguskan poin member yang lewat masa berlaku setiap pukul 00:05 WIB (17:05 UTC)
+	c.AddFunc("5 17 * * *", func() {
+		if err := tools.ExpireMemberPoints(db); err != nil {
+			log.Println("[SCHEDULER] Gagal menghanguskan poin member:", err)
+		} else {
+			log.Println("[SCHEDULER] Poin member kadaluarsa berhasil dihanguskan.")
+		}
+	})
+
 	// 3. Generate laporan harian pukul 06:00 WIB (23:00 UTC)
 	// c.AddFunc("0 23 * * *", func() {
 	// 	log.Println("[SCHEDULER] Generate laporan harian...")
@@ -43,10 +53,25 @@ func InitScheduler(db *gorm.DB) *cron.Cron {
 	// })
 
 	// 5. Cek expired promo tiap 10 menit
-	// c.AddFunc("*/10 * * * *", func() {
-	// 	log.Println("[SCHEDULER] Cek promo kadaluarsa...")
-	// 	DeactivateExpiredPromos()
-	// })
+	c.AddFunc("*/10 * * * *", func() {
+		if err := DeactivateExpiredPromos(db); err != nil {
+			log.Println("[SCHEDULER] Gagal menonaktifkan promo kadaluarsa:", err)
+		}
+	})
+
+	// 6. Hapus Idempotency-Key yang sudah kadaluarsa setiap jam
+	c.AddFunc("0 * * * *", func() {
+		if err := PurgeIdempotencyKeys(db); err != nil {
+			log.Println("[SCHEDULER] Gagal menghapus idempotency key:", err)
+		}
+	})
+
+	// 7. Terapkan jadwal perubahan harga produk tiap 10 menit
+	c.AddFunc("*/10 * * * *", func() {
+		if err := ApplyScheduledPriceChanges(db); err != nil {
+			log.Println("[SCHEDULER] Gagal menerapkan jadwal perubahan harga:", err)
+		}
+	})
 
 	c.Start()
 	log.Println("[SCHEDULER] Semua job terjadwal aktif!")
@@ -0,0 +1,310 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/big"
+	"strings"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// Symbology barcode yang bisa dibuatkan label
+const (
+	BarcodeEAN13   = "ean13"
+	BarcodeCode128 = "code128"
+)
+
+// InternalBarcodePrefix awalan EAN-13 untuk barcode internal toko (GS1 prefix 20-29 untuk penggunaan dalam toko)
+const InternalBarcodePrefix = "20"
+
+// EAN13CheckDigit menghitung digit cek dari 12 digit pertama EAN-13
+func EAN13CheckDigit(digits string) (byte, error) {
+	if len(digits) != 12 || !isDigits(digits) {
+		return 0, errors.New("EAN-13 membutuhkan 12 digit sebelum digit cek")
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// IsValidEAN13 true jika kode terdiri dari 13 digit dengan digit cek yang benar
+func IsValidEAN13(code string) bool {
+	if len(code) != 13 {
+		return false
+	}
+	check, err := EAN13CheckDigit(code[:12])
+	return err == nil && check == code[12]
+}
+
+// NormalizeBarcode membersihkan hasil scan / input barcode dari spasi di awal dan akhir
+func NormalizeBarcode(code string) string {
+	return strings.TrimSpace(code)
+}
+
+// ValidateBarcode memastikan barcode bisa dicetak sebagai Code128 (ASCII 32-126, maks 50 karakter)
+func ValidateBarcode(code string) error {
+	if err := checkCode128Text(code); err != nil {
+		return err
+	}
+	// 13 digit dianggap EAN-13, digit cek harus benar agar tidak salah baca di kasir
+	if len(code) == 13 && isDigits(code) && !IsValidEAN13(code) {
+		return errors.New("digit cek EAN-13 tidak valid")
+	}
+	return nil
+}
+
+// checkCode128Text memastikan teks bisa dikodekan dengan Code128 set B
+func checkCode128Text(code string) error {
+	if code == "" {
+		return errors.New("barcode tidak boleh kosong")
+	}
+	if len(code) > 50 {
+		return errors.New("barcode maksimal 50 karakter")
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 32 || code[i] > 126 {
+			return fmt.Errorf("barcode mengandung karakter tidak valid pada posisi %d", i+1)
+		}
+	}
+	return nil
+}
+
+// GenerateInternalBarcode membuat EAN-13 internal (awalan 20) yang belum dipakai sebagai barcode maupun SKU di cabang
+func GenerateInternalBarcode(db *gorm.DB, branchID string) (string, error) {
+	for attempt := 0; attempt < 10; attempt++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10_000_000_000))
+		if err != nil {
+			return "", err
+		}
+		body := fmt.Sprintf("%s%010d", InternalBarcodePrefix, n.Int64())
+		check, _ := EAN13CheckDigit(body)
+		code := body + string(check)
+
+		used, err := BarcodeInUse(db, branchID, code, "")
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return code, nil
+		}
+	}
+	return "", errors.New("gagal membuat barcode unik, silakan coba lagi")
+}
+
+// BarcodeInUse true jika kode sudah dipakai sebagai barcode di cabang, atau sebagai SKU produk lain
+// (productID diisi agar SKU milik produk itu sendiri tidak dianggap bentrok).
+func BarcodeInUse(db *gorm.DB, branchID string, code string, productID string) (bool, error) {
+	var count int64
+	if err := db.Model(&models.ProductBarcodes{}).
+		Where("branch_id = ? AND barcode = ?", branchID, code).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	query := db.Model(&models.Product{}).Where("branch_id = ? AND sku = ?", branchID, code)
+	if productID != "" {
+		query = query.Where("id <> ?", productID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ean13Left pola digit sisi kiri (kode L), kode G adalah kebalikan kode R
+var ean13Left = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+
+// ean13Parity pola L / G sisi kiri berdasarkan digit pertama
+var ean13Parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLG", "LGLGLG", "LGLGGL", "LGGLGL"}
+
+// EncodeEAN13 mengubah EAN-13 menjadi 95 modul (true = batang hitam)
+func EncodeEAN13(code string) ([]bool, error) {
+	if !IsValidEAN13(code) {
+		return nil, errors.New("kode EAN-13 tidak valid")
+	}
+
+	var b strings.Builder
+	b.WriteString("101")
+	parity := ean13Parity[code[0]-'0']
+	for i := 1; i <= 6; i++ {
+		left := ean13Left[code[i]-'0']
+		if parity[i-1] == 'G' {
+			left = reverse(invert(left))
+		}
+		b.WriteString(left)
+	}
+	b.WriteString("01010")
+	for i := 7; i <= 12; i++ {
+		b.WriteString(invert(ean13Left[code[i]-'0']))
+	}
+	b.WriteString("101")
+
+	return patternModules(b.String()), nil
+}
+
+// code128Widths lebar batang / spasi tiap simbol Code128 (nilai 0-105 dan stop)
+var code128Widths = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// EncodeCode128 mengubah teks ASCII menjadi modul Code128.
+// Angka dengan jumlah digit genap memakai code set C (lebih pendek), selain itu code set B.
+func EncodeCode128(code string) ([]bool, error) {
+	if err := checkCode128Text(code); err != nil {
+		return nil, err
+	}
+
+	var values []int
+	if len(code) >= 4 && len(code)%2 == 0 && isDigits(code) {
+		values = append(values, code128StartC)
+		for i := 0; i < len(code); i += 2 {
+			values = append(values, int(code[i]-'0')*10+int(code[i+1]-'0'))
+		}
+	} else {
+		values = append(values, code128StartB)
+		for i := 0; i < len(code); i++ {
+			values = append(values, int(code[i])-32)
+		}
+	}
+
+	checksum := values[0]
+	for i := 1; i < len(values); i++ {
+		checksum += i * values[i]
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, v := range values {
+		for i, w := range code128Widths[v] {
+			bar := i%2 == 0
+			for n := 0; n < int(w-'0'); n++ {
+				modules = append(modules, bar)
+			}
+		}
+	}
+	return modules, nil
+}
+
+// BarcodeSymbology EAN-13 untuk kode 13 digit yang valid, selain itu Code128
+func BarcodeSymbology(code string) string {
+	if IsValidEAN13(code) {
+		return BarcodeEAN13
+	}
+	return BarcodeCode128
+}
+
+// RenderBarcodePNG menggambar barcode menjadi PNG hitam putih.
+// scale = lebar satu modul dalam piksel, tinggi batang 60 modul, dengan quiet zone 10 modul di kiri dan kanan.
+func RenderBarcodePNG(code string, scale int) ([]byte, string, error) {
+	if scale < 1 || scale > 10 {
+		scale = 2
+	}
+
+	symbology := BarcodeSymbology(code)
+	var modules []bool
+	var err error
+	if symbology == BarcodeEAN13 {
+		modules, err = EncodeEAN13(code)
+	} else {
+		modules, err = EncodeCode128(code)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	const quiet, barHeight = 10, 60
+	width := (len(modules) + 2*quiet) * scale
+	height := barHeight * scale
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.White, color.Black})
+	for i, bar := range modules {
+		if !bar {
+			continue
+		}
+		x0 := (quiet + i) * scale
+		for x := x0; x < x0+scale; x++ {
+			for y := 0; y < height; y++ {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), symbology, nil
+}
+
+// patternModules mengubah pola "1010" menjadi modul
+func patternModules(pattern string) []bool {
+	modules := make([]bool, len(pattern))
+	for i := range pattern {
+		modules[i] = pattern[i] == '1'
+	}
+	return modules
+}
+
+func invert(pattern string) string {
+	b := []byte(pattern)
+	for i := range b {
+		if b[i] == '1' {
+			b[i] = '0'
+		} else {
+			b[i] = '1'
+		}
+	}
+	return string(b)
+}
+
+func reverse(pattern string) string {
+	b := []byte(pattern)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
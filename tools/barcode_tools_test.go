@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// Tabel pola EAN-13 sesuai spesifikasi GS1, ditulis terpisah dari tabel encoder
+var (
+	testEAN13L = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	testEAN13G = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	testEAN13R = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+)
+
+// moduleString mengubah modul menjadi pola "1010" agar mudah dibandingkan
+func moduleString(modules []bool) string {
+	var b strings.Builder
+	for _, bar := range modules {
+		if bar {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+func TestEAN13CheckDigit(t *testing.T) {
+	cases := map[string]byte{
+		"590123412345": '7',
+		"400638133393": '1',
+		"978014300723": '4',
+		"200000000000": '8',
+		"000000000000": '0',
+	}
+	for digits, want := range cases {
+		got, err := EAN13CheckDigit(digits)
+		if err != nil || got != want {
+			t.Errorf("EAN13CheckDigit(%q) = %q, %v, seharusnya %q", digits, got, err, want)
+		}
+	}
+
+	for _, digits := range []string{"", "59012341234", "5901234123456", "59012341234a"} {
+		if _, err := EAN13CheckDigit(digits); err == nil {
+			t.Errorf("EAN13CheckDigit(%q) seharusnya error", digits)
+		}
+	}
+}
+
+func TestValidateBarcode(t *testing.T) {
+	if !IsValidEAN13("5901234123457") || IsValidEAN13("5901234123458") || IsValidEAN13("590123412345") {
+		t.Fatalf("IsValidEAN13 tidak sesuai digit cek")
+	}
+
+	valid := []string{"5901234123457", "8991234567", "OBAT-PCT 500", "9780143007234"}
+	for _, code := range valid {
+		if err := ValidateBarcode(code); err != nil {
+			t.Errorf("ValidateBarcode(%q) = %v, seharusnya valid", code, err)
+		}
+	}
+
+	invalid := []string{"", "5901234123458", strings.Repeat("A", 51), "Obat\tBebas", "Café"}
+	for _, code := range invalid {
+		if err := ValidateBarcode(code); err == nil {
+			t.Errorf("ValidateBarcode(%q) seharusnya error", code)
+		}
+	}
+
+	if BarcodeSymbology("5901234123457") != BarcodeEAN13 || BarcodeSymbology("5901234123458") != BarcodeCode128 || BarcodeSymbology("PCT500") != BarcodeCode128 {
+		t.Fatalf("BarcodeSymbology tidak sesuai")
+	}
+}
+
+func TestEncodeEAN13(t *testing.T) {
+	// 5901234123457: digit pertama 5 memakai paritas LGGLLG di sisi kiri
+	want := "101" +
+		testEAN13L[9] + testEAN13G[0] + testEAN13G[1] + testEAN13L[2] + testEAN13L[3] + testEAN13G[4] +
+		"01010" +
+		testEAN13R[1] + testEAN13R[2] + testEAN13R[3] + testEAN13R[4] + testEAN13R[5] + testEAN13R[7] +
+		"101"
+
+	modules, err := EncodeEAN13("5901234123457")
+	if err != nil {
+		t.Fatalf("EncodeEAN13: %v", err)
+	}
+	if len(modules) != 95 {
+		t.Fatalf("EAN-13 seharusnya 95 modul, didapat %d", len(modules))
+	}
+	if got := moduleString(modules); got != want {
+		t.Fatalf("modul EAN-13\n didapat %s\nseharusnya %s", got, want)
+	}
+
+	// Digit pertama 0 memakai kode L untuk seluruh sisi kiri (sama dengan UPC-A)
+	modules, err = EncodeEAN13("0000000000000")
+	if err != nil {
+		t.Fatalf("EncodeEAN13: %v", err)
+	}
+	want = "101" + strings.Repeat(testEAN13L[0], 6) + "01010" + strings.Repeat(testEAN13R[0], 6) + "101"
+	if got := moduleString(modules); got != want {
+		t.Fatalf("modul EAN-13 digit awal 0\n didapat %s\nseharusnya %s", got, want)
+	}
+
+	if _, err := EncodeEAN13("5901234123458"); err == nil {
+		t.Fatalf("EncodeEAN13 dengan digit cek salah seharusnya error")
+	}
+}
+
+func TestEncodeCode128SetC(t *testing.T) {
+	// "1234" di code set C: Start C, 12, 34, checksum (105 + 1*12 + 2*34) % 103 = 82, Stop
+	want := "11010011100" + "10110011100" + "10001011000" + "10010011110" + "1100011101011"
+
+	modules, err := EncodeCode128("1234")
+	if err != nil {
+		t.Fatalf("EncodeCode128: %v", err)
+	}
+	if got := moduleString(modules); got != want {
+		t.Fatalf("modul Code128 \"1234\"\n didapat %s\nseharusnya %s", got, want)
+	}
+}
+
+func TestEncodeCode128SetB(t *testing.T) {
+	modules, err := EncodeCode128("Wikipedia")
+	if err != nil {
+		t.Fatalf("EncodeCode128: %v", err)
+	}
+	// Start B + 9 karakter + checksum masing-masing 11 modul, stop 13 modul
+	if len(modules) != 11*11+13 {
+		t.Fatalf("jumlah modul %d, seharusnya %d", len(modules), 11*11+13)
+	}
+
+	got := moduleString(modules)
+	if !strings.HasPrefix(got, "11010010000"+"11101000110") {
+		t.Fatalf("Start B / karakter W tidak sesuai: %s", got[:22])
+	}
+	// Checksum "Wikipedia" = 88, pola 421211
+	if check := got[110:121]; check != "11110010010" {
+		t.Fatalf("simbol checksum = %s, seharusnya 11110010010 (nilai 88)", check)
+	}
+	if !strings.HasSuffix(got, "1100011101011") {
+		t.Fatalf("pola stop tidak sesuai: %s", got[121:])
+	}
+
+	// Angka ganjil tetap di code set B
+	modules, err = EncodeCode128("12345")
+	if err != nil {
+		t.Fatalf("EncodeCode128: %v", err)
+	}
+	if got := moduleString(modules); !strings.HasPrefix(got, "11010010000") || len(got) != 11*7+13 {
+		t.Fatalf("angka ganjil seharusnya memakai Start B, didapat %s", got)
+	}
+}
+
+func TestRenderBarcodePNG(t *testing.T) {
+	data, symbology, err := RenderBarcodePNG("5901234123457", 3)
+	if err != nil {
+		t.Fatalf("RenderBarcodePNG: %v", err)
+	}
+	if symbology != BarcodeEAN13 {
+		t.Fatalf("symbology = %s, seharusnya %s", symbology, BarcodeEAN13)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("hasil bukan PNG yang valid: %v", err)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() != (95+20)*3 || bounds.Dy() != 60*3 {
+		t.Fatalf("ukuran gambar %dx%d, seharusnya %dx%d", bounds.Dx(), bounds.Dy(), (95+20)*3, 60*3)
+	}
+
+	// Baca ulang modul dari baris tengah gambar, quiet zone harus putih
+	modules, _ := EncodeEAN13("5901234123457")
+	y := bounds.Dy() / 2
+	for m := -10; m < len(modules)+10; m++ {
+		bar := m >= 0 && m < len(modules) && modules[m]
+		for x := (10 + m) * 3; x < (11+m)*3; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			if black := r == 0; black != bar {
+				t.Fatalf("piksel x=%d (modul %d) hitam=%v, seharusnya %v", x, m, black, bar)
+			}
+		}
+	}
+
+	if _, _, err := RenderBarcodePNG("", 2); err == nil {
+		t.Fatalf("barcode kosong seharusnya error")
+	}
+}
@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/helpers"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddProductBatch membuat batch baru dari item penerimaan barang (purchase, first stock, opname, retur)
+func AddProductBatch(db *gorm.DB, branchID string, productID string, sourceID string, batchNumber string, expiredDate time.Time, qty int) error {
+	if qty <= 0 {
+		return nil
+	}
+
+	// Jika nomor batch tidak diisi, gunakan ID item asal sebagai nomor batch
+	if batchNumber == "" {
+		batchNumber = sourceID
+	}
+
+	batch := models.ProductBatches{
+		ID:          helpers.GenerateID("BCH"),
+		ProductId:   productID,
+		BatchNumber: batchNumber,
+		ExpiredDate: expiredDate,
+		Qty:         qty,
+		SourceID:    sourceID,
+		BranchID:    branchID,
+	}
+	if err := db.Create(&batch).Error; err != nil {
+		return err
+	}
+
+	return SyncProductExpiredDate(db, branchID, productID)
+}
+
+// ConsumedBatch qty yang diambil dari satu batch beserta tanggal kadaluarsanya
+type ConsumedBatch struct {
+	ExpiredDate time.Time
+	Qty         int
+}
+
+// ConsumeProductBatches mengurangi qty batch dengan urutan FEFO (expired paling awal dipakai lebih dulu)
+// dan mencatat pemakaiannya ke product_batch_usages dengan referenceID (biasanya ID sale item).
+// Mengembalikan batch yang dipakai sesuai urutan FEFO.
+// Stok lama yang belum punya batch tidak dianggap error, sisa qty dibiarkan.
+// Baris batch dikunci (SELECT ... FOR UPDATE) agar penjualan bersamaan tidak mengambil qty batch yang sama.
+func ConsumeProductBatches(db *gorm.DB, branchID string, productID string, referenceID string, qty int) ([]ConsumedBatch, error) {
+	if qty <= 0 {
+		return nil, nil
+	}
+
+	var batches []models.ProductBatches
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND branch_id = ? AND qty > 0", productID, branchID).
+		Order("expired_date ASC, created_at ASC").
+		Find(&batches).Error; err != nil {
+		return nil, err
+	}
+
+	var consumed []ConsumedBatch
+	remaining := qty
+	for _, batch := range batches {
+		if remaining == 0 {
+			break
+		}
+
+		take := min(batch.Qty, remaining)
+		if err := db.Model(&models.ProductBatches{}).Where("id = ?", batch.ID).
+			Update("qty", gorm.Expr("qty - ?", take)).Error; err != nil {
+			return consumed, err
+		}
+
+		usage := models.ProductBatchUsages{
+			ID:          helpers.GenerateID("BCU"),
+			BatchId:     batch.ID,
+			ReferenceID: referenceID,
+			Qty:         take,
+		}
+		if err := db.Create(&usage).Error; err != nil {
+			return consumed, err
+		}
+
+		consumed = append(consumed, ConsumedBatch{ExpiredDate: batch.ExpiredDate, Qty: take})
+		remaining -= take
+	}
+
+	return consumed, SyncProductExpiredDate(db, branchID, productID)
+}
+
+// RestoreProductBatches mengembalikan qty ke batch yang sebelumnya dipakai oleh referenceID.
+// Mengembalikan sisa qty yang tidak bisa dipulangkan ke batch asal.
+func RestoreProductBatches(db *gorm.DB, branchID string, productID string, referenceID string, qty int) (int, error) {
+	if qty <= 0 {
+		return 0, nil
+	}
+
+	var usages []models.ProductBatchUsages
+	if err := db.Table("product_batch_usages pbu").
+		Select("pbu.*").
+		Joins("JOIN product_batches pb ON pb.id = pbu.batch_id").
+		Where("pbu.reference_id = ? AND pbu.qty > 0", referenceID).
+		Order("pb.expired_date DESC").
+		Scan(&usages).Error; err != nil {
+		return qty, err
+	}
+
+	remaining := qty
+	for _, usage := range usages {
+		if remaining == 0 {
+			break
+		}
+
+		give := min(usage.Qty, remaining)
+		if err := db.Model(&models.ProductBatches{}).Where("id = ?", usage.BatchId).
+			Update("qty", gorm.Expr("qty + ?", give)).Error; err != nil {
+			return remaining, err
+		}
+		if err := db.Model(&models.ProductBatchUsages{}).Where("id = ?", usage.ID).
+			Update("qty", gorm.Expr("qty - ?", give)).Error; err != nil {
+			return remaining, err
+		}
+
+		remaining -= give
+	}
+
+	return remaining, SyncProductExpiredDate(db, branchID, productID)
+}
+
+// ReduceSourceBatch mengurangi qty batch yang berasal dari sourceID (hapus item pembelian, retur pembelian).
+// Jika batch asal sudah terpakai, sisanya diambil dari batch lain dengan urutan FEFO.
+// Baris batch asal dikunci (SELECT ... FOR UPDATE) seperti ConsumeProductBatches.
+func ReduceSourceBatch(db *gorm.DB, branchID string, productID string, sourceID string, qty int) error {
+	if qty <= 0 {
+		return nil
+	}
+
+	var batches []models.ProductBatches
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("source_id = ? AND product_id = ? AND qty > 0", sourceID, productID).
+		Order("created_at ASC").
+		Find(&batches).Error; err != nil {
+		return err
+	}
+
+	remaining := qty
+	for _, batch := range batches {
+		if remaining == 0 {
+			break
+		}
+
+		take := min(batch.Qty, remaining)
+		if err := db.Model(&models.ProductBatches{}).Where("id = ?", batch.ID).
+			Update("qty", gorm.Expr("qty - ?", take)).Error; err != nil {
+			return err
+		}
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		_, err := ConsumeProductBatches(db, branchID, productID, sourceID, remaining)
+		return err
+	}
+
+	return SyncProductExpiredDate(db, branchID, productID)
+}
+
+// ResetProductBatches dipakai saat opname: semua batch produk di cabang dinolkan
+// lalu diganti dengan satu batch sesuai qty hasil hitung fisik
+func ResetProductBatches(db *gorm.DB, branchID string, productID string, sourceID string, expiredDate time.Time, qty int) error {
+	if err := db.Model(&models.ProductBatches{}).
+		Where("product_id = ? AND branch_id = ? AND qty > 0", productID, branchID).
+		Update("qty", 0).Error; err != nil {
+		return err
+	}
+
+	// Hasil hitung fisik kosong, expired_date produk tetap disamakan dengan batch yang tersisa
+	if qty <= 0 {
+		return SyncProductExpiredDate(db, branchID, productID)
+	}
+
+	return AddProductBatch(db, branchID, productID, sourceID, "", expiredDate, qty)
+}
+
+// SyncProductExpiredDate menyamakan expired_date produk dengan batch aktif yang paling awal kadaluarsa
+func SyncProductExpiredDate(db *gorm.DB, branchID string, productID string) error {
+	var earliest []time.Time
+	if err := db.Model(&models.ProductBatches{}).
+		Where("product_id = ? AND branch_id = ? AND qty > 0", productID, branchID).
+		Order("expired_date ASC").
+		Limit(1).
+		Pluck("expired_date", &earliest).Error; err != nil {
+		return err
+	}
+
+	// Belum ada batch aktif, biarkan expired_date produk apa adanya
+	if len(earliest) == 0 {
+		return nil
+	}
+
+	return db.Model(&models.Product{}).Where("id = ?", productID).Update("expired_date", earliest[0]).Error
+}
+
+// SeedProductBatches membuat batch awal untuk produk yang punya stok tapi belum punya batch sama sekali,
+// memakai expired_date produk sebagai tanggal kadaluarsa batch
+func SeedProductBatches(db *gorm.DB) error {
+	var products []models.Product
+	if err := db.Where("stock > 0 AND NOT EXISTS (SELECT 1 FROM product_batches pb WHERE pb.product_id = products.id)").
+		Find(&products).Error; err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if err := AddProductBatch(db, product.BranchID, product.ID, product.ID, "", product.ExpiredDate, product.Stock); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// SaleProductQuery query dasar produk penjualan (harga jual, stok dan satuan), dipakai combo box penjualan dan keranjang
+func SaleProductQuery(db *gorm.DB, branchID string) *gorm.DB {
+	return db.Table("products").
+		Select("products.id as product_id, products.name as product_name, sales_price AS price, products.stock, products.unit_id, units.name AS unit_name").
+		Joins("LEFT JOIN units ON units.id = products.unit_id").
+		Where("products.branch_id = ?", branchID)
+}
+
+// GetSaleProduct mengambil harga jual dan stok satu produk cabang, gorm.ErrRecordNotFound jika tidak ada
+func GetSaleProduct(db *gorm.DB, branchID string, productID string) (models.ProdSaleCombo, error) {
+	var product models.ProdSaleCombo
+	result := SaleProductQuery(db, branchID).Where("products.id = ?", productID).Limit(1).Scan(&product)
+	if result.Error != nil {
+		return product, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return product, gorm.ErrRecordNotFound
+	}
+	return product, nil
+}
+
+// GetUserCart mengambil keranjang milik kasir di cabang, gorm.ErrRecordNotFound jika tidak ada
+func GetUserCart(db *gorm.DB, cartID string, userID string, branchID string) (models.SaleCarts, error) {
+	var cart models.SaleCarts
+	err := db.Where("id = ? AND user_id = ? AND branch_id = ?", cartID, userID, branchID).First(&cart).Error
+	return cart, err
+}
+
+// CheckCartStock memastikan qty produk di keranjang (baris lain + qty baru) tidak melebihi stok, stok tidak dikurangi.
+// baseQty dalam satuan dasar, baris keranjang dengan satuan lain ikut dihitung dalam satuan dasar.
+// Cabang yang mengizinkan backorder tidak diperiksa.
+// excludeItemID diisi saat mengubah baris agar qty lama baris tersebut tidak ikut dihitung.
+func CheckCartStock(db *gorm.DB, branchID string, cartID string, product models.ProdSaleCombo, baseQty int, excludeItemID string) error {
+	policy, err := GetStockPolicy(db, branchID)
+	if err != nil {
+		return err
+	}
+	if policy == models.StockAllowBackorder {
+		return nil
+	}
+
+	query := db.Model(&models.SaleCartItems{}).
+		Select("COALESCE(SUM(qty * conv_value), 0)").
+		Where("cart_id = ? AND product_id = ?", cartID, product.ProductId)
+	if excludeItemID != "" {
+		query = query.Where("id <> ?", excludeItemID)
+	}
+
+	var inCart int
+	if err := query.Scan(&inCart).Error; err != nil {
+		return err
+	}
+
+	if inCart+baseQty > product.Stock {
+		return &InsufficientStockError{ProductID: product.ProductId, ProductName: product.ProductName, Available: product.Stock, Requested: inCart + baseQty}
+	}
+	return nil
+}
+
+// ParkOpenCarts menahan keranjang lain milik kasir yang masih terbuka, sehingga hanya satu keranjang aktif
+func ParkOpenCarts(db *gorm.DB, userID string, branchID string, exceptID string, now time.Time) error {
+	return db.Model(&models.SaleCarts{}).
+		Where("user_id = ? AND branch_id = ? AND status = ? AND id <> ?", userID, branchID, models.CartOpen, exceptID).
+		Updates(map[string]interface{}{"status": models.CartParked, "updated_at": now}).Error
+}
+
+// MarkCartCheckedOut menandai keranjang sudah menjadi penjualan.
+// Hanya keranjang terbuka yang bisa di-checkout, sehingga checkout ganda pada keranjang yang sama ditolak.
+func MarkCartCheckedOut(db *gorm.DB, cartID string, saleID string, now time.Time) error {
+	result := db.Model(&models.SaleCarts{}).
+		Where("id = ? AND status = ?", cartID, models.CartOpen).
+		Updates(map[string]interface{}{"status": models.CartCheckedOut, "sale_id": saleID, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("keranjang %s tidak terbuka atau sudah di-checkout", cartID)
+	}
+	return nil
+}
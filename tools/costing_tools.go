@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/helpers"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetCostingMethod mengambil metode harga pokok cabang, default rata-rata tertimbang
+func GetCostingMethod(db *gorm.DB, branchID string) models.CostingMethod {
+	var branch models.Branch
+	if err := db.Select("costing_method").First(&branch, "id = ?", branchID).Error; err != nil || branch.CostingMethod == "" {
+		return models.AverageCost
+	}
+	return branch.CostingMethod
+}
+
+// ApplyReceiptCost menghitung ulang harga pokok produk saat barang masuk (pembelian, stok awal, retur penjualan).
+// Dipanggil setelah stok produk ditambah sebanyak qty, unitCost adalah harga per satuan dasar.
+// Perubahan harga pokok dicatat ke riwayat harga dengan dokumen dan user dari ref.
+func ApplyReceiptCost(db *gorm.DB, ref StockRef, productID string, sourceID string, qty int, unitCost int) error {
+	if qty <= 0 {
+		return nil
+	}
+	branchID := ref.BranchID
+
+	var product models.Product
+	if err := db.First(&product, "id = ?", productID).Error; err != nil {
+		return err
+	}
+
+	// Layer FIFO selalu dicatat agar pergantian metode tetap punya data
+	layer := models.CostLayers{
+		ID:        helpers.GenerateID("CSL"),
+		ProductId: productID,
+		BranchID:  branchID,
+		SourceID:  sourceID,
+		UnitCost:  unitCost,
+		Qty:       qty,
+	}
+	if err := db.Create(&layer).Error; err != nil {
+		return err
+	}
+
+	if GetCostingMethod(db, branchID) == models.FIFOCost {
+		return syncFIFOCost(db, ref, productID)
+	}
+
+	// Rata-rata tertimbang: (stok lama * harga lama + qty masuk * harga masuk) / stok baru
+	qtyBefore := max(product.Stock-qty, 0)
+	newCost := (qtyBefore*product.PurchasePrice + qty*unitCost) / (qtyBefore + qty)
+
+	return SetProductPrice(db, PriceRefFromStock(ref), productID, models.PriceFieldPurchase, newCost)
+}
+
+// ReduceReceiptCost membatalkan penerimaan barang (hapus item pembelian, retur pembelian).
+// Dipanggil setelah stok produk dikurangi sebanyak qty, unitCost adalah harga per satuan dasar saat diterima.
+func ReduceReceiptCost(db *gorm.DB, ref StockRef, productID string, sourceID string, qty int, unitCost int) error {
+	if qty <= 0 {
+		return nil
+	}
+	branchID := ref.BranchID
+
+	var product models.Product
+	if err := db.First(&product, "id = ?", productID).Error; err != nil {
+		return err
+	}
+
+	// Kurangi layer asal lebih dulu, sisanya dari layer paling lama
+	remaining, err := takeCostLayers(db, db.Where("source_id = ? AND product_id = ? AND qty > 0", sourceID, productID), qty)
+	if err != nil {
+		return err
+	}
+	if _, err := takeCostLayers(db, db.Where("product_id = ? AND branch_id = ? AND qty > 0", productID, branchID), remaining); err != nil {
+		return err
+	}
+
+	if GetCostingMethod(db, branchID) == models.FIFOCost {
+		return syncFIFOCost(db, ref, productID)
+	}
+
+	// Rata-rata tertimbang: keluarkan nilai barang yang dibatalkan dari total nilai stok
+	if product.Stock <= 0 {
+		return nil
+	}
+	newCost := max(((product.Stock+qty)*product.PurchasePrice-qty*unitCost)/product.Stock, 0)
+
+	return SetProductPrice(db, PriceRefFromStock(ref), productID, models.PriceFieldPurchase, newCost)
+}
+
+// ConsumeCost mengambil harga pokok untuk barang keluar (penjualan) sesuai metode cabang.
+// Mengembalikan harga pokok per unit yang dipakai untuk COGS.
+func ConsumeCost(db *gorm.DB, ref StockRef, productID string, qty int) (int, error) {
+	branchID := ref.BranchID
+	var product models.Product
+	if err := db.First(&product, "id = ?", productID).Error; err != nil {
+		return 0, err
+	}
+
+	if qty <= 0 {
+		return product.PurchasePrice, nil
+	}
+
+	var layers []models.CostLayers
+	// Layer dikunci (SELECT ... FOR UPDATE) agar penjualan bersamaan tidak mengambil qty layer yang sama
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND branch_id = ? AND qty > 0", productID, branchID).
+		Order("created_at ASC, id ASC").
+		Find(&layers).Error; err != nil {
+		return 0, err
+	}
+
+	remaining := qty
+	consumedValue := 0
+	for _, layer := range layers {
+		if remaining == 0 {
+			break
+		}
+
+		take := min(layer.Qty, remaining)
+		if err := db.Model(&models.CostLayers{}).Where("id = ?", layer.ID).
+			Update("qty", gorm.Expr("qty - ?", take)).Error; err != nil {
+			return 0, err
+		}
+
+		consumedValue += take * layer.UnitCost
+		remaining -= take
+	}
+
+	if GetCostingMethod(db, branchID) != models.FIFOCost {
+		return product.PurchasePrice, nil
+	}
+
+	// Stok yang tidak punya layer dinilai dengan harga pokok produk saat ini
+	consumedValue += remaining * product.PurchasePrice
+	unitCost := consumedValue / qty
+
+	if err := syncFIFOCost(db, ref, productID); err != nil {
+		return 0, err
+	}
+
+	return unitCost, nil
+}
+
+// SyncCostLayers menyamakan total qty layer dengan stok produk (dipakai setelah opname)
+func SyncCostLayers(db *gorm.DB, ref StockRef, productID string, sourceID string) error {
+	branchID := ref.BranchID
+	var product models.Product
+	if err := db.First(&product, "id = ?", productID).Error; err != nil {
+		return err
+	}
+
+	var layerQty int
+	if err := db.Model(&models.CostLayers{}).
+		Where("product_id = ? AND branch_id = ?", productID, branchID).
+		Select("COALESCE(SUM(qty), 0)").
+		Scan(&layerQty).Error; err != nil {
+		return err
+	}
+
+	switch {
+	case layerQty > product.Stock:
+		// Stok hilang diambil dari layer paling lama
+		if _, err := takeCostLayers(db, db.Where("product_id = ? AND branch_id = ? AND qty > 0", productID, branchID), layerQty-max(product.Stock, 0)); err != nil {
+			return err
+		}
+	case layerQty < product.Stock:
+		// Stok lebih dinilai dengan harga pokok saat ini
+		layer := models.CostLayers{
+			ID:        helpers.GenerateID("CSL"),
+			ProductId: productID,
+			BranchID:  branchID,
+			SourceID:  sourceID,
+			UnitCost:  product.PurchasePrice,
+			Qty:       product.Stock - layerQty,
+		}
+		if err := db.Create(&layer).Error; err != nil {
+			return err
+		}
+	}
+
+	if GetCostingMethod(db, branchID) == models.FIFOCost {
+		return syncFIFOCost(db, ref, productID)
+	}
+
+	return nil
+}
+
+// SeedCostLayers membuat layer awal untuk produk yang punya stok tapi belum punya layer sama sekali
+func SeedCostLayers(db *gorm.DB) error {
+	var products []models.Product
+	if err := db.Where("stock > 0 AND NOT EXISTS (SELECT 1 FROM cost_layers cl WHERE cl.product_id = products.id)").
+		Find(&products).Error; err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		layer := models.CostLayers{
+			ID:        helpers.GenerateID("CSL"),
+			ProductId: product.ID,
+			BranchID:  product.BranchID,
+			SourceID:  product.ID,
+			UnitCost:  product.PurchasePrice,
+			Qty:       product.Stock,
+		}
+		if err := db.Create(&layer).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// takeCostLayers mengurangi qty dari layer hasil query (urut paling lama, baris dikunci FOR UPDATE), mengembalikan sisa qty
+func takeCostLayers(db *gorm.DB, query *gorm.DB, qty int) (int, error) {
+	if qty <= 0 {
+		return 0, nil
+	}
+
+	var layers []models.CostLayers
+	if err := query.Clauses(clause.Locking{Strength: "UPDATE"}).Order("created_at ASC, id ASC").Find(&layers).Error; err != nil {
+		return qty, err
+	}
+
+	remaining := qty
+	for _, layer := range layers {
+		if remaining == 0 {
+			break
+		}
+
+		take := min(layer.Qty, remaining)
+		if err := db.Model(&models.CostLayers{}).Where("id = ?", layer.ID).
+			Update("qty", gorm.Expr("qty - ?", take)).Error; err != nil {
+			return remaining, err
+		}
+		remaining -= take
+	}
+
+	return remaining, nil
+}
+
+// syncFIFOCost menetapkan harga pokok produk sebagai rata-rata nilai layer yang tersisa
+func syncFIFOCost(db *gorm.DB, ref StockRef, productID string) error {
+	var result struct {
+		Qty   int
+		Value int
+	}
+	if err := db.Model(&models.CostLayers{}).
+		Where("product_id = ? AND branch_id = ? AND qty > 0", productID, ref.BranchID).
+		Select("COALESCE(SUM(qty), 0) AS qty, COALESCE(SUM(qty * unit_cost), 0) AS value").
+		Scan(&result).Error; err != nil {
+		return err
+	}
+
+	// Tidak ada layer tersisa, harga pokok terakhir dipertahankan
+	if result.Qty == 0 {
+		return nil
+	}
+
+	return SetProductPrice(db, PriceRefFromStock(ref), productID, models.PriceFieldPurchase, result.Value/result.Qty)
+}
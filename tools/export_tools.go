@@ -0,0 +1,319 @@
+package tools
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+// ExportKind jenis nilai kolom ekspor, menentukan format Rupiah / angka / tanggal
+type ExportKind int
+
+const (
+	ExportText ExportKind = iota
+	ExportMoney
+	ExportNumber
+	ExportDate
+	ExportDateTime
+)
+
+// ExportColumn kolom file ekspor
+type ExportColumn struct {
+	Header string
+	Kind   ExportKind
+}
+
+// ExportContentTypes format ekspor yang didukung beserta content type-nya
+var ExportContentTypes = map[string]string{
+	"csv":  "text/csv",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportWriter penulis file ekspor baris demi baris, Close wajib dipanggil untuk menutup file
+type ExportWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewExportWriter membuat penulis ekspor csv atau xlsx yang langsung menulis ke w dan menulis baris judul kolom
+func NewExportWriter(format string, w io.Writer, sheetName string, columns []ExportColumn) (ExportWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVExport(w, columns)
+	case "xlsx":
+		return newXLSXExport(w, sheetName, columns)
+	}
+	return nil, fmt.Errorf("format ekspor %q tidak didukung", format)
+}
+
+// csvExport CSV untuk Excel lokal Indonesia: UTF-8 dengan BOM, pemisah titik koma, nominal "Rp 15.000"
+type csvExport struct {
+	w       *csv.Writer
+	columns []ExportColumn
+}
+
+func newCSVExport(w io.Writer, columns []ExportColumn) (*csvExport, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	e := &csvExport{w: csv.NewWriter(w), columns: columns}
+	e.w.Comma = ';'
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+	return e, e.w.Write(headers)
+}
+
+func (e *csvExport) WriteRow(values []interface{}) error {
+	record := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		if i < len(values) {
+			record[i] = exportText(column.Kind, values[i])
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExport) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// Indeks style (cellXfs) pada styles.xml
+const (
+	xlsxStyleHeader   = 1
+	xlsxStyleMoney    = 2
+	xlsxStyleNumber   = 3
+	xlsxStyleDate     = 4
+	xlsxStyleDateTime = 5
+)
+
+// xlsxExport workbook satu sheet. Sheet ditulis terakhir ke arsip zip agar baris bisa langsung di-stream.
+type xlsxExport struct {
+	zw      *zip.Writer
+	sheet   io.Writer
+	columns []ExportColumn
+	row     int
+}
+
+func newXLSXExport(w io.Writer, sheetName string, columns []ExportColumn) (*xlsxExport, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xlsxEscape(xlsxSheetName(sheetName)))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	e := &xlsxExport{zw: zw, sheet: sheet, columns: columns}
+	if _, err := io.WriteString(sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	headers := make([]interface{}, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+	return e, e.writeRow(headers, true)
+}
+
+func (e *xlsxExport) WriteRow(values []interface{}) error {
+	return e.writeRow(values, false)
+}
+
+// writeRow menulis satu baris, nominal dan tanggal disimpan sebagai angka agar tetap bisa dijumlah / difilter di Excel
+func (e *xlsxExport) writeRow(values []interface{}, header bool) error {
+	e.row++
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, e.row)
+	for i, column := range e.columns {
+		if i >= len(values) || values[i] == nil {
+			continue
+		}
+		ref := xlsxColumnName(i) + strconv.Itoa(e.row)
+		if header {
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr" s="%d"><is><t>%s</t></is></c>`, ref, xlsxStyleHeader, xlsxEscape(exportText(ExportText, values[i])))
+			continue
+		}
+
+		switch column.Kind {
+		case ExportMoney, ExportNumber:
+			if n, ok := exportNumber(values[i]); ok {
+				style := xlsxStyleNumber
+				if column.Kind == ExportMoney {
+					style = xlsxStyleMoney
+				}
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, n)
+				continue
+			}
+		case ExportDate, ExportDateTime:
+			if t, ok := values[i].(time.Time); ok && !t.IsZero() {
+				style := xlsxStyleDate
+				if column.Kind == ExportDateTime {
+					style = xlsxStyleDateTime
+				}
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(xlsxSerial(t), 'f', 6, 64))
+				continue
+			}
+		}
+		fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xlsxEscape(exportText(ExportText, values[i])))
+	}
+	b.WriteString(`</row>`)
+
+	_, err := io.WriteString(e.sheet, b.String())
+	return err
+}
+
+func (e *xlsxExport) Close() error {
+	if _, err := io.WriteString(e.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return e.zw.Close()
+}
+
+// exportText nilai sel sebagai teks sesuai jenis kolom
+func exportText(kind ExportKind, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		if kind == ExportDate {
+			return v.In(utils.Location).Format("02-01-2006")
+		}
+		return v.In(utils.Location).Format("02-01-2006 15:04")
+	case bool:
+		if v {
+			return "Ya"
+		}
+		return "Tidak"
+	}
+
+	if n, ok := exportNumber(value); ok && kind == ExportMoney {
+		amount, _ := strconv.Atoi(n)
+		return "Rp " + FormatMoney(amount)
+	}
+	return fmt.Sprint(value)
+}
+
+// exportNumber nilai numerik sebagai teks angka tanpa pemisah ribuan
+func exportNumber(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// xlsxSerial tanggal sebagai nomor seri Excel (hari sejak 30-12-1899) menurut jam lokal
+func xlsxSerial(t time.Time) float64 {
+	local := t.In(utils.Location)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+	return wall.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
+
+// xlsxColumnName nama kolom Excel dari indeks 0: A, B, ..., Z, AA, ...
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xlsxSheetName nama sheet maksimal 31 karakter tanpa karakter yang dilarang Excel
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		name = "Sheet1"
+	}
+	return name
+}
+
+// xlsxEscape escape teks untuk XML, karakter yang tidak valid diganti
+func xlsxEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles style sel: 0 normal, 1 judul tebal, 2 Rupiah, 3 angka ribuan, 4 tanggal, 5 tanggal dan jam.
+// Pemisah ribuan mengikuti locale Excel pengguna (titik pada locale Indonesia).
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="3"><numFmt numFmtId="164" formatCode="&quot;Rp&quot;\ #,##0"/><numFmt numFmtId="165" formatCode="dd\-mm\-yyyy"/><numFmt numFmtId="166" formatCode="dd\-mm\-yyyy\ hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="6">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+var testExportColumns = []ExportColumn{
+	{Header: "Produk"},
+	{Header: "Harga", Kind: ExportMoney},
+	{Header: "Qty", Kind: ExportNumber},
+	{Header: "Tanggal", Kind: ExportDate},
+	{Header: "Dibuat", Kind: ExportDateTime},
+	{Header: "Aktif"},
+}
+
+// writeTestExport menulis dua baris data ke file ekspor dengan format tertentu
+func writeTestExport(t *testing.T, format string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewExportWriter(format, &buf, "Produk: Apotek [Pusat]", testExportColumns)
+	if err != nil {
+		t.Fatalf("NewExportWriter(%s): %v", format, err)
+	}
+	rows := [][]interface{}{
+		{"Salep <Kulit> & Gatal", 15000, 3, time.Date(2026, 10, 17, 0, 0, 0, 0, utils.Location), time.Date(2026, 10, 17, 12, 30, 0, 0, utils.Location), true},
+		{"Kapas", int64(2500), 1.5, time.Time{}, nil, false},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow(%s): %v", format, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close(%s): %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestExportXLSXReadBack(t *testing.T) {
+	data := writeTestExport(t, "xlsx")
+
+	// Setiap bagian workbook harus XML yang valid
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("xlsx bukan arsip zip yang valid: %v", err)
+	}
+	parts := map[string]bool{}
+	for _, f := range zr.File {
+		parts[f.Name] = true
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("gagal membuka %s: %v", f.Name, err)
+		}
+		decoder := xml.NewDecoder(rc)
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s bukan XML yang valid: %v", f.Name, err)
+			}
+		}
+		rc.Close()
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if !parts[name] {
+			t.Fatalf("bagian %s tidak ada di xlsx", name)
+		}
+	}
+
+	rows, err := ReadSpreadsheet("produk.xlsx", data)
+	if err != nil {
+		t.Fatalf("xlsx hasil ekspor tidak bisa dibaca: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("jumlah baris %d, seharusnya 3 (judul + 2 data)", len(rows))
+	}
+
+	header := []string{"Produk", "Harga", "Qty", "Tanggal", "Dibuat", "Aktif"}
+	if strings.Join(rows[0], "|") != strings.Join(header, "|") {
+		t.Fatalf("baris judul = %q", rows[0])
+	}
+
+	first := rows[1]
+	if first[0] != "Salep <Kulit> & Gatal" {
+		t.Fatalf("teks tidak kembali utuh: %q", first[0])
+	}
+	// Nominal dan angka disimpan sebagai angka, bukan teks "Rp 15.000"
+	if first[1] != "15000" || first[2] != "3" {
+		t.Fatalf("nominal / qty = %q / %q, seharusnya 15000 / 3", first[1], first[2])
+	}
+	date, err := ParseImportDate(first[3])
+	if err != nil || !date.Equal(time.Date(2026, 10, 17, 0, 0, 0, 0, utils.Location)) {
+		t.Fatalf("tanggal %q tidak kembali sebagai 17-10-2026: %v %v", first[3], date, err)
+	}
+	if !strings.HasPrefix(first[4], "46312.52") {
+		t.Fatalf("tanggal dan jam = %q, seharusnya nomor seri 46312.52...", first[4])
+	}
+	if first[5] != "Ya" {
+		t.Fatalf("boolean = %q, seharusnya Ya", first[5])
+	}
+
+	second := rows[2]
+	if second[1] != "2500" || second[2] != "1.5" {
+		t.Fatalf("nominal / qty baris kedua = %q / %q", second[1], second[2])
+	}
+	// Tanggal kosong ditulis sebagai teks kosong, nil tidak ditulis
+	if second[3] != "" || (len(second) > 4 && second[4] != "") || second[5] != "Tidak" {
+		t.Fatalf("sel kosong baris kedua tidak sesuai: %q", second)
+	}
+}
+
+func TestExportXLSXSheetName(t *testing.T) {
+	data := writeTestExport(t, "xlsx")
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("xlsx bukan arsip zip yang valid: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := readXMLPart(files, "xl/workbook.xml", &workbook); err != nil {
+		t.Fatalf("workbook.xml: %v", err)
+	}
+	if len(workbook.Sheets) != 1 || workbook.Sheets[0].Name != "Produk- Apotek -Pusat-" {
+		t.Fatalf("nama sheet = %+v, karakter terlarang seharusnya diganti", workbook.Sheets)
+	}
+}
+
+func TestExportCSVReadBack(t *testing.T) {
+	data := writeTestExport(t, "csv")
+
+	if !bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		t.Fatalf("csv tanpa BOM UTF-8")
+	}
+	if !bytes.Contains(data, []byte("Produk;Harga;Qty")) {
+		t.Fatalf("csv tidak memakai pemisah titik koma: %q", data)
+	}
+
+	rows, err := ReadSpreadsheet("produk.csv", data)
+	if err != nil {
+		t.Fatalf("csv hasil ekspor tidak bisa dibaca: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("jumlah baris %d, seharusnya 3", len(rows))
+	}
+	want := []string{"Salep <Kulit> & Gatal", "Rp 15.000", "3", "17-10-2026", "17-10-2026 12:30", "Ya"}
+	if strings.Join(rows[1], "|") != strings.Join(want, "|") {
+		t.Fatalf("baris csv = %q, seharusnya %q", rows[1], want)
+	}
+	if amount, err := ParseImportAmount(rows[2][1]); err != nil || amount != 2500 {
+		t.Fatalf("nominal %q tidak bisa dibaca ulang: %d %v", rows[2][1], amount, err)
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for index, want := range cases {
+		if got := xlsxColumnName(index); got != want {
+			t.Errorf("xlsxColumnName(%d) = %q, seharusnya %q", index, got, want)
+		}
+		if got := xlsxColumnIndex(want + "12"); got != index {
+			t.Errorf("xlsxColumnIndex(%q) = %d, seharusnya %d", want+"12", got, index)
+		}
+	}
+}
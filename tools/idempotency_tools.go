@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKeyTTL lama penyimpanan Idempotency-Key sebelum dihapus scheduler
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// HashIdempotencyRequest menghitung hash SHA-256 dari body request yang sudah di-parse
+func HashIdempotencyRequest(req interface{}) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// GetIdempotencyKey mengambil respons tersimpan untuk key milik user pada endpoint, gorm.ErrRecordNotFound jika belum ada
+func GetIdempotencyKey(db *gorm.DB, key string, userID string, endpoint string) (models.IdempotencyKeys, error) {
+	var record models.IdempotencyKeys
+	err := db.Where("key = ? AND user_id = ? AND endpoint = ?", key, userID, endpoint).First(&record).Error
+	return record, err
+}
+
+// SaveIdempotencyKey menyimpan respons transaksi di dalam transaksi database yang sama.
+// Unique index (key, user_id, endpoint) membuat request ganda yang berjalan bersamaan gagal di sini
+// setelah request pertama commit, sehingga transaksinya ikut dibatalkan.
+func SaveIdempotencyKey(tx *gorm.DB, record models.IdempotencyKeys, status int, message string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	record.ResponseStatus = status
+	record.ResponseMessage = message
+	record.ResponseData = string(body)
+	return tx.Create(&record).Error
+}
+
+// PurgeIdempotencyKeys menghapus Idempotency-Key yang dibuat sebelum batas waktu
+func PurgeIdempotencyKeys(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("created_at < ?", before).Delete(&models.IdempotencyKeys{})
+	return result.RowsAffected, result.Error
+}
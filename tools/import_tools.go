@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+// ReadSpreadsheet membaca file csv / xlsx menjadi baris-baris sel teks, format ditentukan dari ekstensi file.
+// Untuk xlsx hanya sheet pertama yang dibaca, tanggal berformat Excel tetap berupa nomor seri (lihat ParseImportDate).
+func ReadSpreadsheet(fileName string, data []byte) ([][]string, error) {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return readCSV(data)
+	case ".xlsx":
+		return readXLSX(data)
+	}
+	return nil, errors.New("file harus berformat .csv atau .xlsx")
+}
+
+// readCSV membaca csv dengan pemisah koma atau titik koma (dideteksi dari baris judul)
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	return r.ReadAll()
+}
+
+// xlsxRels relasi antar bagian workbook
+type xlsxRels struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxSheetData isi sheet, hanya nilai sel yang dibaca
+type xlsxSheetData struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:",innerxml"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// xlsxRichText teks pada shared string / inline string, bisa terdiri dari beberapa run
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+// readXLSX membaca sheet pertama workbook: shared string, inline string, angka dan boolean
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("file xlsx tidak valid: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	// Cari lokasi sheet pertama dari workbook.xml dan relasinya
+	var workbook struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := readXMLPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("file xlsx tidak memiliki sheet")
+	}
+	var rels xlsxRels
+	if err := readXMLPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RID {
+			sheetPath = strings.TrimPrefix(rel.Target, "/")
+			if !strings.HasPrefix(sheetPath, "xl/") {
+				sheetPath = path.Join("xl", sheetPath)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, errors.New("sheet pertama xlsx tidak ditemukan")
+	}
+
+	var sharedStrings []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xlsxRichText `xml:"si"`
+		}
+		if err := readXMLPart(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			sharedStrings = append(sharedStrings, item.String())
+		}
+	}
+
+	var sheet xlsxSheetData
+	if err := readXMLPart(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var cells []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col = xlsxColumnIndex(cell.Ref)
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(value)
+				if err != nil || idx < 0 || idx >= len(sharedStrings) {
+					return nil, fmt.Errorf("shared string sel %s tidak valid", cell.Ref)
+				}
+				value = sharedStrings[idx]
+			case "inlineStr":
+				var text xlsxRichText
+				if err := xml.Unmarshal([]byte("<is>"+cell.Inline.Text+"</is>"), &text); err != nil {
+					return nil, err
+				}
+				value = text.String()
+			case "b":
+				if value == "1" {
+					value = "TRUE"
+				} else {
+					value = "FALSE"
+				}
+			}
+			cells[col] = value
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+// readXMLPart membaca dan men-decode satu bagian xml di dalam arsip xlsx
+func readXMLPart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("file xlsx tidak valid: %s tidak ditemukan", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("file xlsx tidak valid: %s: %w", name, err)
+	}
+	return nil
+}
+
+// xlsxColumnIndex indeks kolom (mulai 0) dari referensi sel, misal "C12" -> 2
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, ch := range strings.ToUpper(ref) {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		index = index*26 + int(ch-'A'+1)
+	}
+	return index - 1
+}
+
+// localAmountPattern nominal format Indonesia: 15.000 / 1.250.000,50 / 15000,50
+var localAmountPattern = regexp.MustCompile(`^-?(\d{1,3}(\.\d{3})+|\d+)(,\d+)?$`)
+
+// ParseImportAmount membaca nominal dari spreadsheet: "15000", "15.000", "Rp 15.000" atau "15.000,00"
+func ParseImportAmount(value string) (int, error) {
+	s := strings.TrimSpace(strings.ToLower(value))
+	if s == "" {
+		return 0, nil
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "rp."), "rp")
+	s = strings.ReplaceAll(s, " ", "")
+
+	// Format lokal: titik pemisah ribuan, koma desimal (desimal dibuang)
+	if localAmountPattern.MatchString(s) {
+		s, _, _ = strings.Cut(s, ",")
+		s = strings.ReplaceAll(s, ".", "")
+	}
+
+	// Angka murni dari sel numerik xlsx, bisa berupa desimal seperti 15000.5
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("nominal %q tidak valid", value)
+	}
+	return int(f), nil
+}
+
+// ParseImportDate membaca tanggal dari spreadsheet: YYYY-MM-DD, DD-MM-YYYY, DD/MM/YYYY atau nomor seri Excel
+func ParseImportDate(value string) (time.Time, error) {
+	s := strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02", "02-01-2006", "02/01/2006", "2/1/2006"} {
+		if t, err := time.ParseInLocation(layout, s, utils.Location); err == nil {
+			return t, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 0 {
+		days := time.Duration(serial * 24 * float64(time.Hour))
+		wall := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).Add(days)
+		return time.Date(wall.Year(), wall.Month(), wall.Day(), 0, 0, 0, 0, utils.Location), nil
+	}
+	return time.Time{}, fmt.Errorf("tanggal %q tidak valid, gunakan YYYY-MM-DD atau DD-MM-YYYY", value)
+}
+
+// ParseImportBool membaca nilai ya / tidak dari spreadsheet
+func ParseImportBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "0", "tidak", "no", "n", "false":
+		return false, nil
+	case "1", "ya", "yes", "y", "true":
+		return true, nil
+	}
+	return false, fmt.Errorf("nilai %q tidak valid, gunakan Ya atau Tidak", value)
+}
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heru-oktafian/scafold/utils"
+)
+
+// Fixture workbook seperti hasil simpan Excel: teks di shared strings (termasuk rich text),
+// sheet di lokasi absolut, sel kosong yang tidak ditulis dan satu sel inline string
+const (
+	importFixtureWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Produk" sheetId="1" r:id="rId3"/><sheet name="Lain" sheetId="2" r:id="rId4"/></sheets></workbook>`
+
+	importFixtureRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+	importFixtureSharedStrings = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="6" uniqueCount="6">
+<si><t>SKU</t></si>
+<si><t>Nama Produk</t></si>
+<si><t>Harga Jual</t></si>
+<si><t>Kedaluwarsa</t></si>
+<si><r><rPr><b/></rPr><t>Paracetamol </t></r><r><t xml:space="preserve">500 mg</t></r></si>
+<si><t>Bebas Pajak</t></si>
+</sst>`
+
+	importFixtureSheet = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>5</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>PCT500</t></is></c><c r="B2" t="s"><v>4</v></c><c r="C2"><v>12500</v></c><c r="D2"><v>46312</v></c><c r="E2" t="b"><v>1</v></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><r><t>AMX</t></r><r><t>250</t></r></is></c><c r="C3"><v>8000.5</v></c></row>
+<row r="5"><c r="B5" t="inlineStr"><is><t>Tanpa SKU &amp; Harga</t></is></c><c r="E5" t="b"><v>0</v></c></row>
+</sheetData></worksheet>`
+
+	importFixtureOtherSheet = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>bukan sheet pertama</t></is></c></row>
+</sheetData></worksheet>`
+)
+
+// zipFixture menyusun arsip xlsx dari nama bagian dan isinya
+func zipFixture(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("gagal membuat %s: %v", name, err)
+		}
+		if _, err := f.Write([]byte(body)); err != nil {
+			t.Fatalf("gagal menulis %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gagal menutup zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func importFixtureParts() map[string]string {
+	return map[string]string{
+		"xl/workbook.xml":            importFixtureWorkbook,
+		"xl/_rels/workbook.xml.rels": importFixtureRels,
+		"xl/sharedStrings.xml":       importFixtureSharedStrings,
+		"xl/worksheets/sheet2.xml":   importFixtureSheet,
+		"xl/worksheets/sheet1.xml":   importFixtureOtherSheet,
+	}
+}
+
+func TestReadSpreadsheetXLSXFixture(t *testing.T) {
+	rows, err := ReadSpreadsheet("Produk.XLSX", zipFixture(t, importFixtureParts()))
+	if err != nil {
+		t.Fatalf("ReadSpreadsheet: %v", err)
+	}
+
+	want := [][]string{
+		{"SKU", "Nama Produk", "Harga Jual", "Kedaluwarsa", "Bebas Pajak"},
+		{"PCT500", "Paracetamol 500 mg", "12500", "46312", "TRUE"},
+		{"AMX250", "", "8000.5"},
+		{"", "Tanpa SKU & Harga", "", "", "FALSE"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("jumlah baris %d, seharusnya %d: %q", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("baris %d = %q, seharusnya %q", i+1, rows[i], want[i])
+		}
+	}
+}
+
+func TestReadSpreadsheetXLSXInvalid(t *testing.T) {
+	parts := importFixtureParts()
+	parts["xl/worksheets/sheet2.xml"] = strings.Replace(importFixtureSheet, `<c r="B2" t="s"><v>4</v></c>`, `<c r="B2" t="s"><v>9</v></c>`, 1)
+	if _, err := ReadSpreadsheet("produk.xlsx", zipFixture(t, parts)); err == nil || !strings.Contains(err.Error(), "B2") {
+		t.Fatalf("indeks shared string di luar jangkauan seharusnya error menyebut sel B2, didapat %v", err)
+	}
+
+	parts = importFixtureParts()
+	delete(parts, "xl/workbook.xml")
+	if _, err := ReadSpreadsheet("produk.xlsx", zipFixture(t, parts)); err == nil {
+		t.Fatalf("xlsx tanpa workbook.xml seharusnya error")
+	}
+
+	if _, err := ReadSpreadsheet("produk.xlsx", []byte("bukan zip")); err == nil {
+		t.Fatalf("file yang bukan zip seharusnya error")
+	}
+	if _, err := ReadSpreadsheet("produk.xls", nil); err == nil {
+		t.Fatalf("ekstensi .xls seharusnya ditolak")
+	}
+}
+
+func TestReadSpreadsheetCSV(t *testing.T) {
+	cases := map[string]string{
+		"koma":       "sku,nama,harga\nA1,Kapas,\"2.500\"\n",
+		"titik koma": "\ufeffsku;nama;harga\nA1;Kapas;2.500\n",
+	}
+	for name, data := range cases {
+		rows, err := ReadSpreadsheet("produk.csv", []byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(rows) != 2 || rows[0][0] != "sku" || strings.Join(rows[1], "|") != "A1|Kapas|2.500" {
+			t.Fatalf("%s: hasil baca %q", name, rows)
+		}
+	}
+}
+
+func TestParseImportAmount(t *testing.T) {
+	cases := map[string]int{
+		"":              0,
+		"15000":         15000,
+		"15.000":        15000,
+		"Rp 15.000":     15000,
+		"Rp. 1.250.000": 1250000,
+		"15.000,50":     15000,
+		"8000.5":        8000,
+		"-2.500":        -2500,
+	}
+	for input, want := range cases {
+		got, err := ParseImportAmount(input)
+		if err != nil || got != want {
+			t.Errorf("ParseImportAmount(%q) = %d, %v, seharusnya %d", input, got, err, want)
+		}
+	}
+	if _, err := ParseImportAmount("lima ribu"); err == nil {
+		t.Errorf("ParseImportAmount teks bukan angka seharusnya error")
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	want := time.Date(2026, 10, 17, 0, 0, 0, 0, utils.Location)
+	for _, input := range []string{"2026-10-17", "17-10-2026", "17/10/2026", "46312", "46312.75"} {
+		got, err := ParseImportDate(input)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseImportDate(%q) = %v, %v, seharusnya %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseImportDate("2026-13-45"); err == nil {
+		t.Errorf("ParseImportDate tanggal tidak valid seharusnya error")
+	}
+}
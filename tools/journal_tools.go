@@ -0,0 +1,536 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/utils"
+	"gorm.io/gorm"
+)
+
+// journalDraft jurnal yang sedang disusun dari dokumen, nilai per akun sistem disimpan bersih (debit positif, kredit negatif)
+type journalDraft struct {
+	branchID    string
+	userID      string
+	date        time.Time
+	description string
+	keys        []string
+	amounts     map[string]int
+}
+
+func newJournalDraft(branchID string, userID string, date time.Time, description string) *journalDraft {
+	return &journalDraft{
+		branchID:    branchID,
+		userID:      userID,
+		date:        date,
+		description: description,
+		amounts:     make(map[string]int),
+	}
+}
+
+// debit menambah sisi debit akun sistem, nilai negatif berarti kredit
+func (d *journalDraft) debit(key string, amount int) {
+	if amount == 0 {
+		return
+	}
+	if _, ok := d.amounts[key]; !ok {
+		d.keys = append(d.keys, key)
+	}
+	d.amounts[key] += amount
+}
+
+// credit menambah sisi kredit akun sistem, nilai negatif berarti debit
+func (d *journalDraft) credit(key string, amount int) {
+	d.debit(key, -amount)
+}
+
+// paymentAccount akun sistem penampung pembayaran dokumen, pembayaran yang belum lunas masuk ke akun unpaid
+func paymentAccount(payment models.PaymentStatus, unpaid string) string {
+	switch payment {
+	case models.PaidByCash, models.PaidBySplit:
+		return models.AccCash
+	case models.PaidByBank:
+		return models.AccBank
+	case models.PaidBySaldo:
+		return models.AccPointRedemption
+	}
+	return unpaid
+}
+
+// notFoundAsNil dokumen yang sudah dihapus tidak punya jurnal
+func notFoundAsNil(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// buildJournal menyusun jurnal dari dokumen sumber, nil jika dokumen tidak ada
+func buildJournal(db *gorm.DB, source models.JournalSource, sourceID string) (*journalDraft, error) {
+	switch source {
+	case models.JournalSale:
+		return buildSaleJournal(db, sourceID)
+	case models.JournalPurchase:
+		return buildPurchaseJournal(db, sourceID)
+	case models.JournalSaleReturn:
+		return buildSaleReturnJournal(db, sourceID)
+	case models.JournalBuyReturn:
+		return buildBuyReturnJournal(db, sourceID)
+	case models.JournalExpense:
+		return buildExpenseJournal(db, sourceID)
+	case models.JournalIncome:
+		return buildIncomeJournal(db, sourceID)
+	case models.JournalOpname:
+		return buildOpnameJournal(db, sourceID)
+	case models.JournalFirstStock:
+		return buildFirstStockJournal(db, sourceID)
+	case models.JournalTransferOut:
+		return buildTransferOutJournal(db, sourceID)
+	case models.JournalTransferIn:
+		return buildTransferInJournal(db, sourceID)
+	case models.JournalPayablePayment:
+		return buildPayablePaymentJournal(db, sourceID)
+	case models.JournalReceivablePayment:
+		return buildReceivablePaymentJournal(db, sourceID)
+	}
+	return nil, fmt.Errorf("sumber jurnal %s tidak dikenal", source)
+}
+
+// Penjualan: kas / bank / piutang / poin per rincian pembayaran, penjualan bersih dan PPN keluaran, serta HPP
+func buildSaleJournal(db *gorm.DB, saleID string) (*journalDraft, error) {
+	var sale models.Sales
+	if err := db.First(&sale, "id = ?", saleID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(sale.BranchID, sale.UserID, sale.SaleDate, "Penjualan "+sale.ID)
+
+	var payments []models.SalePayments
+	if err := db.Where("sale_id = ?", sale.ID).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	paid := 0
+	for _, payment := range payments {
+		d.debit(paymentAccount(payment.Method, models.AccReceivable), payment.Amount)
+		paid += payment.Amount
+	}
+	// Penjualan lama tanpa rincian pembayaran, atau total berubah setelah item diubah
+	d.debit(paymentAccount(sale.Payment, models.AccReceivable), sale.TotalSale-paid)
+
+	d.credit(models.AccSales, sale.TotalSale-sale.TaxAmount)
+	d.credit(models.AccTaxOut, sale.TaxAmount)
+
+	// Harga pokok per satuan dasar, item lama tanpa cost_price memakai harga pokok produk saat ini
+	var cogs int
+	if err := db.Table("sale_items si").
+		Joins("LEFT JOIN products pro ON pro.id = si.product_id").
+		Where("si.sale_id = ?", sale.ID).
+		Select("COALESCE(SUM(COALESCE(NULLIF(si.cost_price, 0), pro.purchase_price, 0) * si.qty * GREATEST(si.conv_value, 1)), 0)").
+		Scan(&cogs).Error; err != nil {
+		return nil, err
+	}
+	d.debit(models.AccCOGS, cogs)
+	d.credit(models.AccInventory, cogs)
+
+	return d, nil
+}
+
+// Pembelian: persediaan dan PPN masukan terhadap kas / bank / hutang usaha
+func buildPurchaseJournal(db *gorm.DB, purchaseID string) (*journalDraft, error) {
+	var purchase models.Purchases
+	if err := db.First(&purchase, "id = ?", purchaseID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(purchase.BranchID, purchase.UserID, purchase.PurchaseDate, "Pembelian "+purchase.ID)
+	d.debit(models.AccInventory, purchase.TotalPurchase-purchase.TaxAmount)
+	d.debit(models.AccTaxIn, purchase.TaxAmount)
+
+	// Pembelian yang sudah dicicil selalu dikreditkan ke hutang usaha (data lama mengganti metode pembayaran saat lunas),
+	// pelunasannya dijurnal dari pembayaran hutang
+	credited := paymentAccount(purchase.Payment, models.AccPayable)
+	if purchase.PaidAmount > 0 {
+		credited = models.AccPayable
+	}
+	d.credit(credited, purchase.TotalPurchase)
+	return d, nil
+}
+
+// Retur penjualan: retur penjualan dan PPN keluaran terhadap kas / bank / piutang,
+// barang kembali ke persediaan dengan harga pokok item penjualan asalnya
+func buildSaleReturnJournal(db *gorm.DB, saleReturnID string) (*journalDraft, error) {
+	var saleReturn models.SaleReturns
+	if err := db.First(&saleReturn, "id = ?", saleReturnID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+
+	// PPN yang dibatalkan dihitung per baris saat retur dibuat, total retur sudah termasuk PPN
+	d := newJournalDraft(saleReturn.BranchID, saleReturn.UserID, saleReturn.ReturnDate, "Retur penjualan "+saleReturn.ID)
+	d.debit(models.AccSalesReturn, saleReturn.TotalReturn-saleReturn.TaxAmount)
+	d.debit(models.AccTaxOut, saleReturn.TaxAmount)
+	// Bagian yang mengurangi piutang penjualan kredit dikreditkan ke piutang, sisanya dikembalikan sesuai payment
+	d.credit(models.AccReceivable, saleReturn.CreditAmount)
+	d.credit(paymentAccount(saleReturn.Payment, models.AccReceivable), saleReturn.TotalReturn-saleReturn.CreditAmount)
+
+	// Item retur lama tanpa sale_item_id dicocokkan dengan item penjualan asal lewat produk dan satuannya
+	var cost int
+	if err := db.Table("sale_return_items sri").
+		Joins("LEFT JOIN sale_items si ON si.id = COALESCE(NULLIF(sri.sale_item_id, ''), (SELECT si2.id FROM sale_items si2 WHERE si2.sale_id = ? AND si2.product_id = sri.product_id AND si2.unit_id = sri.unit_id LIMIT 1))", saleReturn.SaleId).
+		Joins("LEFT JOIN products pro ON pro.id = sri.product_id").
+		Where("sri.sale_return_id = ?", saleReturn.ID).
+		Select("COALESCE(SUM(sri.qty * GREATEST(sri.conv_value, 1) * COALESCE(NULLIF(si.cost_price, 0), pro.purchase_price, 0)), 0)").
+		Scan(&cost).Error; err != nil {
+		return nil, err
+	}
+	d.debit(models.AccInventory, cost)
+	d.credit(models.AccCOGS, cost)
+
+	return d, nil
+}
+
+// Retur pembelian: kas / bank / hutang usaha terhadap persediaan
+func buildBuyReturnJournal(db *gorm.DB, buyReturnID string) (*journalDraft, error) {
+	var buyReturn models.BuyReturns
+	if err := db.First(&buyReturn, "id = ?", buyReturnID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(buyReturn.BranchID, buyReturn.UserID, buyReturn.ReturnDate, "Retur pembelian "+buyReturn.ID)
+	d.debit(paymentAccount(buyReturn.Payment, models.AccPayable), buyReturn.TotalReturn)
+	d.credit(models.AccInventory, buyReturn.TotalReturn)
+	return d, nil
+}
+
+// Pengeluaran: beban operasional terhadap kas / bank / hutang lain-lain
+func buildExpenseJournal(db *gorm.DB, expenseID string) (*journalDraft, error) {
+	var expense models.Expenses
+	if err := db.First(&expense, "id = ?", expenseID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(expense.BranchID, expense.UserID, expense.ExpenseDate, strings.TrimSpace("Pengeluaran "+expense.ID+" "+expense.Description))
+	d.debit(models.AccOperatingExpense, expense.TotalExpense)
+	d.credit(paymentAccount(expense.Payment, models.AccOtherPayable), expense.TotalExpense)
+	return d, nil
+}
+
+// Pendapatan lain: kas / bank / piutang terhadap pendapatan lain-lain
+func buildIncomeJournal(db *gorm.DB, incomeID string) (*journalDraft, error) {
+	var income models.AnotherIncomes
+	if err := db.First(&income, "id = ?", incomeID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(income.BranchID, income.UserID, income.IncomeDate, strings.TrimSpace("Pendapatan lain "+income.ID+" "+income.Description))
+	d.debit(paymentAccount(income.Payment, models.AccReceivable), income.TotalIncome)
+	d.credit(models.AccOtherIncome, income.TotalIncome)
+	return d, nil
+}
+
+// Opname: selisih nilai stok fisik dan stok sistem, lebih masuk persediaan, kurang menjadi beban selisih persediaan
+func buildOpnameJournal(db *gorm.DB, opnameID string) (*journalDraft, error) {
+	var opname models.Opnames
+	if err := db.First(&opname, "id = ?", opnameID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(opname.BranchID, opname.UserID, opname.OpnameDate, "Stok opname "+opname.ID)
+	// total_opname = SUM(sub_total - sub_total_exist), positif berarti stok fisik lebih
+	d.debit(models.AccInventory, opname.TotalOpname)
+	d.credit(models.AccInventoryAdjustment, opname.TotalOpname)
+	return d, nil
+}
+
+// Stok awal: persediaan terhadap modal awal persediaan
+func buildFirstStockJournal(db *gorm.DB, firstStockID string) (*journalDraft, error) {
+	var firstStock models.FirstStocks
+	if err := db.First(&firstStock, "id = ?", firstStockID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(firstStock.BranchID, firstStock.UserID, firstStock.FirstStockDate, "Stok awal "+firstStock.ID)
+	d.debit(models.AccInventory, firstStock.TotalFirstStock)
+	d.credit(models.AccOpeningEquity, firstStock.TotalFirstStock)
+	return d, nil
+}
+
+// Transfer keluar (cabang asal): persediaan berpindah ke rekening antar cabang saat dikirim
+func buildTransferOutJournal(db *gorm.DB, transferID string) (*journalDraft, error) {
+	var transfer models.StockTransfers
+	if err := db.First(&transfer, "id = ?", transferID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	if transfer.Status == models.TransferDraft {
+		return nil, nil
+	}
+
+	date := transfer.TransferDate
+	if transfer.ShippedAt != nil {
+		date = *transfer.ShippedAt
+	}
+	userID := transfer.ShippedBy
+	if userID == "" {
+		userID = transfer.UserID
+	}
+	d := newJournalDraft(transfer.FromBranchID, userID, date, "Transfer keluar "+transfer.ID)
+	d.debit(models.AccInterBranch, transfer.TotalTransfer)
+	d.credit(models.AccInventory, transfer.TotalTransfer)
+	return d, nil
+}
+
+// Transfer masuk (cabang tujuan): satu jurnal per penerimaan, sumbernya baris transaction_reports transfer_in
+func buildTransferInJournal(db *gorm.DB, reportID string) (*journalDraft, error) {
+	var report models.TransactionReports
+	if err := db.First(&report, "id = ? AND transaction_type = ?", reportID, models.TransferIn).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(report.BranchID, report.UserID, report.CreatedAt, "Penerimaan transfer "+report.ID)
+	d.debit(models.AccInventory, report.Total)
+	d.credit(models.AccInterBranch, report.Total)
+	return d, nil
+}
+
+// Pembayaran hutang: hutang usaha terhadap kas / bank
+func buildPayablePaymentJournal(db *gorm.DB, paymentID string) (*journalDraft, error) {
+	var payment models.SupplierPayments
+	if err := db.First(&payment, "id = ?", paymentID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(payment.BranchID, payment.UserID, payment.PaymentDate, "Pembayaran hutang "+payment.PurchaseId)
+	d.debit(models.AccPayable, payment.Amount)
+	d.credit(paymentAccount(payment.Payment, models.AccCash), payment.Amount)
+	return d, nil
+}
+
+// Pembayaran piutang: kas / bank terhadap piutang usaha
+func buildReceivablePaymentJournal(db *gorm.DB, paymentID string) (*journalDraft, error) {
+	var payment models.ReceivablePayments
+	if err := db.First(&payment, "id = ?", paymentID).Error; err != nil {
+		return nil, notFoundAsNil(err)
+	}
+	d := newJournalDraft(payment.BranchID, payment.UserID, payment.PaymentDate, "Pembayaran piutang "+payment.SaleId)
+	d.debit(paymentAccount(payment.Payment, models.AccCash), payment.Amount)
+	d.credit(models.AccReceivable, payment.Amount)
+	return d, nil
+}
+
+// EnsureBranchAccounts membuat akun bawaan cabang yang belum ada, mengembalikan akun sistem per system_key
+func EnsureBranchAccounts(db *gorm.DB, branchID string) (map[string]models.Accounts, error) {
+	var accounts []models.Accounts
+	if err := db.Where("branch_id = ? AND system_key <> ''", branchID).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	bySystemKey := make(map[string]models.Accounts, len(accounts))
+	for _, account := range accounts {
+		bySystemKey[account.SystemKey] = account
+	}
+
+	for _, def := range models.DefaultAccounts {
+		if _, ok := bySystemKey[def.SystemKey]; ok {
+			continue
+		}
+
+		var codeUsed int64
+		if err := db.Model(&models.Accounts{}).Where("branch_id = ? AND code = ?", branchID, def.Code).Count(&codeUsed).Error; err != nil {
+			return nil, err
+		}
+		if codeUsed > 0 {
+			return nil, fmt.Errorf("kode akun %s sudah dipakai, akun %s tidak bisa dibuat", def.Code, def.Name)
+		}
+
+		account := models.Accounts{
+			ID:          helpers.GenerateID("ACC"),
+			Code:        def.Code,
+			Name:        def.Name,
+			AccountType: def.AccountType,
+			SystemKey:   def.SystemKey,
+			Active:      true,
+			BranchID:    branchID,
+		}
+		if err := db.Create(&account).Error; err != nil {
+			return nil, err
+		}
+		bySystemKey[def.SystemKey] = account
+	}
+
+	return bySystemKey, nil
+}
+
+// SeedBranchAccounts membuat akun bawaan untuk semua cabang yang belum punya
+func SeedBranchAccounts(db *gorm.DB) error {
+	var branchIDs []string
+	if err := db.Model(&models.Branch{}).Pluck("id", &branchIDs).Error; err != nil {
+		return err
+	}
+	for _, branchID := range branchIDs {
+		if _, err := EnsureBranchAccounts(db, branchID); err != nil {
+			return fmt.Errorf("cabang %s: %w", branchID, err)
+		}
+	}
+	return nil
+}
+
+// journalStatus status jurnal baru sesuai metode jurnal cabang, cabang manual harus memposting jurnal sendiri
+func journalStatus(db *gorm.DB, branchID string) (models.JournalStatus, error) {
+	var branch models.Branch
+	if err := db.Select("id, journal_method").First(&branch, "id = ?", branchID).Error; err != nil {
+		return "", err
+	}
+	if branch.JournalMethod == models.Manual {
+		return models.JournalDraft, nil
+	}
+	return models.JournalPosted, nil
+}
+
+// RemoveJournal menghapus jurnal dokumen beserta baris-barisnya
+func RemoveJournal(db *gorm.DB, source models.JournalSource, sourceID string) error {
+	var entryIDs []string
+	if err := db.Model(&models.JournalEntries{}).
+		Where("source_type = ? AND source_id = ?", source, sourceID).
+		Pluck("id", &entryIDs).Error; err != nil {
+		return err
+	}
+	if len(entryIDs) == 0 {
+		return nil
+	}
+	if err := db.Where("journal_entry_id IN ?", entryIDs).Delete(&models.JournalLines{}).Error; err != nil {
+		return err
+	}
+	return db.Where("id IN ?", entryIDs).Delete(&models.JournalEntries{}).Error
+}
+
+// SyncJournal membuat ulang jurnal dokumen dari data dokumen saat ini.
+// Dipanggil setiap kali dokumen dibuat, diubah atau dihapus, dengan db / tx yang sama dengan perubahan dokumen.
+// Jurnal dokumen di cabang dengan metode manual kembali menjadi draft dan harus diposting ulang.
+func SyncJournal(db *gorm.DB, source models.JournalSource, sourceID string) error {
+	draft, err := buildJournal(db, source, sourceID)
+	if err != nil {
+		return err
+	}
+	if err := RemoveJournal(db, source, sourceID); err != nil {
+		return err
+	}
+	if draft == nil {
+		return nil
+	}
+
+	accounts, err := EnsureBranchAccounts(db, draft.branchID)
+	if err != nil {
+		return err
+	}
+	status, err := journalStatus(db, draft.branchID)
+	if err != nil {
+		return err
+	}
+
+	nowWIB := time.Now().In(utils.Location)
+	entry := models.JournalEntries{
+		ID:          helpers.GenerateID("JRN"),
+		EntryDate:   draft.date,
+		Description: draft.description,
+		SourceType:  source,
+		SourceId:    sourceID,
+		Status:      status,
+		UserID:      draft.userID,
+		BranchID:    draft.branchID,
+		CreatedAt:   nowWIB,
+		UpdatedAt:   nowWIB,
+	}
+	if status == models.JournalPosted {
+		entry.PostedBy = draft.userID
+		entry.PostedAt = &nowWIB
+	}
+
+	var lines []models.JournalLines
+	for _, key := range draft.keys {
+		amount := draft.amounts[key]
+		if amount == 0 {
+			continue
+		}
+		line := models.JournalLines{
+			ID:             helpers.GenerateID("JRL"),
+			JournalEntryId: entry.ID,
+			AccountId:      accounts[key].ID,
+		}
+		if amount > 0 {
+			line.Debit = amount
+		} else {
+			line.Credit = -amount
+		}
+		entry.TotalDebit += line.Debit
+		entry.TotalCredit += line.Credit
+		lines = append(lines, line)
+	}
+
+	// Dokumen bernilai nol tidak perlu dijurnal
+	if len(lines) == 0 {
+		return nil
+	}
+	if entry.TotalDebit != entry.TotalCredit {
+		return fmt.Errorf("jurnal %s %s tidak seimbang: debit %d, kredit %d", source, sourceID, entry.TotalDebit, entry.TotalCredit)
+	}
+
+	if err := db.Create(&entry).Error; err != nil {
+		return err
+	}
+	return db.Create(&lines).Error
+}
+
+// journalSourceDocument dokumen sumber jurnal beserta tanggal jurnalnya
+type journalSourceDocument struct {
+	Source models.JournalSource `gorm:"-"`
+	ID     string
+	Date   time.Time
+}
+
+// journalSourceQueries query id dan tanggal jurnal dokumen cabang per sumber jurnal, dipakai untuk membangun ulang jurnal
+func journalSourceQueries(db *gorm.DB, branchID string) map[models.JournalSource]*gorm.DB {
+	return map[models.JournalSource]*gorm.DB{
+		models.JournalSale:              db.Model(&models.Sales{}).Select("id, sale_date AS date").Where("branch_id = ?", branchID),
+		models.JournalPurchase:          db.Model(&models.Purchases{}).Select("id, purchase_date AS date").Where("branch_id = ?", branchID),
+		models.JournalSaleReturn:        db.Model(&models.SaleReturns{}).Select("id, return_date AS date").Where("branch_id = ?", branchID),
+		models.JournalBuyReturn:         db.Model(&models.BuyReturns{}).Select("id, return_date AS date").Where("branch_id = ?", branchID),
+		models.JournalExpense:           db.Model(&models.Expenses{}).Select("id, expense_date AS date").Where("branch_id = ?", branchID),
+		models.JournalIncome:            db.Model(&models.AnotherIncomes{}).Select("id, income_date AS date").Where("branch_id = ?", branchID),
+		models.JournalOpname:            db.Model(&models.Opnames{}).Select("id, opname_date AS date").Where("branch_id = ?", branchID),
+		models.JournalFirstStock:        db.Model(&models.FirstStocks{}).Select("id, first_stock_date AS date").Where("branch_id = ?", branchID),
+		models.JournalTransferOut:       db.Model(&models.StockTransfers{}).Select("id, COALESCE(shipped_at, transfer_date) AS date").Where("from_branch_id = ? AND status <> ?", branchID, models.TransferDraft),
+		models.JournalTransferIn:        db.Model(&models.TransactionReports{}).Select("id, created_at AS date").Where("branch_id = ? AND transaction_type = ?", branchID, models.TransferIn),
+		models.JournalPayablePayment:    db.Model(&models.SupplierPayments{}).Select("id, payment_date AS date").Where("branch_id = ?", branchID),
+		models.JournalReceivablePayment: db.Model(&models.ReceivablePayments{}).Select("id, payment_date AS date").Where("branch_id = ?", branchID),
+	}
+}
+
+// RebuildBranchJournals membangun ulang jurnal seluruh dokumen cabang, misalnya untuk dokumen yang dibuat sebelum ada buku besar.
+// Dokumen diproses urut tanggal lalu ID agar hasilnya sama setiap kali dijalankan. Mengembalikan jumlah dokumen yang diproses.
+func RebuildBranchJournals(db *gorm.DB, branchID string) (int, error) {
+	var documents []journalSourceDocument
+	for source, query := range journalSourceQueries(db, branchID) {
+		var sourceDocuments []journalSourceDocument
+		if err := query.Scan(&sourceDocuments).Error; err != nil {
+			return 0, err
+		}
+		for i := range sourceDocuments {
+			sourceDocuments[i].Source = source
+		}
+		documents = append(documents, sourceDocuments...)
+	}
+
+	sort.Slice(documents, func(i, j int) bool {
+		if !documents[i].Date.Equal(documents[j].Date) {
+			return documents[i].Date.Before(documents[j].Date)
+		}
+		if documents[i].ID != documents[j].ID {
+			return documents[i].ID < documents[j].ID
+		}
+		return documents[i].Source < documents[j].Source
+	})
+
+	processed := 0
+	for _, document := range documents {
+		if err := SyncJournal(db, document.Source, document.ID); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
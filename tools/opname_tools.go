@@ -39,6 +39,7 @@ func AutoCleanupOpnames(db *gorm.DB) error {
 
 			// Hapus transaction_report
 			db.Where("id = ?", opname.ID).Delete(&models.TransactionReports{})
+			RemoveJournal(db, models.JournalOpname, opname.ID)
 
 			// Hapus opname
 			db.Where("id = ?", opname.ID).Delete(&models.Opnames{})
@@ -93,13 +94,8 @@ func JSONOpnameWithItemsResponse(c *framework.Ctx, status int, message string, o
 }
 
 // Opname stock product
-func OpnameProductStock(db *gorm.DB, productID string, qty int) error {
-	var product models.Product
-	if err := db.First(&product, "id = ?", productID).Error; err != nil {
-		return err
-	}
-	product.Stock = qty
-	return db.Save(&product).Error
+func OpnameProductStock(db *gorm.DB, productID string, qty int, ref StockRef) error {
+	return SetProductStock(db, productID, qty, ref)
 }
 
 // RecalculateTotalOpname menghitung ulang total opname
@@ -136,5 +132,6 @@ func RecalculateTotalOpname(db *gorm.DB, opnameID string) error {
 		return err
 	}
 
-	return nil
+	// Jurnal selisih persediaan
+	return SyncJournal(db, models.JournalOpname, opname.ID)
 }
@@ -0,0 +1,26 @@
+package tools
+
+import (
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// SetPurchaseDueDate mengisi jatuh tempo pembelian paid_by_credit berdasarkan tempo pembayaran supplier.
+// Pembelian selain paid_by_credit tidak memiliki jatuh tempo, kecuali hutang yang sudah dilunasi.
+func SetPurchaseDueDate(db *gorm.DB, purchase *models.Purchases) error {
+	if purchase.Payment != models.PaidByCredit {
+		if purchase.PaidAmount == 0 {
+			purchase.DueDate = nil
+		}
+		return nil
+	}
+
+	var supplier models.Supplier
+	if err := db.Select("payment_terms").First(&supplier, "id = ?", purchase.SupplierId).Error; err != nil {
+		return err
+	}
+
+	dueDate := purchase.PurchaseDate.AddDate(0, 0, supplier.PaymentTerms)
+	purchase.DueDate = &dueDate
+	return nil
+}
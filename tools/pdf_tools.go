@@ -0,0 +1,325 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/heru-oktafian/api-retail/models"
+)
+
+// Ukuran halaman A4 dan margin dalam point (1/72 inci)
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 40.0
+	pdfRowHeight  = 16.0
+)
+
+// Lebar karakter ASCII 32-126 font standar Helvetica dan Helvetica-Bold (satuan 1/1000 ukuran font),
+// dipakai untuk rata kanan dan memotong teks yang melebihi lebar kolom
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// pdfWriter penyusun halaman PDF sederhana: teks Helvetica, garis dan kotak, tanpa dependensi eksternal
+type pdfWriter struct {
+	pages []*bytes.Buffer
+	y     float64 // Posisi baris berikutnya dari bawah halaman
+}
+
+// RenderDocumentPDF merender dokumen ke PDF A4: kop cabang, info dokumen, tabel item (header diulang
+// di setiap halaman), total, catatan, kolom tanda tangan dan nomor halaman
+func RenderDocumentPDF(doc models.Document) []byte {
+	w := &pdfWriter{}
+	w.newPage()
+	right := pdfPageWidth - pdfMargin
+
+	// Kop cabang dan judul dokumen
+	w.text(pdfMargin, w.y-14, 14, true, fitText(doc.BranchName, 14, true, 300))
+	w.textRight(right, w.y-14, 16, true, doc.Title)
+	w.y -= 22
+	var contact []string
+	for _, line := range strings.Split(doc.BranchAddress, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			contact = append(contact, line)
+		}
+	}
+	if doc.BranchPhone != "" {
+		contact = append(contact, "Telp. "+doc.BranchPhone)
+	}
+	if doc.BranchEmail != "" {
+		contact = append(contact, doc.BranchEmail)
+	}
+	for _, line := range contact {
+		w.text(pdfMargin, w.y-9, 9, false, fitText(line, 9, false, right-pdfMargin))
+		w.y -= 12
+	}
+	w.y -= 4
+	w.rule(pdfMargin, right, w.y)
+	w.y -= 10
+
+	// Info dokumen
+	for _, field := range doc.Info {
+		w.text(pdfMargin, w.y-10, 10, false, field.Label)
+		w.text(pdfMargin+100, w.y-10, 10, false, ": "+fitText(field.Value, 10, false, right-pdfMargin-110))
+		w.y -= 14
+	}
+	w.y -= 8
+
+	// Tabel item
+	widths := columnWidths(doc.Columns, right-pdfMargin)
+	w.tableHeader(doc.Columns, widths)
+	for _, row := range doc.Rows {
+		if w.y-pdfRowHeight < pdfMargin {
+			w.newPage()
+			w.tableHeader(doc.Columns, widths)
+		}
+		w.tableRow(doc.Columns, widths, row, false)
+	}
+	w.rule(pdfMargin, right, w.y)
+	w.y -= 6
+
+	// Total rata kanan, baris terakhir dicetak tebal
+	for i, field := range doc.Totals {
+		if w.y-14 < pdfMargin {
+			w.newPage()
+		}
+		bold := i == len(doc.Totals)-1
+		w.text(right-220, w.y-11, 10, bold, field.Label)
+		w.textRight(right, w.y-11, 10, bold, field.Value)
+		w.y -= 14
+	}
+
+	// Catatan
+	if len(doc.Notes) > 0 {
+		w.y -= 10
+		for _, note := range doc.Notes {
+			for _, line := range wrapWidth(note, 9, right-pdfMargin) {
+				if w.y-12 < pdfMargin {
+					w.newPage()
+				}
+				w.text(pdfMargin, w.y-9, 9, false, line)
+				w.y -= 12
+			}
+		}
+	}
+
+	// Kolom tanda tangan dibagi rata selebar halaman
+	if len(doc.Signatures) > 0 {
+		w.y -= 20
+		if w.y-80 < pdfMargin {
+			w.newPage()
+		}
+		colWidth := (right - pdfMargin) / float64(len(doc.Signatures))
+		for i, title := range doc.Signatures {
+			center := pdfMargin + colWidth*(float64(i)+0.5)
+			w.text(center-textWidth(title, 10, false)/2, w.y-10, 10, false, title)
+			w.rule(center-60, center+60, w.y-70)
+		}
+		w.y -= 80
+	}
+
+	// Nomor halaman
+	for i, page := range w.pages {
+		label := fmt.Sprintf("Halaman %d dari %d", i+1, len(w.pages))
+		fmt.Fprintf(page, "BT /F1 8 Tf %.2f %.2f Td (%s) Tj ET\n", right-textWidth(label, 8, false), pdfMargin/2, pdfString(label))
+	}
+
+	return w.bytes()
+}
+
+// newPage menambah halaman baru dan memindahkan posisi ke batas atas
+func (w *pdfWriter) newPage() {
+	w.pages = append(w.pages, &bytes.Buffer{})
+	w.y = pdfPageHeight - pdfMargin
+}
+
+// page halaman yang sedang ditulis
+func (w *pdfWriter) page() *bytes.Buffer {
+	return w.pages[len(w.pages)-1]
+}
+
+// text menulis teks rata kiri dengan baseline di y
+func (w *pdfWriter) text(x, y, size float64, bold bool, s string) {
+	if s == "" {
+		return
+	}
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(w.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// textRight menulis teks rata kanan dengan ujung kanan di x
+func (w *pdfWriter) textRight(x, y, size float64, bold bool, s string) {
+	w.text(x-textWidth(s, size, bold), y, size, bold, s)
+}
+
+// rule garis horizontal tipis
+func (w *pdfWriter) rule(x1, x2, y float64) {
+	fmt.Fprintf(w.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y, x2, y)
+}
+
+// tableHeader baris judul kolom dengan latar abu-abu
+func (w *pdfWriter) tableHeader(columns []models.DocumentColumn, widths []float64) {
+	fmt.Fprintf(w.page(), "0.9 g %.2f %.2f %.2f %.2f re f 0 g\n", pdfMargin, w.y-pdfRowHeight, pdfPageWidth-2*pdfMargin, pdfRowHeight)
+	titles := make([]string, len(columns))
+	for i, column := range columns {
+		titles[i] = column.Title
+	}
+	w.tableRow(columns, widths, titles, true)
+}
+
+// tableRow satu baris tabel, teks yang melebihi lebar kolom dipotong
+func (w *pdfWriter) tableRow(columns []models.DocumentColumn, widths []float64, cells []string, bold bool) {
+	x := pdfMargin
+	for i, column := range columns {
+		if i < len(cells) {
+			cell := fitText(cells[i], 9, bold, widths[i]-8)
+			if column.Right {
+				w.textRight(x+widths[i]-4, w.y-11, 9, bold, cell)
+			} else {
+				w.text(x+4, w.y-11, 9, bold, cell)
+			}
+		}
+		x += widths[i]
+	}
+	w.y -= pdfRowHeight
+}
+
+// bytes menyusun file PDF: katalog, daftar halaman, dua font standar, halaman beserta isinya dan tabel xref
+func (w *pdfWriter) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range w.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// columnWidths membagi lebar tabel sesuai porsi Width tiap kolom
+func columnWidths(columns []models.DocumentColumn, total float64) []float64 {
+	sum := 0.0
+	for _, column := range columns {
+		sum += column.Width
+	}
+	widths := make([]float64, len(columns))
+	for i, column := range columns {
+		if sum > 0 {
+			widths[i] = total * column.Width / sum
+		} else {
+			widths[i] = total / float64(len(columns))
+		}
+	}
+	return widths
+}
+
+// textWidth lebar teks dalam point, karakter di luar ASCII dihitung selebar angka
+func textWidth(s string, size float64, bold bool) float64 {
+	table := &helveticaWidths
+	if bold {
+		table = &helveticaBoldWidths
+	}
+	units := 0
+	for _, ch := range s {
+		if ch >= 32 && ch <= 126 {
+			units += table[ch-32]
+		} else {
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// fitText memotong teks dengan "..." agar tidak melebihi lebar maksimal
+func fitText(s string, size float64, bold bool, maxWidth float64) string {
+	if textWidth(s, size, bold) <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size, bold) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// wrapWidth memecah teks per kata agar setiap baris muat dalam lebar maksimal
+func wrapWidth(s string, size float64, maxWidth float64) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && textWidth(candidate, size, false) > maxWidth {
+			lines = append(lines, current)
+			candidate = word
+		}
+		current = fitText(candidate, size, false, maxWidth)
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// pdfString mengubah teks ke WinAnsi (Latin-1) dan meng-escape karakter khusus string PDF.
+// Karakter di luar Latin-1 diganti "?".
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, ch := range s {
+		switch {
+		case ch == '(' || ch == ')' || ch == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(ch))
+		case ch >= 32 && ch <= 126, ch >= 160 && ch <= 255:
+			b.WriteByte(byte(ch))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
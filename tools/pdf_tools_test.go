@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/heru-oktafian/api-retail/models"
+)
+
+// testDocument dokumen faktur dengan rows baris item, cukup banyak untuk beberapa halaman
+func testDocument(rows int) models.Document {
+	doc := models.Document{
+		Title:         "FAKTUR PENJUALAN",
+		BranchName:    "Toko Sehat (Pusat)",
+		BranchAddress: "Jl. Merdeka No. 1\nBandung",
+		BranchPhone:   "022-123456",
+		Info: []models.DocumentField{
+			{Label: "No. Faktur", Value: "SAL2510170001"},
+			{Label: "Tanggal", Value: "17-10-2026"},
+		},
+		Columns: []models.DocumentColumn{
+			{Title: "Produk", Width: 4},
+			{Title: "Qty", Width: 1, Right: true},
+			{Title: "Subtotal", Width: 2, Right: true},
+		},
+		Totals: []models.DocumentField{
+			{Label: "Subtotal", Value: "Rp 1.000.000"},
+			{Label: "Total", Value: "Rp 1.110.000"},
+		},
+		Notes:      []string{"Barang yang sudah dibeli tidak dapat dikembalikan kecuali ada perjanjian."},
+		Signatures: []string{"Penerima", "Hormat Kami"},
+	}
+	for i := 0; i < rows; i++ {
+		doc.Rows = append(doc.Rows, []string{fmt.Sprintf("Produk uji nomor %d dengan nama yang sangat panjang sekali", i+1), "2", "Rp 20.000"})
+	}
+	return doc
+}
+
+// parsedPDF hasil pembacaan struktur PDF: offset objek dari xref dan isi trailer
+type parsedPDF struct {
+	offsets []int
+	size    int
+	root    int
+}
+
+// parsePDF membaca header, startxref, tabel xref dan trailer, lalu memastikan setiap offset xref menunjuk ke objeknya
+func parsePDF(t *testing.T, data []byte) parsedPDF {
+	t.Helper()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("header PDF tidak valid: %q", data[:min(len(data), 16)])
+	}
+	if !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("PDF tidak diakhiri %%%%EOF")
+	}
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if match == nil {
+		t.Fatalf("startxref tidak ditemukan")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if xref <= 0 || xref >= len(data) || !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d tidak menunjuk ke tabel xref", xref)
+	}
+
+	lines := strings.Split(string(data[xref:]), "\n")
+	var first, count int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &count); err != nil || first != 0 {
+		t.Fatalf("subseksi xref tidak valid: %q", lines[1])
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Fatalf("entri xref objek 0 tidak valid: %q", lines[2])
+	}
+
+	result := parsedPDF{}
+	for i := 1; i < count; i++ {
+		entry := lines[2+i]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("entri xref %d tidak valid: %q", i, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if !bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i))) {
+			t.Fatalf("offset xref objek %d (%d) tidak menunjuk ke awal objek", i, offset)
+		}
+		result.offsets = append(result.offsets, offset)
+	}
+
+	trailer := strings.Join(lines[2+count:], "\n")
+	if _, err := fmt.Sscanf(trailer, "trailer\n<< /Size %d /Root %d 0 R >>", &result.size, &result.root); err != nil {
+		t.Fatalf("trailer tidak valid: %q", trailer)
+	}
+	if result.size != count {
+		t.Fatalf("/Size trailer %d, jumlah entri xref %d", result.size, count)
+	}
+	return result
+}
+
+// pdfObject isi objek ke-n (1-based) di antara "n 0 obj" dan "endobj"
+func pdfObject(t *testing.T, data []byte, parsed parsedPDF, n int) string {
+	t.Helper()
+
+	start := parsed.offsets[n-1]
+	end := bytes.Index(data[start:], []byte("\nendobj\n"))
+	if end < 0 {
+		t.Fatalf("objek %d tidak ditutup endobj", n)
+	}
+	return string(data[start : start+end])
+}
+
+func TestRenderDocumentPDFStructure(t *testing.T) {
+	data := RenderDocumentPDF(testDocument(120))
+	parsed := parsePDF(t, data)
+
+	if parsed.root != 1 || !strings.Contains(pdfObject(t, data, parsed, 1), "/Type /Catalog /Pages 2 0 R") {
+		t.Fatalf("objek root bukan katalog")
+	}
+
+	pages := pdfObject(t, data, parsed, 2)
+	match := regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(pages)
+	if match == nil {
+		t.Fatalf("objek pages tanpa /Count: %q", pages)
+	}
+	pageCount, _ := strconv.Atoi(match[1])
+	if pageCount < 2 {
+		t.Fatalf("120 baris seharusnya lebih dari satu halaman, didapat %d", pageCount)
+	}
+	if want := 4 + pageCount*2; len(parsed.offsets) != want {
+		t.Fatalf("jumlah objek %d, seharusnya %d", len(parsed.offsets), want)
+	}
+
+	streamPattern := regexp.MustCompile(`(?s)^\d+ 0 obj\n<< /Length (\d+) >>\nstream\n(.*)endstream$`)
+	for i := 0; i < pageCount; i++ {
+		page := pdfObject(t, data, parsed, 5+i*2)
+		if !strings.Contains(page, "/Type /Page /Parent 2 0 R") || !strings.Contains(page, fmt.Sprintf("/Contents %d 0 R", 6+i*2)) {
+			t.Fatalf("objek halaman %d tidak valid: %q", i+1, page)
+		}
+
+		content := streamPattern.FindStringSubmatch(pdfObject(t, data, parsed, 6+i*2))
+		if content == nil {
+			t.Fatalf("stream halaman %d tidak valid", i+1)
+		}
+		length, _ := strconv.Atoi(content[1])
+		if length != len(content[2]) {
+			t.Fatalf("/Length halaman %d = %d, isi stream %d byte", i+1, length, len(content[2]))
+		}
+		if label := fmt.Sprintf("(Halaman %d dari %d) Tj", i+1, pageCount); !strings.Contains(content[2], label) {
+			t.Fatalf("halaman %d tanpa nomor halaman %q", i+1, label)
+		}
+		// Header tabel diulang di setiap halaman
+		if !strings.Contains(content[2], "(Produk) Tj") {
+			t.Fatalf("halaman %d tanpa header tabel", i+1)
+		}
+	}
+}
+
+func TestRenderDocumentPDFSinglePage(t *testing.T) {
+	data := RenderDocumentPDF(testDocument(3))
+	parsed := parsePDF(t, data)
+
+	if !strings.Contains(pdfObject(t, data, parsed, 2), "/Count 1") {
+		t.Fatalf("dokumen 3 baris seharusnya satu halaman")
+	}
+	if !bytes.Contains(data, []byte(`(Toko Sehat \(Pusat\)) Tj`)) {
+		t.Fatalf("nama cabang dengan tanda kurung tidak di-escape")
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	cases := map[string]string{
+		"Harga (net)": `Harga \(net\)`,
+		`C:\data`:     `C:\\data`,
+		"Café":        "Caf\xe9",
+		"Rp 10.000 ✓": "Rp 10.000 ?",
+	}
+	for input, want := range cases {
+		if got := pdfString(input); got != want {
+			t.Errorf("pdfString(%q) = %q, seharusnya %q", input, got, want)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	if got := fitText("Obat", 9, false, 100); got != "Obat" {
+		t.Fatalf("teks pendek tidak boleh dipotong, didapat %q", got)
+	}
+
+	long := strings.Repeat("Paracetamol ", 10)
+	got := fitText(long, 9, false, 80)
+	if !strings.HasSuffix(got, "...") || textWidth(got, 9, false) > 80 {
+		t.Fatalf("fitText tidak memotong teks ke lebar 80: %q (%.2f)", got, textWidth(got, 9, false))
+	}
+}
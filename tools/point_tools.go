@@ -0,0 +1,269 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/helpers"
+	"gorm.io/gorm"
+)
+
+// PointRef menyimpan sumber dokumen dari sebuah mutasi poin member
+type PointRef struct {
+	ReferenceID string
+	Reason      string
+	UserID      string
+	BranchID    string
+}
+
+// EarnMemberPoints menambah poin member dari transaksi, masa berlaku mengikuti pengaturan cabang
+func EarnMemberPoints(db *gorm.DB, memberID string, points int, ref PointRef) error {
+	if points <= 0 {
+		return nil
+	}
+
+	expiredAt, err := pointExpiry(db, ref.BranchID)
+	if err != nil {
+		return err
+	}
+
+	return addMemberPoints(db, memberID, models.PointEarn, points, expiredAt, ref)
+}
+
+// RefundMemberPoints mengembalikan poin yang sudah ditukar (misalnya karena penjualan dihapus).
+// Poin kembali bisa dipakai dan masa berlakunya mengikuti pengaturan cabang.
+func RefundMemberPoints(db *gorm.DB, memberID string, points int, ref PointRef) error {
+	if points <= 0 {
+		return nil
+	}
+
+	expiredAt, err := pointExpiry(db, ref.BranchID)
+	if err != nil {
+		return err
+	}
+
+	return addMemberPoints(db, memberID, models.PointReversal, points, expiredAt, ref)
+}
+
+// RedeemMemberPoints menukar poin member, poin yang paling cepat kadaluarsa dipakai lebih dulu
+func RedeemMemberPoints(db *gorm.DB, memberID string, points int, ref PointRef) error {
+	if points <= 0 {
+		return nil
+	}
+
+	var member models.Member
+	if err := db.First(&member, "id = ?", memberID).Error; err != nil {
+		return err
+	}
+	if member.Points < points {
+		return errors.New("insufficient member points")
+	}
+
+	return takeMemberPoints(db, memberID, models.PointRedeem, points, ref)
+}
+
+// ReverseMemberPoints menarik kembali poin yang didapat dari sebuah penjualan (misalnya karena retur).
+// Poin ditarik dari sisa poin penjualan tersebut lebih dulu, dan saldo member tidak dibuat negatif.
+func ReverseMemberPoints(db *gorm.DB, memberID string, saleID string, points int, ref PointRef) (int, error) {
+	if points <= 0 {
+		return 0, nil
+	}
+
+	var member models.Member
+	if err := db.First(&member, "id = ?", memberID).Error; err != nil {
+		return 0, err
+	}
+	points = min(points, member.Points)
+	if points <= 0 {
+		return 0, nil
+	}
+
+	// Kurangi sisa poin dari penjualan asal lebih dulu agar poin lain tidak ikut terpakai
+	var earned models.MemberPoints
+	err := db.Where("member_id = ? AND reference_id = ? AND point_type = ? AND remaining > 0", memberID, saleID, models.PointEarn).
+		First(&earned).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	take := 0
+	if err == nil {
+		take = min(earned.Remaining, points)
+		if err := db.Model(&earned).Update("remaining", earned.Remaining-take).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	if err := consumeMemberPoints(db, memberID, points-take); err != nil {
+		return 0, err
+	}
+
+	return points, recordMemberPoints(db, memberID, models.PointReversal, -points, 0, nil, ref)
+}
+
+// AdjustMemberPoints menyesuaikan poin member secara manual dengan alasan tertentu
+func AdjustMemberPoints(db *gorm.DB, memberID string, points int, ref PointRef) error {
+	if points > 0 {
+		return addMemberPoints(db, memberID, models.PointAdjust, points, nil, ref)
+	}
+
+	var member models.Member
+	if err := db.First(&member, "id = ?", memberID).Error; err != nil {
+		return err
+	}
+	if member.Points < -points {
+		return errors.New("insufficient member points")
+	}
+
+	return takeMemberPoints(db, memberID, models.PointAdjust, -points, ref)
+}
+
+// ExpireMemberPoints menghanguskan sisa poin yang sudah lewat masa berlaku.
+// Entri yang gagal dicatat di log dan dilewati agar entri lain tetap diproses, semua error dikembalikan bersama.
+func ExpireMemberPoints(db *gorm.DB) error {
+	var expired []models.MemberPoints
+	if err := db.Where("remaining > 0 AND expired_at IS NOT NULL AND expired_at < ?", time.Now()).
+		Find(&expired).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range expired {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.MemberPoints{}).Where("id = ?", entry.ID).Update("remaining", 0).Error; err != nil {
+				return err
+			}
+
+			// Saldo member tidak dibuat negatif jika poin sudah terpakai di luar buku besar
+			var member models.Member
+			if err := tx.First(&member, "id = ?", entry.MemberId).Error; err != nil {
+				return err
+			}
+			points := min(entry.Remaining, member.Points)
+			if points <= 0 {
+				return nil
+			}
+
+			return recordMemberPoints(tx, entry.MemberId, models.PointExpire, -points, 0, nil, PointRef{
+				ReferenceID: entry.ID,
+				Reason:      "Poin kadaluarsa",
+				BranchID:    entry.BranchID,
+			})
+		})
+		if err != nil {
+			log.Printf("[POINT EXPIRY] Gagal menghanguskan poin %s: %v", entry.ID, err)
+			errs = append(errs, fmt.Errorf("poin %s: %w", entry.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SeedMemberPoints membuat saldo awal buku besar untuk member yang punya poin tapi belum punya riwayat
+func SeedMemberPoints(db *gorm.DB) error {
+	var members []models.Member
+	if err := db.Where("points > 0 AND NOT EXISTS (SELECT 1 FROM member_points mp WHERE mp.member_id = members.id)").
+		Find(&members).Error; err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		entry := models.MemberPoints{
+			ID:        helpers.GenerateID("MPT"),
+			MemberId:  member.ID,
+			PointType: models.PointAdjust,
+			Points:    member.Points,
+			Remaining: member.Points,
+			Reason:    "Saldo awal poin",
+			BranchID:  member.BranchID,
+		}
+		if err := db.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pointExpiry menghitung tanggal kadaluarsa poin baru sesuai pengaturan cabang, nil jika poin tidak kadaluarsa
+func pointExpiry(db *gorm.DB, branchID string) (*time.Time, error) {
+	var branch models.Branch
+	if err := db.Select("point_expiry_days").First(&branch, "id = ?", branchID).Error; err != nil {
+		return nil, err
+	}
+
+	if branch.PointExpiryDays <= 0 {
+		return nil, nil
+	}
+	expiry := time.Now().AddDate(0, 0, branch.PointExpiryDays)
+	return &expiry, nil
+}
+
+// addMemberPoints mencatat poin masuk dan menambah saldo member
+func addMemberPoints(db *gorm.DB, memberID string, pointType models.PointType, points int, expiredAt *time.Time, ref PointRef) error {
+	return recordMemberPoints(db, memberID, pointType, points, points, expiredAt, ref)
+}
+
+// takeMemberPoints mengurangi sisa poin masuk lalu mencatat poin keluar
+func takeMemberPoints(db *gorm.DB, memberID string, pointType models.PointType, points int, ref PointRef) error {
+	if err := consumeMemberPoints(db, memberID, points); err != nil {
+		return err
+	}
+
+	return recordMemberPoints(db, memberID, pointType, -points, 0, nil, ref)
+}
+
+// consumeMemberPoints mengurangi sisa poin masuk dengan urutan kadaluarsa paling awal.
+// Poin lama yang belum punya riwayat tidak dianggap error, sisa qty dibiarkan.
+func consumeMemberPoints(db *gorm.DB, memberID string, points int) error {
+	if points <= 0 {
+		return nil
+	}
+
+	var entries []models.MemberPoints
+	if err := db.Where("member_id = ? AND remaining > 0", memberID).
+		Order("expired_at ASC NULLS LAST, created_at ASC").
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	remaining := points
+	for _, entry := range entries {
+		if remaining == 0 {
+			break
+		}
+		take := min(entry.Remaining, remaining)
+		if err := db.Model(&models.MemberPoints{}).Where("id = ?", entry.ID).
+			Update("remaining", gorm.Expr("remaining - ?", take)).Error; err != nil {
+			return err
+		}
+		remaining -= take
+	}
+
+	return nil
+}
+
+// recordMemberPoints menyimpan satu baris buku besar poin dan memperbarui saldo poin member
+func recordMemberPoints(db *gorm.DB, memberID string, pointType models.PointType, points int, remaining int, expiredAt *time.Time, ref PointRef) error {
+	entry := models.MemberPoints{
+		ID:          helpers.GenerateID("MPT"),
+		MemberId:    memberID,
+		PointType:   pointType,
+		Points:      points,
+		Remaining:   remaining,
+		ReferenceID: ref.ReferenceID,
+		Reason:      ref.Reason,
+		ExpiredAt:   expiredAt,
+		BranchID:    ref.BranchID,
+		UserID:      ref.UserID,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		return err
+	}
+
+	return db.Model(&models.Member{}).Where("id = ?", memberID).
+		Update("points", gorm.Expr("points + ?", points)).Error
+}
@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"sort"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/helpers"
+	"gorm.io/gorm"
+)
+
+// productPriceTiers harga daftar harga satu produk per satuan, urut dari min_qty terkecil
+type productPriceTiers struct {
+	baseUnitID string
+	units      map[string][]models.PriceBreak
+}
+
+// GetMemberPriceList mengambil daftar harga aktif dari kategori member, kosong jika member tidak punya daftar harga
+func GetMemberPriceList(db *gorm.DB, branchID string, memberID string) (string, error) {
+	if memberID == "" {
+		return "", nil
+	}
+
+	var priceListID string
+	err := db.Table("members mem").
+		Select("COALESCE(pl.id, '')").
+		Joins("JOIN member_categories mc ON mc.id = mem.member_category_id").
+		Joins("JOIN price_lists pl ON pl.id = mc.price_list_id AND pl.active = ? AND pl.branch_id = ?", true, branchID).
+		Where("mem.id = ?", memberID).
+		Limit(1).
+		Scan(&priceListID).Error
+	return priceListID, err
+}
+
+// GetSalePriceList mengambil daftar harga aktif member penjualan
+func GetSalePriceList(db *gorm.DB, branchID string, saleID string) (string, error) {
+	var memberID string
+	if err := db.Model(&models.Sales{}).Select("member_id").Where("id = ?", saleID).Scan(&memberID).Error; err != nil {
+		return "", err
+	}
+	return GetMemberPriceList(db, branchID, memberID)
+}
+
+// loadPriceTiers memuat harga daftar harga untuk produk-produk tertentu, dikelompokkan per product_id
+func loadPriceTiers(db *gorm.DB, priceListID string, productIDs []string) (map[string]productPriceTiers, error) {
+	var rows []struct {
+		ProductId  string
+		BaseUnitId string
+		UnitId     string
+		MinQty     int
+		Price      int
+	}
+	query := db.Table("price_list_items pli").
+		Select("pli.product_id, pro.unit_id AS base_unit_id, pli.unit_id, pli.min_qty, pli.price").
+		Joins("JOIN products pro ON pro.id = pli.product_id").
+		Where("pli.price_list_id = ?", priceListID).
+		Order("pli.min_qty ASC")
+	if len(productIDs) > 0 {
+		query = query.Where("pli.product_id IN ?", productIDs)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	tiers := make(map[string]productPriceTiers)
+	for _, row := range rows {
+		product, ok := tiers[row.ProductId]
+		if !ok {
+			product = productPriceTiers{baseUnitID: row.BaseUnitId, units: make(map[string][]models.PriceBreak)}
+			tiers[row.ProductId] = product
+		}
+		product.units[row.UnitId] = append(product.units[row.UnitId], models.PriceBreak{MinQty: max(row.MinQty, 1), Price: row.Price})
+	}
+	return tiers, nil
+}
+
+// tierPrice harga dari tingkat dengan min_qty terbesar yang masih <= qty
+func tierPrice(breaks []models.PriceBreak, qty int) (int, bool) {
+	price, found := 0, false
+	for _, tier := range breaks {
+		if tier.MinQty <= qty {
+			price, found = tier.Price, true
+		}
+	}
+	return price, found
+}
+
+// price harga satuan jual untuk qty tertentu. Jika satuan tidak punya harga sendiri,
+// harga satuan dasar di daftar harga dikalikan nilai konversi (qty break dihitung dalam satuan dasar).
+func (p productPriceTiers) price(unit models.ProdSaleUnit, qty int) (int, bool) {
+	if price, ok := tierPrice(p.units[unit.UnitId], qty); ok {
+		return price, true
+	}
+	if unit.UnitId != p.baseUnitID && unit.ConvValue > 0 {
+		if price, ok := tierPrice(p.units[p.baseUnitID], qty*unit.ConvValue); ok {
+			return price * unit.ConvValue, true
+		}
+	}
+	return 0, false
+}
+
+// ResolveSalePrice harga jual per satuan untuk qty tertentu menurut daftar harga,
+// jika daftar harga kosong atau tidak memuat produk tersebut dipakai harga jual satuan (unit.Price)
+func ResolveSalePrice(db *gorm.DB, priceListID string, unit models.ProdSaleUnit, qty int) (int, error) {
+	if priceListID == "" {
+		return unit.Price, nil
+	}
+
+	tiers, err := loadPriceTiers(db, priceListID, []string{unit.ProductId})
+	if err != nil {
+		return 0, err
+	}
+	if price, ok := tiers[unit.ProductId].price(unit, qty); ok {
+		return price, nil
+	}
+	return unit.Price, nil
+}
+
+// ApplyPriceList mengganti harga satuan jual (hasil GetSaleUnits) dengan harga daftar harga untuk qty 1,
+// beserta harga bertingkat yang berlaku untuk qty lebih besar
+func ApplyPriceList(db *gorm.DB, priceListID string, units map[string][]models.ProdSaleUnit) error {
+	if priceListID == "" || len(units) == 0 {
+		return nil
+	}
+
+	productIDs := make([]string, 0, len(units))
+	for productID := range units {
+		productIDs = append(productIDs, productID)
+	}
+	tiers, err := loadPriceTiers(db, priceListID, productIDs)
+	if err != nil {
+		return err
+	}
+
+	for productID, productUnits := range units {
+		product, ok := tiers[productID]
+		if !ok {
+			continue
+		}
+		for i, unit := range productUnits {
+			if price, ok := product.price(unit, 1); ok {
+				productUnits[i].Price = price
+			}
+			productUnits[i].PriceBreaks = product.breaks(unit)
+		}
+	}
+	return nil
+}
+
+// breaks harga bertingkat satuan untuk qty > 1, termasuk turunan dari harga bertingkat satuan dasar
+func (p productPriceTiers) breaks(unit models.ProdSaleUnit) []models.PriceBreak {
+	var result []models.PriceBreak
+	for _, tier := range p.units[unit.UnitId] {
+		if tier.MinQty > 1 {
+			result = append(result, tier)
+		}
+	}
+	if len(p.units[unit.UnitId]) == 0 && unit.UnitId != p.baseUnitID && unit.ConvValue > 0 {
+		for _, tier := range p.units[p.baseUnitID] {
+			// Qty minimal dalam satuan dasar dibulatkan ke atas menjadi qty satuan jual
+			minQty := (tier.MinQty + unit.ConvValue - 1) / unit.ConvValue
+			if minQty > 1 {
+				result = append(result, models.PriceBreak{MinQty: minQty, Price: tier.Price * unit.ConvValue})
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].MinQty < result[j].MinQty })
+	return result
+}
+
+// RecordPriceOverride mencatat harga item penjualan yang diubah kasir dari harga daftar harga
+func RecordPriceOverride(db *gorm.DB, item models.SaleItems, userID string, branchID string, now time.Time) error {
+	return db.Create(&models.PriceOverrides{
+		ID:          helpers.GenerateID("POV"),
+		SaleId:      item.SaleId,
+		SaleItemId:  item.ID,
+		ProductId:   item.ProductId,
+		UnitId:      item.UnitId,
+		PriceListId: item.PriceListId,
+		ListPrice:   item.ListPrice,
+		Price:       item.Price,
+		Reason:      item.OverrideReason,
+		UserID:      userID,
+		BranchID:    branchID,
+		CreatedAt:   now,
+	}).Error
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/helpers"
+	"gorm.io/gorm"
+)
+
+// PriceRef menyimpan sumber dokumen dari sebuah perubahan harga produk
+type PriceRef struct {
+	Source      string
+	ReferenceID string
+	UserID      string
+	BranchID    string
+}
+
+// PriceRefFromStock membuat PriceRef dari sumber mutasi stok, dipakai saat harga pokok berubah karena barang masuk / keluar
+func PriceRefFromStock(ref StockRef) PriceRef {
+	return PriceRef{
+		Source:      string(ref.MovementType),
+		ReferenceID: ref.ReferenceID,
+		UserID:      ref.UserID,
+		BranchID:    ref.BranchID,
+	}
+}
+
+// productPriceValue nilai kolom harga produk sesuai field
+func productPriceValue(product models.Product, field string) (int, error) {
+	switch field {
+	case models.PriceFieldPurchase:
+		return product.PurchasePrice, nil
+	case models.PriceFieldSales:
+		return product.SalesPrice, nil
+	case models.PriceFieldAlternate:
+		return product.AlternatePrice, nil
+	}
+	return 0, fmt.Errorf("kolom harga %s tidak dikenal", field)
+}
+
+// RecordPriceChange mencatat satu perubahan harga produk ke product_price_histories.
+// Harus dipanggil dengan db / tx yang sama dengan update harga agar ikut di-rollback.
+func RecordPriceChange(db *gorm.DB, ref PriceRef, productID string, field string, oldPrice int, newPrice int) error {
+	// Tidak ada perubahan, tidak perlu dicatat
+	if oldPrice == newPrice {
+		return nil
+	}
+
+	history := models.ProductPriceHistory{
+		ID:          helpers.GenerateID("PPH"),
+		ProductId:   productID,
+		Field:       field,
+		OldPrice:    oldPrice,
+		NewPrice:    newPrice,
+		Source:      ref.Source,
+		ReferenceID: ref.ReferenceID,
+		UserID:      ref.UserID,
+		BranchID:    ref.BranchID,
+	}
+
+	return db.Create(&history).Error
+}
+
+// SetProductPrice mengubah satu kolom harga produk dan mencatat riwayatnya jika nilainya berubah
+func SetProductPrice(db *gorm.DB, ref PriceRef, productID string, field string, newPrice int) error {
+	var product models.Product
+	if err := db.Select("id, purchase_price, sales_price, alternate_price").First(&product, "id = ?", productID).Error; err != nil {
+		return err
+	}
+
+	oldPrice, err := productPriceValue(product, field)
+	if err != nil {
+		return err
+	}
+	if oldPrice == newPrice {
+		return nil
+	}
+
+	if err := db.Model(&models.Product{}).Where("id = ?", productID).Update(field, newPrice).Error; err != nil {
+		return err
+	}
+	return RecordPriceChange(db, ref, productID, field, oldPrice, newPrice)
+}
+
+// ApplyScheduledPriceChanges menerapkan jadwal perubahan harga yang tanggal berlakunya sudah tiba.
+// Tiap jadwal diterapkan dalam transaksi sendiri agar satu jadwal gagal tidak menahan jadwal lain.
+func ApplyScheduledPriceChanges(db *gorm.DB, now time.Time) (int, error) {
+	var schedules []models.ScheduledPriceChanges
+	if err := db.Where("status = ? AND effective_date <= ?", models.ScheduledPricePending, now).
+		Order("effective_date ASC, created_at ASC").
+		Find(&schedules).Error; err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	var firstErr error
+	for _, schedule := range schedules {
+		skipped := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Jadwal bisa dibatalkan setelah daftar diambil
+			result := tx.Model(&models.ScheduledPriceChanges{}).
+				Where("id = ? AND status = ?", schedule.ID, models.ScheduledPricePending).
+				Updates(map[string]interface{}{"status": models.ScheduledPriceApplied, "applied_at": now})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				skipped = true
+				return nil
+			}
+
+			return SetProductPrice(tx, PriceRef{
+				Source:      models.PriceSourceScheduled,
+				ReferenceID: schedule.ID,
+				UserID:      schedule.UserID,
+				BranchID:    schedule.BranchID,
+			}, schedule.ProductId, schedule.Field, schedule.NewPrice)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Produk sudah dihapus, jadwal dibatalkan agar tidak dicoba terus
+			skipped = true
+			err = db.Model(&models.ScheduledPriceChanges{}).Where("id = ?", schedule.ID).
+				Update("status", models.ScheduledPriceCancelled).Error
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("jadwal harga %s: %w", schedule.ID, err)
+			}
+			continue
+		}
+		if !skipped {
+			applied++
+		}
+	}
+
+	return applied, firstErr
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// GetActivePromos mengambil promo cabang yang berlaku pada saleDate untuk total belanja basket.
+// memberCategoryID 0 berarti pembeli umum, promo khusus kategori member tidak ikut.
+func GetActivePromos(db *gorm.DB, branchID string, memberCategoryID uint, basket int, saleDate time.Time) ([]models.Promos, error) {
+	day := time.Date(saleDate.Year(), saleDate.Month(), saleDate.Day(), 0, 0, 0, 0, saleDate.Location())
+
+	var promos []models.Promos
+	err := db.Where("branch_id = ? AND active = ? AND start_date <= ? AND end_date >= ? AND min_basket <= ?", branchID, true, saleDate, day, basket).
+		Where("member_category_id IS NULL OR member_category_id = ?", memberCategoryID).
+		Find(&promos).Error
+	return promos, err
+}
+
+// ApplySalePromos menghitung promo terbaik (potongan terbesar) untuk setiap item penjualan.
+// PromoId, PromoDiscount dan SubTotal item diisi ulang, harga item dianggap sudah final.
+// Mengembalikan total potongan promo seluruh item.
+func ApplySalePromos(db *gorm.DB, branchID string, memberID string, saleDate time.Time, items []models.SaleItems) (int, error) {
+	// Total belanja sebelum promo untuk syarat minimal basket
+	basket := 0
+	for _, item := range items {
+		basket += item.Price * item.Qty
+	}
+
+	var memberCategoryID uint
+	if memberID != "" {
+		var member models.Member
+		if err := db.Select("member_category_id").First(&member, "id = ?", memberID).Error; err != nil {
+			return 0, err
+		}
+		memberCategoryID = member.MemberCategoryId
+	}
+
+	promos, err := GetActivePromos(db, branchID, memberCategoryID, basket, saleDate)
+	if err != nil {
+		return 0, err
+	}
+
+	totalDiscount := 0
+	for i := range items {
+		items[i].PromoId = ""
+		items[i].PromoDiscount = 0
+
+		if len(promos) > 0 {
+			var product models.Product
+			if err := db.Select("id, product_category_id").First(&product, "id = ?", items[i].ProductId).Error; err != nil {
+				return 0, err
+			}
+
+			for _, promo := range promos {
+				if promo.ProductId != nil && *promo.ProductId != product.ID {
+					continue
+				}
+				if promo.ProductCategoryId != nil && *promo.ProductCategoryId != product.ProductCategoryId {
+					continue
+				}
+
+				discount := promoDiscount(promo, items[i].Price, items[i].Qty)
+				if discount > items[i].PromoDiscount {
+					items[i].PromoId = promo.ID
+					items[i].PromoDiscount = discount
+				}
+			}
+		}
+
+		items[i].SubTotal = items[i].Price*items[i].Qty - items[i].PromoDiscount
+		totalDiscount += items[i].PromoDiscount
+	}
+
+	return totalDiscount, nil
+}
+
+// promoDiscount menghitung potongan sebuah promo untuk satu baris item
+func promoDiscount(promo models.Promos, price int, qty int) int {
+	var discount int
+	switch promo.PromoType {
+	case models.PromoPercentage:
+		discount = price * qty * min(promo.Value, 100) / 100
+	case models.PromoAmount:
+		discount = min(promo.Value, price) * qty
+	case models.PromoBuyXGetY:
+		if promo.BuyQty > 0 && promo.GetQty > 0 {
+			discount = qty / (promo.BuyQty + promo.GetQty) * promo.GetQty * price
+		}
+	}
+	return max(discount, 0)
+}
+
+// DeactivateExpiredPromos menonaktifkan promo yang tanggal berakhirnya sudah lewat
+func DeactivateExpiredPromos(db *gorm.DB, now time.Time) (int64, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	result := db.Model(&models.Promos{}).
+		Where("active = ? AND end_date < ?", true, today).
+		Update("active", false)
+	return result.RowsAffected, result.Error
+}
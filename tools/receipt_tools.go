@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/heru-oktafian/api-retail/models"
+)
+
+// ReceiptColumns jumlah karakter per baris (font A) untuk lebar kertas printer thermal yang didukung
+var ReceiptColumns = map[int]int{
+	58: 32,
+	80: 48,
+}
+
+// Perintah ESC/POS yang dipakai struk
+var (
+	escInit        = []byte{0x1B, 0x40}             // ESC @ : reset printer
+	escAlignLeft   = []byte{0x1B, 0x61, 0x00}       // ESC a 0
+	escAlignCenter = []byte{0x1B, 0x61, 0x01}       // ESC a 1
+	escBoldOn      = []byte{0x1B, 0x45, 0x01}       // ESC E 1
+	escBoldOff     = []byte{0x1B, 0x45, 0x00}       // ESC E 0
+	escFeed        = []byte{0x1B, 0x64, 0x04}       // ESC d 4 : feed 4 baris
+	escCut         = []byte{0x1D, 0x56, 0x42, 0x00} // GS V 66 0 : feed lalu potong sebagian
+)
+
+// receiptLine satu baris struk beserta format cetaknya
+type receiptLine struct {
+	text   string
+	center bool
+	bold   bool
+}
+
+// Receipt struk yang sudah disusun per baris untuk lebar kertas tertentu
+type Receipt struct {
+	columns int
+	lines   []receiptLine
+}
+
+// paymentLabels nama metode pembayaran yang dicetak pada struk
+var paymentLabels = map[models.PaymentStatus]string{
+	models.PaidByCash:   "Tunai",
+	models.PaidByBank:   "Bank / Transfer",
+	models.PaidByCredit: "Kredit",
+	models.PaidBySaldo:  "Poin",
+	models.PaidBySplit:  "Split",
+}
+
+// PaymentLabel nama metode pembayaran untuk struk dan dokumen cetak, metode lain dicetak apa adanya
+func PaymentLabel(method models.PaymentStatus) string {
+	if label, ok := paymentLabels[method]; ok {
+		return label
+	}
+	return string(method)
+}
+
+// BuildReceipt menyusun struk penjualan: kop cabang, item, diskon, PPN, pembayaran, kembalian dan footer
+func BuildReceipt(data models.SaleReceipt, columns int) Receipt {
+	r := Receipt{columns: columns}
+	separator := strings.Repeat("-", columns)
+
+	r.center(data.BranchName, true)
+	r.center(data.Address, false)
+	if data.Phone != "" {
+		r.center("Telp. "+data.Phone, false)
+	}
+	r.center(data.Header, false)
+
+	r.add(separator)
+	r.add("No    : " + data.SaleID)
+	r.add("Tgl   : " + data.SaleDate)
+	r.add("Kasir : " + data.Cashier)
+	if data.MemberName != "" {
+		r.add("Member: " + data.MemberName)
+	}
+	r.add(separator)
+
+	for _, item := range data.Items {
+		r.add(item.ProductName)
+		r.row(fmt.Sprintf("  %d %s x %s", item.Qty, item.UnitName, FormatMoney(item.Price)), item.Price*item.Qty)
+		if item.PromoDiscount > 0 {
+			r.row("  "+item.PromoName, -item.PromoDiscount)
+		}
+	}
+
+	r.add(separator)
+	r.row("Subtotal", data.SubTotal)
+	if data.Discount > 0 {
+		r.row("Diskon", -data.Discount)
+	}
+	if data.TaxAmount > 0 {
+		if data.TaxMode == models.TaxInclusive {
+			r.row("PPN (termasuk)", data.TaxAmount)
+		} else {
+			r.row("PPN", data.TaxAmount)
+		}
+	}
+	r.boldRow("TOTAL", data.Total)
+
+	change := 0
+	for _, payment := range data.Payments {
+		label := PaymentLabel(payment.Method)
+		if payment.Method == models.PaidByCash && payment.Tendered > 0 {
+			r.row(label, payment.Tendered)
+			change += payment.ChangeDue
+			continue
+		}
+		r.row(label, payment.Amount)
+	}
+	if len(data.Payments) == 0 {
+		r.row(PaymentLabel(data.Payment), data.Total-data.PointsAmount)
+	}
+	if change > 0 {
+		r.row("Kembali", change)
+	}
+
+	r.add(separator)
+	r.center(data.Footer, false)
+
+	return r
+}
+
+// Lines baris struk sebagai teks, baris tengah diberi spasi di kiri
+func (r Receipt) Lines() []string {
+	lines := make([]string, 0, len(r.lines))
+	for _, line := range r.lines {
+		text := line.text
+		if line.center {
+			text = strings.Repeat(" ", max((r.columns-len([]rune(text)))/2, 0)) + text
+		}
+		lines = append(lines, text)
+	}
+	return lines
+}
+
+// Text struk teks biasa
+func (r Receipt) Text() string {
+	return strings.Join(r.Lines(), "\n") + "\n"
+}
+
+// EscPos struk sebagai byte stream ESC/POS, karakter non-ASCII diganti "?"
+func (r Receipt) EscPos() []byte {
+	out := append([]byte{}, escInit...)
+	for _, line := range r.lines {
+		if line.center {
+			out = append(out, escAlignCenter...)
+		} else {
+			out = append(out, escAlignLeft...)
+		}
+		if line.bold {
+			out = append(out, escBoldOn...)
+		}
+		out = append(out, asciiOnly(line.text)...)
+		out = append(out, '\n')
+		if line.bold {
+			out = append(out, escBoldOff...)
+		}
+	}
+	out = append(out, escAlignLeft...)
+	out = append(out, escFeed...)
+	return append(out, escCut...)
+}
+
+// FormatMoney format angka dengan pemisah ribuan titik, misal 15000 -> 15.000
+func FormatMoney(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// add menambah baris rata kiri, teks panjang dipotong ke baris berikutnya
+func (r *Receipt) add(text string) {
+	if len([]rune(text)) <= r.columns {
+		r.lines = append(r.lines, receiptLine{text: text})
+		return
+	}
+	for _, part := range wrapText(text, r.columns) {
+		r.lines = append(r.lines, receiptLine{text: part})
+	}
+}
+
+// center menambah baris rata tengah, teks multi baris (header / footer) dipecah per baris
+func (r *Receipt) center(text string, bold bool) {
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		for _, part := range wrapText(paragraph, r.columns) {
+			r.lines = append(r.lines, receiptLine{text: part, center: true, bold: bold})
+		}
+	}
+}
+
+// row menambah baris label di kiri dan nominal di kanan
+func (r *Receipt) row(label string, amount int) {
+	r.lines = append(r.lines, receiptLine{text: r.rowText(label, amount)})
+}
+
+// boldRow sama seperti row dengan huruf tebal
+func (r *Receipt) boldRow(label string, amount int) {
+	r.lines = append(r.lines, receiptLine{text: r.rowText(label, amount), bold: true})
+}
+
+// rowText teks baris dengan nominal rata kanan, label yang terlalu panjang dipotong
+func (r *Receipt) rowText(label string, amount int) string {
+	value := FormatMoney(amount)
+	width := r.columns - len(value) - 1
+	labelRunes := []rune(label)
+	if len(labelRunes) > width {
+		labelRunes = labelRunes[:max(width, 0)]
+	}
+	return string(labelRunes) + strings.Repeat(" ", r.columns-len(labelRunes)-len(value)) + value
+}
+
+// wrapText memecah teks per kata agar tidak melebihi jumlah kolom
+func wrapText(text string, columns int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for len([]rune(word)) > columns {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:columns]))
+			word = string(runes[columns:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= columns:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// asciiOnly mengganti karakter di luar ASCII agar aman untuk code page default printer
+func asciiOnly(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, ch := range text {
+		if ch < 0x20 || ch > 0x7E {
+			out = append(out, '?')
+			continue
+		}
+		out = append(out, byte(ch))
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// SaleOutstanding sisa piutang penjualan kredit setelah cicilan dan retur
+func SaleOutstanding(sale models.Sales) int {
+	return sale.TotalSale - sale.PaidAmount - sale.ReturnedAmount
+}
+
+// GetMemberOutstanding menghitung total piutang member yang belum dibayar, excludeSaleID tidak ikut dihitung
+func GetMemberOutstanding(db *gorm.DB, memberID string, excludeSaleID string) (int, error) {
+	var outstanding int
+	err := db.Model(&models.Sales{}).
+		Select("COALESCE(SUM(GREATEST(total_sale - paid_amount - returned_amount, 0)), 0)").
+		Where("member_id = ? AND payment = ? AND id <> ?", memberID, models.PaidByCredit, excludeSaleID).
+		Scan(&outstanding).Error
+	return outstanding, err
+}
+
+// CheckMemberCredit memastikan penjualan kredit sebesar amount tidak melebihi batas piutang member.
+// Batas piutang member dipakai jika diisi, selain itu mengikuti kategori member.
+// excludeSaleID diisi saat mengecek ulang penjualan yang sudah tersimpan.
+func CheckMemberCredit(db *gorm.DB, memberID string, excludeSaleID string, amount int) error {
+	var member models.Member
+	if err := db.First(&member, "id = ?", memberID).Error; err != nil {
+		return err
+	}
+
+	creditLimit := member.CreditLimit
+	if creditLimit == 0 {
+		var category models.MemberCategory
+		if err := db.Select("credit_limit").First(&category, "id = ?", member.MemberCategoryId).Error; err != nil {
+			return err
+		}
+		creditLimit = category.CreditLimit
+	}
+
+	if creditLimit <= 0 {
+		return fmt.Errorf("member %s tidak memiliki batas kredit", member.Name)
+	}
+
+	outstanding, err := GetMemberOutstanding(db, memberID, excludeSaleID)
+	if err != nil {
+		return err
+	}
+
+	if outstanding+amount > creditLimit {
+		return fmt.Errorf("piutang member %s melebihi batas kredit. Batas: %d, Piutang: %d, Transaksi: %d", member.Name, creditLimit, outstanding, amount)
+	}
+
+	return nil
+}
+
+// SetSaleDueDate mengisi jatuh tempo penjualan paid_by_credit berdasarkan tempo kategori member.
+// Penjualan selain paid_by_credit tidak memiliki jatuh tempo, kecuali piutang yang sudah dilunasi.
+func SetSaleDueDate(db *gorm.DB, sale *models.Sales) error {
+	if sale.Payment != models.PaidByCredit {
+		if sale.PaidAmount == 0 {
+			sale.DueDate = nil
+		}
+		return nil
+	}
+
+	var category models.MemberCategory
+	err := db.Table("member_categories mc").
+		Select("mc.credit_terms").
+		Joins("JOIN members m ON m.member_category_id = mc.id").
+		Where("m.id = ?", sale.MemberId).
+		Take(&category).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	dueDate := sale.SaleDate.AddDate(0, 0, category.CreditTerms)
+	sale.DueDate = &dueDate
+	return nil
+}
@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/helpers"
+	"gorm.io/gorm"
+)
+
+// ResolveSalePayment menentukan metode pembayaran header penjualan dari daftar pembayaran.
+// Lebih dari satu metode dicatat sebagai paid_by_split, pembayaran split hanya untuk tunai dan bank.
+func ResolveSalePayment(payment models.PaymentStatus, payments []models.SalePayments) (models.PaymentStatus, error) {
+	if len(payments) == 0 {
+		return payment, nil
+	}
+
+	if payment == models.PaidByCredit || payment == models.PaidBySaldo {
+		return payment, fmt.Errorf("pembayaran %s tidak bisa digabung dengan rincian pembayaran", payment)
+	}
+
+	methods := map[models.PaymentStatus]bool{}
+	for _, p := range payments {
+		methods[p.Method] = true
+	}
+
+	if len(methods) > 1 {
+		return models.PaidBySplit, nil
+	}
+	return payments[0].Method, nil
+}
+
+// PrepareSalePayments melengkapi rincian pembayaran penjualan: memastikan jumlahnya sama dengan total
+// yang harus dibayar, menghitung kembalian tunai dan menambahkan baris penukaran poin.
+// Jika payments kosong dibuat satu baris sesuai metode pembayaran header.
+func PrepareSalePayments(sale models.Sales, payments []models.SalePayments) ([]models.SalePayments, error) {
+	due := sale.TotalSale - sale.PointsAmount
+
+	if len(payments) == 0 && due > 0 {
+		payments = []models.SalePayments{{Method: sale.Payment, Amount: due}}
+	}
+
+	paid := 0
+	for i := range payments {
+		if payments[i].Amount <= 0 {
+			return nil, fmt.Errorf("nominal pembayaran %s harus lebih dari 0", payments[i].Method)
+		}
+
+		payments[i].ChangeDue = 0
+		if payments[i].Method == models.PaidByCash && payments[i].Tendered > 0 {
+			if payments[i].Tendered < payments[i].Amount {
+				return nil, fmt.Errorf("uang diterima (%d) kurang dari nominal pembayaran tunai (%d)", payments[i].Tendered, payments[i].Amount)
+			}
+			payments[i].ChangeDue = payments[i].Tendered - payments[i].Amount
+		} else if payments[i].Method != models.PaidByCash {
+			payments[i].Tendered = 0
+		}
+
+		paid += payments[i].Amount
+	}
+
+	if paid != due {
+		return nil, fmt.Errorf("total pembayaran (%d) tidak sama dengan total yang harus dibayar (%d)", paid, due)
+	}
+
+	// Poin yang ditukar dicatat sebagai pembayaran saldo
+	if sale.PointsAmount > 0 {
+		payments = append(payments, models.SalePayments{
+			Method:          models.PaidBySaldo,
+			Amount:          sale.PointsAmount,
+			ReferenceNumber: fmt.Sprintf("%d poin", sale.PointsRedeemed),
+		})
+	}
+
+	for i := range payments {
+		payments[i].ID = helpers.GenerateID("SPY")
+		payments[i].SaleId = sale.ID
+		payments[i].BranchID = sale.BranchID
+		payments[i].CreatedAt = sale.CreatedAt
+	}
+
+	return payments, nil
+}
+
+// SyncSalePayments membuat ulang rincian pembayaran penjualan satu metode setelah total atau metode berubah.
+// Penjualan split tidak disentuh karena rinciannya berasal dari kasir.
+func SyncSalePayments(db *gorm.DB, sale models.Sales) error {
+	if sale.Payment == models.PaidBySplit {
+		return nil
+	}
+
+	payments, err := PrepareSalePayments(sale, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Where("sale_id = ?", sale.ID).Delete(&models.SalePayments{}).Error; err != nil {
+		return err
+	}
+
+	if len(payments) == 0 {
+		return nil
+	}
+	return db.Create(&payments).Error
+}
+
+// SaleTenderTotals menghitung total pembayaran penjualan cabang per metode dalam rentang waktu [start, end),
+// start kosong berarti seluruh periode
+func SaleTenderTotals(db *gorm.DB, branchID string, start time.Time, end time.Time) ([]models.SaleTenderSummary, error) {
+	query := db.Table("sale_payments spy").
+		Select("spy.method, COUNT(DISTINCT spy.sale_id) AS count, COALESCE(SUM(spy.amount), 0) AS total").
+		Joins("JOIN sales sl ON sl.id = spy.sale_id").
+		Where("sl.branch_id = ?", branchID)
+
+	if !start.IsZero() {
+		query = query.Where("sl.created_at >= ? AND sl.created_at < ?", start, end)
+	}
+
+	var totals []models.SaleTenderSummary
+	err := query.Group("spy.method").Order("spy.method").Scan(&totals).Error
+	return totals, err
+}
+
+// SeedSalePayments membuat rincian pembayaran untuk penjualan lama yang belum punya sale_payments
+func SeedSalePayments(db *gorm.DB) error {
+	var sales []models.Sales
+	return db.Where("total_sale > 0 AND NOT EXISTS (SELECT 1 FROM sale_payments spy WHERE spy.sale_id = sales.id)").
+		FindInBatches(&sales, 500, func(tx *gorm.DB, batch int) error {
+			for _, sale := range sales {
+				if err := SyncSalePayments(db, sale); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// GetOpenShift mengambil shift kasir yang masih terbuka, gorm.ErrRecordNotFound jika tidak ada
+func GetOpenShift(db *gorm.DB, userID string, branchID string) (models.CashierShifts, error) {
+	var shift models.CashierShifts
+	err := db.Where("user_id = ? AND branch_id = ? AND status = ?", userID, branchID, models.ShiftOpen).
+		Order("opened_at DESC").
+		First(&shift).Error
+	return shift, err
+}
+
+// shiftEnd batas akhir perhitungan shift, shift terbuka dihitung sampai until
+func shiftEnd(shift models.CashierShifts, until time.Time) time.Time {
+	if shift.ClosedAt != nil {
+		return *shift.ClosedAt
+	}
+	return until
+}
+
+// ShiftCashFlows menghitung transaksi tunai kasir selama shift per tipe transaksi,
+// beserta total kas masuk dan kas keluar.
+// Penjualan dihitung dari rincian pembayaran tunai agar penjualan split hanya menghitung porsi tunainya.
+func ShiftCashFlows(db *gorm.DB, shift models.CashierShifts, until time.Time) ([]models.ShiftCashFlow, int, int, error) {
+	end := shiftEnd(shift, until)
+
+	var saleFlow models.ShiftCashFlow
+	err := db.Table("sale_payments spy").
+		Select("COUNT(DISTINCT spy.sale_id) AS count, COALESCE(SUM(spy.amount), 0) AS total").
+		Joins("JOIN sales sl ON sl.id = spy.sale_id").
+		Where("sl.user_id = ? AND sl.branch_id = ? AND spy.method = ?", shift.UserID, shift.BranchID, models.PaidByCash).
+		Where("sl.created_at >= ? AND sl.created_at <= ?", shift.OpenedAt, end).
+		Scan(&saleFlow).Error
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var flows []models.ShiftCashFlow
+	if saleFlow.Count > 0 {
+		saleFlow.TransactionType = string(models.Sale)
+		flows = append(flows, saleFlow)
+	}
+
+	var otherFlows []models.ShiftCashFlow
+	err = db.Model(&models.TransactionReports{}).
+		Select("transaction_type, COUNT(*) AS count, COALESCE(SUM(total), 0) AS total").
+		Where("user_id = ? AND branch_id = ? AND payment = ? AND transaction_type <> ?", shift.UserID, shift.BranchID, models.PaidByCash, models.Sale).
+		Where("created_at >= ? AND created_at <= ?", shift.OpenedAt, end).
+		Group("transaction_type").
+		Order("transaction_type").
+		Scan(&otherFlows).Error
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	flows = append(flows, otherFlows...)
+
+	var cashIn, cashOut int
+	for i := range flows {
+		switch models.TransactionType(flows[i].TransactionType) {
+		case models.Sale, models.Income, models.BuyReturn, models.ReceivablePayment:
+			flows[i].Direction = "in"
+			cashIn += flows[i].Total
+		case models.Purchase, models.Expense, models.SaleReturn, models.PayablePayment:
+			flows[i].Direction = "out"
+			cashOut += flows[i].Total
+		}
+	}
+
+	return flows, cashIn, cashOut, nil
+}
+
+// ShiftExpectedCash menghitung kas yang seharusnya ada di laci: modal awal + kas masuk - kas keluar
+func ShiftExpectedCash(db *gorm.DB, shift models.CashierShifts, until time.Time) (int, error) {
+	_, cashIn, cashOut, err := ShiftCashFlows(db, shift, until)
+	if err != nil {
+		return 0, err
+	}
+	return shift.OpeningCash + cashIn - cashOut, nil
+}
+
+// ShiftSalesByPayment menghitung total penjualan kasir selama shift per metode pembayaran
+func ShiftSalesByPayment(db *gorm.DB, shift models.CashierShifts, until time.Time) ([]models.ShiftPaymentTotal, error) {
+	var totals []models.ShiftPaymentTotal
+	err := db.Model(&models.Sales{}).
+		Select("payment, COUNT(*) AS count, COALESCE(SUM(total_sale), 0) AS total").
+		Where("user_id = ? AND branch_id = ?", shift.UserID, shift.BranchID).
+		Where("created_at >= ? AND created_at <= ?", shift.OpenedAt, shiftEnd(shift, until)).
+		Group("payment").
+		Order("payment").
+		Scan(&totals).Error
+	return totals, err
+}
@@ -1,115 +1,143 @@
 package tools
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/heru-oktafian/api-retail/models"
 	"github.com/heru-oktafian/api-retail/reports"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// Tambah stock product
-func AddProductStock(db *gorm.DB, productID string, qty int) error {
-	var product models.Product
-	if err := db.First(&product, "id = ?", productID).Error; err != nil {
-		return err
-	}
-	product.Stock += qty
-	return db.Save(&product).Error
+// InsufficientStockError stok produk tidak cukup pada cabang yang tidak mengizinkan stok minus
+type InsufficientStockError struct {
+	ProductID   string
+	ProductName string
+	Available   int
+	Requested   int
 }
 
-// Kurangi stock product
-func ReduceProductStock(db *gorm.DB, productID string, qty int) error {
-	var product models.Product
-	if err := db.First(&product, "id = ?", productID).Error; err != nil {
-		return err
-	}
-
-	if product.Stock < qty {
-		return errors.New("insufficient stock")
-	}
-	product.Stock -= qty
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("Insufficient stock for product %s. Available: %d, Requested: %d", e.ProductName, e.Available, e.Requested)
+}
 
-	if err := db.Save(&product).Error; err != nil {
-		return err
-	}
+// GetStockPolicy mengambil kebijakan stok minus cabang
+func GetStockPolicy(db *gorm.DB, branchID string) (models.StockPolicy, error) {
+	var branch models.Branch
+	err := db.Select("id, stock_policy").First(&branch, "id = ?", branchID).Error
+	return branch.StockPolicy, err
+}
 
-	return nil
+// ChangeProductStock satu-satunya jalur perubahan stok relatif (delta positif menambah, negatif mengurangi).
+// Stok diubah dengan satu UPDATE atomik sehingga transaksi yang berjalan bersamaan tidak saling menimpa;
+// pengurangan hanya berhasil jika stok cukup, kecuali cabang produk mengizinkan backorder.
+// Mengembalikan stok sebelum dan sesudah perubahan, mutasi dicatat ke stock_tracks.
+func ChangeProductStock(db *gorm.DB, productID string, delta int, ref StockRef) (int, int, error) {
+	var qtyBefore, qtyAfter int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var stocks []int
+		err := tx.Raw(`
+			UPDATE products SET stock = stock + ?
+			WHERE id = ? AND (stock + ? >= 0 OR EXISTS (
+				SELECT 1 FROM branches brc WHERE brc.id = products.branch_id AND brc.stock_policy = ?
+			))
+			RETURNING stock`, delta, productID, delta, models.StockAllowBackorder).
+			Scan(&stocks).Error
+		if err != nil {
+			return err
+		}
+
+		if len(stocks) == 0 {
+			// Produk tidak ada atau stok tidak cukup
+			var product models.Product
+			if err := tx.Select("id, name, stock").First(&product, "id = ?", productID).Error; err != nil {
+				return err
+			}
+			return &InsufficientStockError{ProductID: product.ID, ProductName: product.Name, Available: product.Stock, Requested: -delta}
+		}
+
+		qtyAfter = stocks[0]
+		qtyBefore = qtyAfter - delta
+		return RecordStockTrack(tx, ref, productID, qtyBefore, qtyAfter)
+	})
+	return qtyBefore, qtyAfter, err
 }
 
-// SubtractProductStock menambah stok produk
-func SubtractProductStock(db *gorm.DB, productID string, qty int) error {
-	var product models.Product
-	if err := db.First(&product, "id = ?", productID).Error; err != nil {
-		return err
-	}
+// SetProductStock mengubah stok menjadi nilai tertentu (opname, kosongkan stok).
+// Baris produk dikunci (SELECT ... FOR UPDATE) agar stok sebelum perubahan yang dicatat akurat.
+func SetProductStock(db *gorm.DB, productID string, qty int, ref StockRef) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, stock").First(&product, "id = ?", productID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Product{}).Where("id = ?", productID).Update("stock", qty).Error; err != nil {
+			return err
+		}
+		return RecordStockTrack(tx, ref, productID, product.Stock, qty)
+	})
+}
 
-	product.Stock += qty
+// Tambah stock product
+func AddProductStock(db *gorm.DB, productID string, qty int, ref StockRef) error {
+	_, _, err := ChangeProductStock(db, productID, qty, ref)
+	return err
+}
 
-	if err := db.Save(&product).Error; err != nil {
-		return err
-	}
+// Kurangi stock product
+func ReduceProductStock(db *gorm.DB, productID string, qty int, ref StockRef) error {
+	_, _, err := ChangeProductStock(db, productID, -qty, ref)
+	return err
+}
 
-	return nil
+// SubtractProductStock menambah stok produk
+func SubtractProductStock(db *gorm.DB, productID string, qty int, ref StockRef) error {
+	_, _, err := ChangeProductStock(db, productID, qty, ref)
+	return err
 }
 
 // ZeroProductStock kosongkan stok produk
-func ZeroProductStock(db *gorm.DB, productID string, qty int) error {
-	var product models.Product
-	if err := db.First(&product, "id = ?", productID).Error; err != nil {
-		return err
-	}
-
-	product.Stock = 0
+func ZeroProductStock(db *gorm.DB, productID string, qty int, ref StockRef) error {
+	return SetProductStock(db, productID, 0, ref)
+}
 
-	if err := db.Save(&product).Error; err != nil {
-		return err
+// RecalculateTotalPurchase menghitung ulang total pembelian berdasarkan item
+func RecalculateTotalPurchase(db *gorm.DB, purchaseID string) error {
+	var totals struct {
+		SubTotal int
+		Tax      int
 	}
 
-	return nil
-}
-
-// UpdateProductPriceIfHigher memperbarui harga produk jika harga baru lebih tinggi
-func UpdateProductPriceIfHigher(db *gorm.DB, productId string, newPrice int) error {
-	var product models.Product
+	// Hitung total sub_total dan PPN dari purchase_items
+	err := db.Model(&models.PurchaseItems{}).
+		Where("purchase_id = ?", purchaseID).
+		Select("COALESCE(SUM(sub_total), 0) AS sub_total, COALESCE(SUM(tax_amount), 0) AS tax").
+		Scan(&totals).Error
 
-	if err := db.First(&product, "id = ?", productId).Error; err != nil {
+	if err != nil {
 		return err
 	}
 
-	if newPrice > product.PurchasePrice {
-		product.PurchasePrice = newPrice
-		return db.Save(&product).Error
+	// Ambil purchase lengkap buat update report
+	var purchase models.Purchases
+	if err := db.First(&purchase, "id = ?", purchaseID).Error; err != nil {
+		return err
 	}
 
-	// Tidak update jika harga baru lebih rendah
-	return nil
-}
-
-// RecalculateTotalPurchase menghitung ulang total pembelian berdasarkan item
-func RecalculateTotalPurchase(db *gorm.DB, purchaseID string) error {
-	var total int64
-
-	// Hitung total sub_total dari purchase_items
-	err := db.Model(&models.PurchaseItems{}).
-		Where("purchase_id = ?", purchaseID).
-		Select("COALESCE(SUM(sub_total), 0)").
-		Scan(&total).Error
-
+	branch, err := GetBranchTax(db, purchase.BranchID)
 	if err != nil {
 		return err
 	}
+	purchase.TaxAmount, purchase.TotalPurchase = branch.TaxTotals(totals.SubTotal, totals.Tax, 0)
 
 	// Update ke purchases
 	if err := db.Model(&models.Purchases{}).
 		Where("id = ?", purchaseID).
-		Update("total_purchase", total).Error; err != nil {
-		return err
-	}
-
-	// Ambil purchase lengkap buat update report
-	var purchase models.Purchases
-	if err := db.First(&purchase, "id = ?", purchaseID).Error; err != nil {
+		Updates(map[string]interface{}{
+			"total_purchase": purchase.TotalPurchase,
+			"tax_amount":     purchase.TaxAmount,
+		}).Error; err != nil {
 		return err
 	}
 
@@ -118,5 +146,6 @@ func RecalculateTotalPurchase(db *gorm.DB, purchaseID string) error {
 		return err
 	}
 
-	return nil
+	// Jurnal pembelian
+	return SyncJournal(db, models.JournalPurchase, purchase.ID)
 }
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Test konkurensi stok butuh Postgres sungguhan, set TEST_DATABASE_URL untuk menjalankannya, contoh:
+// TEST_DATABASE_URL="host=localhost user=postgres password=postgres dbname=retail_test sslmode=disable" go test ./tools -run Stock
+// Tabel dibuat di schema sementara yang dihapus setelah test selesai.
+func openStockTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL tidak di-set, test konkurensi stok dilewati")
+	}
+
+	schema := fmt.Sprintf("stock_test_%d", time.Now().UnixNano())
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gagal koneksi ke database test: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("gagal membuat schema test: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	// Semua koneksi pool memakai schema test
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gagal koneksi ke schema test: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("gagal mengambil koneksi database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(20)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	statements := []string{
+		`CREATE TYPE movement_type AS ENUM ('purchase', 'purchase_return', 'sale', 'sale_return', 'opname', 'first_stock', 'transfer_out', 'transfer_in')`,
+		`CREATE TABLE branches (id varchar(15) PRIMARY KEY, stock_policy varchar(20) NOT NULL)`,
+		`CREATE TABLE products (id varchar(15) PRIMARY KEY, name varchar(100) NOT NULL, stock int NOT NULL DEFAULT 0, branch_id varchar(15) NOT NULL)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("gagal menyiapkan tabel test: %v", err)
+		}
+	}
+	if err := db.AutoMigrate(&models.StockTracks{}); err != nil {
+		t.Fatalf("gagal migrasi stock_tracks: %v", err)
+	}
+
+	return db
+}
+
+// seedStockProduct membuat satu cabang dengan kebijakan stok tertentu dan satu produk
+func seedStockProduct(t *testing.T, db *gorm.DB, policy models.StockPolicy, stock int) string {
+	t.Helper()
+
+	if err := db.Exec("INSERT INTO branches (id, stock_policy) VALUES (?, ?)", "BRC-TEST", policy).Error; err != nil {
+		t.Fatalf("gagal membuat cabang: %v", err)
+	}
+	if err := db.Exec("INSERT INTO products (id, name, stock, branch_id) VALUES (?, ?, ?, ?)", "PRD-TEST", "Produk Test", stock, "BRC-TEST").Error; err != nil {
+		t.Fatalf("gagal membuat produk: %v", err)
+	}
+	return "PRD-TEST"
+}
+
+// runStockChanges menjalankan setiap delta di goroutine sendiri secara bersamaan,
+// mengembalikan jumlah perubahan yang berhasil dan yang ditolak karena stok kurang per arah
+func runStockChanges(t *testing.T, db *gorm.DB, productID string, deltas []int) (addedOK int, reducedOK int, rejected int) {
+	t.Helper()
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		start = make(chan struct{})
+		other []error
+	)
+	for i, delta := range deltas {
+		wg.Add(1)
+		go func(i int, delta int) {
+			defer wg.Done()
+			<-start
+
+			ref := StockRef{MovementType: models.SaleTrans, ReferenceID: fmt.Sprintf("REF%d", i), UserID: "USR-TEST", BranchID: "BRC-TEST"}
+			_, _, err := ChangeProductStock(db, productID, delta, ref)
+
+			mu.Lock()
+			defer mu.Unlock()
+			var insufficient *InsufficientStockError
+			switch {
+			case err == nil && delta > 0:
+				addedOK++
+			case err == nil:
+				reducedOK++
+			case errors.As(err, &insufficient):
+				rejected++
+			default:
+				other = append(other, err)
+			}
+		}(i, delta)
+	}
+	close(start)
+	wg.Wait()
+
+	if len(other) > 0 {
+		t.Fatalf("%d perubahan stok gagal dengan error tak terduga, contoh: %v", len(other), other[0])
+	}
+	return addedOK, reducedOK, rejected
+}
+
+// assertStockTracks memastikan setiap mutasi tercatat, stok tidak pernah minus dan total mutasi sama dengan selisih stok
+func assertStockTracks(t *testing.T, db *gorm.DB, productID string, initial int, final int, changes int, allowNegative bool) {
+	t.Helper()
+
+	var tracks []models.StockTracks
+	if err := db.Where("product_id = ?", productID).Find(&tracks).Error; err != nil {
+		t.Fatalf("gagal mengambil stock_tracks: %v", err)
+	}
+	if len(tracks) != changes {
+		t.Fatalf("jumlah stock_tracks = %d, seharusnya %d", len(tracks), changes)
+	}
+
+	sum := initial
+	for _, track := range tracks {
+		if !allowNegative && track.QtyAfter < 0 {
+			t.Fatalf("stok sempat minus: %d (ref %s)", track.QtyAfter, track.ReferenceID)
+		}
+		if track.QtyAfter-track.QtyBefore != track.Stock {
+			t.Fatalf("stock_tracks %s tidak konsisten: before %d, stock %d, after %d", track.ReferenceID, track.QtyBefore, track.Stock, track.QtyAfter)
+		}
+		sum += track.Stock
+	}
+	if sum != final {
+		t.Fatalf("stok awal %d + total mutasi tercatat = %d, stok akhir %d", initial, sum, final)
+	}
+}
+
+func productStock(t *testing.T, db *gorm.DB, productID string) int {
+	t.Helper()
+
+	var stock int
+	if err := db.Table("products").Select("stock").Where("id = ?", productID).Scan(&stock).Error; err != nil {
+		t.Fatalf("gagal membaca stok: %v", err)
+	}
+	return stock
+}
+
+func repeatDelta(delta int, n int) []int {
+	deltas := make([]int, n)
+	for i := range deltas {
+		deltas[i] = delta
+	}
+	return deltas
+}
+
+// Banyak kasir menjual produk yang sama: yang berhasil tepat sebanyak stok, sisanya ditolak
+func TestChangeProductStockConcurrentSales(t *testing.T) {
+	db := openStockTestDB(t)
+	productID := seedStockProduct(t, db, models.StockForbidNegative, 50)
+
+	_, reducedOK, rejected := runStockChanges(t, db, productID, repeatDelta(-1, 100))
+
+	if reducedOK != 50 || rejected != 50 {
+		t.Fatalf("penjualan berhasil %d dan ditolak %d, seharusnya 50 dan 50", reducedOK, rejected)
+	}
+	if stock := productStock(t, db, productID); stock != 0 {
+		t.Fatalf("stok akhir = %d, seharusnya 0", stock)
+	}
+	assertStockTracks(t, db, productID, 50, 0, reducedOK, false)
+}
+
+// Penjualan dan penerimaan barang bersamaan: stok akhir = awal + masuk - keluar dan tidak pernah minus
+func TestChangeProductStockConcurrentMixed(t *testing.T) {
+	db := openStockTestDB(t)
+	productID := seedStockProduct(t, db, models.StockForbidNegative, 10)
+
+	deltas := append(repeatDelta(-2, 60), repeatDelta(3, 20)...)
+	addedOK, reducedOK, rejected := runStockChanges(t, db, productID, deltas)
+
+	if addedOK != 20 {
+		t.Fatalf("penambahan stok berhasil %d, seharusnya 20", addedOK)
+	}
+	if reducedOK+rejected != 60 {
+		t.Fatalf("penjualan berhasil %d + ditolak %d, seharusnya total 60", reducedOK, rejected)
+	}
+
+	expected := 10 + 3*addedOK - 2*reducedOK
+	stock := productStock(t, db, productID)
+	if stock != expected {
+		t.Fatalf("stok akhir = %d, seharusnya %d", stock, expected)
+	}
+	if stock < 0 {
+		t.Fatalf("stok akhir minus: %d", stock)
+	}
+	assertStockTracks(t, db, productID, 10, stock, addedOK+reducedOK, false)
+}
+
+// Cabang backorder boleh minus, semua penjualan berhasil dan tidak ada yang hilang
+func TestChangeProductStockConcurrentBackorder(t *testing.T) {
+	db := openStockTestDB(t)
+	productID := seedStockProduct(t, db, models.StockAllowBackorder, 5)
+
+	_, reducedOK, rejected := runStockChanges(t, db, productID, repeatDelta(-1, 40))
+
+	if reducedOK != 40 || rejected != 0 {
+		t.Fatalf("penjualan berhasil %d dan ditolak %d, seharusnya 40 dan 0", reducedOK, rejected)
+	}
+	if stock := productStock(t, db, productID); stock != -35 {
+		t.Fatalf("stok akhir = %d, seharusnya -35", stock)
+	}
+	assertStockTracks(t, db, productID, 5, -35, reducedOK, true)
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"github.com/heru-oktafian/api-retail/models"
+	"github.com/heru-oktafian/scafold/framework"
+	"github.com/heru-oktafian/scafold/helpers"
+	"github.com/heru-oktafian/scafold/middlewares"
+	"gorm.io/gorm"
+)
+
+// StockRef menyimpan sumber dokumen dari sebuah mutasi stok
+type StockRef struct {
+	MovementType models.MovementType
+	ReferenceID  string
+	UserID       string
+	BranchID     string
+}
+
+// NewStockRef membuat StockRef dengan user dan cabang diambil dari token request
+func NewStockRef(c *framework.Ctx, movementType models.MovementType, referenceID string) StockRef {
+	userID, _ := middlewares.GetUserID(c.Request)
+	branchID, _ := middlewares.GetBranchID(c.Request)
+
+	return StockRef{
+		MovementType: movementType,
+		ReferenceID:  referenceID,
+		UserID:       userID,
+		BranchID:     branchID,
+	}
+}
+
+// RecordStockTrack mencatat satu baris mutasi stok ke stock_tracks.
+// Harus dipanggil dengan db / tx yang sama dengan update stok agar ikut di-rollback.
+func RecordStockTrack(db *gorm.DB, ref StockRef, productID string, qtyBefore int, qtyAfter int) error {
+	// Tidak ada perubahan, tidak perlu dicatat
+	if qtyBefore == qtyAfter {
+		return nil
+	}
+
+	track := models.StockTracks{
+		ID:           helpers.GenerateID("STK"),
+		MovementType: ref.MovementType,
+		ReferenceID:  ref.ReferenceID,
+		ProductID:    productID,
+		QtyBefore:    qtyBefore,
+		Stock:        qtyAfter - qtyBefore,
+		QtyAfter:     qtyAfter,
+		UserID:       ref.UserID,
+		BranchID:     ref.BranchID,
+	}
+
+	return db.Create(&track).Error
+}
@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// GetBranchTax mengambil pengaturan PPN cabang (persentase dan mode inclusive / exclusive)
+func GetBranchTax(db *gorm.DB, branchID string) (models.Branch, error) {
+	var branch models.Branch
+	err := db.Select("id, tax_percentage, tax_mode").First(&branch, "id = ?", branchID).Error
+	return branch, err
+}
+
+// ProductLineTax menghitung PPN satu baris item sesuai pengaturan cabang dan status bebas pajak produk
+func ProductLineTax(db *gorm.DB, branchID string, productID string, subTotal int) (int, error) {
+	branch, err := GetBranchTax(db, branchID)
+	if err != nil {
+		return 0, err
+	}
+
+	var product models.Product
+	if err := db.Select("id, tax_exempt").First(&product, "id = ?", productID).Error; err != nil {
+		return 0, err
+	}
+
+	return branch.LineTax(subTotal, product.TaxExempt), nil
+}
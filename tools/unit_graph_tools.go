@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnitNoConversion tidak ada jalur konversi antara dua satuan produk
+	ErrUnitNoConversion = errors.New("tidak ada konversi satuan untuk produk ini")
+	// ErrUnitFraction satuan lebih kecil dari satuan dasar sehingga jumlahnya tidak bulat dalam satuan dasar
+	ErrUnitFraction = errors.New("satuan lebih kecil dari satuan dasar produk")
+	// ErrUnitConvCycle konversi baru membentuk siklus (misal box -> strip -> box)
+	ErrUnitConvCycle = errors.New("konversi satuan membentuk siklus")
+	// ErrUnitConvInconsistent nilai konversi baru berbeda dengan nilai dari jalur konversi yang sudah ada
+	ErrUnitConvInconsistent = errors.New("nilai konversi satuan tidak konsisten")
+)
+
+// UnitGraph graf konversi satuan satu produk. Tiap baris unit_conversions adalah sisi init_id -> final_id
+// dengan arti 1 init_id = value_conv final_id, sehingga faktor bisa dihitung lewat jalur apa pun,
+// misal box -> strip -> tablet. Jika ada dua jalur dengan nilai berbeda, jalur yang ditemukan lebih dulu dipakai.
+type UnitGraph struct {
+	ProductId   string
+	BaseUnitId  string
+	Conversions []models.UnitConversion // Baris konversi yang membentuk graf, urut berdasarkan id
+	edges       map[string][]unitEdge
+	factors     map[string]*big.Rat // Jumlah satuan dasar dalam 1 satuan, hanya satuan yang terhubung ke satuan dasar
+}
+
+// unitEdge sisi graf, 1 satuan asal = ratio satuan tujuan
+type unitEdge struct {
+	to      string
+	ratio   *big.Rat
+	forward bool // true jika searah baris konversi (init_id -> final_id)
+}
+
+// NewUnitGraph membangun graf dari baris konversi produk, baris dengan value_conv <= 0 atau init_id = final_id diabaikan
+func NewUnitGraph(productID string, baseUnitID string, conversions []models.UnitConversion) *UnitGraph {
+	g := &UnitGraph{
+		ProductId:  productID,
+		BaseUnitId: baseUnitID,
+		edges:      make(map[string][]unitEdge),
+	}
+	for _, conv := range conversions {
+		if conv.ValueConv <= 0 || conv.InitId == conv.FinalId {
+			continue
+		}
+		g.Conversions = append(g.Conversions, conv)
+		value := big.NewRat(int64(conv.ValueConv), 1)
+		g.edges[conv.InitId] = append(g.edges[conv.InitId], unitEdge{to: conv.FinalId, ratio: value, forward: true})
+		g.edges[conv.FinalId] = append(g.edges[conv.FinalId], unitEdge{to: conv.InitId, ratio: new(big.Rat).Inv(value)})
+	}
+	g.factors = g.relative(baseUnitID)
+	return g
+}
+
+// relative menelusuri graf dari satuan target, hasilnya jumlah satuan target dalam 1 satuan untuk tiap satuan yang terhubung
+func (g *UnitGraph) relative(target string) map[string]*big.Rat {
+	rel := map[string]*big.Rat{target: big.NewRat(1, 1)}
+	queue := []string{target}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.edges[cur] {
+			if _, seen := rel[edge.to]; seen {
+				continue
+			}
+			// 1 cur = ratio to, maka 1 to = rel[cur] / ratio target
+			rel[edge.to] = new(big.Rat).Quo(rel[cur], edge.ratio)
+			queue = append(queue, edge.to)
+		}
+	}
+	return rel
+}
+
+// Factor jumlah satuan dasar dalam 1 unitID, false jika unitID tidak terhubung ke satuan dasar
+func (g *UnitGraph) Factor(unitID string) (*big.Rat, bool) {
+	if unitID == "" {
+		unitID = g.BaseUnitId
+	}
+	factor, ok := g.factors[unitID]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).Set(factor), true
+}
+
+// Units semua satuan yang terhubung ke satuan dasar, urut dari faktor terkecil
+func (g *UnitGraph) Units() []string {
+	units := make([]string, 0, len(g.factors))
+	for unitID := range g.factors {
+		units = append(units, unitID)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if cmp := g.factors[units[i]].Cmp(g.factors[units[j]]); cmp != 0 {
+			return cmp < 0
+		}
+		return units[i] < units[j]
+	})
+	return units
+}
+
+// ConvValue jumlah satuan dasar (bulat) dalam 1 unitID, dipakai untuk mengubah qty ke stok satuan dasar
+func (g *UnitGraph) ConvValue(unitID string) (int, error) {
+	factor, ok := g.Factor(unitID)
+	if !ok {
+		return 0, fmt.Errorf("%w: satuan %s, produk %s", ErrUnitNoConversion, unitID, g.ProductId)
+	}
+	if !factor.IsInt() {
+		return 0, fmt.Errorf("%w: satuan %s, produk %s", ErrUnitFraction, unitID, g.ProductId)
+	}
+	return int(factor.Num().Int64()), nil
+}
+
+// Ratio jumlah satuan toID dalam 1 satuan fromID, lewat jalur mana pun (tidak harus melalui satuan dasar)
+func (g *UnitGraph) Ratio(fromID string, toID string) (*big.Rat, error) {
+	ratio, ok := g.relative(toID)[fromID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s ke %s, produk %s", ErrUnitNoConversion, fromID, toID, g.ProductId)
+	}
+	return ratio, nil
+}
+
+// CheckConversion memastikan konversi baru 1 initID = value finalID tidak membentuk siklus
+// dan sesuai dengan nilai dari jalur konversi yang sudah ada antara kedua satuan
+func (g *UnitGraph) CheckConversion(initID string, finalID string, value int) error {
+	if initID == finalID {
+		return errors.New("satuan awal dan akhir konversi tidak boleh sama")
+	}
+	if value < 1 {
+		return errors.New("nilai konversi minimal 1")
+	}
+	if g.reaches(finalID, initID) {
+		return fmt.Errorf("%w: %s sudah dikonversi (langsung atau bertingkat) menjadi %s", ErrUnitConvCycle, finalID, initID)
+	}
+	existing, err := g.Ratio(initID, finalID)
+	if err != nil {
+		// Belum ada jalur antara kedua satuan, konversi baru selalu konsisten
+		return nil
+	}
+	if existing.Cmp(big.NewRat(int64(value), 1)) != 0 {
+		return fmt.Errorf("%w: jalur konversi yang ada memberi 1 %s = %s %s, bukan %d",
+			ErrUnitConvInconsistent, initID, existing.RatString(), finalID, value)
+	}
+	return nil
+}
+
+// reaches true jika ada jalur searah baris konversi dari fromID ke toID
+func (g *UnitGraph) reaches(fromID string, toID string) bool {
+	seen := map[string]bool{fromID: true}
+	stack := []string{fromID}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if cur == toID {
+			return true
+		}
+		for _, edge := range g.edges[cur] {
+			if edge.forward && !seen[edge.to] {
+				seen[edge.to] = true
+				stack = append(stack, edge.to)
+			}
+		}
+	}
+	return false
+}
+
+// ConvertPrice mengalikan harga dengan faktor konversi, dibulatkan ke rupiah terdekat
+func ConvertPrice(price int, factor *big.Rat) int {
+	value, _ := new(big.Rat).Mul(big.NewRat(int64(price), 1), factor).Float64()
+	return int(math.Round(value))
+}
+
+// LoadUnitGraphs memuat graf konversi satuan produk cabang, dikelompokkan per product_id.
+// productIDs kosong berarti semua produk cabang.
+func LoadUnitGraphs(db *gorm.DB, branchID string, productIDs []string) (map[string]*UnitGraph, error) {
+	var products []models.Product
+	productQuery := db.Select("id, unit_id").Where("branch_id = ?", branchID)
+	if len(productIDs) > 0 {
+		productQuery = productQuery.Where("id IN ?", productIDs)
+	}
+	if err := productQuery.Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	var conversions []models.UnitConversion
+	conversionQuery := db.Where("branch_id = ?", branchID).Order("id ASC")
+	if len(productIDs) > 0 {
+		conversionQuery = conversionQuery.Where("product_id IN ?", productIDs)
+	}
+	if err := conversionQuery.Find(&conversions).Error; err != nil {
+		return nil, err
+	}
+
+	byProduct := make(map[string][]models.UnitConversion)
+	for _, conv := range conversions {
+		byProduct[conv.ProductId] = append(byProduct[conv.ProductId], conv)
+	}
+
+	graphs := make(map[string]*UnitGraph, len(products))
+	for _, product := range products {
+		graphs[product.ID] = NewUnitGraph(product.ID, product.UnitId, byProduct[product.ID])
+	}
+	return graphs, nil
+}
+
+// LoadUnitGraph memuat graf konversi satuan satu produk
+func LoadUnitGraph(db *gorm.DB, branchID string, product models.Product) (*UnitGraph, error) {
+	return LoadUnitGraphWithout(db, branchID, product, "")
+}
+
+// LoadUnitGraphWithout memuat graf konversi satuan satu produk tanpa baris konversi excludeID,
+// dipakai untuk memvalidasi nilai baru baris yang sedang diubah
+func LoadUnitGraphWithout(db *gorm.DB, branchID string, product models.Product, excludeID string) (*UnitGraph, error) {
+	var conversions []models.UnitConversion
+	query := db.Where("product_id = ? AND branch_id = ?", product.ID, branchID)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Order("id ASC").Find(&conversions).Error; err != nil {
+		return nil, err
+	}
+	return NewUnitGraph(product.ID, product.UnitId, conversions), nil
+}
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/heru-oktafian/api-retail/models"
+	"gorm.io/gorm"
+)
+
+// GetConversionValue mengambil nilai konversi dari unitID ke satuan dasar produk lewat graf konversi,
+// termasuk konversi bertingkat (misal box -> strip -> tablet).
+// Jika unit sama dengan satuan dasar atau konversi tidak ditemukan, nilai konversi adalah 1.
+// ErrUnitFraction jika unitID lebih kecil dari satuan dasar.
+func GetConversionValue(db *gorm.DB, branchID string, product models.Product, unitID string) (int, error) {
+	if unitID == "" || unitID == product.UnitId {
+		return 1, nil
+	}
+
+	graph, err := LoadUnitGraph(db, branchID, product)
+	if err != nil {
+		return 0, err
+	}
+	value, err := graph.ConvValue(unitID)
+	if errors.Is(err, ErrUnitNoConversion) {
+		return 1, nil
+	}
+	return value, err
+}
+
+// ErrUnitNotSellable satuan tidak punya konversi ke satuan dasar produk sehingga tidak bisa dijual
+var ErrUnitNotSellable = errors.New("satuan tidak bisa dijual untuk produk ini")
+
+// GetSaleUnits mengambil semua satuan jual produk cabang, dikelompokkan per product_id.
+// Satuan dasar selalu di urutan pertama (ConvValue 1), diikuti satuan lain yang terhubung ke satuan dasar
+// lewat graf konversi dengan nilai konversi bulat, urut dari yang terkecil.
+// Harga satuan konversi memakai sales_price baris konversi dengan init_id satuan tersebut,
+// atau harga jual produk x nilai konversi jika kosong.
+// productIDs kosong berarti semua produk cabang.
+func GetSaleUnits(db *gorm.DB, branchID string, productIDs []string) (map[string][]models.ProdSaleUnit, error) {
+	var products []struct {
+		ID         string
+		UnitId     string
+		SalesPrice int
+		Stock      int
+	}
+	productQuery := db.Table("products").
+		Select("id, unit_id, sales_price, stock").
+		Where("branch_id = ?", branchID)
+	if len(productIDs) > 0 {
+		productQuery = productQuery.Where("id IN ?", productIDs)
+	}
+	if err := productQuery.Scan(&products).Error; err != nil {
+		return nil, err
+	}
+
+	graphs, err := LoadUnitGraphs(db, branchID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	unitIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		unitIDs = append(unitIDs, product.UnitId)
+		if graph, ok := graphs[product.ID]; ok {
+			unitIDs = append(unitIDs, graph.Units()...)
+		}
+	}
+	unitNames := make(map[string]string)
+	if len(unitIDs) > 0 {
+		var unitRows []models.Unit
+		if err := db.Select("id, name").Where("id IN ?", unitIDs).Find(&unitRows).Error; err != nil {
+			return nil, err
+		}
+		for _, unit := range unitRows {
+			unitNames[unit.ID] = unit.Name
+		}
+	}
+
+	units := make(map[string][]models.ProdSaleUnit, len(products))
+	for _, product := range products {
+		units[product.ID] = []models.ProdSaleUnit{{
+			ProductId: product.ID,
+			UnitId:    product.UnitId,
+			UnitName:  unitNames[product.UnitId],
+			ConvValue: 1,
+			Price:     product.SalesPrice,
+			Stock:     product.Stock,
+		}}
+
+		graph, ok := graphs[product.ID]
+		if !ok {
+			continue
+		}
+		salesPrices := make(map[string]int)
+		for _, conv := range graph.Conversions {
+			if _, set := salesPrices[conv.InitId]; !set && conv.SalesPrice > 0 {
+				salesPrices[conv.InitId] = conv.SalesPrice
+			}
+		}
+		for _, unitID := range graph.Units() {
+			if unitID == product.UnitId {
+				continue
+			}
+			convValue, err := graph.ConvValue(unitID)
+			if err != nil {
+				// Satuan lebih kecil dari satuan dasar tidak bisa dijual karena stok tercatat bulat
+				continue
+			}
+			price := salesPrices[unitID]
+			if price <= 0 {
+				price = product.SalesPrice * convValue
+			}
+			units[product.ID] = append(units[product.ID], models.ProdSaleUnit{
+				ProductId: product.ID,
+				UnitId:    unitID,
+				UnitName:  unitNames[unitID],
+				ConvValue: convValue,
+				Price:     price,
+				Stock:     product.Stock / convValue,
+			})
+		}
+	}
+	return units, nil
+}
+
+// GetSaleUnit mengambil satu satuan jual produk, unitID kosong berarti satuan dasar.
+// gorm.ErrRecordNotFound jika produk tidak ada di cabang, ErrUnitNotSellable jika satuan tidak punya konversi.
+func GetSaleUnit(db *gorm.DB, branchID string, productID string, unitID string) (models.ProdSaleUnit, error) {
+	units, err := GetSaleUnits(db, branchID, []string{productID})
+	if err != nil {
+		return models.ProdSaleUnit{}, err
+	}
+	productUnits, ok := units[productID]
+	if !ok {
+		return models.ProdSaleUnit{}, gorm.ErrRecordNotFound
+	}
+	if unitID == "" {
+		return productUnits[0], nil
+	}
+	for _, unit := range productUnits {
+		if unit.UnitId == unitID {
+			return unit, nil
+		}
+	}
+	return models.ProdSaleUnit{}, fmt.Errorf("%w: satuan %s, produk %s", ErrUnitNotSellable, unitID, productID)
+}
+
+// SeedSaleLineUnits mengisi satuan jual item penjualan, retur dan keranjang lama dengan satuan dasar produk
+func SeedSaleLineUnits(db *gorm.DB) error {
+	for _, table := range []string{"sale_items", "sale_return_items", "sale_cart_items"} {
+		err := db.Exec("UPDATE " + table + " SET unit_id = products.unit_id, conv_value = 1 FROM products " +
+			"WHERE products.id = " + table + ".product_id AND (" + table + ".unit_id IS NULL OR " + table + ".unit_id = '')").Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}